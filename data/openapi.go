@@ -0,0 +1,791 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/muqsitnawaz/mq/internal/toml"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI and Swagger specs get a semantic structure instead of key paths:
+//
+//   - Tags become H1 sections, untagged operations go under "default"
+//   - Each operation ("POST /users") is an H2 section with its source lines
+//   - Parameters (including the request body) and responses become tables,
+//     with $ref resolved to schema names
+//   - components/schemas (OpenAPI 3) or definitions (Swagger 2) become a
+//     "Schemas" section with one H2 per schema and a property table
+//
+// Operations and schemas are also attached as mq.Endpoint and mq.Schema
+// values for the .endpoints and .schema selectors.
+
+// openAPIMethods are the operation keys of a path item.
+var openAPIMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// isOpenAPI reports whether obj is an OpenAPI 3 or Swagger 2 document.
+func isOpenAPI(obj map[string]interface{}) bool {
+	if v, ok := obj["openapi"].(string); ok && strings.HasPrefix(v, "3") {
+		return true
+	}
+	if v, ok := obj["swagger"].(string); ok && strings.HasPrefix(v, "2") {
+		return true
+	}
+	return false
+}
+
+// openAPIBuilder builds a document from a spec.
+type openAPIBuilder struct {
+	root    map[string]interface{}
+	spans   toml.Spans
+	index   *mq.LineIndex
+	swagger bool // Swagger 2.0 rather than OpenAPI 3
+
+	headings  []*mq.Heading
+	sections  []*mq.Section
+	tables    []*mq.Table
+	endpoints []*mq.Endpoint
+	schemas   []*mq.Schema
+}
+
+// buildOpenAPI creates a document for an OpenAPI or Swagger spec. spans
+// gives source line ranges by key path and may be empty.
+func buildOpenAPI(source []byte, path string, root map[string]interface{}, format mq.Format, spans toml.Spans) *mq.Document {
+	b := &openAPIBuilder{
+		root:  root,
+		spans: spans,
+		index: mq.NewLineIndex(source),
+	}
+	_, b.swagger = root["swagger"]
+
+	b.buildOperations()
+	b.buildSchemas()
+
+	info := asMap(root["info"])
+	title, _ := info["title"].(string)
+	if title == "" {
+		title = "API"
+	}
+
+	doc := mq.NewDocument(
+		source,
+		path,
+		format,
+		title,
+		b.headings,
+		b.sections,
+		nil, // codeBlocks
+		nil, // links
+		nil, // images
+		b.tables,
+		nil, // lists
+		b.readableText(title),
+	)
+	doc.SetEndpoints(b.endpoints)
+	doc.SetSchemas(b.schemas)
+	doc.SetMetadata(b.metadata(title))
+	return doc
+}
+
+// buildOperations creates tag sections with one child per operation.
+func (b *openAPIBuilder) buildOperations() {
+	byTag := make(map[string][]*mq.Section)
+	var tagOrder []string
+	addTag := func(tag string) {
+		if _, ok := byTag[tag]; !ok {
+			byTag[tag] = nil
+			tagOrder = append(tagOrder, tag)
+		}
+	}
+
+	// Declared tags keep their declared order
+	tagDescriptions := make(map[string]string)
+	for _, t := range asList(b.root["tags"]) {
+		tag := asMap(t)
+		if name, ok := tag["name"].(string); ok {
+			addTag(name)
+			tagDescriptions[name], _ = tag["description"].(string)
+		}
+	}
+
+	paths := asMap(b.root["paths"])
+	for _, p := range b.orderedKeys(paths, []string{"paths"}) {
+		item := b.resolve(paths[p])
+		for _, method := range b.orderedKeys(item, []string{"paths", p}) {
+			if !openAPIMethods[method] {
+				continue
+			}
+			endpoint := b.endpoint(p, method, item)
+			b.endpoints = append(b.endpoints, endpoint)
+
+			tags := endpoint.Tags
+			if len(tags) == 0 {
+				tags = []string{"default"}
+			}
+			span := b.spans[toml.JoinKeyPath([]string{"paths", p, method})]
+			for _, tag := range tags {
+				addTag(tag)
+				h := &mq.Heading{
+					Level: 2,
+					Text:  endpoint.Method + " " + p,
+					ID:    endpoint.OperationID,
+				}
+				h.SetPosition(b.keyPosition([]string{"paths", p, method}))
+				s := &mq.Section{
+					Heading: h,
+					Start:   span.Start,
+					End:     span.End,
+					Doc:     endpoint.Summary,
+					Metadata: mq.Metadata{
+						"method":       endpoint.Method,
+						"path":         p,
+						"operation_id": endpoint.OperationID,
+						"summary":      endpoint.Summary,
+						"tags":         endpoint.Tags,
+						"deprecated":   endpoint.Deprecated,
+					},
+				}
+				if endpoint.Section == nil {
+					endpoint.Section = s
+				}
+				byTag[tag] = append(byTag[tag], s)
+			}
+		}
+	}
+
+	for _, tag := range tagOrder {
+		children := byTag[tag]
+		if len(children) == 0 {
+			continue
+		}
+		h := &mq.Heading{Level: 1, Text: tag}
+		s := &mq.Section{Heading: h, Children: children, Doc: tagDescriptions[tag]}
+		// A tag covers its operations; most specs keep them together
+		for _, c := range children {
+			c.Parent = s
+			if c.Start > 0 && (s.Start == 0 || c.Start < s.Start) {
+				s.Start = c.Start
+			}
+			s.End = max(s.End, c.End)
+		}
+		h.Line = s.Start
+
+		b.headings = append(b.headings, h)
+		b.sections = append(b.sections, s)
+		for _, c := range children {
+			b.headings = append(b.headings, c.Heading)
+			b.sections = append(b.sections, c)
+		}
+	}
+}
+
+// endpoint converts one operation.
+func (b *openAPIBuilder) endpoint(path, method string, item map[string]interface{}) *mq.Endpoint {
+	op := asMap(item[method])
+	e := &mq.Endpoint{
+		Method: strings.ToUpper(method),
+		Path:   path,
+	}
+	e.OperationID, _ = op["operationId"].(string)
+	e.Summary, _ = op["summary"].(string)
+	e.Description, _ = op["description"].(string)
+	e.Deprecated, _ = op["deprecated"].(bool)
+	for _, t := range asList(op["tags"]) {
+		if tag, ok := t.(string); ok {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+
+	opPath := []string{"paths", path, method}
+	e.Parameters = b.parameterTable(item, op, opPath)
+	e.Responses = b.responseTable(op, opPath)
+	if e.Parameters != nil {
+		b.tables = append(b.tables, e.Parameters)
+	}
+	if e.Responses != nil {
+		b.tables = append(b.tables, e.Responses)
+	}
+	return e
+}
+
+// parameterTable lists path-level and operation parameters, operation
+// parameters overriding path-level ones, followed by the request body.
+// opPath is the operation's key path.
+func (b *openAPIBuilder) parameterTable(item, op map[string]interface{}, opPath []string) *mq.Table {
+	var params []map[string]interface{}
+	index := make(map[string]int)
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		for _, raw := range asList(list) {
+			p := b.resolve(raw)
+			key := fmt.Sprint(p["in"], "/", p["name"])
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+
+	table := &mq.Table{Headers: []string{"Name", "In", "Type", "Required", "Description"}}
+	for _, p := range params {
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		required, _ := p["required"].(bool)
+		description, _ := p["description"].(string)
+
+		var typ string
+		if b.swagger && in != "body" {
+			typ = b.schemaType(p, 0) // Swagger 2 puts the type on the parameter
+		} else {
+			typ = b.schemaType(p["schema"], 0)
+		}
+		table.Rows = append(table.Rows, []string{name, in, typ, yesNo(required || in == "path"), oneLine(description)})
+	}
+
+	// OpenAPI 3 request body
+	if body := b.resolve(op["requestBody"]); len(body) > 0 {
+		required, _ := body["required"].(bool)
+		description, _ := body["description"].(string)
+		mediaType, media := b.pickMedia(body["content"])
+		typ := b.schemaType(media["schema"], 0)
+		if mediaType != "" && mediaType != "application/json" {
+			typ = strings.TrimSpace(typ + " (" + mediaType + ")")
+		}
+		table.Rows = append(table.Rows, []string{"body", "body", typ, yesNo(required), oneLine(description)})
+	}
+
+	if len(table.Rows) == 0 {
+		return nil
+	}
+	table.Position = b.position(append(opPath, "parameters"), append(opPath[:2:2], "parameters"), append(opPath, "requestBody"))
+	return table
+}
+
+// responseTable lists responses by status code in source order. opPath
+// is the operation's key path.
+func (b *openAPIBuilder) responseTable(op map[string]interface{}, opPath []string) *mq.Table {
+	responses := asMap(op["responses"])
+	if len(responses) == 0 {
+		return nil
+	}
+
+	table := &mq.Table{
+		Headers:  []string{"Status", "Description", "Type"},
+		Position: b.position(append(opPath, "responses")),
+	}
+	for _, status := range b.orderedKeys(responses, append(opPath, "responses")) {
+		r := b.resolve(responses[status])
+		description, _ := r["description"].(string)
+
+		var typ string
+		if b.swagger {
+			typ = b.schemaType(r["schema"], 0)
+		} else {
+			_, media := b.pickMedia(r["content"])
+			typ = b.schemaType(media["schema"], 0)
+		}
+		table.Rows = append(table.Rows, []string{status, oneLine(description), typ})
+	}
+	return table
+}
+
+// buildSchemas creates the Schemas section.
+func (b *openAPIBuilder) buildSchemas() {
+	containerPath := []string{"components", "schemas"}
+	if b.swagger {
+		containerPath = []string{"definitions"}
+	}
+	container := b.lookup(containerPath)
+	if len(container) == 0 {
+		return
+	}
+
+	span := b.spans[toml.JoinKeyPath(containerPath)]
+	parentHeading := &mq.Heading{Level: 1, Text: "Schemas"}
+	parentHeading.SetPosition(b.keyPosition(containerPath))
+	parent := &mq.Section{Heading: parentHeading, Start: span.Start, End: span.End}
+	b.headings = append(b.headings, parentHeading)
+	b.sections = append(b.sections, parent)
+
+	for _, name := range b.orderedKeys(container, containerPath) {
+		raw := asMap(container[name])
+		schemaSpan := b.spans[toml.JoinKeyPath(append(containerPath, name))]
+
+		schema := &mq.Schema{
+			Name: name,
+			Type: b.schemaType(raw, 0),
+		}
+		schema.Description, _ = raw["description"].(string)
+		schema.Properties, schema.Required = b.propertyTable(raw, append(containerPath, name))
+		if schema.Properties != nil {
+			b.tables = append(b.tables, schema.Properties)
+		}
+		if schema.Type == "" {
+			schema.Type = "object"
+		}
+
+		h := &mq.Heading{Level: 2, Text: name}
+		h.SetPosition(b.keyPosition(append(containerPath, name)))
+		s := &mq.Section{
+			Heading:  h,
+			Parent:   parent,
+			Start:    schemaSpan.Start,
+			End:      schemaSpan.End,
+			Doc:      oneLine(schema.Description),
+			Metadata: mq.Metadata{"type": schema.Type},
+		}
+		schema.Section = s
+		parent.Children = append(parent.Children, s)
+
+		b.headings = append(b.headings, h)
+		b.sections = append(b.sections, s)
+		b.schemas = append(b.schemas, schema)
+	}
+}
+
+// propertyTable lists a schema's properties, merging allOf members.
+// path is the schema's key path.
+func (b *openAPIBuilder) propertyTable(schema map[string]interface{}, path []string) (*mq.Table, []string) {
+	table := &mq.Table{Headers: []string{"Property", "Type", "Required", "Description"}}
+	var required []string
+	seen := make(map[string]bool)
+
+	var collect func(raw interface{}, path []string, depth int)
+	collect = func(raw interface{}, path []string, depth int) {
+		if depth > 8 {
+			return
+		}
+		s, path := b.resolveAt(raw, path)
+		for i, member := range asList(s["allOf"]) {
+			collect(member, append(path, "allOf", strconv.Itoa(i)), depth+1)
+		}
+
+		requiredSet := make(map[string]bool)
+		for _, r := range asList(s["required"]) {
+			if name, ok := r.(string); ok {
+				requiredSet[name] = true
+				required = append(required, name)
+			}
+		}
+
+		props := asMap(s["properties"])
+		for _, name := range b.orderedKeys(props, append(path, "properties")) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			prop := asMap(props[name])
+			description, _ := prop["description"].(string)
+			table.Rows = append(table.Rows, []string{name, b.schemaType(prop, 0), yesNo(requiredSet[name]), oneLine(description)})
+		}
+	}
+	collect(schema, path, 0)
+
+	if len(table.Rows) == 0 {
+		return nil, required
+	}
+	table.Position = b.position(append(path, "properties"), path)
+	return table, required
+}
+
+// position returns the lines of the first key path found in the source.
+func (b *openAPIBuilder) position(paths ...[]string) mq.Position {
+	for _, path := range paths {
+		if span, ok := b.spans[toml.JoinKeyPath(path)]; ok {
+			return b.index.LinePosition(span.Start, span.End)
+		}
+	}
+	return mq.Position{}
+}
+
+// keyPosition returns the line of a key, for headings.
+func (b *openAPIBuilder) keyPosition(path []string) mq.Position {
+	span, ok := b.spans[toml.JoinKeyPath(path)]
+	if !ok {
+		return mq.Position{}
+	}
+	return b.index.LinePosition(span.Start, span.Start)
+}
+
+// schemaType renders a schema as a short type: "User", "[]Pet",
+// "map[string]integer", "string(date-time)", "Cat | Dog".
+func (b *openAPIBuilder) schemaType(raw interface{}, depth int) string {
+	s := asMap(raw)
+	if len(s) == 0 || depth > 8 {
+		return ""
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return refName(ref)
+	}
+
+	for _, combo := range []struct{ key, sep string }{{"allOf", " & "}, {"oneOf", " | "}, {"anyOf", " | "}} {
+		if members := asList(s[combo.key]); len(members) > 0 {
+			parts := make([]string, 0, len(members))
+			for _, m := range members {
+				if t := b.schemaType(m, depth+1); t != "" {
+					parts = append(parts, t)
+				}
+			}
+			return strings.Join(parts, combo.sep)
+		}
+	}
+
+	var typ string
+	switch t := s["type"].(type) {
+	case string:
+		typ = t
+	case []interface{}:
+		var parts []string
+		for _, p := range t {
+			parts = append(parts, fmt.Sprint(p))
+		}
+		typ = strings.Join(parts, " | ")
+	}
+
+	switch {
+	case typ == "array":
+		item := b.schemaType(s["items"], depth+1)
+		if item == "" {
+			item = "any"
+		}
+		return "[]" + item
+	case (typ == "object" || typ == "") && s["additionalProperties"] != nil:
+		if value := b.schemaType(s["additionalProperties"], depth+1); value != "" {
+			return "map[string]" + value
+		}
+	case typ == "" && s["properties"] != nil:
+		typ = "object"
+	}
+
+	if format, ok := s["format"].(string); ok && typ != "" {
+		typ += "(" + format + ")"
+	}
+	if enum := asList(s["enum"]); len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, v := range enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		typ = strings.TrimSpace(typ + " enum(" + strings.Join(values, "|") + ")")
+	}
+	return typ
+}
+
+// pickMedia chooses JSON from an OpenAPI 3 content map, else the first
+// media type.
+func (b *openAPIBuilder) pickMedia(raw interface{}) (string, map[string]interface{}) {
+	content := asMap(raw)
+	if media, ok := content["application/json"]; ok {
+		return "application/json", asMap(media)
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasSuffix(k, "+json") {
+			return k, asMap(content[k])
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0], asMap(content[keys[0]])
+	}
+	return "", nil
+}
+
+// resolve follows local "$ref" pointers such as
+// "#/components/parameters/Limit". External references are returned
+// unresolved.
+func (b *openAPIBuilder) resolve(raw interface{}) map[string]interface{} {
+	m, _ := b.resolveAt(raw, nil)
+	return m
+}
+
+// resolveAt is resolve that also returns the key path of the object it
+// lands on, given the key path of raw.
+func (b *openAPIBuilder) resolveAt(raw interface{}, path []string) (map[string]interface{}, []string) {
+	m := asMap(raw)
+	path = append([]string{}, path...)
+	for hops := 0; hops < 16; hops++ {
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			break
+		}
+		target := b.lookup(splitPointer(ref))
+		if target == nil {
+			break
+		}
+		m, path = target, splitPointer(ref)
+	}
+	return m, path
+}
+
+// lookup returns the object at a key path, or nil.
+func (b *openAPIBuilder) lookup(path []string) map[string]interface{} {
+	var cur interface{} = b.root
+	for _, key := range path {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			cur = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			cur = v[i]
+		default:
+			return nil
+		}
+	}
+	return asMap(cur)
+}
+
+// orderedKeys returns the keys of m in source order when line spans are
+// known, falling back to lexical order.
+func (b *openAPIBuilder) orderedKeys(m map[string]interface{}, path []string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	line := func(k string) int {
+		if path == nil {
+			return 0
+		}
+		return b.spans[toml.JoinKeyPath(append(append([]string{}, path...), k))].Start
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		li, lj := line(keys[i]), line(keys[j])
+		if li != lj {
+			return li < lj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// metadata summarizes the spec's info block.
+func (b *openAPIBuilder) metadata(title string) mq.Metadata {
+	info := asMap(b.root["info"])
+	meta := mq.Metadata{
+		"title":     title,
+		"endpoints": len(b.endpoints),
+		"schemas":   len(b.schemas),
+	}
+	if v, ok := info["version"]; ok {
+		meta["version"] = fmt.Sprint(v)
+	}
+	if v, ok := info["description"].(string); ok {
+		meta["description"] = v
+	}
+	if b.swagger {
+		meta["swagger"] = fmt.Sprint(b.root["swagger"])
+		if host, ok := b.root["host"].(string); ok {
+			basePath, _ := b.root["basePath"].(string)
+			meta["servers"] = []string{host + basePath}
+		}
+	} else {
+		meta["openapi"] = fmt.Sprint(b.root["openapi"])
+		var servers []string
+		for _, s := range asList(b.root["servers"]) {
+			if url, ok := asMap(s)["url"].(string); ok {
+				servers = append(servers, url)
+			}
+		}
+		if len(servers) > 0 {
+			meta["servers"] = servers
+		}
+	}
+	return meta
+}
+
+// readableText lists operations and schemas, one per line.
+func (b *openAPIBuilder) readableText(title string) string {
+	var buf strings.Builder
+	buf.WriteString(title + "\n\n")
+	for _, e := range b.endpoints {
+		buf.WriteString(e.Method + " " + e.Path)
+		if e.Summary != "" {
+			buf.WriteString(" - " + e.Summary)
+		}
+		buf.WriteString("\n")
+	}
+	if len(b.schemas) > 0 {
+		buf.WriteString("\nSchemas:\n")
+		for _, s := range b.schemas {
+			buf.WriteString("  " + s.Name + " (" + s.Type + ")\n")
+		}
+	}
+	return buf.String()
+}
+
+// splitPointer splits a local JSON pointer into unescaped keys.
+func splitPointer(ref string) []string {
+	parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts
+}
+
+// refName is the last segment of a $ref: "#/components/schemas/User" is "User".
+func refName(ref string) string {
+	parts := splitPointer(ref)
+	return parts[len(parts)-1]
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asList(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
+
+// oneLine collapses whitespace so descriptions fit in a table cell.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stringKeys converts YAML maps with non-string keys, such as unquoted
+// response codes (200:), to map[string]interface{}.
+func stringKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = stringKeys(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range t {
+			t[i] = stringKeys(val)
+		}
+		return t
+	}
+	return v
+}
+
+// yamlSpans records the line range of every key in a YAML document.
+func yamlSpans(content []byte) toml.Spans {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	spans := toml.Spans{}
+
+	var walk func(n *yaml.Node, path []string)
+	walk = func(n *yaml.Node, path []string) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				child := append(append([]string{}, path...), key.Value)
+				spans[toml.JoinKeyPath(child)] = toml.Span{Start: key.Line, End: lastYAMLLine(value)}
+				walk(value, child)
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				child := append(append([]string{}, path...), strconv.Itoa(i))
+				spans[toml.JoinKeyPath(child)] = toml.Span{Start: item.Line, End: lastYAMLLine(item)}
+				walk(item, child)
+			}
+		}
+	}
+	walk(root.Content[0], nil)
+	return spans
+}
+
+// lastYAMLLine is the last line a node's value occupies.
+func lastYAMLLine(n *yaml.Node) int {
+	last := n.Line
+	if n.Kind == yaml.ScalarNode && (n.Style == yaml.LiteralStyle || n.Style == yaml.FoldedStyle) {
+		last += strings.Count(strings.TrimRight(n.Value, "\n"), "\n") + 1
+	}
+	for _, c := range n.Content {
+		last = max(last, lastYAMLLine(c))
+	}
+	return last
+}
+
+// jsonSpans records the line range of every key in a JSON document.
+func jsonSpans(content []byte) toml.Spans {
+	newlines := make([]int, 0, bytes.Count(content, []byte("\n")))
+	for i, c := range content {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineAt := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)) + 1
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	spans := toml.Spans{}
+
+	// walk consumes one value and returns the offset of its last byte
+	var walk func(path []string) (int64, error)
+	walk = func(path []string) (int64, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok || (delim != '{' && delim != '[') {
+			return dec.InputOffset() - 1, nil
+		}
+
+		for i := 0; dec.More(); i++ {
+			var key string
+			var start int64
+			if delim == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return 0, err
+				}
+				key = fmt.Sprint(keyTok)
+				start = dec.InputOffset() - 1
+			} else {
+				key = strconv.Itoa(i)
+				start = dec.InputOffset() + 1
+			}
+			child := append(append([]string{}, path...), key)
+			end, err := walk(child)
+			if err != nil {
+				return 0, err
+			}
+			spans[toml.JoinKeyPath(child)] = toml.Span{Start: lineAt(start), End: lineAt(end)}
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return 0, err
+		}
+		return dec.InputOffset() - 1, nil
+	}
+
+	if _, err := walk(nil); err != nil {
+		return nil
+	}
+	return spans
+}
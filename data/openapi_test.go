@@ -0,0 +1,264 @@
+package data
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstoreYAML = `openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.2.0
+servers:
+  - url: https://api.example.com/v1
+tags:
+  - name: users
+    description: Account management
+  - name: pets
+paths:
+  /users:
+    get:
+      tags: [users]
+      operationId: listUsers
+      summary: List users
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+      responses:
+        200:
+          description: A page of users
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/User'
+    post:
+      tags: [users]
+      operationId: createUser
+      summary: Create a user
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+      responses:
+        '201':
+          $ref: '#/components/responses/Created'
+        default:
+          description: Error
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        schema:
+          type: integer
+          format: int64
+    delete:
+      tags: [pets]
+      deprecated: true
+      responses:
+        '204':
+          description: Deleted
+  /health:
+    get:
+      responses:
+        '200':
+          description: OK
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      description: Page size
+      schema:
+        type: integer
+  responses:
+    Created:
+      description: Created
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/User'
+  schemas:
+    User:
+      type: object
+      description: An account
+      required: [id, email]
+      properties:
+        id:
+          type: string
+          format: uuid
+        email:
+          type: string
+        roles:
+          type: array
+          items:
+            type: string
+            enum: [admin, member]
+        labels:
+          type: object
+          additionalProperties:
+            type: string
+    Admin:
+      allOf:
+        - $ref: '#/components/schemas/User'
+        - type: object
+          properties:
+            level:
+              type: integer
+`
+
+const swaggerJSON = `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy", "version": "0.9"},
+  "host": "legacy.example.com",
+  "basePath": "/api",
+  "paths": {
+    "/orders": {
+      "post": {
+        "tags": ["orders"],
+        "operationId": "placeOrder",
+        "parameters": [
+          {"name": "order", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Order"}},
+          {"name": "dryRun", "in": "query", "type": "boolean"}
+        ],
+        "responses": {
+          "200": {"description": "Placed", "schema": {"$ref": "#/definitions/Order"}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Order": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer"},
+        "items": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }
+}
+`
+
+func TestOpenAPIYAMLStructure(t *testing.T) {
+	doc, err := NewYAMLParser().Parse([]byte(petstoreYAML), "petstore.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Petstore", doc.Title())
+	meta := doc.Metadata()
+	assert.Equal(t, "3.0.3", meta["openapi"])
+	assert.Equal(t, "1.2.0", meta["version"])
+	assert.Equal(t, []string{"https://api.example.com/v1"}, meta["servers"])
+
+	var h1 []string
+	for _, h := range doc.GetHeadings(1) {
+		h1 = append(h1, h.Text)
+	}
+	assert.Equal(t, []string{"users", "pets", "default", "Schemas"}, h1)
+
+	users, ok := doc.GetSection("users")
+	require.True(t, ok)
+	require.Len(t, users.Children, 2)
+	assert.Equal(t, "GET /users", users.Children[0].Heading.Text)
+	assert.Equal(t, "POST /users", users.Children[1].Heading.Text)
+	assert.Equal(t, "Account management", users.Doc)
+
+	post, ok := doc.GetSection("POST /users")
+	require.True(t, ok)
+	assert.Equal(t, 28, post.Start)
+	assert.Equal(t, 42, post.End)
+	assert.Contains(t, post.GetText(), "operationId: createUser")
+	assert.Equal(t, "createUser", post.Heading.ID)
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	doc, err := NewYAMLParser().Parse([]byte(petstoreYAML), "petstore.yaml")
+	require.NoError(t, err)
+
+	endpoints := doc.GetEndpoints()
+	require.Len(t, endpoints, 4)
+
+	list := endpoints[0]
+	assert.Equal(t, "GET", list.Method)
+	assert.Equal(t, "listUsers", list.OperationID)
+	assert.Equal(t, [][]string{{"limit", "query", "integer", "", "Page size"}}, list.Parameters.Rows)
+	assert.Equal(t, [][]string{{"200", "A page of users", "[]User"}}, list.Responses.Rows)
+
+	create := endpoints[1]
+	assert.Equal(t, [][]string{{"body", "body", "User", "yes", ""}}, create.Parameters.Rows)
+	assert.Equal(t, [][]string{{"201", "Created", "User"}, {"default", "Error", ""}}, create.Responses.Rows)
+
+	// Path-level parameters apply to each operation
+	remove := endpoints[2]
+	assert.Equal(t, "/pets/{id}", remove.Path)
+	assert.True(t, remove.Deprecated)
+	assert.Equal(t, [][]string{{"id", "path", "integer(int64)", "yes", ""}}, remove.Parameters.Rows)
+
+	health := endpoints[3]
+	assert.Empty(t, health.Tags)
+	assert.Equal(t, "default", health.Section.Parent.Heading.Text)
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := NewYAMLParser().Parse([]byte(petstoreYAML), "petstore.yaml")
+	require.NoError(t, err)
+
+	user, ok := doc.GetSchema("User")
+	require.True(t, ok)
+	assert.Equal(t, "object", user.Type)
+	assert.Equal(t, "An account", user.Description)
+	assert.Equal(t, []string{"id", "email"}, user.Required)
+	assert.Equal(t, [][]string{
+		{"id", "string(uuid)", "yes", ""},
+		{"email", "string", "yes", ""},
+		{"roles", "[]string enum(admin|member)", "", ""},
+		{"labels", "map[string]string", "", ""},
+	}, user.Properties.Rows)
+	assert.Equal(t, "Schemas", user.Section.Parent.Heading.Text)
+
+	// allOf merges the referenced schema's properties
+	admin, ok := doc.GetSchema("Admin")
+	require.True(t, ok)
+	assert.Equal(t, "User & object", admin.Type)
+	require.Len(t, admin.Properties.Rows, 5)
+	assert.Equal(t, "level", admin.Properties.Rows[4][0])
+
+	_, ok = doc.GetSchema("Missing")
+	assert.False(t, ok)
+}
+
+func TestOpenAPISwaggerJSON(t *testing.T) {
+	doc, err := NewJSONParser().Parse([]byte(swaggerJSON), "legacy.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatJSON, doc.Format())
+	assert.Equal(t, "2.0", doc.Metadata()["swagger"])
+	assert.Equal(t, []string{"legacy.example.com/api"}, doc.Metadata()["servers"])
+
+	endpoints := doc.GetEndpoints()
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, [][]string{
+		{"order", "body", "Order", "yes", ""},
+		{"dryRun", "query", "boolean", "", ""},
+	}, endpoints[0].Parameters.Rows)
+	assert.Equal(t, [][]string{{"200", "Placed", "Order"}}, endpoints[0].Responses.Rows)
+
+	post, ok := doc.GetSection("POST /orders")
+	require.True(t, ok)
+	assert.Equal(t, 8, post.Start)
+	assert.Equal(t, 18, post.End)
+
+	order, ok := doc.GetSchema("Order")
+	require.True(t, ok)
+	assert.Equal(t, [][]string{{"id", "integer", "", ""}, {"items", "[]string", "", ""}}, order.Properties.Rows)
+}
+
+func TestNonOpenAPIUnchanged(t *testing.T) {
+	doc, err := NewYAMLParser().Parse([]byte("name: demo\npaths:\n  a: b\n"), "config.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, doc.GetEndpoints())
+}
@@ -1,4 +1,4 @@
-// Package data provides parsers for structured data formats (JSON, JSONL, YAML).
+// Package data provides parsers for structured data formats (JSON, JSONL, YAML, TOML).
 //
 // These formats don't have document structure like headings/sections, but they
 // have data structure (keys, arrays, nested objects). The parser exposes this
@@ -10,6 +10,7 @@
 //   - ReadableText: Pretty-printed or summarized content
 //
 // JSONL files are treated as arrays where each line is an element.
+// TOML files additionally keep source line ranges for every table and key.
 //
 // Example:
 //
@@ -28,8 +29,11 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/muqsitnawaz/mq/internal/toml"
 	mq "github.com/muqsitnawaz/mq/lib"
 	"gopkg.in/yaml.v3"
 )
@@ -74,7 +78,10 @@ func (p *JSONParser) Parse(content []byte, path string) (*mq.Document, error) {
 		return nil, &mq.ParseError{Format: mq.FormatJSON, Path: path, Err: err}
 	}
 
-	return p.buildDocument(content, path, data, mq.FormatJSON)
+	if obj, ok := data.(map[string]interface{}); ok && isOpenAPI(obj) {
+		return buildOpenAPI(content, path, obj, mq.FormatJSON, jsonSpans(content)), nil
+	}
+	return p.buildDocument(content, path, data, mq.FormatJSON, jsonSpans(content))
 }
 
 // JSONLParser parses JSONL (JSON Lines) files.
@@ -120,13 +127,14 @@ func (p *JSONLParser) ParseFile(path string) (*mq.Document, error) {
 // Parse parses JSONL content.
 func (p *JSONLParser) Parse(content []byte, path string) (*mq.Document, error) {
 	var items []interface{}
+	spans := toml.Spans{}
 
 	scanner := bufio.NewScanner(bytes.NewReader(content))
 	// Increase buffer size for large lines
 	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
 
 	lineNum := 0
-	for scanner.Scan() {
+	for sourceLine := 1; scanner.Scan(); sourceLine++ {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
@@ -138,6 +146,7 @@ func (p *JSONLParser) Parse(content []byte, path string) (*mq.Document, error) {
 			continue
 		}
 
+		spans[strconv.Itoa(len(items))] = toml.Span{Start: sourceLine, End: sourceLine}
 		items = append(items, item)
 		lineNum++
 
@@ -152,7 +161,7 @@ func (p *JSONLParser) Parse(content []byte, path string) (*mq.Document, error) {
 
 	// Build document from array of items
 	jsonParser := &JSONParser{prettyPrint: true}
-	return jsonParser.buildDocument(content, path, items, mq.FormatJSONL)
+	return jsonParser.buildDocument(content, path, items, mq.FormatJSONL, spans)
 }
 
 // YAMLParser parses YAML files.
@@ -184,27 +193,45 @@ func (p *YAMLParser) Parse(content []byte, path string) (*mq.Document, error) {
 		return nil, &mq.ParseError{Format: mq.FormatYAML, Path: path, Err: err}
 	}
 
+	if obj, ok := stringKeys(data).(map[string]interface{}); ok && isOpenAPI(obj) {
+		return buildOpenAPI(content, path, obj, mq.FormatYAML, yamlSpans(content)), nil
+	}
 	jsonParser := &JSONParser{prettyPrint: true}
-	return jsonParser.buildDocument(content, path, data, mq.FormatYAML)
+	return jsonParser.buildDocument(content, path, data, mq.FormatYAML, yamlSpans(content))
 }
 
 // buildDocument creates an mq.Document from parsed data.
-func (p *JSONParser) buildDocument(source []byte, path string, data interface{}, format mq.Format) (*mq.Document, error) {
+//
+// spans maps key paths to where they live in the source; headings,
+// sections and tables get their line ranges from it. TOML additionally
+// indexes every nested section and turns nested arrays of objects into
+// tables.
+func (p *JSONParser) buildDocument(source []byte, path string, data interface{}, format mq.Format, spans toml.Spans) (*mq.Document, error) {
 	var headings []*mq.Heading
 	var sections []*mq.Section
 	var tables []*mq.Table
 	var title string
+	idx := mq.NewLineIndex(source)
 
 	switch v := data.(type) {
 	case map[string]interface{}:
 		// Object: keys become headings
 		title = inferTitle(v)
 		headings, sections = extractObjectStructure(v, 1)
+		applySpans(sections, spans, nil, idx)
+
+		if format == mq.FormatTOML {
+			title = tomlTitle(v)
+			headings = sortBySource(sections)
+			sections = flattenSections(sections)
+			tables = collectRecordTables(v, nil, spans, idx)
+		}
 
 	case []interface{}:
 		// Array: check if it's a table (array of uniform objects)
 		if len(v) > 0 {
 			if table := tryExtractTable(v); table != nil {
+				table.Position = idx.LinePosition(firstContentLine(spans), idx.Lines())
 				tables = append(tables, table)
 				title = fmt.Sprintf("Array (%d items)", len(v))
 			} else {
@@ -221,10 +248,19 @@ func (p *JSONParser) buildDocument(source []byte, path string, data interface{},
 					headings = append(headings, h)
 
 					s := &mq.Section{Heading: h}
+					key := strconv.Itoa(i)
+					if span, ok := spans[key]; ok {
+						h.SetPosition(idx.LinePosition(span.Start, span.Start))
+						s.Start, s.End = span.Start, span.End
+					}
 					if obj, ok := item.(map[string]interface{}); ok {
 						childHeadings, childSections := extractObjectStructure(obj, 2)
 						headings = append(headings, childHeadings...)
+						applySpans(childSections, spans, []string{key}, idx)
 						s.Children = childSections
+						for _, child := range childSections {
+							child.Parent = s
+						}
 					}
 					sections = append(sections, s)
 				}
@@ -282,6 +318,9 @@ func extractObjectStructure(obj map[string]interface{}, level int) ([]*mq.Headin
 			childHeadings, childSections := extractObjectStructure(nested, level+1)
 			headings = append(headings, childHeadings...)
 			s.Children = childSections
+			for _, child := range childSections {
+				child.Parent = s
+			}
 		}
 
 		sections = append(sections, s)
@@ -290,6 +329,15 @@ func extractObjectStructure(obj map[string]interface{}, level int) ([]*mq.Headin
 	return headings, sections
 }
 
+// firstContentLine is where a top-level array starts: its first item,
+// or line 1 without spans.
+func firstContentLine(spans toml.Spans) int {
+	if span, ok := spans["0"]; ok {
+		return max(span.Start-1, 1)
+	}
+	return 1
+}
+
 // inferTitle tries to find a suitable title from object keys.
 func inferTitle(obj map[string]interface{}) string {
 	// Common title keys
@@ -374,8 +422,15 @@ func formatValue(v interface{}) string {
 			return fmt.Sprintf("%d", int64(val))
 		}
 		return fmt.Sprintf("%.2f", val)
+	case int64:
+		return fmt.Sprintf("%d", val)
 	case bool:
 		return fmt.Sprintf("%t", val)
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 && val.Nanosecond() == 0 {
+			return val.Format("2006-01-02")
+		}
+		return val.Format(time.RFC3339)
 	case nil:
 		return "null"
 	case map[string]interface{}:
@@ -410,4 +465,5 @@ var (
 	_ mq.FormatParser = (*JSONParser)(nil)
 	_ mq.FormatParser = (*JSONLParser)(nil)
 	_ mq.FormatParser = (*YAMLParser)(nil)
+	_ mq.FormatParser = (*TOMLParser)(nil)
 )
@@ -0,0 +1,196 @@
+package data
+
+import (
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/muqsitnawaz/mq/internal/toml"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// TOMLParser parses TOML files (pyproject.toml, Cargo.toml, netlify.toml, ...).
+//
+// Tables become headings, arrays of tables become tables, and every key
+// keeps the source lines it was defined on so sections have real
+// Start/End ranges.
+type TOMLParser struct{}
+
+// NewTOMLParser creates a new TOML parser.
+func NewTOMLParser() *TOMLParser {
+	return &TOMLParser{}
+}
+
+// Format implements mq.FormatParser.
+func (p *TOMLParser) Format() mq.Format {
+	return mq.FormatTOML
+}
+
+// ParseFile reads and parses a TOML file.
+func (p *TOMLParser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatTOML, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses TOML content.
+func (p *TOMLParser) Parse(content []byte, path string) (*mq.Document, error) {
+	data, spans, err := toml.Decode(content)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatTOML, Path: path, Err: err}
+	}
+
+	jsonParser := &JSONParser{prettyPrint: true}
+	return jsonParser.buildDocument(content, path, data, mq.FormatTOML, spans)
+}
+
+// applySpans copies key line ranges onto headings and sections, recursing
+// into child sections. A heading's position is its key's line.
+func applySpans(sections []*mq.Section, spans toml.Spans, prefix []string, idx *mq.LineIndex) {
+	for _, s := range sections {
+		path := append(append([]string{}, prefix...), s.Heading.Text)
+		if span, ok := spans[toml.JoinKeyPath(path)]; ok {
+			s.Heading.SetPosition(idx.LinePosition(span.Start, span.Start))
+			s.Start = span.Start
+			s.End = span.End
+		}
+		applySpans(s.Children, spans, path, idx)
+	}
+}
+
+// sortBySource orders sections and their children by their first line,
+// keeping sections without a span in key order after the others, and
+// returns their headings in the same order.
+func sortBySource(sections []*mq.Section) []*mq.Heading {
+	sort.SliceStable(sections, func(i, j int) bool {
+		a, b := sections[i].Start, sections[j].Start
+		return a > 0 && (b == 0 || a < b)
+	})
+	var headings []*mq.Heading
+	for _, s := range sections {
+		headings = append(headings, s.Heading)
+		headings = append(headings, sortBySource(s.Children)...)
+	}
+	return headings
+}
+
+// flattenSections returns every section in the tree, deepest first, so that
+// when names collide the shallower section wins the document's title index.
+func flattenSections(sections []*mq.Section) []*mq.Section {
+	var levels [][]*mq.Section
+	current := sections
+	for len(current) > 0 {
+		levels = append(levels, current)
+		var next []*mq.Section
+		for _, s := range current {
+			next = append(next, s.Children...)
+		}
+		current = next
+	}
+
+	var all []*mq.Section
+	for i := len(levels) - 1; i >= 0; i-- {
+		all = append(all, levels[i]...)
+	}
+	return all
+}
+
+// collectRecordTables turns every nested array of tables into an mq.Table.
+// Unlike tryExtractTable, records may have different keys; the header row is
+// the union of keys and missing cells are left empty. Tables span their
+// array's lines.
+func collectRecordTables(obj map[string]interface{}, prefix []string, spans toml.Spans, idx *mq.LineIndex) []*mq.Table {
+	var tables []*mq.Table
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := append(append([]string{}, prefix...), k)
+		switch v := obj[k].(type) {
+		case map[string]interface{}:
+			tables = append(tables, collectRecordTables(v, path, spans, idx)...)
+		case []interface{}:
+			if table := recordTable(v); table != nil {
+				if span, ok := spans[toml.JoinKeyPath(path)]; ok {
+					table.Position = idx.LinePosition(span.Start, span.End)
+				}
+				tables = append(tables, table)
+			}
+			for i, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					itemPath := append(append([]string{}, path...), strconv.Itoa(i))
+					tables = append(tables, collectRecordTables(m, itemPath, spans, idx)...)
+				}
+			}
+		}
+	}
+
+	return tables
+}
+
+// recordTable builds a table from an array whose items are all objects.
+func recordTable(arr []interface{}) *mq.Table {
+	if len(arr) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var headers []string
+	for _, item := range arr {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for k := range obj {
+			if !seen[k] {
+				seen[k] = true
+				headers = append(headers, k)
+			}
+		}
+	}
+	if len(headers) == 0 || len(headers) > 20 {
+		return nil
+	}
+	sort.Strings(headers)
+
+	table := &mq.Table{
+		Headers: headers,
+		Rows:    make([][]string, 0, len(arr)),
+	}
+	for _, item := range arr {
+		obj := item.(map[string]interface{})
+		row := make([]string, len(headers))
+		for i, h := range headers {
+			if v, ok := obj[h]; ok {
+				row[i] = formatValue(v)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// tomlTitle prefers the package/project name used by Cargo and pyproject.
+func tomlTitle(obj map[string]interface{}) string {
+	for _, key := range []string{"package", "project"} {
+		if table, ok := obj[key].(map[string]interface{}); ok {
+			if name, ok := table["name"].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	if tool, ok := obj["tool"].(map[string]interface{}); ok {
+		if poetry, ok := tool["poetry"].(map[string]interface{}); ok {
+			if name, ok := poetry["name"].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	return inferTitle(obj)
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cargoTOML = `# Cargo manifest
+[package]
+name = "mq-demo"
+version = "0.1.0"
+authors = [
+  "Ada <ada@example.com>",
+  "Linus <linus@example.com>",
+]
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+tokio = "1"
+
+[[bin]]
+name = "server"
+path = "src/server.rs"
+
+[[bin]]
+name = "cli"
+path = "src/cli.rs"
+test = false
+
+[profile.release]
+lto = true
+`
+
+func TestTOMLParserStructure(t *testing.T) {
+	doc, err := NewTOMLParser().Parse([]byte(cargoTOML), "Cargo.toml")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatTOML, doc.Format())
+	assert.Equal(t, "mq-demo", doc.Title())
+
+	// Sections keep source order
+	var order []string
+	for _, s := range doc.GetTableOfContents() {
+		order = append(order, s.Heading.Text)
+	}
+	assert.Equal(t, []string{"package", "dependencies", "bin", "profile"}, order)
+
+	pkg, ok := doc.GetSection("package")
+	require.True(t, ok)
+	assert.Equal(t, 2, pkg.Start)
+	assert.Equal(t, 8, pkg.End)
+	assert.Equal(t, 2, pkg.Heading.Line)
+	assert.Contains(t, pkg.GetText(), `name = "mq-demo"`)
+
+	authors, ok := doc.GetSection("authors")
+	require.True(t, ok)
+	assert.Equal(t, 5, authors.Start)
+	assert.Equal(t, 8, authors.End)
+
+	deps, ok := doc.GetSection("dependencies")
+	require.True(t, ok)
+	assert.Equal(t, 10, deps.Start)
+	assert.Equal(t, 12, deps.End)
+
+	bin, ok := doc.GetSection("bin")
+	require.True(t, ok)
+	assert.Equal(t, 14, bin.Start)
+	assert.Equal(t, 21, bin.End)
+
+	// Implicit parent tables cover their children.
+	profile, ok := doc.GetSection("profile")
+	require.True(t, ok)
+	assert.Equal(t, 23, profile.Start)
+	assert.Equal(t, 24, profile.End)
+	require.Len(t, profile.Children, 1)
+	assert.Equal(t, "release", profile.Children[0].Heading.Text)
+}
+
+func TestTOMLParserArrayOfTables(t *testing.T) {
+	doc, err := NewTOMLParser().Parse([]byte(cargoTOML), "Cargo.toml")
+	require.NoError(t, err)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"name", "path", "test"}, tables[0].Headers)
+	assert.Equal(t, [][]string{
+		{"server", "src/server.rs", ""},
+		{"cli", "src/cli.rs", "false"},
+	}, tables[0].Rows)
+}
+
+func TestDecodeTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"duplicate key", "a = 1\na = 2\n"},
+		{"duplicate table", "[a]\nx = 1\n[b]\n[a]\ny = 2\n"},
+		{"escape outside TOML 1.0", "a = \"\\e[0m\"\n"},
+		{"unterminated string", "a = \"oops\n"},
+		{"missing equals", "a 1\n"},
+		{"unclosed header", "[table\n"},
+		{"trailing garbage", "a = 1 b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTOMLParser().Parse([]byte(tt.src), "bad.toml")
+			require.Error(t, err)
+			var parseErr *mq.ParseError
+			assert.ErrorAs(t, err, &parseErr)
+		})
+	}
+}
@@ -0,0 +1,898 @@
+// Package docx provides Word (Office Open XML) parsing for mq.
+//
+// A .docx file is a zip archive of XML parts. The parser reads them with
+// the standard library only and maps Word's structure onto mq's unified types:
+//   - Headings: paragraphs styled Heading 1-6 (or with an outline level)
+//   - Tables: Word tables, first row as headers
+//   - Links: external hyperlinks and internal bookmarks
+//   - Lists: numbered and bulleted paragraphs, nested by indent level
+//   - Images: embedded drawings with their alt text
+//   - Metadata: core properties (title, author, created, modified, ...)
+//
+// DOCX files have no meaningful text lines, so the document source is a
+// Markdown rendering of the body. Section line ranges and GetText refer to
+// that rendering.
+//
+// Example:
+//
+//	parser := docx.NewParser()
+//	doc, _ := parser.ParseFile("spec.docx")
+//
+//	headings := doc.GetHeadings()
+//	author, _ := doc.GetMetadataField("author")
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muqsitnawaz/mq/internal/outline"
+	"github.com/muqsitnawaz/mq/internal/zippart"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Parser parses DOCX documents into mq.Document.
+type Parser struct {
+	// Options
+	outlineLevels bool // Treat paragraphs with an outline level as headings
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new DOCX parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		outlineLevels: true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithOutlineLevels enables/disables treating paragraphs that carry an
+// outline level (but no Heading style) as headings.
+func WithOutlineLevels(enabled bool) Option {
+	return func(p *Parser) {
+		p.outlineLevels = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatDOCX
+}
+
+// ParseFile reads and parses a DOCX file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatDOCX, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses DOCX content and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatDOCX, Path: path, Err: err}
+	}
+
+	pkg := &pkg{files: make(map[string]*zip.File)}
+	for _, f := range zr.File {
+		pkg.files[f.Name] = f
+	}
+
+	body, err := pkg.tree("word/document.xml")
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatDOCX, Path: path, Err: err}
+	}
+
+	ext := &extractor{
+		parser:    p,
+		path:      path,
+		rels:      pkg.relationships("word/_rels/document.xml.rels"),
+		styles:    pkg.styles("word/styles.xml"),
+		numbering: pkg.numbering("word/numbering.xml"),
+		props:     pkg.coreProperties("docProps/core.xml"),
+	}
+	return ext.extract(body), nil
+}
+
+// node is a generic XML element. Names are local (namespace prefixes
+// dropped), which is unambiguous within WordprocessingML.
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string // character data directly inside this element
+}
+
+// child returns the first child with the given name, or nil.
+func (n *node) child(name string) *node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// attr returns an attribute value; safe on nil nodes.
+func (n *node) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.attrs[name]
+}
+
+// find returns the first descendant with the given name, or nil.
+func (n *node) find(name string) *node {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+		if found := c.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// parseNodes decodes an XML part into a node tree.
+func parseNodes(data []byte) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	root := &node{}
+	stack := []*node{root}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top := stack[len(stack)-1]
+			top.text += string(t)
+		}
+	}
+
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("empty XML part")
+	}
+	return root.children[0], nil
+}
+
+// pkg gives access to the parts of an OPC package.
+type pkg struct {
+	files map[string]*zip.File
+}
+
+// read returns the bytes of a part, or nil if it does not exist.
+func (p *pkg) read(name string) ([]byte, error) {
+	f, ok := p.files[name]
+	if !ok {
+		return nil, nil
+	}
+	return zippart.Read(f)
+}
+
+// tree reads and decodes a required part.
+func (p *pkg) tree(name string) (*node, error) {
+	data, err := p.read(name)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("not a Word document: missing %s", name)
+	}
+	return parseNodes(data)
+}
+
+// optional reads and decodes a part that may be absent or broken.
+func (p *pkg) optional(name string) *node {
+	data, err := p.read(name)
+	if err != nil || data == nil {
+		return nil
+	}
+	root, err := parseNodes(data)
+	if err != nil {
+		return nil
+	}
+	return root
+}
+
+// relationship is a target referenced from document.xml by r:id.
+type relationship struct {
+	target   string
+	external bool
+}
+
+func (p *pkg) relationships(name string) map[string]relationship {
+	rels := make(map[string]relationship)
+	root := p.optional(name)
+	if root == nil {
+		return rels
+	}
+	for _, r := range root.children {
+		if r.name != "Relationship" {
+			continue
+		}
+		rels[r.attr("Id")] = relationship{
+			target:   r.attr("Target"),
+			external: r.attr("TargetMode") == "External",
+		}
+	}
+	return rels
+}
+
+// style is a paragraph style definition from styles.xml.
+type style struct {
+	name     string
+	basedOn  string
+	outline  int // 0-based outline level, -1 if unset
+	numID    string
+	numLevel string
+}
+
+func (p *pkg) styles(name string) map[string]*style {
+	styles := make(map[string]*style)
+	root := p.optional(name)
+	if root == nil {
+		return styles
+	}
+	for _, s := range root.children {
+		if s.name != "style" {
+			continue
+		}
+		st := &style{
+			name:    s.child("name").attr("val"),
+			basedOn: s.child("basedOn").attr("val"),
+			outline: -1,
+		}
+		pPr := s.child("pPr")
+		if lvl := pPr.child("outlineLvl"); lvl != nil {
+			if n, err := strconv.Atoi(lvl.attr("val")); err == nil {
+				st.outline = n
+			}
+		}
+		if numPr := pPr.child("numPr"); numPr != nil {
+			st.numID = numPr.child("numId").attr("val")
+			st.numLevel = numPr.child("ilvl").attr("val")
+		}
+		styles[s.attr("styleId")] = st
+	}
+	return styles
+}
+
+// numbering maps numId to the number format of each indent level.
+type numbering map[string]map[string]string
+
+func (p *pkg) numbering(name string) numbering {
+	result := make(numbering)
+	root := p.optional(name)
+	if root == nil {
+		return result
+	}
+
+	abstract := make(map[string]map[string]string)
+	for _, a := range root.children {
+		if a.name != "abstractNum" {
+			continue
+		}
+		levels := make(map[string]string)
+		for _, lvl := range a.children {
+			if lvl.name == "lvl" {
+				levels[lvl.attr("ilvl")] = lvl.child("numFmt").attr("val")
+			}
+		}
+		abstract[a.attr("abstractNumId")] = levels
+	}
+
+	for _, n := range root.children {
+		if n.name != "num" {
+			continue
+		}
+		if levels, ok := abstract[n.child("abstractNumId").attr("val")]; ok {
+			result[n.attr("numId")] = levels
+		}
+	}
+	return result
+}
+
+// ordered reports whether a list level uses numbers rather than bullets.
+func (n numbering) ordered(numID, level string) bool {
+	levels, ok := n[numID]
+	if !ok {
+		return false
+	}
+	switch levels[level] {
+	case "bullet", "none", "":
+		return false
+	}
+	return true
+}
+
+// coreProperties reads docProps/core.xml into metadata keys.
+func (p *pkg) coreProperties(name string) mq.Metadata {
+	root := p.optional(name)
+	if root == nil {
+		return nil
+	}
+
+	keys := map[string]string{
+		"title":          "title",
+		"subject":        "subject",
+		"creator":        "author",
+		"keywords":       "keywords",
+		"description":    "description",
+		"lastModifiedBy": "last_modified_by",
+		"revision":       "revision",
+		"created":        "created",
+		"modified":       "modified",
+		"category":       "category",
+	}
+
+	meta := mq.Metadata{}
+	for _, c := range root.children {
+		key, ok := keys[c.name]
+		if !ok {
+			continue
+		}
+		if v := strings.TrimSpace(c.text); v != "" {
+			meta[key] = v
+		}
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// extractor walks document.xml and renders it as Markdown while
+// collecting structural elements.
+type extractor struct {
+	parser    *Parser
+	path      string
+	rels      map[string]relationship
+	styles    map[string]*style
+	numbering numbering
+	props     mq.Metadata
+
+	// Rendered output
+	out  strings.Builder
+	line int // lines written so far
+
+	// Extracted elements
+	title    string
+	headings []*mq.Heading
+	sections []*mq.Section
+	links    []*mq.Link
+	images   []*mq.Image
+	tables   []*mq.Table
+	lists    []*mq.List
+
+	// Links and images not yet placed in the rendering
+	pendingLinks  []*mq.Link
+	pendingImages []*mq.Image
+
+	// Open list being accumulated from consecutive numbered paragraphs
+	list      *mq.List
+	listNumID string
+	listItems []*listNode
+	listStack []*listNode
+}
+
+// listNode is a mutable list item used while nesting is still unknown.
+type listNode struct {
+	text     string
+	level    int
+	pos      mq.Position
+	children []*listNode
+}
+
+func (e *extractor) extract(root *node) *mq.Document {
+	body := root.child("body")
+	if body == nil {
+		body = root
+	}
+	e.walkBlocks(body)
+	e.flushList()
+
+	source := strings.TrimRight(e.out.String(), "\n")
+	e.sections = outline.Nest(e.headings, strings.Count(source, "\n")+1, 1)
+
+	title := e.title
+	if t, ok := e.props["title"].(string); ok {
+		title = t
+	}
+	if title == "" && len(e.headings) > 0 {
+		title = e.headings[0].Text
+	}
+
+	doc := mq.NewDocument(
+		[]byte(source),
+		e.path,
+		mq.FormatDOCX,
+		title,
+		e.headings,
+		e.sections,
+		nil, // codeBlocks
+		e.links,
+		e.images,
+		e.tables,
+		e.lists,
+		source,
+	)
+	if e.props != nil {
+		doc.SetMetadata(e.props)
+	}
+	return doc
+}
+
+// walkBlocks handles block-level content in document order.
+func (e *extractor) walkBlocks(n *node) {
+	for _, c := range n.children {
+		switch c.name {
+		case "p":
+			e.paragraph(c)
+		case "tbl":
+			e.flushList()
+			e.table(c)
+		case "sdt":
+			if content := c.child("sdtContent"); content != nil {
+				e.walkBlocks(content)
+			}
+		case "customXml", "ins":
+			e.walkBlocks(c)
+		}
+	}
+}
+
+// paragraph classifies a paragraph as heading, list item, or body text.
+func (e *extractor) paragraph(p *node) {
+	pPr := p.child("pPr")
+	styleID := pPr.child("pStyle").attr("val")
+
+	in := &inline{rels: e.rels}
+	in.walk(p)
+	text := strings.Join(strings.Fields(in.buf.String()), " ")
+
+	if level := e.headingLevel(styleID, pPr); level > 0 && text != "" {
+		e.flushList()
+		e.addInline(in)
+		h := &mq.Heading{
+			Level: level,
+			Text:  text,
+			ID:    mq.Slugify(text, mq.SlugGitHub),
+		}
+		e.headings = append(e.headings, h)
+		h.SetPosition(e.writeBlock(strings.Repeat("#", level) + " " + text))
+		return
+	}
+
+	if e.isTitleStyle(styleID) && e.title == "" {
+		e.title = text
+	}
+
+	if numID, level := e.numberingFor(styleID, pPr); numID != "" && numID != "0" && text != "" {
+		e.addInline(in)
+		e.listItem(numID, level, text)
+		return
+	}
+
+	e.flushList()
+	e.addInline(in)
+	if text != "" {
+		e.writeBlock(text)
+	}
+}
+
+// headingLevel returns 1-6 for heading paragraphs, 0 otherwise.
+func (e *extractor) headingLevel(styleID string, pPr *node) int {
+	if e.parser.outlineLevels {
+		if lvl := pPr.child("outlineLvl"); lvl != nil {
+			if n, err := strconv.Atoi(lvl.attr("val")); err == nil && n < 6 {
+				return n + 1
+			}
+		}
+	}
+
+	// Follow the basedOn chain; custom heading styles usually inherit
+	// from a built-in one.
+	id := styleID
+	for depth := 0; id != "" && depth < 10; depth++ {
+		if n := headingNumber(id); n > 0 {
+			return n
+		}
+		st, ok := e.styles[id]
+		if !ok {
+			break
+		}
+		if n := headingNumber(strings.ReplaceAll(st.name, " ", "")); n > 0 {
+			return n
+		}
+		if e.parser.outlineLevels && st.outline >= 0 && st.outline < 6 {
+			return st.outline + 1
+		}
+		id = st.basedOn
+	}
+	return 0
+}
+
+var headingStyleRe = regexp.MustCompile(`(?i)^heading([1-6])$`)
+
+// headingNumber parses "Heading1" / "heading 1" style names.
+func headingNumber(name string) int {
+	m := headingStyleRe.FindStringSubmatch(name)
+	if m == nil {
+		return 0
+	}
+	return int(m[1][0] - '0')
+}
+
+func (e *extractor) isTitleStyle(styleID string) bool {
+	if strings.EqualFold(styleID, "Title") {
+		return true
+	}
+	st, ok := e.styles[styleID]
+	return ok && strings.EqualFold(st.name, "Title")
+}
+
+// numberingFor returns the numbering instance and indent level of a
+// paragraph, from direct formatting or its style.
+func (e *extractor) numberingFor(styleID string, pPr *node) (string, int) {
+	numID, level := "", ""
+	if numPr := pPr.child("numPr"); numPr != nil {
+		numID = numPr.child("numId").attr("val")
+		level = numPr.child("ilvl").attr("val")
+	}
+	if numID == "" {
+		for id, depth := styleID, 0; id != "" && depth < 10; depth++ {
+			st, ok := e.styles[id]
+			if !ok {
+				break
+			}
+			if st.numID != "" {
+				numID, level = st.numID, st.numLevel
+				break
+			}
+			id = st.basedOn
+		}
+	}
+	n, _ := strconv.Atoi(level)
+	return numID, n
+}
+
+// listItem appends an item to the open list, starting a new list when
+// the numbering instance changes.
+func (e *extractor) listItem(numID string, level int, text string) {
+	if e.list != nil && e.listNumID != numID {
+		e.flushList()
+	}
+	if e.list == nil {
+		e.list = &mq.List{Ordered: e.numbering.ordered(numID, "0")}
+		e.listNumID = numID
+	}
+
+	marker := "- "
+	if e.numbering.ordered(numID, strconv.Itoa(level)) {
+		marker = "1. "
+	}
+	item := &listNode{text: text, level: level}
+	item.pos = e.write(strings.Repeat("  ", level) + marker + text)
+
+	for len(e.listStack) > 0 && e.listStack[len(e.listStack)-1].level >= level {
+		e.listStack = e.listStack[:len(e.listStack)-1]
+	}
+	if len(e.listStack) == 0 {
+		e.listItems = append(e.listItems, item)
+	} else {
+		parent := e.listStack[len(e.listStack)-1]
+		parent.children = append(parent.children, item)
+	}
+	e.listStack = append(e.listStack, item)
+}
+
+// flushList closes the open list, if any.
+func (e *extractor) flushList() {
+	if e.list == nil {
+		return
+	}
+	e.list.Items = toListItems(e.listItems)
+	first, last := e.list.Items[0].Position, outline.LastItem(e.list.Items).Position
+	e.list.Position = mq.Position{Line: first.Line, Column: first.Column, EndLine: last.EndLine, EndColumn: last.EndColumn}
+	e.lists = append(e.lists, e.list)
+	e.list, e.listNumID, e.listItems, e.listStack = nil, "", nil, nil
+
+	// Blank line after the list, like any other block.
+	e.out.WriteString("\n")
+	e.line++
+}
+
+func toListItems(nodes []*listNode) []mq.ListItem {
+	items := make([]mq.ListItem, len(nodes))
+	for i, n := range nodes {
+		items[i] = mq.ListItem{Text: n.text, Position: n.pos, Children: toListItems(n.children)}
+	}
+	return items
+}
+
+// table converts a Word table, using the first row as headers.
+func (e *extractor) table(tbl *node) {
+	var rows [][]string
+	for _, tr := range tbl.children {
+		if tr.name != "tr" {
+			continue
+		}
+		var row []string
+		for _, tc := range tr.children {
+			if tc.name != "tc" {
+				continue
+			}
+			in := &inline{rels: e.rels}
+			in.walkCell(tc)
+			e.addInline(in)
+			row = append(row, strings.Join(strings.Fields(in.buf.String()), " "))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	table := &mq.Table{Headers: rows[0], Rows: rows[1:]}
+	e.tables = append(e.tables, table)
+
+	var buf strings.Builder
+	writeRow(&buf, table.Headers)
+	buf.WriteString("|")
+	for range table.Headers {
+		buf.WriteString(" --- |")
+	}
+	buf.WriteString("\n")
+	for _, row := range table.Rows {
+		writeRow(&buf, row)
+	}
+	table.Position = e.writeBlock(strings.TrimRight(buf.String(), "\n"))
+}
+
+func writeRow(buf *strings.Builder, cells []string) {
+	buf.WriteString("|")
+	for _, c := range cells {
+		buf.WriteString(" " + strings.ReplaceAll(c, "|", `\|`) + " |")
+	}
+	buf.WriteString("\n")
+}
+
+// writeBlock writes a block followed by a blank line.
+func (e *extractor) writeBlock(text string) mq.Position {
+	pos := e.write(text)
+	e.out.WriteString("\n")
+	e.line++
+	return pos
+}
+
+// write writes text and a newline, returning where the text landed.
+// Pending links are located by their text; images, which have none in
+// the rendering, take the position of the whole text.
+func (e *extractor) write(text string) mq.Position {
+	index := mq.NewLineIndex([]byte(text))
+	at := func(start, end int) mq.Position {
+		pos := index.Position(start, end)
+		pos.Line += e.line
+		pos.EndLine += e.line
+		return pos
+	}
+	whole := at(0, len(text))
+
+	from := 0
+	for _, l := range e.pendingLinks {
+		if i := strings.Index(text[from:], l.Text); i >= 0 && l.Text != "" {
+			l.Position = at(from+i, from+i+len(l.Text))
+			from += i + len(l.Text)
+		} else {
+			l.Position = whole
+		}
+	}
+	for _, img := range e.pendingImages {
+		img.Position = whole
+	}
+	e.pendingLinks, e.pendingImages = nil, nil
+
+	e.out.WriteString(text)
+	e.out.WriteString("\n")
+	e.line += strings.Count(text, "\n") + 1
+	return whole
+}
+
+// addInline records the links and images of a paragraph or cell; they
+// are placed when their text is written.
+func (e *extractor) addInline(in *inline) {
+	e.links = append(e.links, in.links...)
+	e.images = append(e.images, in.images...)
+	e.pendingLinks = append(e.pendingLinks, in.links...)
+	e.pendingImages = append(e.pendingImages, in.images...)
+}
+
+// inline collects run text, hyperlinks and images within a paragraph.
+type inline struct {
+	rels   map[string]relationship
+	buf    strings.Builder
+	links  []*mq.Link
+	images []*mq.Image
+
+	// Complex field state (HYPERLINK fields built from fldChar runs)
+	inInstr    bool
+	instr      strings.Builder
+	fieldURL   string
+	fieldStart int
+}
+
+var hyperlinkFieldRe = regexp.MustCompile(`HYPERLINK\s+(?:\\l\s+)?"([^"]+)"`)
+
+func (in *inline) walk(n *node) {
+	for _, c := range n.children {
+		switch c.name {
+		case "pPr", "rPr", "del", "moveFrom":
+			// Properties and deleted text are not content.
+		case "t":
+			if !in.inInstr {
+				in.buf.WriteString(c.text)
+			}
+		case "tab", "br", "cr":
+			in.buf.WriteString(" ")
+		case "noBreakHyphen":
+			in.buf.WriteString("-")
+		case "instrText":
+			if in.inInstr {
+				in.instr.WriteString(c.text)
+			}
+		case "fldChar":
+			in.fieldChar(c.attr("fldCharType"))
+		case "hyperlink":
+			in.hyperlink(c)
+		case "fldSimple":
+			start := in.buf.Len()
+			in.walk(c)
+			if m := hyperlinkFieldRe.FindStringSubmatch(c.attr("instr")); m != nil {
+				in.addLink(in.buf.String()[start:], m[1])
+			}
+		case "drawing", "pict":
+			in.image(c)
+		default:
+			in.walk(c)
+		}
+	}
+}
+
+// walkCell collects the text of every paragraph in a table cell.
+func (in *inline) walkCell(tc *node) {
+	for _, c := range tc.children {
+		switch c.name {
+		case "p":
+			in.walk(c)
+			in.buf.WriteString(" ")
+		case "tbl", "sdt", "sdtContent", "tr", "tc", "customXml":
+			in.walkCell(c)
+		}
+	}
+}
+
+func (in *inline) fieldChar(kind string) {
+	switch kind {
+	case "begin":
+		in.inInstr = true
+		in.instr.Reset()
+	case "separate":
+		in.inInstr = false
+		if m := hyperlinkFieldRe.FindStringSubmatch(in.instr.String()); m != nil {
+			in.fieldURL = m[1]
+			if strings.Contains(in.instr.String(), `\l`) {
+				in.fieldURL = "#" + m[1]
+			}
+			in.fieldStart = in.buf.Len()
+		}
+	case "end":
+		in.inInstr = false
+		if in.fieldURL != "" {
+			in.addLink(in.buf.String()[in.fieldStart:], in.fieldURL)
+			in.fieldURL = ""
+		}
+	}
+}
+
+func (in *inline) hyperlink(h *node) {
+	start := in.buf.Len()
+	in.walk(h)
+
+	var url string
+	if rel, ok := in.rels[h.attr("id")]; ok {
+		url = rel.target
+	}
+	if anchor := h.attr("anchor"); anchor != "" {
+		url += "#" + anchor
+	}
+	if url != "" {
+		in.addLink(in.buf.String()[start:], url)
+	}
+}
+
+func (in *inline) addLink(text, url string) {
+	in.links = append(in.links, &mq.Link{
+		Text: strings.Join(strings.Fields(text), " "),
+		URL:  url,
+	})
+}
+
+// image records an embedded picture. The URL is the part name inside
+// the package (e.g., word/media/image1.png).
+func (in *inline) image(n *node) {
+	id := n.find("blip").attr("embed")
+	if id == "" {
+		id = n.find("imagedata").attr("id")
+	}
+	rel, ok := in.rels[id]
+	if !ok {
+		return
+	}
+
+	url := rel.target
+	if !rel.external {
+		url = path.Join("word", url)
+	}
+
+	docPr := n.find("docPr")
+	alt := docPr.attr("descr")
+	if alt == "" {
+		alt = docPr.attr("name")
+	}
+	in.images = append(in.images, &mq.Image{
+		AltText: alt,
+		URL:     url,
+		Title:   docPr.attr("title"),
+	})
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseDOCX is a convenience function for quick parsing.
+func ParseDOCX(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseDOCXFile is a convenience function for quick file parsing.
+func ParseDOCXFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
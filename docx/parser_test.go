@@ -0,0 +1,180 @@
+package docx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/muqsitnawaz/mq/docx"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const documentXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+            xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<w:body>
+  <w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Payments Spec</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Overview</w:t></w:r></w:p>
+  <w:p><w:r><w:t xml:space="preserve">See the </w:t></w:r><w:hyperlink r:id="rId5"><w:r><w:t>API docs</w:t></w:r></w:hyperlink><w:r><w:t>.</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="CustomHeading"/></w:pPr><w:r><w:t>Goals</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Fast</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Under 100ms</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Safe</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t>Design</w:t></w:r></w:p>
+  <w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t>Build</w:t></w:r></w:p>
+  <w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Limits</w:t></w:r></w:p>
+  <w:tbl>
+    <w:tr><w:tc><w:p><w:r><w:t>Plan</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Rate</w:t></w:r></w:p></w:tc></w:tr>
+    <w:tr><w:tc><w:p><w:r><w:t>Free</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>10/s</w:t></w:r></w:p></w:tc></w:tr>
+    <w:tr><w:tc><w:p><w:r><w:t>Pro</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>100/s</w:t></w:r></w:p></w:tc></w:tr>
+  </w:tbl>
+  <w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> HYPERLINK "https://example.com/status" </w:instrText></w:r><w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>Status page</w:t></w:r><w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>
+  <w:p><w:r><w:del><w:r><w:delText>removed</w:delText></w:r></w:del></w:r></w:p>
+</w:body>
+</w:document>`
+
+const stylesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/></w:style>
+  <w:style w:type="paragraph" w:styleId="CustomHeading"><w:name w:val="Custom Heading"/><w:basedOn w:val="Heading2"/></w:style>
+</w:styles>`
+
+const numberingXML = `<?xml version="1.0" encoding="UTF-8"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:abstractNum w:abstractNumId="10">
+    <w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl>
+    <w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/></w:lvl>
+  </w:abstractNum>
+  <w:abstractNum w:abstractNumId="11">
+    <w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/></w:lvl>
+  </w:abstractNum>
+  <w:num w:numId="1"><w:abstractNumId w:val="10"/></w:num>
+  <w:num w:numId="2"><w:abstractNumId w:val="11"/></w:num>
+</w:numbering>`
+
+const relsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/api" TargetMode="External"/>
+</Relationships>`
+
+const coreXML = `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
+                   xmlns:dc="http://purl.org/dc/elements/1.1/"
+                   xmlns:dcterms="http://purl.org/dc/terms/">
+  <dc:creator>Dana Reyes</dc:creator>
+  <cp:lastModifiedBy>Sam Ito</cp:lastModifiedBy>
+  <dcterms:modified>2024-03-01T10:00:00Z</dcterms:modified>
+</cp:coreProperties>`
+
+func buildDOCX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func parseSample(t *testing.T) *mq.Document {
+	t.Helper()
+	content := buildDOCX(t, map[string]string{
+		"word/document.xml":            documentXML,
+		"word/styles.xml":              stylesXML,
+		"word/numbering.xml":           numberingXML,
+		"word/_rels/document.xml.rels": relsXML,
+		"docProps/core.xml":            coreXML,
+	})
+	doc, err := docx.ParseDOCX(content, "spec.docx")
+	require.NoError(t, err)
+	return doc
+}
+
+func TestParserFormat(t *testing.T) {
+	p := docx.NewParser()
+	assert.Equal(t, mq.FormatDOCX, p.Format())
+}
+
+func TestParseHeadingsAndSections(t *testing.T) {
+	doc := parseSample(t)
+
+	assert.Equal(t, mq.FormatDOCX, doc.Format())
+	assert.Equal(t, "Payments Spec", doc.Title())
+
+	h1s := doc.GetHeadings(1)
+	require.Len(t, h1s, 2)
+	assert.Equal(t, "Overview", h1s[0].Text)
+	assert.Equal(t, "Limits", h1s[1].Text)
+
+	// CustomHeading inherits its level from Heading2
+	h2s := doc.GetHeadings(2)
+	require.Len(t, h2s, 1)
+	assert.Equal(t, "Goals", h2s[0].Text)
+
+	section, ok := doc.GetSection("Overview")
+	require.True(t, ok)
+	require.Len(t, section.Children, 1)
+	assert.Equal(t, "Goals", section.Children[0].Heading.Text)
+	assert.Contains(t, section.GetText(), "See the API docs.")
+	assert.Contains(t, section.GetText(), "- Fast")
+	assert.NotContains(t, section.GetText(), "Limits")
+
+	limits, ok := doc.GetSection("Limits")
+	require.True(t, ok)
+	assert.Contains(t, limits.GetText(), "| Free | 10/s |")
+
+	assert.NotContains(t, doc.ReadableText(), "removed")
+}
+
+func TestParseListsTablesLinks(t *testing.T) {
+	doc := parseSample(t)
+
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 2)
+	assert.False(t, lists[0].Ordered)
+	require.Len(t, lists[0].Items, 2)
+	assert.Equal(t, "Fast", lists[0].Items[0].Text)
+	require.Len(t, lists[0].Items[0].Children, 1)
+	assert.Equal(t, "Under 100ms", lists[0].Items[0].Children[0].Text)
+	assert.True(t, lists[1].Ordered)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"Plan", "Rate"}, tables[0].Headers)
+	assert.Equal(t, [][]string{{"Free", "10/s"}, {"Pro", "100/s"}}, tables[0].Rows)
+
+	links := doc.GetLinks()
+	require.Len(t, links, 2)
+	assert.Equal(t, "API docs", links[0].Text)
+	assert.Equal(t, "https://example.com/api", links[0].URL)
+	assert.Equal(t, "Status page", links[1].Text)
+	assert.Equal(t, "https://example.com/status", links[1].URL)
+}
+
+func TestParseCoreProperties(t *testing.T) {
+	doc := parseSample(t)
+
+	author, ok := doc.GetMetadataField("author")
+	require.True(t, ok)
+	assert.Equal(t, "Dana Reyes", author)
+
+	modified, _ := doc.GetMetadataField("modified")
+	assert.Equal(t, "2024-03-01T10:00:00Z", modified)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := docx.ParseDOCX([]byte("not a zip"), "bad.docx")
+	var parseErr *mq.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, mq.FormatDOCX, parseErr.Format)
+
+	content := buildDOCX(t, map[string]string{"xl/workbook.xml": "<workbook/>"})
+	_, err = docx.ParseDOCX(content, "sheet.docx")
+	assert.ErrorContains(t, err, "word/document.xml")
+}
@@ -0,0 +1,802 @@
+// Package email provides RFC 5322/MIME message parsing for mq.
+//
+// A single message (.eml) or a mailbox of messages (.mbox) becomes one
+// document. The parser maps mail structure onto mq's unified types:
+//   - Sections: one per message, headed "Subject — Sender, Date" and
+//     nested by In-Reply-To/References so the tree shows each thread
+//   - Section metadata: from, to, cc, date, subject, message_id, ...
+//   - Body: text/plain preferred; HTML-only bodies go through html.Parser
+//   - Quoted replies: collapsed to a single marker line by default
+//   - Links: URLs in bodies, and attachments as attachment:filename
+//
+// Mail has no meaningful line structure across messages, so the document
+// source is a rendering of the threads in conversation order. Section
+// line ranges and GetText refer to that rendering.
+//
+// Example:
+//
+//	parser := email.NewParser()
+//	doc, _ := parser.ParseFile("incident.mbox")
+//
+//	threads := doc.GetTableOfContents()
+//	from, _ := threads[0].Metadata["from"]
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	xhtml "golang.org/x/net/html"
+
+	"github.com/muqsitnawaz/mq/html"
+	"github.com/muqsitnawaz/mq/internal/outline"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// quoteMarker replaces collapsed quoted replies.
+const quoteMarker = "[quoted text hidden]"
+
+// Parser parses email messages and mailboxes into mq.Document.
+type Parser struct {
+	// Options
+	keepQuotes bool // Keep quoted replies instead of collapsing them
+	threads    bool // Nest replies under the messages they answer
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new email parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		threads: true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithQuotes keeps quoted replies ("> ...", "On ... wrote:") in bodies.
+// By default they are collapsed, since each quoted message usually
+// appears in full elsewhere in the thread.
+func WithQuotes(enabled bool) Option {
+	return func(p *Parser) {
+		p.keepQuotes = enabled
+	}
+}
+
+// WithThreads enables/disables nesting replies by In-Reply-To/References.
+// When disabled, messages are listed flat in mailbox order.
+func WithThreads(enabled bool) Option {
+	return func(p *Parser) {
+		p.threads = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatEmail
+}
+
+// ParseFile reads and parses an .eml or .mbox file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatEmail, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses a message or mailbox and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+
+	ext := &extractor{parser: p, path: path}
+
+	// An empty file is a mailbox with no messages
+	if len(bytes.TrimSpace(content)) == 0 {
+		return ext.extract(), nil
+	}
+
+	raws := [][]byte{content}
+	if isMbox(content) {
+		raws = splitMbox(content)
+	}
+
+	for _, raw := range raws {
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			if len(raws) == 1 {
+				return nil, &mq.ParseError{Format: mq.FormatEmail, Path: path, Err: err}
+			}
+			continue // skip damaged mailbox entries
+		}
+		ext.addMessage(msg)
+	}
+	if len(ext.messages) == 0 {
+		return nil, &mq.ParseError{Format: mq.FormatEmail, Path: path, Err: errors.New("no messages found")}
+	}
+
+	return ext.extract(), nil
+}
+
+// isMbox reports whether content starts with an mbox "From " separator.
+func isMbox(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("From "))
+}
+
+// splitMbox splits a mailbox on "From " separator lines and undoes
+// >From quoting.
+func splitMbox(content []byte) [][]byte {
+	var msgs [][]byte
+	var cur bytes.Buffer
+	prevBlank := true
+
+	flush := func() {
+		if len(bytes.TrimSpace(cur.Bytes())) > 0 {
+			msgs = append(msgs, append([]byte(nil), cur.Bytes()...))
+		}
+		cur.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if prevBlank && bytes.HasPrefix(line, []byte("From ")) {
+			flush()
+			prevBlank = false
+			continue
+		}
+		if len(line) > 0 && line[0] == '>' && bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			line = line[1:]
+		}
+		cur.Write(line)
+		cur.WriteByte('\n')
+		prevBlank = len(line) == 0
+	}
+	flush()
+	return msgs
+}
+
+// message is one parsed mail message.
+type message struct {
+	meta      mq.Metadata
+	subject   string
+	sender    string
+	date      time.Time
+	id        string
+	inReplyTo string
+	refs      []string
+
+	body        []string
+	links       []*mq.Link
+	attachments []attachment
+
+	parent   *message
+	children []*message
+}
+
+type attachment struct {
+	name  string
+	ctype string
+	size  int
+}
+
+// extractor collects messages, threads them and renders the document.
+type extractor struct {
+	parser   *Parser
+	path     string
+	messages []*message
+
+	// Rendered output
+	out  strings.Builder
+	line int // lines written so far
+
+	// Extracted elements
+	headings []*mq.Heading
+	sections []*mq.Section
+	links    []*mq.Link
+	pending  []*mq.Link // links not yet found in the rendering
+}
+
+var headerKeys = []struct{ header, key string }{
+	{"From", "from"},
+	{"To", "to"},
+	{"Cc", "cc"},
+	{"Date", "date"},
+	{"Subject", "subject"},
+	{"Message-Id", "message_id"},
+	{"In-Reply-To", "in_reply_to"},
+	{"References", "references"},
+	{"Reply-To", "reply_to"},
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+func (e *extractor) addMessage(msg *mail.Message) {
+	m := &message{meta: mq.Metadata{}}
+
+	for _, h := range headerKeys {
+		v := msg.Header.Get(h.header)
+		if v == "" {
+			continue
+		}
+		if dec, err := wordDecoder.DecodeHeader(v); err == nil {
+			v = dec
+		}
+		m.meta[h.key] = strings.Join(strings.Fields(v), " ")
+	}
+
+	m.subject, _ = m.meta["subject"].(string)
+	if m.subject == "" {
+		m.subject = "(no subject)"
+	}
+	m.sender, _ = m.meta["from"].(string)
+	if addr, err := mail.ParseAddress(m.sender); err == nil {
+		m.sender = addr.Name
+		if m.sender == "" {
+			m.sender = addr.Address
+		}
+	}
+	if d, err := msg.Header.Date(); err == nil {
+		m.date = d
+	}
+	m.id = firstID(msg.Header.Get("Message-Id"))
+	m.inReplyTo = firstID(msg.Header.Get("In-Reply-To"))
+	m.refs = messageIDs(msg.Header.Get("References"))
+
+	e.part(m, textproto.MIMEHeader(msg.Header), msg.Body)
+	e.messages = append(e.messages, m)
+}
+
+// part walks a MIME part, appending body text and attachments to m.
+func (e *extractor) part(m *message, header textproto.MIMEHeader, body io.Reader) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if dec, err := wordDecoder.DecodeHeader(filename); err == nil {
+		filename = dec
+	}
+
+	if disposition == "attachment" || (filename != "" && !strings.HasPrefix(mediaType, "multipart/")) {
+		data, _ := io.ReadAll(decodeTransfer(header, body))
+		if filename == "" {
+			filename = "attachment"
+		}
+		m.attachments = append(m.attachments, attachment{name: filename, ctype: mediaType, size: len(data)})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		var parts []rawPart
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(p)
+			parts = append(parts, rawPart{header: p.Header, data: data})
+		}
+		if mediaType == "multipart/alternative" {
+			if best, ok := chooseAlternative(parts); ok {
+				e.part(m, best.header, bytes.NewReader(best.data))
+			}
+			return
+		}
+		for _, p := range parts {
+			e.part(m, p.header, bytes.NewReader(p.data))
+		}
+
+	case mediaType == "message/rfc822":
+		inner, err := mail.ReadMessage(decodeTransfer(header, body))
+		if err != nil {
+			return
+		}
+		fwd := &message{meta: mq.Metadata{}}
+		e.part(fwd, textproto.MIMEHeader(inner.Header), inner.Body)
+		var buf strings.Builder
+		buf.WriteString("---------- Forwarded message ----------\n")
+		for _, h := range []string{"From", "Date", "Subject", "To"} {
+			if v := inner.Header.Get(h); v != "" {
+				if dec, err := wordDecoder.DecodeHeader(v); err == nil {
+					v = dec
+				}
+				fmt.Fprintf(&buf, "%s: %s\n", h, v)
+			}
+		}
+		buf.WriteString("\n")
+		buf.WriteString(strings.Join(fwd.body, "\n\n"))
+		m.body = append(m.body, buf.String())
+		m.links = append(m.links, fwd.links...)
+		m.attachments = append(m.attachments, fwd.attachments...)
+
+	case mediaType == "text/html":
+		data, _ := io.ReadAll(decodeTransfer(header, body))
+		e.htmlBody(m, decodeCharset(data, params["charset"]))
+
+	case mediaType == "text/plain" || mediaType == "":
+		data, _ := io.ReadAll(decodeTransfer(header, body))
+		text := decodeCharset(data, params["charset"])
+		if !e.parser.keepQuotes {
+			text = collapseQuotes(text)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		m.body = append(m.body, text)
+		for _, u := range urlRe.FindAllString(text, -1) {
+			u = strings.TrimRight(u, ".,;:!?)")
+			m.links = append(m.links, &mq.Link{Text: u, URL: u})
+		}
+	}
+}
+
+type rawPart struct {
+	header textproto.MIMEHeader
+	data   []byte
+}
+
+// chooseAlternative prefers text/plain, then text/html, then a nested
+// multipart, then whatever comes first.
+func chooseAlternative(parts []rawPart) (rawPart, bool) {
+	if len(parts) == 0 {
+		return rawPart{}, false
+	}
+	for _, want := range []string{"text/plain", "text/html", "multipart/"} {
+		for _, p := range parts {
+			mt, _, _ := mime.ParseMediaType(p.header.Get("Content-Type"))
+			if strings.HasPrefix(mt, want) && len(bytes.TrimSpace(p.data)) > 0 {
+				return p, true
+			}
+		}
+	}
+	return parts[0], true
+}
+
+// htmlBody renders an HTML part through the HTML parser.
+func (e *extractor) htmlBody(m *message, source string) {
+	if !e.parser.keepQuotes {
+		source = collapseHTMLQuotes(source)
+	}
+	doc, err := html.NewParser(html.WithReadability(false)).Parse([]byte(source), e.path)
+	if err != nil {
+		return
+	}
+	text := doc.ReadableText()
+	// With Readability disabled the <title> text leads the body.
+	if t := doc.Title(); t != "" {
+		text = strings.TrimPrefix(text, t+"\n")
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		m.body = append(m.body, text)
+	}
+	m.links = append(m.links, doc.GetLinks()...)
+}
+
+var (
+	urlRe         = regexp.MustCompile(`https?://[^\s<>"']+`)
+	attributionRe = regexp.MustCompile(`(?i)^(on .+ wrote:|.+ schrieb .+:|le .+ a écrit ?:)$`)
+	originalRe    = regexp.MustCompile(`(?i)^-{2,}\s*(original message|forwarded by)`)
+)
+
+// collapseQuotes replaces each run of "> " lines (with its attribution
+// line) by a marker, and drops everything after an Outlook-style
+// "Original Message" separator.
+func collapseQuotes(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if originalRe.MatchString(trimmed) {
+			out = append(out, quoteMarker)
+			break
+		}
+
+		if isQuoted(line) {
+			// Consume the run, including blank lines between quoted lines.
+			for i+1 < len(lines) {
+				if isQuoted(lines[i+1]) {
+					i++
+				} else if strings.TrimSpace(lines[i+1]) == "" && i+2 < len(lines) && isQuoted(lines[i+2]) {
+					i += 2
+				} else {
+					break
+				}
+			}
+			// Drop the attribution ("On Mon, Bob wrote:") above the quote.
+			for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+				out = out[:len(out)-1]
+			}
+			if len(out) > 0 && attributionRe.MatchString(strings.TrimSpace(out[len(out)-1])) {
+				out = out[:len(out)-1]
+			}
+			if len(out) > 0 {
+				out = append(out, "")
+			}
+			out = append(out, quoteMarker)
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func isQuoted(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ">")
+}
+
+// quoteContainers are HTML elements mail clients wrap quoted replies in.
+var quoteContainers = map[string]bool{
+	"gmail_quote":          true,
+	"moz-cite-prefix":      true,
+	"yahoo_quoted":         true,
+	"protonmail_quote":     true,
+	"OutlookMessageHeader": true,
+}
+
+// collapseHTMLQuotes replaces blockquotes and known quote containers
+// with the quote marker.
+func collapseHTMLQuotes(source string) string {
+	root, err := xhtml.Parse(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+
+	var walk func(n *xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == xhtml.ElementNode && isQuoteNode(c) {
+				marker := &xhtml.Node{Type: xhtml.ElementNode, Data: "p"}
+				marker.AppendChild(&xhtml.Node{Type: xhtml.TextNode, Data: quoteMarker})
+				n.InsertBefore(marker, c)
+				n.RemoveChild(c)
+			} else {
+				walk(c)
+			}
+			c = next
+		}
+	}
+	walk(root)
+
+	var buf bytes.Buffer
+	if err := xhtml.Render(&buf, root); err != nil {
+		return source
+	}
+	return buf.String()
+}
+
+func isQuoteNode(n *xhtml.Node) bool {
+	if n.Data == "blockquote" {
+		return true
+	}
+	for _, a := range n.Attr {
+		if a.Key != "class" && a.Key != "id" {
+			continue
+		}
+		for _, c := range strings.Fields(a.Val) {
+			if quoteContainers[c] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeTransfer undoes base64 or quoted-printable transfer encoding.
+// Parts read through multipart.Reader have quoted-printable removed already.
+func decodeTransfer(header textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &whitespaceStripper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// whitespaceStripper drops line breaks and spaces from base64 input.
+type whitespaceStripper struct {
+	r io.Reader
+}
+
+func (w *whitespaceStripper) Read(p []byte) (int, error) {
+	n, err := w.r.Read(p)
+	j := 0
+	for _, b := range p[:n] {
+		if b != '\n' && b != '\r' && b != ' ' && b != '\t' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
+
+// decodeCharset converts Latin-1 family text to UTF-8; other charsets
+// are passed through.
+func decodeCharset(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "iso-8859-15", "latin1", "windows-1252", "cp1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return string(data)
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(decodeCharset(data, charset)), nil
+}
+
+var idRe = regexp.MustCompile(`<[^<>\s]+>`)
+
+func messageIDs(v string) []string {
+	return idRe.FindAllString(v, -1)
+}
+
+func firstID(v string) string {
+	if ids := messageIDs(v); len(ids) > 0 {
+		return ids[0]
+	}
+	return strings.TrimSpace(v)
+}
+
+// thread links replies to their parents and returns the roots in
+// mailbox order.
+func (e *extractor) thread() []*message {
+	byID := make(map[string]*message)
+	for _, m := range e.messages {
+		if m.id != "" {
+			if _, dup := byID[m.id]; !dup {
+				byID[m.id] = m
+			}
+		}
+	}
+
+	var roots []*message
+	for _, m := range e.messages {
+		var parent *message
+		if e.parser.threads {
+			candidates := append([]string{m.inReplyTo}, reversed(m.refs)...)
+			for _, id := range candidates {
+				if p, ok := byID[id]; ok && p != m && !isAncestor(m, p) {
+					parent = p
+					break
+				}
+			}
+		}
+		if parent == nil {
+			roots = append(roots, m)
+			continue
+		}
+		m.parent = parent
+		parent.children = append(parent.children, m)
+	}
+	return roots
+}
+
+// isAncestor reports whether a is an ancestor of (or equal to) m.
+func isAncestor(a, m *message) bool {
+	for p := m; p != nil; p = p.parent {
+		if p == a {
+			return true
+		}
+	}
+	return false
+}
+
+func reversed(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
+
+func (e *extractor) extract() *mq.Document {
+	roots := e.thread()
+	for _, m := range roots {
+		e.render(m, 1, nil)
+	}
+
+	source := strings.TrimRight(e.out.String(), "\n")
+	total := strings.Count(source, "\n") + 1
+	// Sections nest by thread, which levels cap at 6, so only ends are set
+	outline.Close(e.sections, total, 1)
+
+	var title string
+	if len(roots) > 0 {
+		title = strings.TrimSpace(replyPrefixRe.ReplaceAllString(roots[0].subject, ""))
+	}
+
+	doc := mq.NewDocument(
+		[]byte(source),
+		e.path,
+		mq.FormatEmail,
+		title,
+		e.headings,
+		e.sections,
+		nil, // codeBlocks
+		e.links,
+		nil, // images
+		nil, // tables
+		nil, // lists
+		source,
+	)
+	doc.SetMetadata(e.metadata(roots))
+	return doc
+}
+
+var replyPrefixRe = regexp.MustCompile(`(?i)^((re|fwd?|aw|sv)(\[\d+\])?:\s*)+`)
+
+// metadata returns a single message's headers, or mailbox statistics.
+func (e *extractor) metadata(roots []*message) mq.Metadata {
+	if len(e.messages) == 1 {
+		return e.messages[0].meta
+	}
+
+	var participants []interface{}
+	seen := make(map[string]bool)
+	for _, m := range e.messages {
+		if from, ok := m.meta["from"].(string); ok && !seen[from] {
+			seen[from] = true
+			participants = append(participants, from)
+		}
+	}
+	return mq.Metadata{
+		"messages":     len(e.messages),
+		"threads":      len(roots),
+		"participants": participants,
+	}
+}
+
+// render writes a message and its replies depth-first.
+func (e *extractor) render(m *message, level int, parent *mq.Section) {
+	text := m.subject
+	if m.sender != "" {
+		text += " — " + m.sender
+	}
+	if !m.date.IsZero() {
+		text += ", " + m.date.Format("2006-01-02 15:04")
+	}
+
+	marker := strings.Repeat("#", min(level, 6)) + " "
+	h := &mq.Heading{
+		Level: min(level, 6),
+		Text:  text,
+		ID:    strings.Trim(m.id, "<>"),
+
+		Line:      e.line + 1,
+		Column:    1,
+		EndLine:   e.line + 1,
+		EndColumn: len(marker + text),
+	}
+	s := &mq.Section{
+		Heading:  h,
+		Parent:   parent,
+		Start:    h.Line,
+		Metadata: m.meta,
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	e.headings = append(e.headings, h)
+	e.sections = append(e.sections, s)
+
+	var head strings.Builder
+	head.WriteString(marker + text)
+	for _, k := range []struct{ label, key string }{{"From", "from"}, {"To", "to"}, {"Cc", "cc"}, {"Date", "date"}} {
+		if v, ok := m.meta[k.key].(string); ok {
+			fmt.Fprintf(&head, "\n%s: %s", k.label, v)
+		}
+	}
+	e.writeBlock(head.String())
+
+	e.links = append(e.links, m.links...)
+	e.pending = m.links
+	for _, b := range m.body {
+		e.writeBlock(b)
+	}
+	// Links whose text the rendering changed point at the message
+	for _, l := range e.pending {
+		l.Position = h.Position()
+	}
+
+	if len(m.attachments) > 0 {
+		var att strings.Builder
+		att.WriteString("Attachments:")
+		e.pending = nil
+		for _, a := range m.attachments {
+			fmt.Fprintf(&att, "\n- %s (%s, %s)", a.name, a.ctype, formatSize(a.size))
+			l := &mq.Link{Text: a.name, URL: "attachment:" + a.name}
+			e.links = append(e.links, l)
+			e.pending = append(e.pending, l)
+		}
+		e.writeBlock(att.String())
+	}
+	e.pending = nil
+
+	for _, c := range m.children {
+		e.render(c, level+1, s)
+	}
+}
+
+// writeBlock writes a block followed by a blank line. Pending links are
+// located in it in order; the first one not found stays pending along
+// with those after it.
+func (e *extractor) writeBlock(text string) {
+	index := mq.NewLineIndex([]byte(text))
+	from := 0
+	for len(e.pending) > 0 {
+		l := e.pending[0]
+		i := strings.Index(text[from:], l.Text)
+		if i < 0 || l.Text == "" {
+			break
+		}
+		l.Position = index.Position(from+i, from+i+len(l.Text))
+		l.Line += e.line
+		l.EndLine += e.line
+		from += i + len(l.Text)
+		e.pending = e.pending[1:]
+	}
+
+	e.out.WriteString(text)
+	e.out.WriteString("\n\n")
+	e.line += strings.Count(text, "\n") + 2
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseEmail is a convenience function for quick parsing.
+func ParseEmail(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseEmailFile is a convenience function for quick file parsing.
+func ParseEmailFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,124 @@
+package email_test
+
+import (
+	"testing"
+
+	"github.com/muqsitnawaz/mq/email"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserFormat(t *testing.T) {
+	p := email.NewParser()
+	assert.Equal(t, mq.FormatEmail, p.Format())
+}
+
+func TestParseMboxThreads(t *testing.T) {
+	doc, err := email.ParseEmailFile("testdata/incident.mbox")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatEmail, doc.Format())
+	assert.Equal(t, "DB outage on primary", doc.Title())
+
+	// Two threads: the outage conversation and the postmortem notice
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 2)
+	root := toc[0]
+	assert.Equal(t, "DB outage on primary — Alice Smith, 2024-03-01 10:00", root.Heading.Text)
+	assert.Equal(t, "Postmortem scheduled — Davé, 2024-03-01 11:00", toc[1].Heading.Text)
+
+	// Replies nest by In-Reply-To
+	require.Len(t, root.Children, 1)
+	reply := root.Children[0]
+	assert.Equal(t, "Re: DB outage on primary — Bob Jones, 2024-03-01 10:05", reply.Heading.Text)
+	require.Len(t, reply.Children, 1)
+	assert.Equal(t, 3, reply.Children[0].Heading.Level)
+
+	// A message's section covers its replies, like nested headings
+	assert.Contains(t, root.GetText(), "Failover done.")
+	assert.NotContains(t, root.GetText(), "Postmortem")
+
+	meta := doc.Metadata()
+	assert.Equal(t, 4, meta["messages"])
+	assert.Equal(t, 2, meta["threads"])
+}
+
+func TestParseBodies(t *testing.T) {
+	doc, err := email.ParseEmailFile("testdata/incident.mbox")
+	require.NoError(t, err)
+
+	sections := doc.GetSections()
+	require.Len(t, sections, 4)
+
+	// Quoted-printable decoded, >From unescaped, quote and attribution collapsed
+	bob := sections[1].GetText()
+	assert.Contains(t, bob, "Failing over now — ETA 5 minutes.")
+	assert.Contains(t, bob, "\nFrom the logs")
+	assert.Contains(t, bob, "[quoted text hidden]")
+	assert.NotContains(t, bob, "wrote:")
+	assert.NotContains(t, bob, "09:52")
+	assert.Contains(t, bob, "- df.txt (text/plain, 14 B)")
+
+	// HTML bodies go through the HTML parser; blockquotes collapse too
+	carol := sections[2].GetText()
+	assert.Contains(t, carol, "Failover done. Writes are back.")
+	assert.NotContains(t, carol, "Disk is full")
+
+	assert.Equal(t, "Bob Jones <bob@example.com>", sections[1].Metadata["from"])
+	assert.Equal(t, "<1@example.com>", sections[1].Metadata["in_reply_to"])
+
+	var urls []string
+	for _, l := range doc.GetLinks() {
+		urls = append(urls, l.URL)
+	}
+	assert.Equal(t, []string{
+		"https://grafana.example.com/d/db",
+		"attachment:df.txt",
+		"https://status.example.com",
+	}, urls)
+}
+
+func TestParseKeepQuotesAndFlat(t *testing.T) {
+	doc, err := email.NewParser(email.WithQuotes(true), email.WithThreads(false)).ParseFile("testdata/incident.mbox")
+	require.NoError(t, err)
+
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 4)
+	assert.Contains(t, toc[1].GetText(), "> The primary database stopped")
+}
+
+func TestParseSingleMessage(t *testing.T) {
+	doc, err := email.ParseEmailFile("testdata/single.eml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Vendor notice", doc.Title())
+	sections := doc.GetSections()
+	require.Len(t, sections, 1)
+
+	// text/plain wins in multipart/alternative; the forwarded original collapses
+	text := sections[0].GetText()
+	assert.Contains(t, text, "Cc: oncall@example.com")
+	assert.Contains(t, text, "See below.")
+	assert.NotContains(t, text, "Maintenance window")
+
+	// A single message's headers are the document metadata
+	subject, ok := doc.GetMetadataField("subject")
+	require.True(t, ok)
+	assert.Equal(t, "Fwd: Vendor notice", subject)
+	assert.Equal(t, "<5@example.com>", doc.Metadata()["message_id"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := email.ParseEmail([]byte("no headers here"), "bad.eml")
+	var parseErr *mq.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, mq.FormatEmail, parseErr.Format)
+}
+
+func TestParseEmptyMbox(t *testing.T) {
+	doc, err := email.ParseEmail(nil, "empty.mbox")
+	require.NoError(t, err)
+	assert.Empty(t, doc.GetSections())
+	assert.Equal(t, 0, doc.Metadata()["messages"])
+}
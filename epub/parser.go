@@ -0,0 +1,712 @@
+// Package epub provides EPUB parsing for mq with chapter-level sections.
+//
+// An EPUB is a zip archive holding XHTML chapters plus an OPF package file
+// that lists them in reading order (the spine). The parser:
+//   - Reads the spine to find chapters in order
+//   - Names chapters from the nav document (EPUB 3) or NCX (EPUB 2)
+//   - Runs every chapter through the html package's extractor
+//   - Stitches the results into one Document whose top-level sections
+//     are chapters, with chapter headings nested below them
+//   - Stores book metadata (title, creator, language, ...) in Metadata
+//
+// The document source is the stitched readable text, one "# Chapter"
+// block per chapter, so section line ranges and GetText refer to it.
+//
+// Example:
+//
+//	parser := epub.NewParser()
+//	doc, _ := parser.ParseFile("book.epub")
+//
+//	chapters := doc.GetHeadings(1)
+//	ch3, _ := doc.GetSection("Concurrency")
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+
+	"github.com/muqsitnawaz/mq/html"
+	"github.com/muqsitnawaz/mq/internal/outline"
+	"github.com/muqsitnawaz/mq/internal/zippart"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Parser parses EPUB books into mq.Document.
+type Parser struct {
+	// Options
+	includeNonLinear bool // Include spine items marked linear="no"
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new EPUB parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		includeNonLinear: false,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithNonLinear includes auxiliary spine items (covers, pop-up notes)
+// that the book marks as outside the main reading order.
+func WithNonLinear(enabled bool) Option {
+	return func(p *Parser) {
+		p.includeNonLinear = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatEPUB
+}
+
+// ParseFile reads and parses an EPUB file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatEPUB, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses EPUB content and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatEPUB, Path: path, Err: err}
+	}
+
+	book := &book{files: make(map[string]*zip.File)}
+	for _, f := range zr.File {
+		book.files[f.Name] = f
+	}
+
+	if err := book.load(); err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatEPUB, Path: path, Err: err}
+	}
+
+	ext := &extractor{
+		parser: p,
+		path:   path,
+		book:   book,
+	}
+	return ext.extract()
+}
+
+// OPF package document structures.
+type opfPackage struct {
+	Metadata struct {
+		Titles      []string `xml:"title"`
+		Creators    []string `xml:"creator"`
+		Languages   []string `xml:"language"`
+		Identifiers []string `xml:"identifier"`
+		Publishers  []string `xml:"publisher"`
+		Dates       []string `xml:"date"`
+		Subjects    []string `xml:"subject"`
+		Description string   `xml:"description"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Items []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		Toc      string `xml:"toc,attr"`
+		ItemRefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// ncxPoint is a navPoint in an EPUB 2 NCX table of contents.
+type ncxPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Points []ncxPoint `xml:"navPoint"`
+}
+
+// manifestItem is a resolved manifest entry.
+type manifestItem struct {
+	path string // path inside the zip
+	nav  bool
+}
+
+// spineItem is a chapter file in reading order.
+type spineItem struct {
+	path   string
+	linear bool
+	nav    bool
+}
+
+// book is an opened EPUB container.
+type book struct {
+	files map[string]*zip.File
+
+	opf      opfPackage
+	spine    []spineItem
+	tocTitle map[string]string // chapter path -> first TOC label
+}
+
+// read returns the bytes of a zip entry.
+func (b *book) read(name string) ([]byte, error) {
+	f, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing %s", name)
+	}
+	return zippart.Read(f)
+}
+
+// load reads the container, package document, and table of contents.
+func (b *book) load() error {
+	data, err := b.read("META-INF/container.xml")
+	if err != nil {
+		return fmt.Errorf("not an EPUB: %w", err)
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return fmt.Errorf("container.xml: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return fmt.Errorf("container.xml: no rootfile")
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	data, err = b.read(opfPath)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, &b.opf); err != nil {
+		return fmt.Errorf("%s: %w", opfPath, err)
+	}
+
+	base := path.Dir(opfPath)
+	items := make(map[string]manifestItem)
+	var navPath, ncxPath string
+	for _, it := range b.opf.Manifest.Items {
+		mi := manifestItem{
+			path: resolve(base, it.Href),
+			nav:  hasProperty(it.Properties, "nav"),
+		}
+		items[it.ID] = mi
+		if mi.nav {
+			navPath = mi.path
+		}
+		if it.ID == b.opf.Spine.Toc || it.MediaType == "application/x-dtbncx+xml" {
+			ncxPath = mi.path
+		}
+	}
+
+	for _, ref := range b.opf.Spine.ItemRefs {
+		mi, ok := items[ref.IDRef]
+		if !ok {
+			continue
+		}
+		b.spine = append(b.spine, spineItem{
+			path:   mi.path,
+			linear: ref.Linear != "no",
+			nav:    mi.nav,
+		})
+	}
+	if len(b.spine) == 0 {
+		return fmt.Errorf("%s: empty spine", opfPath)
+	}
+
+	b.tocTitle = make(map[string]string)
+	if navPath != "" {
+		b.loadNav(navPath)
+	}
+	if len(b.tocTitle) == 0 && ncxPath != "" {
+		b.loadNCX(ncxPath)
+	}
+	return nil
+}
+
+// loadNav reads chapter titles from an EPUB 3 navigation document.
+func (b *book) loadNav(navPath string) {
+	data, err := b.read(navPath)
+	if err != nil {
+		return
+	}
+	root, err := xhtml.Parse(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	toc := findNav(root)
+	if toc == nil {
+		return
+	}
+	base := path.Dir(navPath)
+	walkNodes(toc, func(n *xhtml.Node) {
+		if n.Type != xhtml.ElementNode || n.Data != "a" {
+			return
+		}
+		b.addTOCEntry(base, attr(n, "href"), nodeText(n))
+	})
+}
+
+// loadNCX reads chapter titles from an EPUB 2 NCX file.
+func (b *book) loadNCX(ncxPath string) {
+	data, err := b.read(ncxPath)
+	if err != nil {
+		return
+	}
+	var ncx struct {
+		Points []ncxPoint `xml:"navMap>navPoint"`
+	}
+	if err := xml.Unmarshal(data, &ncx); err != nil {
+		return
+	}
+
+	base := path.Dir(ncxPath)
+	var walk func(points []ncxPoint)
+	walk = func(points []ncxPoint) {
+		for _, pt := range points {
+			b.addTOCEntry(base, pt.Content.Src, pt.Label)
+			walk(pt.Points)
+		}
+	}
+	walk(ncx.Points)
+}
+
+// addTOCEntry records the first TOC label pointing at each file.
+func (b *book) addTOCEntry(base, href, label string) {
+	label = strings.Join(strings.Fields(label), " ")
+	if href == "" || label == "" {
+		return
+	}
+	target, _, _ := strings.Cut(href, "#")
+	target = resolve(base, target)
+	if _, ok := b.tocTitle[target]; !ok {
+		b.tocTitle[target] = label
+	}
+}
+
+// metadata converts OPF metadata into document metadata.
+func (b *book) metadata() mq.Metadata {
+	m := b.opf.Metadata
+	meta := mq.Metadata{}
+	set := func(key string, values []string) {
+		var clean []string
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				clean = append(clean, v)
+			}
+		}
+		if len(clean) > 0 {
+			meta[key] = strings.Join(clean, ", ")
+		}
+	}
+	set("title", m.Titles[:min(len(m.Titles), 1)])
+	set("creator", m.Creators)
+	set("language", m.Languages)
+	set("identifier", m.Identifiers[:min(len(m.Identifiers), 1)])
+	set("publisher", m.Publishers)
+	set("date", m.Dates[:min(len(m.Dates), 1)])
+	set("subject", m.Subjects)
+	set("description", []string{m.Description})
+	return meta
+}
+
+// extractor stitches parsed chapters into one document.
+type extractor struct {
+	parser *Parser
+	path   string
+	book   *book
+
+	// Rendered output
+	out  strings.Builder
+	line int // lines written so far
+
+	// Extracted elements
+	headings   []*mq.Heading
+	sections   []*mq.Section
+	chapters   map[*mq.Heading]string // chapter heading -> file path
+	codeBlocks []*mq.CodeBlock
+	links      []*mq.Link
+	images     []*mq.Image
+	tables     []*mq.Table
+	lists      []*mq.List
+}
+
+func (e *extractor) extract() (*mq.Document, error) {
+	e.chapters = make(map[*mq.Heading]string)
+	htmlParser := html.NewParser(html.WithReadability(false))
+
+	for _, item := range e.book.spine {
+		if item.nav || (!item.linear && !e.parser.includeNonLinear) {
+			continue
+		}
+		data, err := e.book.read(item.path)
+		if err != nil {
+			continue
+		}
+		chapter, err := htmlParser.Parse(data, item.path)
+		if err != nil {
+			continue
+		}
+		e.addChapter(item.path, chapter)
+	}
+
+	source := strings.TrimRight(e.out.String(), "\n")
+	e.sections = outline.Nest(e.headings, strings.Count(source, "\n")+1, 1)
+	for _, section := range e.sections {
+		if file, ok := e.chapters[section.Heading]; ok {
+			section.Metadata = mq.Metadata{"href": file}
+		}
+	}
+
+	meta := e.book.metadata()
+	title, _ := meta["title"].(string)
+
+	doc := mq.NewDocument(
+		[]byte(source),
+		e.path,
+		mq.FormatEPUB,
+		title,
+		e.headings,
+		e.sections,
+		e.codeBlocks,
+		e.links,
+		e.images,
+		e.tables,
+		e.lists,
+		source,
+	)
+	if len(meta) > 0 {
+		doc.SetMetadata(meta)
+	}
+	return doc, nil
+}
+
+// addChapter renders one chapter and records its elements.
+func (e *extractor) addChapter(file string, chapter *mq.Document) {
+	text := chapter.ReadableText()
+
+	// With Readability disabled the <title> text leads the body.
+	if t := chapter.Title(); t != "" {
+		text = strings.TrimPrefix(text, t+"\n")
+	}
+	text = strings.TrimSpace(text)
+
+	var inner []*mq.Heading
+	for _, s := range chapter.GetSections() {
+		inner = append(inner, s.Heading)
+	}
+
+	title, fromTOC := e.book.tocTitle[file]
+	if !fromTOC {
+		switch {
+		case len(inner) > 0:
+			title = inner[0].Text
+		case chapter.Title() != "":
+			title = chapter.Title()
+		default:
+			title = strings.TrimSuffix(path.Base(file), path.Ext(file))
+		}
+	}
+	if !fromTOC && text == "" && len(inner) == 0 {
+		return // cover pages and other empty spine items
+	}
+
+	// The chapter's own title heading is represented by the chapter.
+	lines := strings.Split(text, "\n")
+	if len(inner) > 0 && sameText(inner[0].Text, title) {
+		if len(lines) > 0 && sameText(lines[0], title) {
+			lines = lines[1:]
+		}
+		inner = inner[1:]
+	}
+	body := strings.TrimSpace(strings.Join(lines, "\n"))
+
+	ch := &mq.Heading{
+		Level: 1,
+		Text:  title,
+		ID:    mq.Slugify(title, mq.SlugGitHub),
+	}
+	e.headings = append(e.headings, ch)
+	e.chapters[ch] = file
+	ch.SetPosition(e.write("# " + title))
+
+	bodyStart := e.line + 1
+	if body != "" {
+		e.write(body)
+	}
+	loc := &bodyLocator{
+		body:     body,
+		index:    mq.NewLineIndex([]byte(body)),
+		line:     bodyStart,
+		fallback: ch.Position(),
+		cursors:  make(map[string]int),
+	}
+
+	// Locate inner headings in the rendered body so their sections get
+	// line ranges. Levels shift so the chapter's top heading level sits
+	// directly below the chapter.
+	top := 6
+	for _, h := range inner {
+		top = min(top, h.Level)
+	}
+	bodyLines := strings.Split(body, "\n")
+	next := 0
+	for _, h := range inner {
+		nh := &mq.Heading{
+			Level: min(h.Level-top+2, 6),
+			Text:  h.Text,
+			ID:    h.ID,
+		}
+		for i := next; i < len(bodyLines); i++ {
+			if sameText(bodyLines[i], h.Text) {
+				nh.SetPosition(loc.lines(i+1, i+1))
+				next = i + 1
+				break
+			}
+		}
+		if nh.Line == 0 {
+			continue // heading text not found in readable text
+		}
+		e.headings = append(e.headings, nh)
+	}
+
+	// Other elements carry positions in the chapter's XHTML; move them
+	// into the rendering.
+	for _, cb := range chapter.GetCodeBlocks() {
+		lines := strings.Split(strings.TrimSpace(cb.Content), "\n")
+		cb.Position = loc.span("code", lines[0], lines[len(lines)-1])
+		e.codeBlocks = append(e.codeBlocks, cb)
+	}
+	for _, l := range chapter.GetLinks() {
+		l.Position = loc.span("link", l.Text, l.Text)
+		e.links = append(e.links, l)
+	}
+	for _, t := range chapter.GetTables() {
+		rows := append([][]string{t.Headers}, t.Rows...)
+		first, last := firstCell(rows), lastCell(rows)
+		if first != "" {
+			t.Position = loc.span("table", first, last)
+		} else {
+			t.Position = loc.fallback
+		}
+		e.tables = append(e.tables, t)
+	}
+	for _, l := range chapter.GetLists(nil) {
+		loc.listItems(l.Items)
+		if len(l.Items) > 0 {
+			first, last := l.Items[0].Position, outline.LastItem(l.Items).Position
+			l.Position = mq.Position{Line: first.Line, Column: first.Column, EndLine: last.EndLine, EndColumn: last.EndColumn}
+		}
+		e.lists = append(e.lists, l)
+	}
+	for _, img := range chapter.GetImages() {
+		img.Position = loc.fallback
+		// Resolve image paths against the chapter so they name zip entries.
+		if u, err := url.Parse(img.URL); err == nil && !u.IsAbs() && !strings.HasPrefix(img.URL, "/") {
+			img.URL = resolve(path.Dir(file), img.URL)
+		}
+		e.images = append(e.images, img)
+	}
+}
+
+// write appends a block followed by a blank line and returns the
+// block's position.
+func (e *extractor) write(text string) mq.Position {
+	pos := mq.NewLineIndex([]byte(text)).Position(0, len(text))
+	pos.Line += e.line
+	pos.EndLine += e.line
+	e.out.WriteString(text)
+	e.out.WriteString("\n\n")
+	e.line += strings.Count(text, "\n") + 2
+	return pos
+}
+
+// bodyLocator finds a chapter's elements in its rendered body by their
+// text. Each kind of element is searched from where the previous one of
+// that kind ended, so repeated text resolves in document order.
+type bodyLocator struct {
+	body     string
+	index    *mq.LineIndex
+	line     int         // document line of the body's first line
+	fallback mq.Position // used when the text is not found
+	cursors  map[string]int
+}
+
+// span returns the position from the first occurrence of first to the
+// following occurrence of last.
+func (l *bodyLocator) span(kind, first, last string) mq.Position {
+	start, ok := l.find(kind, first)
+	if !ok {
+		return l.fallback
+	}
+	l.cursors[kind] = start
+	end, ok := l.find(kind, last)
+	if !ok {
+		end = start + len(strings.TrimSpace(first))
+	} else {
+		end += len(strings.TrimSpace(last))
+	}
+	l.cursors[kind] = end
+	return l.shift(l.index.Position(start, end))
+}
+
+// find returns the offset of text at or after the kind's cursor.
+func (l *bodyLocator) find(kind, text string) (int, bool) {
+	text = strings.TrimSpace(text)
+	from := l.cursors[kind]
+	if text == "" || from > len(l.body) {
+		return 0, false
+	}
+	i := strings.Index(l.body[from:], text)
+	if i < 0 {
+		return 0, false
+	}
+	return from + i, true
+}
+
+// lines returns the position of whole body lines (1-based).
+func (l *bodyLocator) lines(start, end int) mq.Position {
+	return l.shift(l.index.LinePosition(start, end))
+}
+
+func (l *bodyLocator) shift(pos mq.Position) mq.Position {
+	pos.Line += l.line - 1
+	pos.EndLine += l.line - 1
+	return pos
+}
+
+// listItems positions items and their children in order.
+func (l *bodyLocator) listItems(items []mq.ListItem) {
+	for i := range items {
+		items[i].Position = l.span("item", items[i].Text, items[i].Text)
+		l.listItems(items[i].Children)
+	}
+}
+
+// firstCell returns the first non-empty cell of a table's rows.
+func firstCell(rows [][]string) string {
+	for _, row := range rows {
+		for _, cell := range row {
+			if strings.TrimSpace(cell) != "" {
+				return cell
+			}
+		}
+	}
+	return ""
+}
+
+// lastCell returns the last non-empty cell of a table's rows.
+func lastCell(rows [][]string) string {
+	for i := len(rows) - 1; i >= 0; i-- {
+		for j := len(rows[i]) - 1; j >= 0; j-- {
+			if strings.TrimSpace(rows[i][j]) != "" {
+				return rows[i][j]
+			}
+		}
+	}
+	return ""
+}
+
+// findNav returns the <nav epub:type="toc"> element, or the first <nav>.
+func findNav(root *xhtml.Node) *xhtml.Node {
+	var first, toc *xhtml.Node
+	walkNodes(root, func(n *xhtml.Node) {
+		if n.Type != xhtml.ElementNode || n.Data != "nav" {
+			return
+		}
+		if first == nil {
+			first = n
+		}
+		if toc == nil && hasProperty(attr(n, "epub:type"), "toc") {
+			toc = n
+		}
+	})
+	if toc != nil {
+		return toc
+	}
+	return first
+}
+
+func walkNodes(n *xhtml.Node, fn func(*xhtml.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkNodes(c, fn)
+	}
+}
+
+func attr(n *xhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		name := a.Key
+		if a.Namespace != "" {
+			name = a.Namespace + ":" + a.Key
+		}
+		if name == key || a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(n *xhtml.Node) string {
+	var buf strings.Builder
+	walkNodes(n, func(c *xhtml.Node) {
+		if c.Type == xhtml.TextNode {
+			buf.WriteString(c.Data)
+		}
+	})
+	return buf.String()
+}
+
+// resolve joins an href against the directory of the file referencing it.
+func resolve(base, href string) string {
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return strings.TrimPrefix(path.Join(base, href), "./")
+}
+
+func hasProperty(list, prop string) bool {
+	for _, p := range strings.Fields(list) {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
+
+func sameText(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseEPUB is a convenience function for quick parsing.
+func ParseEPUB(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseEPUBFile is a convenience function for quick file parsing.
+func ParseEPUBFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,225 @@
+package epub_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/muqsitnawaz/mq/epub"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const containerXML = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const contentOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:isbn:9780000000000</dc:identifier>
+    <dc:title>Systems in Go</dc:title>
+    <dc:creator>Ada Byron</dc:creator>
+    <dc:creator>Alan Church</dc:creator>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="cover" href="text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="cover" linear="no"/>
+    <itemref idref="nav"/>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+  </spine>
+</package>`
+
+const navXHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Contents</title></head>
+<body>
+  <nav epub:type="toc">
+    <ol>
+      <li><a href="text/ch1.xhtml">Getting Started</a></li>
+      <li><a href="text/ch2.xhtml">Concurrency</a>
+        <ol><li><a href="text/ch2.xhtml#chan">Channels</a></li></ol>
+      </li>
+    </ol>
+  </nav>
+</body>
+</html>`
+
+const tocNCX = `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <navMap>
+    <navPoint id="p1"><navLabel><text>Chapter One (NCX)</text></navLabel><content src="text/ch1.xhtml"/></navPoint>
+    <navPoint id="p2"><navLabel><text>Chapter Two (NCX)</text></navLabel><content src="text/ch2.xhtml"/></navPoint>
+  </navMap>
+</ncx>`
+
+const coverXHTML = `<html><body><img src="../images/cover.png" alt="Cover"/><p>First edition</p></body></html>`
+
+const ch1XHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Systems in Go</title></head>
+<body>
+  <h1>Getting Started</h1>
+  <p>Install the toolchain from <a href="https://go.dev">go.dev</a>.</p>
+  <h2>Hello World</h2>
+  <p>Write your first program.</p>
+  <img src="../images/gopher.png" alt="Gopher"/>
+</body>
+</html>`
+
+const ch2XHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Systems in Go</title></head>
+<body>
+  <h1>Concurrency</h1>
+  <p>Goroutines are cheap.</p>
+  <h2 id="chan">Channels</h2>
+  <p>Channels connect goroutines.</p>
+  <table>
+    <tr><th>Kind</th><th>Blocks</th></tr>
+    <tr><td>unbuffered</td><td>always</td></tr>
+  </table>
+</body>
+</html>`
+
+func buildEPUB(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// The mimetype entry comes first, as the EPUB spec requires.
+	w, err := zw.Create("mimetype")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("application/epub+zip"))
+	require.NoError(t, err)
+
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func sampleParts() map[string]string {
+	return map[string]string{
+		"META-INF/container.xml": containerXML,
+		"OEBPS/content.opf":      contentOPF,
+		"OEBPS/nav.xhtml":        navXHTML,
+		"OEBPS/toc.ncx":          tocNCX,
+		"OEBPS/text/cover.xhtml": coverXHTML,
+		"OEBPS/text/ch1.xhtml":   ch1XHTML,
+		"OEBPS/text/ch2.xhtml":   ch2XHTML,
+	}
+}
+
+func TestParserFormat(t *testing.T) {
+	p := epub.NewParser()
+	assert.Equal(t, mq.FormatEPUB, p.Format())
+	assert.Equal(t, mq.FormatEPUB, mq.DetectFormat("unknown", buildEPUB(t, sampleParts())))
+}
+
+func TestParseChapters(t *testing.T) {
+	doc, err := epub.ParseEPUB(buildEPUB(t, sampleParts()), "book.epub")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatEPUB, doc.Format())
+	assert.Equal(t, "Systems in Go", doc.Title())
+
+	// Chapters are the top-level sections, in spine order; the nav
+	// document and the non-linear cover are skipped.
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 2)
+	assert.Equal(t, "Getting Started", toc[0].Heading.Text)
+	assert.Equal(t, "Concurrency", toc[1].Heading.Text)
+	assert.Equal(t, "OEBPS/text/ch2.xhtml", toc[1].Metadata["href"])
+
+	// Chapter headings nest below their chapter, one level down.
+	require.Len(t, toc[1].Children, 1)
+	channels := toc[1].Children[0]
+	assert.Equal(t, "Channels", channels.Heading.Text)
+	assert.Equal(t, 2, channels.Heading.Level)
+	assert.Contains(t, channels.GetText(), "Channels connect goroutines.")
+
+	text := toc[0].GetText()
+	assert.Contains(t, text, "# Getting Started")
+	assert.Contains(t, text, "Write your first program.")
+	assert.NotContains(t, text, "Goroutines")
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, "https://go.dev", links[0].URL)
+	// Positions refer to the rendering, not the chapter's XHTML
+	assert.Equal(t, mq.Position{Line: 3, Column: 28, EndLine: 3, EndColumn: 33}, links[0].Position)
+
+	images := doc.GetImages()
+	require.Len(t, images, 1)
+	assert.Equal(t, "OEBPS/images/gopher.png", images[0].URL)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, 17, tables[0].Line)
+	assert.Equal(t, 19, tables[0].EndLine)
+}
+
+func TestParseMetadata(t *testing.T) {
+	doc, err := epub.ParseEPUB(buildEPUB(t, sampleParts()), "book.epub")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "Systems in Go", meta["title"])
+	assert.Equal(t, "Ada Byron, Alan Church", meta["creator"])
+	assert.Equal(t, "en", meta["language"])
+	assert.Equal(t, "urn:isbn:9780000000000", meta["identifier"])
+}
+
+func TestParseNCXFallback(t *testing.T) {
+	parts := sampleParts()
+	delete(parts, "OEBPS/nav.xhtml")
+
+	doc, err := epub.ParseEPUB(buildEPUB(t, parts), "book.epub")
+	require.NoError(t, err)
+
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 2)
+	assert.Equal(t, "Chapter One (NCX)", toc[0].Heading.Text)
+
+	// The chapter's own <h1> no longer matches the TOC label, so it
+	// stays as a nested heading.
+	require.NotEmpty(t, toc[0].Children)
+	assert.Equal(t, "Getting Started", toc[0].Children[0].Heading.Text)
+}
+
+func TestParseNonLinear(t *testing.T) {
+	doc, err := epub.NewParser(epub.WithNonLinear(true)).Parse(buildEPUB(t, sampleParts()), "book.epub")
+	require.NoError(t, err)
+
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 3)
+	assert.Equal(t, "cover", toc[0].Heading.Text, "untitled chapters fall back to the file name")
+	assert.Equal(t, "OEBPS/images/cover.png", doc.GetImages()[0].URL)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := epub.ParseEPUB([]byte("not a zip"), "bad.epub")
+	var parseErr *mq.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, mq.FormatEPUB, parseErr.Format)
+
+	_, err = epub.ParseEPUB(buildEPUB(t, map[string]string{"OEBPS/a.xhtml": "<html/>"}), "bad.epub")
+	assert.ErrorContains(t, err, "not an EPUB")
+}
@@ -3,15 +3,14 @@ module github.com/muqsitnawaz/mq
 go 1.24.0
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/stretchr/testify v1.11.1
 	github.com/yuin/goldmark v1.7.13
-	github.com/yuin/goldmark-meta v1.1.0
 	golang.org/x/net v0.45.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
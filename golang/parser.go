@@ -0,0 +1,483 @@
+// Package golang provides Go source parsing for mq using go/ast.
+//
+// Go files have explicit structure, so the parser maps declarations
+// directly onto mq's unified types:
+//   - Title: first sentence of the package doc comment
+//   - Sections: funcs, methods (Type.Method), types, consts and vars,
+//     with exact line ranges that include the doc comment
+//   - Section preview: the declaration's doc comment
+//   - Code blocks: indented code after "Example" in doc comments, and
+//     the bodies of ExampleXxx functions in _test.go files
+//   - Links: URLs in doc comments
+//   - Metadata: package name and imports
+//
+// Example:
+//
+//	parser := golang.NewParser()
+//	doc, _ := parser.ParseFile("lib/engine.go")
+//
+//	fn, _ := doc.GetSection("Engine.LoadDocument")
+//	body := fn.GetText()
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Parser parses Go source files into mq.Document.
+type Parser struct {
+	// Options
+	unexported bool // Include unexported declarations
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new Go parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		unexported: true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithUnexported includes or excludes unexported declarations.
+// Excluding them gives an API-only outline, like go doc.
+func WithUnexported(enabled bool) Option {
+	return func(p *Parser) {
+		p.unexported = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatGo
+}
+
+// ParseFile reads and parses a Go source file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatGo, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses Go source and returns an mq.Document.
+//
+// Files with syntax errors are parsed as far as possible; an error is
+// returned only when not even the package clause can be read.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if file == nil || file.Name == nil || file.Name.Name == "" {
+		if err == nil {
+			err = fmt.Errorf("missing package clause")
+		}
+		return nil, &mq.ParseError{Format: mq.FormatGo, Path: path, Err: err}
+	}
+
+	ext := &extractor{
+		parser: p,
+		source: content,
+		path:   path,
+		fset:   fset,
+		file:   file,
+		index:  mq.NewLineIndex(content),
+	}
+	return ext.extract(), nil
+}
+
+// extractor walks a parsed file and collects structural elements.
+type extractor struct {
+	parser *Parser
+	source []byte
+	path   string
+	fset   *token.FileSet
+	file   *ast.File
+	index  *mq.LineIndex
+
+	// Extracted elements
+	headings   []*mq.Heading
+	sections   []*mq.Section
+	codeBlocks []*mq.CodeBlock
+	links      []*mq.Link
+}
+
+func (e *extractor) extract() *mq.Document {
+	// Package doc examples and links belong to the document, not a section.
+	e.docComment(e.file.Doc, nil)
+
+	for _, decl := range e.file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			e.funcDecl(d)
+		case *ast.GenDecl:
+			e.genDecl(d)
+		}
+	}
+
+	doc := mq.NewDocument(
+		e.source,
+		e.path,
+		mq.FormatGo,
+		e.title(),
+		e.headings,
+		e.sections,
+		e.codeBlocks,
+		e.links,
+		nil, // images
+		nil, // tables
+		nil, // lists
+		string(e.source),
+	)
+
+	meta := mq.Metadata{"package": e.file.Name.Name}
+	if len(e.file.Imports) > 0 {
+		imports := make([]interface{}, 0, len(e.file.Imports))
+		for _, imp := range e.file.Imports {
+			imports = append(imports, strings.Trim(imp.Path.Value, "\"`"))
+		}
+		meta["imports"] = imports
+	}
+	doc.SetMetadata(meta)
+
+	return doc
+}
+
+// title returns the first sentence of the package doc, or "package name".
+func (e *extractor) title() string {
+	if e.file.Doc != nil {
+		if s := firstSentence(e.file.Doc.Text()); s != "" {
+			return s
+		}
+	}
+	return "package " + e.file.Name.Name
+}
+
+func (e *extractor) funcDecl(d *ast.FuncDecl) {
+	name := d.Name.Name
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv := receiverType(d.Recv.List[0].Type)
+		if !e.include(recv) {
+			return
+		}
+		name = recv + "." + name
+	}
+	if !e.include(d.Name.Name) {
+		return
+	}
+
+	s := e.addSection(name, 1, d.Doc, d.Pos(), d.End(), nil)
+
+	if d.Recv == nil && strings.HasPrefix(d.Name.Name, "Example") &&
+		strings.HasSuffix(e.path, "_test.go") && d.Body != nil {
+		e.exampleBody(d.Body, s)
+	}
+}
+
+func (e *extractor) genDecl(d *ast.GenDecl) {
+	if d.Tok == token.IMPORT {
+		return
+	}
+
+	// Single spec: one section covering the whole declaration.
+	if !d.Lparen.IsValid() && len(d.Specs) == 1 {
+		name := specName(d.Specs[0])
+		if !e.include(name) {
+			return
+		}
+		e.addSection(name, 1, d.Doc, d.Pos(), d.End(), nil)
+		return
+	}
+
+	// Grouped: a parent section for the block, one child per spec.
+	var names []string
+	for _, spec := range d.Specs {
+		if name := specName(spec); e.include(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	label := strings.Join(names[:min(len(names), 3)], ", ")
+	if len(names) > 3 {
+		label += ", ..."
+	}
+	parent := e.addSection(fmt.Sprintf("%s (%s)", d.Tok, label), 1, d.Doc, d.Pos(), d.End(), nil)
+
+	for _, spec := range d.Specs {
+		name := specName(spec)
+		if !e.include(name) {
+			continue
+		}
+		e.addSection(name, 2, specDoc(spec), spec.Pos(), spec.End(), parent)
+	}
+}
+
+// addSection records a heading and section spanning doc comment through end.
+func (e *extractor) addSection(name string, level int, doc *ast.CommentGroup, pos, end token.Pos, parent *mq.Section) *mq.Section {
+	declLine := e.line(pos)
+	start := declLine
+	if doc != nil {
+		start = e.line(doc.Pos())
+	}
+
+	h := &mq.Heading{
+		Level: level,
+		Text:  name,
+		ID:    name,
+	}
+	h.SetPosition(e.index.LinePosition(declLine, declLine))
+	s := &mq.Section{
+		Heading: h,
+		Parent:  parent,
+		Start:   start,
+		End:     e.line(end),
+	}
+	if doc != nil {
+		s.Doc = strings.TrimSpace(doc.Text())
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+
+	e.headings = append(e.headings, h)
+	e.sections = append(e.sections, s)
+	e.docComment(doc, s)
+	return s
+}
+
+// docComment extracts example code and links from a doc comment.
+func (e *extractor) docComment(doc *ast.CommentGroup, s *mq.Section) {
+	if doc == nil {
+		return
+	}
+
+	var p comment.Parser
+	parsed := p.Parse(doc.Text())
+
+	// Code is found in the comment's source in order
+	c := commentCursor{e: e, start: e.offset(doc.Pos()), end: e.offset(doc.End())}
+	c.offset = c.start
+
+	inExample := false
+	for _, block := range parsed.Content {
+		switch b := block.(type) {
+		case *comment.Heading:
+			inExample = mentionsExample(b.Text)
+		case *comment.Paragraph:
+			inExample = mentionsExample(b.Text)
+			e.collectLinks(b.Text, &c)
+		case *comment.List:
+			for _, item := range b.Items {
+				for _, content := range item.Content {
+					if para, ok := content.(*comment.Paragraph); ok {
+						e.collectLinks(para.Text, &c)
+					}
+				}
+			}
+		case *comment.Code:
+			if !inExample {
+				continue
+			}
+			code := strings.TrimRight(b.Text, "\n")
+			first, _, _ := strings.Cut(code, "\n")
+			pos := c.find(first)
+			if pos.Line > 0 {
+				end := pos.Line + strings.Count(code, "\n")
+				pos = e.index.LinePosition(pos.Line, end)
+			}
+			cb := &mq.CodeBlock{
+				Language: "go",
+				Content:  code,
+				Position: pos,
+			}
+			e.codeBlocks = append(e.codeBlocks, cb)
+			if s != nil {
+				s.AddCodeBlock(cb)
+			}
+		}
+	}
+}
+
+// exampleBody records the body of an ExampleXxx function as a code block.
+func (e *extractor) exampleBody(body *ast.BlockStmt, s *mq.Section) {
+	file := e.fset.File(body.Pos())
+	start := file.Offset(body.Lbrace) + 1
+	end := file.Offset(body.Rbrace)
+	if start >= end || end > len(e.source) {
+		return
+	}
+
+	lines := strings.Split(strings.Trim(string(e.source[start:end]), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+
+	cb := &mq.CodeBlock{
+		Language: "go",
+		Content:  strings.Join(lines, "\n"),
+		Position: e.index.LinePosition(e.line(body.Lbrace)+1, e.line(body.Rbrace)-1),
+	}
+	e.codeBlocks = append(e.codeBlocks, cb)
+	s.AddCodeBlock(cb)
+}
+
+func (e *extractor) collectLinks(texts []comment.Text, c *commentCursor) {
+	for _, t := range texts {
+		if link, ok := t.(*comment.Link); ok {
+			e.links = append(e.links, &mq.Link{Text: plainText(link.Text), URL: link.URL, Position: c.locate(link.URL)})
+		}
+	}
+}
+
+// commentCursor finds text in a doc comment's source.
+type commentCursor struct {
+	e          *extractor
+	start, end int // the comment
+	offset     int // after the last find
+}
+
+// find returns the position of s after the previous find, or the zero
+// Position when it isn't in the comment's source verbatim.
+func (c *commentCursor) find(s string) mq.Position {
+	pos, next := c.search(s, c.offset)
+	if pos.Line > 0 {
+		c.offset = next
+	}
+	return pos
+}
+
+// locate returns the position of the first occurrence of s. Link URLs
+// may sit in a definition at the end of the comment, out of order.
+func (c *commentCursor) locate(s string) mq.Position {
+	pos, _ := c.search(s, c.start)
+	return pos
+}
+
+func (c *commentCursor) search(s string, from int) (mq.Position, int) {
+	if s == "" || from >= c.end {
+		return mq.Position{}, from
+	}
+	i := strings.Index(string(c.e.source[from:c.end]), s)
+	if i < 0 {
+		return mq.Position{}, from
+	}
+	start := from + i
+	return c.e.index.Position(start, start+len(s)), start + len(s)
+}
+
+// include reports whether a declaration name passes the export filter.
+func (e *extractor) include(name string) bool {
+	return e.parser.unexported || ast.IsExported(name)
+}
+
+func (e *extractor) line(pos token.Pos) int {
+	return e.fset.Position(pos).Line
+}
+
+func (e *extractor) offset(pos token.Pos) int {
+	return e.fset.Position(pos).Offset
+}
+
+// receiverType returns the base type name of a method receiver,
+// dropping pointers and type parameters.
+func receiverType(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return fmt.Sprintf("%T", expr)
+		}
+	}
+}
+
+func specName(spec ast.Spec) string {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name
+	case *ast.ValueSpec:
+		names := make([]string, len(s.Names))
+		for i, n := range s.Names {
+			names[i] = n.Name
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
+
+func mentionsExample(texts []comment.Text) bool {
+	return strings.Contains(strings.ToLower(plainText(texts)), "example")
+}
+
+func plainText(texts []comment.Text) string {
+	var buf strings.Builder
+	for _, t := range texts {
+		switch v := t.(type) {
+		case comment.Plain:
+			buf.WriteString(string(v))
+		case comment.Italic:
+			buf.WriteString(string(v))
+		case *comment.Link:
+			buf.WriteString(plainText(v.Text))
+		case *comment.DocLink:
+			buf.WriteString(plainText(v.Text))
+		}
+	}
+	return buf.String()
+}
+
+// firstSentence returns the first sentence of a doc comment, on one line.
+func firstSentence(text string) string {
+	para, _, _ := strings.Cut(strings.TrimSpace(text), "\n\n")
+	para = strings.Join(strings.Fields(para), " ")
+	if i := strings.Index(para, ". "); i >= 0 {
+		return para[:i+1]
+	}
+	return para
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseGo is a convenience function for quick parsing.
+func ParseGo(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseGoFile is a convenience function for quick file parsing.
+func ParseGoFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,120 @@
+package golang_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/muqsitnawaz/mq/golang"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserFormat(t *testing.T) {
+	p := golang.NewParser()
+	assert.Equal(t, mq.FormatGo, p.Format())
+}
+
+func TestParseDeclarations(t *testing.T) {
+	doc, err := golang.ParseGoFile(filepath.Join("testdata", "store.go"))
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatGo, doc.Format())
+	assert.Equal(t, "Package store keeps documents in memory.", doc.Title())
+
+	var names []string
+	for _, s := range doc.GetTableOfContents() {
+		names = append(names, s.Heading.Text)
+	}
+	assert.Equal(t, []string{
+		"ErrNotFound",
+		"const (KindText, KindJSON)",
+		"Kind",
+		"Store",
+		"New",
+		"Store.Get",
+		"Store.put",
+	}, names)
+
+	// Methods are addressable as Type.Method, with the doc comment included
+	get, ok := doc.GetSection("Store.Get")
+	require.True(t, ok)
+	assert.Equal(t, 40, get.Start)
+	assert.Equal(t, 45, get.Heading.Line)
+	assert.Equal(t, 54, get.End)
+	assert.Contains(t, get.GetText(), "func (s *Store[T]) Get(key string) (T, error) {")
+	assert.NotContains(t, get.GetText(), "func (s *Store[T]) put")
+	assert.Equal(t, "Get returns the value for key.", get.Doc[:30])
+
+	// Grouped consts nest each spec below the block
+	kinds, ok := doc.GetSection("const (KindText, KindJSON)")
+	require.True(t, ok)
+	require.Len(t, kinds.Children, 2)
+	assert.Equal(t, "KindText", kinds.Children[0].Heading.Text)
+	assert.Equal(t, "KindText is plain text.", kinds.Children[0].Doc)
+	assert.Equal(t, 2, kinds.Children[0].Heading.Level)
+}
+
+func TestParseExamplesAndLinks(t *testing.T) {
+	doc, err := golang.ParseGoFile(filepath.Join("testdata", "store.go"))
+	require.NoError(t, err)
+
+	blocks := doc.GetCodeBlocks("go")
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "s := store.New()\ns.Put(\"a\", doc)", blocks[0].Content)
+	assert.Equal(t, "v, err := s.Get(\"a\")", blocks[1].Content)
+	assert.Equal(t, 7, blocks[0].Line)
+	assert.Equal(t, 8, blocks[0].EndLine)
+
+	get, _ := doc.GetSection("Store.Get")
+	assert.Len(t, get.GetCodeBlocks(), 1)
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, "https://example.com/store", links[0].URL)
+	assert.Equal(t, mq.Position{Line: 3, Column: 8, EndLine: 3, EndColumn: 32}, links[0].Position)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "store", meta["package"])
+	assert.Equal(t, []interface{}{"errors", "sync"}, meta["imports"])
+}
+
+func TestParseExampleFunctions(t *testing.T) {
+	src := []byte(`package store_test
+
+func ExampleStore_Get() {
+	s := store.New()
+	fmt.Println(s.Get("a"))
+	// Output: a
+}
+`)
+	doc, err := golang.ParseGo(src, "example_test.go")
+	require.NoError(t, err)
+
+	blocks := doc.GetCodeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "s := store.New()\nfmt.Println(s.Get(\"a\"))\n// Output: a", blocks[0].Content)
+}
+
+func TestParseExportedOnly(t *testing.T) {
+	doc, err := golang.NewParser(golang.WithUnexported(false)).ParseFile(filepath.Join("testdata", "store.go"))
+	require.NoError(t, err)
+
+	_, ok := doc.GetSection("Store.put")
+	assert.False(t, ok)
+	_, ok = doc.GetSection("Store.Get")
+	assert.True(t, ok)
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := golang.ParseGo([]byte("not go"), "bad.go")
+	var parseErr *mq.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, mq.FormatGo, parseErr.Format)
+
+	// Syntax errors after the package clause still yield an outline
+	doc, err := golang.ParseGo([]byte("package p\n\nfunc A() {}\n\nfunc B( {\n"), "broken.go")
+	require.NoError(t, err)
+	_, ok := doc.GetSection("A")
+	assert.True(t, ok)
+}
@@ -0,0 +1,58 @@
+// Package store keeps documents in memory. It is safe for concurrent use.
+//
+// See https://example.com/store for the design notes.
+//
+// Example:
+//
+//	s := store.New()
+//	s.Put("a", doc)
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when a key is missing.
+var ErrNotFound = errors.New("not found")
+
+// Kinds of stored values.
+const (
+	// KindText is plain text.
+	KindText Kind = iota
+	KindJSON
+)
+
+// Kind describes a stored value.
+type Kind int
+
+// Store is an in-memory document store.
+type Store[T any] struct {
+	mu    sync.RWMutex
+	items map[string]T
+}
+
+// New creates an empty store.
+func New() *Store[string] {
+	return &Store[string]{items: make(map[string]string)}
+}
+
+// Get returns the value for key.
+//
+// Example:
+//
+//	v, err := s.Get("a")
+func (s *Store[T]) Get(key string) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.items[key]
+	if !ok {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *Store[T]) put(key string, v T) {
+	s.items[key] = v
+}
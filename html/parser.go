@@ -31,6 +31,7 @@ import (
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 
+	"github.com/muqsitnawaz/mq/internal/outline"
 	mq "github.com/muqsitnawaz/mq/lib"
 )
 
@@ -111,6 +112,7 @@ func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
 		path:   path,
 		root:   node,
 		seen:   make(map[*html.Node]bool),
+		pos:    newTagPositions(content, node),
 	}
 
 	return ext.extract()
@@ -132,6 +134,7 @@ type extractor struct {
 	path   string
 	root   *html.Node
 	seen   map[*html.Node]bool
+	pos    *tagPositions
 
 	// Extracted elements
 	title      string
@@ -159,8 +162,9 @@ func (e *extractor) extract() (*mq.Document, error) {
 	// Extract structural elements
 	e.extractElements(mainNode, 0)
 
-	// Build section hierarchy from headings
-	e.buildSections()
+	// Build section hierarchy from headings, each running from its
+	// heading's line to the next heading at the same or a higher level
+	e.sections = outline.Nest(e.headings, bytes.Count(e.source, []byte("\n"))+1, 0)
 
 	// Extract readable text
 	readableText := e.extractReadableText(mainNode)
@@ -422,11 +426,13 @@ func (e *extractor) extractHeading(n *html.Node) {
 		}
 	}
 
-	e.headings = append(e.headings, &mq.Heading{
+	h := &mq.Heading{
 		Level: level,
 		Text:  text,
 		ID:    id,
-	})
+	}
+	h.SetPosition(e.pos.of(n))
+	e.headings = append(e.headings, h)
 }
 
 func (e *extractor) extractLink(n *html.Node) {
@@ -456,8 +462,9 @@ func (e *extractor) extractLink(n *html.Node) {
 	}
 
 	e.links = append(e.links, &mq.Link{
-		Text: text,
-		URL:  href,
+		Text:     text,
+		URL:      href,
+		Position: e.pos.of(n),
 	})
 }
 
@@ -499,14 +506,15 @@ func (e *extractor) extractImage(n *html.Node) {
 	}
 
 	e.images = append(e.images, &mq.Image{
-		URL:     src,
-		AltText: alt,
-		Title:   title,
+		URL:      src,
+		AltText:  alt,
+		Title:    title,
+		Position: e.pos.of(n),
 	})
 }
 
 func (e *extractor) extractTable(n *html.Node) {
-	table := &mq.Table{}
+	table := &mq.Table{Position: e.pos.of(n)}
 
 	// Look for thead/tbody structure or direct rows
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -579,7 +587,8 @@ func (e *extractor) extractTableRow(tr *html.Node, table *mq.Table) {
 
 func (e *extractor) extractList(n *html.Node) {
 	list := &mq.List{
-		Ordered: n.DataAtom == atom.Ol,
+		Ordered:  n.DataAtom == atom.Ol,
+		Position: e.pos.of(n),
 	}
 
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -595,7 +604,7 @@ func (e *extractor) extractList(n *html.Node) {
 }
 
 func (e *extractor) extractListItem(li *html.Node) mq.ListItem {
-	item := mq.ListItem{}
+	item := mq.ListItem{Position: e.pos.of(li)}
 
 	// Check for checkbox input (task list)
 	for c := li.FirstChild; c != nil; c = c.NextSibling {
@@ -670,6 +679,7 @@ func (e *extractor) extractCodeBlock(pre *html.Node) {
 		Language: language,
 		Content:  content,
 		Lines:    strings.Count(content, "\n") + 1,
+		Position: e.pos.of(pre),
 	})
 }
 
@@ -707,41 +717,6 @@ func (e *extractor) detectLanguageFromClass(class string) string {
 	return ""
 }
 
-// buildSections creates a section hierarchy from headings.
-func (e *extractor) buildSections() {
-	if len(e.headings) == 0 {
-		return
-	}
-
-	var stack []*mq.Section
-
-	for _, h := range e.headings {
-		section := &mq.Section{
-			Heading: h,
-		}
-
-		// Pop sections of equal or higher level (lower number = higher level)
-		for len(stack) > 0 {
-			top := stack[len(stack)-1]
-			if top.Heading.Level >= h.Level {
-				stack = stack[:len(stack)-1]
-			} else {
-				break
-			}
-		}
-
-		// Set parent relationship
-		if len(stack) > 0 {
-			parent := stack[len(stack)-1]
-			section.Parent = parent
-			parent.Children = append(parent.Children, section)
-		}
-
-		stack = append(stack, section)
-		e.sections = append(e.sections, section)
-	}
-}
-
 // getTextContent extracts text from a node and its descendants.
 func (e *extractor) getTextContent(n *html.Node) string {
 	var buf strings.Builder
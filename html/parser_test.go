@@ -242,6 +242,12 @@ func TestSectionHierarchy(t *testing.T) {
 	require.True(t, ok)
 	assert.NotNil(t, chapter2)
 
+	// Sections run from their heading to the next heading at the same or a
+	// higher level
+	assert.Equal(t, []int{7, 9}, []int{chapter1.Start, chapter1.End})
+	assert.Equal(t, []int{10, 14}, []int{chapter2.Start, chapter2.End})
+	assert.Equal(t, "<h2>Chapter 1</h2>\n<h3>Section 1.1</h3>\n<h3>Section 1.2</h3>", chapter1.GetText())
+
 	// Check TOC
 	toc := doc.GetTableOfContents()
 	t.Logf("TOC has %d top-level sections", len(toc))
@@ -345,3 +351,63 @@ func TestSkipElements(t *testing.T) {
 	assert.NotContains(t, readable, "Comments section")
 	assert.NotContains(t, readable, "Hidden content")
 }
+
+func TestElementPositions(t *testing.T) {
+	src := `<html><body><main>
+<h1>Guide</h1>
+<p>Read <a href="/docs">the docs</a>.</p>
+<pre><code class="language-go">fmt.Println()
+</code></pre>
+<ul>
+  <li>one</li>
+  <li>two</li>
+</ul>
+</main></body></html>`
+
+	doc, err := html.NewParser(html.WithReadability(false)).Parse([]byte(src), "guide.html")
+	require.NoError(t, err)
+
+	headings := doc.GetHeadings()
+	require.Len(t, headings, 1)
+	assert.Equal(t, mq.Position{Line: 2, Column: 1, EndLine: 2, EndColumn: 14}, headings[0].Position())
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, mq.Position{Line: 3, Column: 9, EndLine: 3, EndColumn: 36}, links[0].Position)
+
+	blocks := doc.GetCodeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, 4, blocks[0].Line)
+	assert.Equal(t, 5, blocks[0].EndLine)
+
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 1)
+	assert.Equal(t, "6-9", lists[0].Range())
+	require.Len(t, lists[0].Items, 2)
+	assert.Equal(t, mq.Position{Line: 8, Column: 3, EndLine: 8, EndColumn: 14}, lists[0].Items[1].Position)
+}
+
+func TestElementPositionsMisnested(t *testing.T) {
+	// The parser copies the first link into the paragraph it overlaps
+	src := `<html><body><main>
+<a href="/one"><p>x</a></p>
+<table><tr><td>cell</td></tr></table>
+<p><a href="/two">two</a></p>
+<table><tbody><tr><td>cell</td></tr></tbody></table>
+<p><a href="/three">three</a></p>
+</main></body></html>`
+
+	doc, err := html.NewParser(html.WithReadability(false)).Parse([]byte(src), "misnested.html")
+	require.NoError(t, err)
+
+	var lines []int
+	for _, link := range doc.GetLinks() {
+		lines = append(lines, link.Line)
+	}
+	assert.Equal(t, []int{2, 4, 6}, lines)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 2)
+	assert.Equal(t, 3, tables[0].Line)
+	assert.Equal(t, 5, tables[1].Line)
+}
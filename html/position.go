@@ -0,0 +1,125 @@
+package html
+
+import (
+	"bytes"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"golang.org/x/net/html"
+)
+
+// voidElements have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// formattingElements are the elements the HTML parser clones when tags
+// are misnested: <a href="/one"><p>x</a></p> puts a copy of the link
+// inside the paragraph.
+var formattingElements = map[string]bool{
+	"a": true, "b": true, "big": true, "code": true, "em": true, "font": true,
+	"i": true, "nobr": true, "s": true, "small": true, "strike": true,
+	"strong": true, "tt": true, "u": true,
+}
+
+// tagPositions finds where elements start and end in the source.
+//
+// The html package's parser keeps no offsets, so the source is tokenized
+// a second time and elements are matched to start tags of the same name
+// in source order. An element matches the next unclaimed start tag only
+// if their attributes agree, so elements the parser implies (tbody, a
+// missing <li>) don't shift the ones after them; a clone of a formatting
+// element takes the position of the tag it copies. Elements whose end tag
+// is implied end where their start tag does.
+type tagPositions struct {
+	index *mq.LineIndex
+	spans map[string][]tagSpan // occurrences by tag, in source order
+	found map[*html.Node]tagSpan
+}
+
+// tagSpan is one start tag in the source: [start, end) of the element and
+// the tag's attributes.
+type tagSpan struct {
+	start, end int
+	attrs      []html.Attribute
+}
+
+func newTagPositions(source []byte, root *html.Node) *tagPositions {
+	t := &tagPositions{
+		index: mq.NewLineIndex(source),
+		spans: make(map[string][]tagSpan),
+		found: make(map[*html.Node]tagSpan),
+	}
+
+	open := make(map[string][]int)
+	z := html.NewTokenizer(bytes.NewReader(source))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		size := len(z.Raw())
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			tag := token.Data
+			t.spans[tag] = append(t.spans[tag], tagSpan{start: offset, end: offset + size, attrs: token.Attr})
+			if tt == html.StartTagToken && !voidElements[tag] {
+				open[tag] = append(open[tag], len(t.spans[tag])-1)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if stack := open[tag]; len(stack) > 0 {
+				t.spans[tag][stack[len(stack)-1]].end = offset + size
+				open[tag] = stack[:len(stack)-1]
+			}
+		}
+		offset += size
+	}
+
+	next := make(map[string]int)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			spans, i := t.spans[n.Data], next[n.Data]
+			switch {
+			case i < len(spans) && sameAttrs(spans[i].attrs, n.Attr):
+				t.found[n] = spans[i]
+				next[n.Data]++
+			case formattingElements[n.Data] && i > 0 && sameAttrs(spans[i-1].attrs, n.Attr):
+				t.found[n] = spans[i-1]
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return t
+}
+
+// sameAttrs reports whether a start tag's attributes are an element's.
+func sameAttrs(tag, node []html.Attribute) bool {
+	if len(tag) != len(node) {
+		return false
+	}
+	for i := range tag {
+		if tag[i].Key != node[i].Key || tag[i].Val != node[i].Val {
+			return false
+		}
+	}
+	return true
+}
+
+// of returns the position of element n, or the zero Position when no
+// start tag in the source matches it.
+func (t *tagPositions) of(n *html.Node) mq.Position {
+	span, ok := t.found[n]
+	if !ok {
+		return mq.Position{}
+	}
+	return t.index.Position(span.start, span.end)
+}
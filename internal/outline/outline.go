@@ -0,0 +1,60 @@
+// Package outline nests headings into sections for parsers that find
+// headings in text they render or scan line by line.
+package outline
+
+import mq "github.com/muqsitnawaz/mq/lib"
+
+// Nest builds sections from headings. Each section starts at its heading
+// and ends gap lines before the next heading at the same or a higher
+// level, so a gap of 1 skips the blank line the DOCX, EPUB, man page and
+// email parsers write before each heading. The last sections end at
+// totalLines. Headings without a line (Line 0) get an empty range and
+// don't end the sections before them.
+func Nest(headings []*mq.Heading, totalLines, gap int) []*mq.Section {
+	var sections []*mq.Section
+	var stack []*mq.Section
+
+	for _, h := range headings {
+		section := &mq.Section{Heading: h, Start: h.Line}
+		for len(stack) > 0 && stack[len(stack)-1].Heading.Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			section.Parent = parent
+			parent.Children = append(parent.Children, section)
+		}
+		stack = append(stack, section)
+		sections = append(sections, section)
+	}
+
+	Close(sections, totalLines, gap)
+	return sections
+}
+
+// Close sets the End of sections already nested by their caller, the
+// same way Nest does.
+func Close(sections []*mq.Section, totalLines, gap int) {
+	for i, s := range sections {
+		if s.Start == 0 {
+			s.End = 0 // Position unknown
+			continue
+		}
+		s.End = totalLines
+		for _, next := range sections[i+1:] {
+			if next.Start > 0 && next.Heading.Level <= s.Heading.Level {
+				s.End = max(s.Start, next.Start-1-gap) // never before it starts
+				break
+			}
+		}
+	}
+}
+
+// LastItem returns the innermost last item of a list.
+func LastItem(items []mq.ListItem) mq.ListItem {
+	item := items[len(items)-1]
+	if len(item.Children) > 0 {
+		return LastItem(item.Children)
+	}
+	return item
+}
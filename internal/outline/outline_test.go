@@ -0,0 +1,50 @@
+package outline
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNest(t *testing.T) {
+	// Rendered text: a blank line precedes every heading after the first
+	headings := []*mq.Heading{
+		{Level: 1, Text: "Book", Line: 1},
+		{Level: 2, Text: "One", Line: 3},
+		{Level: 2, Text: "Two", Line: 4},
+		{Level: 1, Text: "Appendix", Line: 9},
+	}
+	sections := Nest(headings, 12, 1)
+	require.Len(t, sections, 4)
+
+	assert.Equal(t, []int{1, 7}, []int{sections[0].Start, sections[0].End})
+	assert.Equal(t, []int{3, 3}, []int{sections[1].Start, sections[1].End}, "never ends before it starts")
+	assert.Equal(t, []int{4, 7}, []int{sections[2].Start, sections[2].End})
+	assert.Equal(t, []int{9, 12}, []int{sections[3].Start, sections[3].End})
+	assert.Equal(t, sections[0], sections[2].Parent)
+	assert.Len(t, sections[0].Children, 2)
+
+	// Without a gap, sections run up to the next heading
+	sections = Nest(headings, 12, 0)
+	assert.Equal(t, 8, sections[0].End)
+}
+
+func TestNestUnlocatedHeadings(t *testing.T) {
+	headings := []*mq.Heading{
+		{Level: 1, Text: "Guide", Line: 1},
+		{Level: 1, Text: "Lost"},
+		{Level: 1, Text: "Reference", Line: 6},
+	}
+	sections := Nest(headings, 9, 0)
+
+	assert.Equal(t, []int{1, 5}, []int{sections[0].Start, sections[0].End})
+	assert.Equal(t, []int{0, 0}, []int{sections[1].Start, sections[1].End})
+	assert.Equal(t, []int{6, 9}, []int{sections[2].Start, sections[2].End})
+}
+
+func TestLastItem(t *testing.T) {
+	items := []mq.ListItem{{Text: "a"}, {Text: "b", Children: []mq.ListItem{{Text: "c"}}}}
+	assert.Equal(t, "c", LastItem(items).Text)
+}
@@ -0,0 +1,821 @@
+// Package toml is a small TOML 1.0 decoder that records the line span of
+// every key and table. The data package parses .toml files with it, and
+// the lib package decodes +++ frontmatter.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Span is the 1-based line range a key occupies in the source.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Spans maps a key path (joined with KeyPathSep) to its line range.
+type Spans map[string]Span
+
+// KeyPathSep joins key path segments. Dots can't be used because quoted
+// keys may contain them.
+const KeyPathSep = "\x1f"
+
+// JoinKeyPath joins path with KeyPathSep.
+func JoinKeyPath(path []string) string {
+	return strings.Join(path, KeyPathSep)
+}
+
+// extend widens the span for path so it covers [start, end].
+func (s Spans) extend(path []string, start, end int) {
+	key := JoinKeyPath(path)
+	span, ok := s[key]
+	if !ok {
+		s[key] = Span{Start: start, End: end}
+		return
+	}
+	if start < span.Start {
+		span.Start = start
+	}
+	if end > span.End {
+		span.End = end
+	}
+	s[key] = span
+}
+
+// coverChildren widens every parent table's span to include its keys, so
+// implicit tables like `tool` in `[tool.poetry]` get a range too.
+func (s Spans) coverChildren() {
+	paths := make([]string, 0, len(s))
+	for key := range s {
+		paths = append(paths, key)
+	}
+	for _, key := range paths {
+		span := s[key]
+		parts := strings.Split(key, KeyPathSep)
+		for i := 1; i < len(parts); i++ {
+			s.extend(parts[:i], span.Start, span.End)
+		}
+	}
+}
+
+// header records a [table] or [[array]] header.
+type header struct {
+	path []string
+	line int
+}
+
+// decoder is a small TOML 1.0 decoder that records line spans.
+type decoder struct {
+	src  []byte
+	pos  int
+	line int
+
+	root        map[string]interface{}
+	current     map[string]interface{}
+	currentPath []string
+
+	headers []header
+	spans   Spans
+	defined map[string]bool // Tables defined by a [header], by key path
+	dotted  map[string]bool // Tables defined by dotted keys, by key path
+	frozen  map[string]bool // Inline tables and arrays, by key path
+}
+
+// Decode decodes TOML content into plain Go values and returns the
+// line span of every key and table.
+func Decode(src []byte) (map[string]interface{}, Spans, error) {
+	d := &decoder{
+		src:     src,
+		line:    1,
+		root:    make(map[string]interface{}),
+		spans:   make(Spans),
+		defined: make(map[string]bool),
+		dotted:  make(map[string]bool),
+		frozen:  make(map[string]bool),
+	}
+	d.current = d.root
+
+	if err := d.decode(); err != nil {
+		return nil, nil, err
+	}
+	d.closeHeaders()
+	d.spans.coverChildren()
+
+	return d.root, d.spans, nil
+}
+
+func (d *decoder) decode() error {
+	for {
+		d.skipBlank()
+		if d.eof() {
+			return nil
+		}
+
+		var err error
+		if d.peek() == '[' {
+			err = d.parseHeader()
+		} else {
+			err = d.parseKeyValue(d.current, d.currentPath)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := d.expectLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseHeader parses a [table] or [[array.of.tables]] header.
+func (d *decoder) parseHeader() error {
+	line := d.line
+	d.closeHeaders()
+
+	d.pos++ // [
+	array := false
+	if d.peek() == '[' {
+		array = true
+		d.pos++
+	}
+
+	d.skipSpaces()
+	path, err := d.parseKey()
+	if err != nil {
+		return err
+	}
+	d.skipSpaces()
+
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(string(d.src[d.pos:]), closing) {
+		return d.errorf("expected %q to close table header", closing)
+	}
+	d.pos += len(closing)
+
+	if err := d.checkFrozen(path, len(path)); err != nil {
+		return err
+	}
+	parent, err := d.descend(d.root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+
+	if array {
+		var arr []interface{}
+		switch existing := parent[last].(type) {
+		case nil:
+		case []interface{}:
+			arr = existing
+		default:
+			return d.errorf("key %q is already defined as %T", last, existing)
+		}
+		table := make(map[string]interface{})
+		parent[last] = append(arr, table)
+		d.current = table
+
+		// Subtables of the previous element may be defined again
+		prefix := JoinKeyPath(path) + KeyPathSep
+		for _, paths := range []map[string]bool{d.defined, d.dotted, d.frozen} {
+			for key := range paths {
+				if strings.HasPrefix(key, prefix) {
+					delete(paths, key)
+				}
+			}
+		}
+	} else {
+		key := JoinKeyPath(path)
+		if d.defined[key] || d.dotted[key] {
+			return d.errorf("table %q is already defined", strings.Join(path, "."))
+		}
+		d.defined[key] = true
+
+		switch existing := parent[last].(type) {
+		case nil:
+			table := make(map[string]interface{})
+			parent[last] = table
+			d.current = table
+		case map[string]interface{}:
+			d.current = existing
+		default:
+			return d.errorf("key %q is already defined as %T", last, existing)
+		}
+	}
+
+	d.currentPath = path
+	d.headers = append(d.headers, header{path: path, line: line})
+	d.spans.extend(path, line, line)
+	return nil
+}
+
+// closeHeaders extends the most recent table header down to the last
+// non-blank line before the cursor, which sits on the next header or EOF.
+func (d *decoder) closeHeaders() {
+	if len(d.headers) == 0 {
+		return
+	}
+	h := d.headers[len(d.headers)-1]
+	end := d.lastContentLine(h.line)
+	d.spans.extend(h.path, h.line, end)
+}
+
+// lastContentLine returns the last non-blank line before the cursor,
+// never earlier than floor.
+func (d *decoder) lastContentLine(floor int) int {
+	line := d.line
+	for i := d.pos - 1; i >= 0; i-- {
+		c := d.src[i]
+		if c == '\n' {
+			line--
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' {
+			break
+		}
+	}
+	if line < floor {
+		return floor
+	}
+	return line
+}
+
+// parseKeyValue parses `key = value` into table, recording spans under prefix.
+func (d *decoder) parseKeyValue(table map[string]interface{}, prefix []string) error {
+	start := d.line
+	key, err := d.parseKey()
+	if err != nil {
+		return err
+	}
+	d.skipSpaces()
+	if d.peek() != '=' {
+		return d.errorf("expected '=' after key %q", strings.Join(key, "."))
+	}
+	d.pos++
+	d.skipSpaces()
+
+	value, err := d.parseValue()
+	if err != nil {
+		return err
+	}
+
+	full := append(append([]string{}, prefix...), key...)
+	if err := d.checkFrozen(full, len(full)-1); err != nil {
+		return err
+	}
+	parent, err := d.descend(table, key[:len(key)-1])
+	if err != nil {
+		return err
+	}
+	last := key[len(key)-1]
+	if _, exists := parent[last]; exists {
+		return d.errorf("duplicate key %q", strings.Join(key, "."))
+	}
+	parent[last] = value
+
+	for i := len(prefix) + 1; i <= len(full); i++ {
+		d.spans.extend(full[:i], start, d.line)
+		if i < len(full) {
+			d.dotted[JoinKeyPath(full[:i])] = true
+		}
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		d.frozen[JoinKeyPath(full)] = true
+		d.extendInline(full, value, start, d.line)
+	}
+	return nil
+}
+
+// checkFrozen fails if one of the first n tables along path is an inline
+// table or array value, which can't be extended.
+func (d *decoder) checkFrozen(path []string, n int) error {
+	for i := 1; i <= n; i++ {
+		if d.frozen[JoinKeyPath(path[:i])] {
+			return d.errorf("key %q is an inline value and can't be extended", strings.Join(path[:i], "."))
+		}
+	}
+	return nil
+}
+
+// extendInline gives the keys nested in an inline table or array the
+// span of the key-value pair holding them.
+func (d *decoder) extendInline(path []string, value interface{}, start, end int) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := append(path[:len(path):len(path)], key)
+			d.spans.extend(childPath, start, end)
+			d.extendInline(childPath, child, start, end)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				itemPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+				d.spans.extend(itemPath, start, end)
+				d.extendInline(itemPath, item, start, end)
+			}
+		}
+	}
+}
+
+// descend walks (creating as needed) nested tables along path.
+func (d *decoder) descend(table map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, k := range path {
+		switch next := table[k].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			table[k] = child
+			table = child
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			// Dotted paths into an array of tables address its last element.
+			if len(next) == 0 {
+				return nil, d.errorf("key %q is an empty array", k)
+			}
+			last, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, d.errorf("key %q is not a table", k)
+			}
+			table = last
+		default:
+			return nil, d.errorf("key %q is already defined as %T", k, next)
+		}
+	}
+	return table, nil
+}
+
+// parseKey parses a bare, quoted or dotted key.
+func (d *decoder) parseKey() ([]string, error) {
+	var parts []string
+	for {
+		d.skipSpaces()
+		var part string
+		switch c := d.peek(); {
+		case c == '"':
+			s, err := d.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		case c == '\'':
+			s, err := d.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		case isBareKeyChar(c):
+			start := d.pos
+			for !d.eof() && isBareKeyChar(d.peek()) {
+				d.pos++
+			}
+			part = string(d.src[start:d.pos])
+		default:
+			return nil, d.errorf("expected key, got %q", string(c))
+		}
+		parts = append(parts, part)
+
+		d.skipSpaces()
+		if d.peek() != '.' {
+			return parts, nil
+		}
+		d.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseValue parses any TOML value.
+func (d *decoder) parseValue() (interface{}, error) {
+	rest := d.src[d.pos:]
+	switch {
+	case len(rest) == 0:
+		return nil, d.errorf("expected value, got end of input")
+	case strings.HasPrefix(string(rest), `"""`):
+		return d.parseMultilineString(`"""`, true)
+	case strings.HasPrefix(string(rest), `'''`):
+		return d.parseMultilineString(`'''`, false)
+	case rest[0] == '"':
+		return d.parseBasicString()
+	case rest[0] == '\'':
+		return d.parseLiteralString()
+	case rest[0] == '[':
+		return d.parseArray()
+	case rest[0] == '{':
+		return d.parseInlineTable()
+	case strings.HasPrefix(string(rest), "true") && !isValueChar(at(rest, 4)):
+		d.pos += 4
+		return true, nil
+	case strings.HasPrefix(string(rest), "false") && !isValueChar(at(rest, 5)):
+		d.pos += 5
+		return false, nil
+	default:
+		return d.parseScalar()
+	}
+}
+
+func at(b []byte, i int) byte {
+	if i < len(b) {
+		return b[i]
+	}
+	return 0
+}
+
+func isValueChar(c byte) bool {
+	return isBareKeyChar(c) || c == '.' || c == '+' || c == ':'
+}
+
+// parseScalar parses numbers and date/times.
+func (d *decoder) parseScalar() (interface{}, error) {
+	start := d.pos
+	for !d.eof() && isValueChar(d.peek()) {
+		d.pos++
+	}
+	// A date followed by a space and a time is a single datetime.
+	if d.pos-start == 10 && d.peek() == ' ' && d.pos+3 < len(d.src) &&
+		isDigit(d.src[d.pos+1]) && isDigit(d.src[d.pos+2]) && d.src[d.pos+3] == ':' {
+		d.pos++
+		for !d.eof() && isValueChar(d.peek()) {
+			d.pos++
+		}
+	}
+
+	token := string(d.src[start:d.pos])
+	if token == "" {
+		return nil, d.errorf("expected value, got %q", string(d.peek()))
+	}
+
+	if v, ok := parseNumber(token); ok {
+		return v, nil
+	}
+	if v, ok := parseDateTime(token); ok {
+		return v, nil
+	}
+	return nil, d.errorf("invalid value %q", token)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseNumber parses integers (decimal, hex, octal, binary) and floats.
+func parseNumber(token string) (interface{}, bool) {
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+
+	clean := strings.ReplaceAll(token, "_", "")
+	if len(clean) > 2 && clean[0] == '0' {
+		base := 0
+		switch clean[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			if i, err := strconv.ParseInt(clean[2:], base, 64); err == nil {
+				return i, true
+			}
+			return nil, false
+		}
+	}
+	if i, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		return i, true
+	}
+	if strings.ContainsAny(clean, ".eE") && !strings.ContainsAny(clean, ":T") {
+		if f, err := strconv.ParseFloat(clean, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// timeLayouts are the date/time forms TOML allows, most specific first.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseDateTime parses offset and local date-times and local dates.
+// Local times (no date) are kept as strings.
+func parseDateTime(token string) (interface{}, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, token); err == nil {
+			return t, true
+		}
+	}
+	if _, err := time.Parse("15:04:05.999999999", token); err == nil {
+		return token, true
+	}
+	return nil, false
+}
+
+// parseArray parses [a, b, c], which may span lines and contain comments.
+func (d *decoder) parseArray() (interface{}, error) {
+	d.pos++ // [
+	arr := []interface{}{}
+	for {
+		d.skipBlank()
+		if d.eof() {
+			return nil, d.errorf("unterminated array")
+		}
+		if d.peek() == ']' {
+			d.pos++
+			return arr, nil
+		}
+
+		v, err := d.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+
+		d.skipBlank()
+		switch d.peek() {
+		case ',':
+			d.pos++
+		case ']':
+			d.pos++
+			return arr, nil
+		default:
+			return nil, d.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable parses { key = value, ... } on a single line.
+func (d *decoder) parseInlineTable() (interface{}, error) {
+	d.pos++ // {
+	table := make(map[string]interface{})
+	d.skipSpaces()
+	if d.peek() == '}' {
+		d.pos++
+		return table, nil
+	}
+	for {
+		d.skipSpaces()
+		key, err := d.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		d.skipSpaces()
+		if d.peek() != '=' {
+			return nil, d.errorf("expected '=' in inline table")
+		}
+		d.pos++
+		d.skipSpaces()
+		v, err := d.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		parent, err := d.descend(table, key[:len(key)-1])
+		if err != nil {
+			return nil, err
+		}
+		parent[key[len(key)-1]] = v
+
+		d.skipSpaces()
+		switch d.peek() {
+		case ',':
+			d.pos++
+		case '}':
+			d.pos++
+			return table, nil
+		default:
+			return nil, d.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseBasicString parses a "double-quoted" string with escapes.
+func (d *decoder) parseBasicString() (string, error) {
+	d.pos++ // "
+	var buf strings.Builder
+	for {
+		if d.eof() || d.peek() == '\n' {
+			return "", d.errorf("unterminated string")
+		}
+		c := d.peek()
+		if c == '"' {
+			d.pos++
+			return buf.String(), nil
+		}
+		if c == '\\' {
+			if err := d.parseEscape(&buf); err != nil {
+				return "", err
+			}
+			continue
+		}
+		buf.WriteByte(c)
+		d.pos++
+	}
+}
+
+// parseLiteralString parses a 'single-quoted' string without escapes.
+func (d *decoder) parseLiteralString() (string, error) {
+	d.pos++ // '
+	start := d.pos
+	for !d.eof() && d.peek() != '\'' {
+		if d.peek() == '\n' {
+			return "", d.errorf("unterminated string")
+		}
+		d.pos++
+	}
+	if d.eof() {
+		return "", d.errorf("unterminated string")
+	}
+	s := string(d.src[start:d.pos])
+	d.pos++
+	return s, nil
+}
+
+// parseMultilineString parses triple-quoted basic and literal strings.
+func (d *decoder) parseMultilineString(delim string, escapes bool) (string, error) {
+	d.pos += len(delim)
+	// A newline immediately after the opening delimiter is trimmed.
+	if strings.HasPrefix(string(d.src[d.pos:]), "\r\n") {
+		d.pos += 2
+		d.line++
+	} else if d.peek() == '\n' {
+		d.pos++
+		d.line++
+	}
+
+	var buf strings.Builder
+	for {
+		if d.eof() {
+			return "", d.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(string(d.src[d.pos:]), delim) {
+			// Up to two quotes may directly precede the closing delimiter.
+			extra := 0
+			for extra < 2 && d.pos+len(delim)+extra < len(d.src) && d.src[d.pos+len(delim)+extra] == delim[0] {
+				extra++
+			}
+			buf.WriteString(strings.Repeat(delim[:1], extra))
+			d.pos += len(delim) + extra
+			return buf.String(), nil
+		}
+
+		c := d.peek()
+		if escapes && c == '\\' {
+			// Line-ending backslash trims the newline and leading whitespace.
+			j := d.pos + 1
+			for j < len(d.src) && (d.src[j] == ' ' || d.src[j] == '\t' || d.src[j] == '\r') {
+				j++
+			}
+			if j < len(d.src) && d.src[j] == '\n' {
+				d.pos = j
+				for !d.eof() && (d.peek() == ' ' || d.peek() == '\t' || d.peek() == '\n' || d.peek() == '\r') {
+					if d.peek() == '\n' {
+						d.line++
+					}
+					d.pos++
+				}
+				continue
+			}
+			if err := d.parseEscape(&buf); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if c == '\n' {
+			d.line++
+		}
+		buf.WriteByte(c)
+		d.pos++
+	}
+}
+
+// parseEscape parses a backslash escape sequence into buf.
+func (d *decoder) parseEscape(buf *strings.Builder) error {
+	d.pos++ // backslash
+	if d.eof() {
+		return d.errorf("unterminated escape sequence")
+	}
+	c := d.peek()
+	d.pos++
+	switch c {
+	case 'b':
+		buf.WriteByte('\b')
+	case 't':
+		buf.WriteByte('\t')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'r':
+		buf.WriteByte('\r')
+	case '"':
+		buf.WriteByte('"')
+	case '\\':
+		buf.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if d.pos+n > len(d.src) {
+			return d.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(d.src[d.pos:d.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return d.errorf("invalid unicode escape")
+		}
+		buf.WriteRune(rune(code))
+		d.pos += n
+	default:
+		return d.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// expectLineEnd consumes trailing spaces and a comment, then requires a newline or EOF.
+func (d *decoder) expectLineEnd() error {
+	d.skipSpaces()
+	if d.peek() == '#' {
+		d.skipComment()
+	}
+	if d.eof() {
+		return nil
+	}
+	if d.peek() == '\r' {
+		d.pos++
+	}
+	if d.peek() != '\n' {
+		return d.errorf("expected newline, got %q", string(d.peek()))
+	}
+	d.pos++
+	d.line++
+	return nil
+}
+
+// skipSpaces skips spaces and tabs.
+func (d *decoder) skipSpaces() {
+	for !d.eof() && (d.peek() == ' ' || d.peek() == '\t') {
+		d.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (d *decoder) skipBlank() {
+	for !d.eof() {
+		switch d.peek() {
+		case ' ', '\t', '\r':
+			d.pos++
+		case '\n':
+			d.pos++
+			d.line++
+		case '#':
+			d.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (d *decoder) skipComment() {
+	for !d.eof() && d.peek() != '\n' {
+		d.pos++
+	}
+}
+
+func (d *decoder) peek() byte {
+	if d.pos >= len(d.src) {
+		return 0
+	}
+	return d.src[d.pos]
+}
+
+func (d *decoder) eof() bool {
+	return d.pos >= len(d.src)
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", d.line, fmt.Sprintf(format, args...))
+}
+
+// applySpans copies key line ranges onto headings and sections, recursing
@@ -0,0 +1,99 @@
+package toml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeValues(t *testing.T) {
+	src := []byte(`
+int = 1_000
+hex = 0xff
+float = 3.14
+neg_inf = -inf
+bool = true
+date = 1979-05-27
+when = 1979-05-27T07:32:00Z
+literal = 'C:\path'
+escaped = "tab\there"
+multi = """
+line one
+line two"""
+"quoted.key" = "x"
+a.b.c = 1
+`)
+	data, spans, err := Decode(src)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1000), data["int"])
+	assert.Equal(t, int64(255), data["hex"])
+	assert.Equal(t, 3.14, data["float"])
+	assert.Equal(t, true, data["bool"])
+	assert.Equal(t, time.Date(1979, 5, 27, 0, 0, 0, 0, time.UTC), data["date"])
+	assert.Equal(t, `C:\path`, data["literal"])
+	assert.Equal(t, "tab\there", data["escaped"])
+	assert.Equal(t, "line one\nline two", data["multi"])
+	assert.Equal(t, "x", data["quoted.key"])
+	assert.Equal(t, int64(1), data["a"].(map[string]interface{})["b"].(map[string]interface{})["c"])
+
+	assert.Equal(t, Span{Start: 11, End: 13}, spans[JoinKeyPath([]string{"multi"})])
+	assert.Equal(t, Span{Start: 15, End: 15}, spans[JoinKeyPath([]string{"a", "b"})])
+}
+
+func TestDecodeTables(t *testing.T) {
+	// Subtables repeat per array element, and a super-table may be
+	// defined after its subtable
+	src := "[[fruit]]\nname = \"apple\"\n[fruit.color]\nhex = \"f00\"\n\n" +
+		"[[fruit]]\nname = \"pear\"\n[fruit.color]\nhex = \"0f0\"\n\n" +
+		"[x.y]\nz = 1\n[x]\nw = 2\n"
+	data, _, err := Decode([]byte(src))
+	require.NoError(t, err)
+	assert.Len(t, data["fruit"], 2)
+	assert.Equal(t, map[string]interface{}{"y": map[string]interface{}{"z": int64(1)}, "w": int64(2)}, data["x"])
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"duplicate key", "a = 1\na = 2\n", "line 2"},
+		{"duplicate table", "[a]\nx = 1\n[b]\n[a]\ny = 2\n", "already defined"},
+		{"escape outside TOML 1.0", "a = \"\\e[0m\"\n", "line 1"},
+		{"unclosed header", "[table\n", "line 1"},
+		{"header extends inline table", "a = {b = 1}\n[a.c]\n", "can't be extended"},
+		{"dotted key extends inline table", "a = {b = 1}\na.c = 2\n", "can't be extended"},
+		{"array of tables over static array", "a = [1]\n[[a]]\n", "can't be extended"},
+		{"header redefines dotted table", "a.b = 1\n[a]\n", "already defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Decode([]byte(tt.src))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestDecodeInlineSpans(t *testing.T) {
+	src := "[server]\nlimits = { cpu = 2, mem = { max = 4 } }\nports = [{ n = 80 }]\n"
+	_, spans, err := Decode([]byte(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, Span{Start: 2, End: 2}, spans[JoinKeyPath([]string{"server", "limits", "cpu"})])
+	assert.Equal(t, Span{Start: 2, End: 2}, spans[JoinKeyPath([]string{"server", "limits", "mem", "max"})])
+	assert.Equal(t, Span{Start: 3, End: 3}, spans[JoinKeyPath([]string{"server", "ports", "0", "n"})])
+}
+
+func TestDecodeExtendingTables(t *testing.T) {
+	// Dotted keys may gain subtables, and each array element starts over
+	src := "[fruit]\napple.color = \"red\"\n[fruit.apple.texture]\nsmooth = true\n\n" +
+		"[[veg]]\nsize = { cm = 3 }\n[[veg]]\nsize.cm = 4\n"
+	data, _, err := Decode([]byte(src))
+	require.NoError(t, err)
+	assert.Len(t, data["veg"], 2)
+}
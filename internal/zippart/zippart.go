@@ -0,0 +1,35 @@
+// Package zippart reads parts of zip-based documents (DOCX, EPUB) and
+// archives with a bound on how much a single entry may inflate to.
+package zippart
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+)
+
+// MaxSize bounds how much of a single zip entry is read.
+const MaxSize = 64 << 20
+
+// Read returns the contents of f, failing if it inflates past MaxSize.
+func Read(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ReadLimited(rc, MaxSize, f.Name)
+}
+
+// ReadLimited reads r to the end, failing once more than limit bytes
+// come out of it. name identifies r in the error.
+func ReadLimited(r io.Reader, limit int64, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: larger than %d bytes", name, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,18 @@
+package zippart
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLimited(t *testing.T) {
+	data, err := ReadLimited(strings.NewReader("abcd"), 4, "part")
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(data))
+
+	_, err = ReadLimited(strings.NewReader("abcde"), 4, "part")
+	assert.ErrorContains(t, err, "part: larger than 4 bytes")
+}
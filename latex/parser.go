@@ -0,0 +1,836 @@
+// Package latex provides LaTeX source parsing for mq.
+//
+// LaTeX sources carry explicit structure that is lost in the compiled PDF.
+// The parser maps it onto mq's unified types:
+//   - Headings: \part, \chapter, \section ... \subparagraph, with levels
+//     normalized so the outermost command used becomes H1
+//   - Code blocks: lstlisting, minted, verbatim and Verbatim environments
+//   - Tables: tabular, tabular*, tabularx and longtable environments
+//   - Links: \href, \url, and \cite keys (as cite:key)
+//   - Images: \includegraphics
+//   - Metadata: \title, \author, \date, \documentclass and the abstract
+//
+// \input and \include are followed relative to the including file and
+// spliced into the document. Line numbers refer to the file each element
+// was written in; elements from an included file name it in their
+// Position.File, and sections in their "file" metadata.
+//
+// Example:
+//
+//	parser := latex.NewParser()
+//	doc, _ := parser.ParseFile("thesis.tex")
+//
+//	methods, _ := doc.GetSection("Methods")
+//	tables := doc.GetTables()
+package latex
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// maxIncludeDepth bounds nested \input/\include expansion.
+const maxIncludeDepth = 16
+
+// Parser parses LaTeX documents into mq.Document.
+type Parser struct {
+	// Options
+	followIncludes bool // Splice in \input and \include files
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new LaTeX parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		followIncludes: true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithIncludes enables/disables following \input and \include.
+func WithIncludes(enabled bool) Option {
+	return func(p *Parser) {
+		p.followIncludes = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatLaTeX
+}
+
+// ParseFile reads and parses a LaTeX file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatLaTeX, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses LaTeX content and returns an mq.Document.
+// Includes are resolved relative to the directory of path.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	ext := &extractor{
+		content: content,
+		source:  string(content),
+		path:    path,
+	}
+	if p.followIncludes {
+		abs, _ := filepath.Abs(path)
+		x := &expansion{files: make(map[string][]byte)}
+		ext.source, ext.origins = x.expand(ext.source, filepath.Dir(path), "", nil, map[string]bool{abs: true}, 0)
+		ext.files = x.files
+	}
+	return ext.extract(), nil
+}
+
+var includeRe = regexp.MustCompile(`\\(input|include)\s*\{([^}]+)\}`)
+
+// origin is where a line of the expanded source came from.
+type origin struct {
+	file string  // Included file, "" for the main file
+	line int     // Line in file
+	from *origin // The \input line that included file, nil in the main file
+}
+
+// lineIn returns the line in file that covers o: o's own line, or the
+// line of the \input that brought it in.
+func (o *origin) lineIn(file string) (int, bool) {
+	for ; o != nil; o = o.from {
+		if o.file == file {
+			return o.line, true
+		}
+	}
+	return 0, false
+}
+
+// expansion collects the files spliced in by expand.
+type expansion struct {
+	files map[string][]byte // Included file contents by path
+}
+
+// expand splices included files into the source of file and returns the
+// origin of every resulting line. Includes are resolved relative to dir;
+// missing files and cycles leave the command in place.
+func (x *expansion) expand(source, dir, file string, from *origin, seen map[string]bool, depth int) (string, []*origin) {
+	lines := strings.Split(source, "\n")
+	origins := make([]*origin, 0, len(lines))
+	for i, line := range lines {
+		self := &origin{file: file, line: i + 1, from: from}
+		code := stripComment(line)
+		if depth >= maxIncludeDepth || !strings.Contains(code, `\in`) {
+			origins = append(origins, self)
+			continue
+		}
+
+		lineOrigins := []*origin{self}
+		lines[i] = includeRe.ReplaceAllStringFunc(code, func(cmd string) string {
+			name := strings.TrimSpace(includeRe.FindStringSubmatch(cmd)[2])
+			if filepath.Ext(name) == "" {
+				name += ".tex"
+			}
+			included := filepath.Join(dir, name)
+			abs, _ := filepath.Abs(included)
+			if seen[abs] {
+				return cmd
+			}
+			data, err := os.ReadFile(included)
+			if err != nil {
+				return cmd
+			}
+			seen[abs] = true
+			defer delete(seen, abs)
+			x.files[included] = data
+
+			text, inner := x.expand(string(data), filepath.Dir(included), included, self, seen, depth+1)
+			text = strings.TrimRight(text, "\n")
+			// The included text replaces the command, so its first line
+			// shares a line with whatever preceded it
+			lineOrigins = append(lineOrigins[:len(lineOrigins)-1], inner[:strings.Count(text, "\n")+1]...)
+			return text
+		}) + line[len(code):]
+		origins = append(origins, lineOrigins...)
+	}
+	return strings.Join(lines, "\n"), origins
+}
+
+// stripComment removes an unescaped % comment from a line.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // skip escaped character
+		case '%':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// extractor scans the expanded source for structural commands.
+type extractor struct {
+	content []byte // The main file
+	source  string // content with includes spliced in
+	path    string
+
+	// origins maps each line of source back to its file; nil without
+	// includes. files holds the included files' contents.
+	origins []*origin
+	files   map[string][]byte
+
+	// masked is source with comments and verbatim bodies blanked out
+	// (newlines kept) so offsets and line numbers still line up.
+	masked     string
+	lineStarts []int
+	index      *mq.LineIndex
+
+	// Extracted elements
+	headings   []*mq.Heading
+	sections   []*mq.Section
+	codeBlocks []*mq.CodeBlock
+	codeLines  []int // Line of each code block's \begin
+	links      []*mq.Link
+	images     []*mq.Image
+	tables     []*mq.Table
+	metadata   mq.Metadata
+}
+
+var (
+	beginVerbatimRe = regexp.MustCompile(`\\begin\{(lstlisting|minted|verbatim|Verbatim)\}`)
+	languageRe      = regexp.MustCompile(`language\s*=\s*\{?([\w+#-]+)`)
+)
+
+func (e *extractor) extract() *mq.Document {
+	e.lineStarts = computeLineStarts(e.source)
+	e.index = mq.NewLineIndex([]byte(e.source))
+	e.masked = e.mask()
+
+	e.extractHeadings()
+	e.attachCodeBlocks()
+	e.extractTables()
+	e.extractLinks()
+	e.extractMetadata()
+	e.relocate()
+
+	title, _ := e.metadata["title"].(string)
+	if title == "" && len(e.headings) > 0 {
+		title = e.headings[0].Text
+	}
+
+	doc := mq.NewDocument(
+		e.content,
+		e.path,
+		mq.FormatLaTeX,
+		title,
+		e.headings,
+		e.sections,
+		e.codeBlocks,
+		e.links,
+		e.images,
+		e.tables,
+		nil, // lists
+		e.source,
+	)
+	if len(e.metadata) > 0 {
+		doc.SetMetadata(e.metadata)
+	}
+	return doc
+}
+
+// mask blanks comments and verbatim environment bodies, recording the
+// verbatim bodies as code blocks along the way.
+func (e *extractor) mask() string {
+	buf := []byte(e.source)
+
+	// Comments first, line by line.
+	for i, start := range e.lineStarts {
+		end := len(e.source)
+		if i+1 < len(e.lineStarts) {
+			end = e.lineStarts[i+1] - 1
+		}
+		line := e.source[start:end]
+		if code := stripComment(line); len(code) < len(line) {
+			blank(buf, start+len(code), end)
+		}
+	}
+
+	// Then verbatim bodies, scanning the original text so % inside
+	// code is preserved.
+	offset := 0
+	for {
+		loc := beginVerbatimRe.FindStringSubmatchIndex(string(buf[offset:]))
+		if loc == nil {
+			break
+		}
+		env := string(buf[offset+loc[2] : offset+loc[3]])
+		bodyStart := offset + loc[1]
+		endTag := `\end{` + env + `}`
+		endIdx := strings.Index(e.source[bodyStart:], endTag)
+		if endIdx < 0 {
+			break
+		}
+		bodyEnd := bodyStart + endIdx
+
+		// Options: [language=Python] for lstlisting, {python} for minted.
+		lang := ""
+		rest := e.source[bodyStart:bodyEnd]
+		if opts, n, ok := readGroup(rest, 0, '[', ']'); ok && strings.HasPrefix(rest, "[") {
+			if m := languageRe.FindStringSubmatch(opts); m != nil {
+				lang = strings.ToLower(m[1])
+			}
+			rest, bodyStart = rest[n:], bodyStart+n
+		}
+		if env == "minted" {
+			if arg, n, ok := readGroup(rest, 0, '{', '}'); ok {
+				lang = strings.ToLower(strings.TrimSpace(arg))
+				bodyStart += n
+			}
+		}
+
+		content := strings.TrimPrefix(e.source[bodyStart:bodyEnd], "\n")
+		content = strings.TrimRight(content, " \t\n")
+		e.codeBlocks = append(e.codeBlocks, &mq.CodeBlock{
+			Language: lang,
+			Content:  content,
+			Position: e.index.Position(offset+loc[0], bodyEnd+len(endTag)),
+		})
+		e.codeLines = append(e.codeLines, e.lineAt(offset+loc[0]))
+
+		blank(buf, bodyStart, bodyEnd)
+		offset = bodyEnd + len(endTag)
+	}
+
+	return string(buf)
+}
+
+// blank replaces bytes in [start, end) with spaces, keeping newlines.
+func blank(buf []byte, start, end int) {
+	for i := start; i < end; i++ {
+		if buf[i] != '\n' {
+			buf[i] = ' '
+		}
+	}
+}
+
+var sectionLevels = map[string]int{
+	"part":          1,
+	"chapter":       2,
+	"section":       3,
+	"subsection":    4,
+	"subsubsection": 5,
+	"paragraph":     6,
+	"subparagraph":  7,
+}
+
+var sectionRe = regexp.MustCompile(`\\(part|chapter|section|subsection|subsubsection|paragraph|subparagraph)\b\*?`)
+
+var labelRe = regexp.MustCompile(`^\s*\\label\s*\{([^}]*)\}`)
+
+var endDocumentRe = regexp.MustCompile(`\\end\{document\}`)
+
+// extractHeadings finds sectioning commands and builds sections.
+func (e *extractor) extractHeadings() {
+	type raw struct {
+		level int
+		text  string
+		id    string
+		line  int
+		pos   mq.Position
+	}
+	var found []raw
+	top := 7
+
+	for _, loc := range sectionRe.FindAllStringSubmatchIndex(e.masked, -1) {
+		cmd := e.masked[loc[2]:loc[3]]
+		i := loc[1]
+		// Optional short title: \section[short]{Long title}
+		if _, n, ok := readGroup(e.masked, i, '[', ']'); ok {
+			i = n
+		}
+		arg, n, ok := readGroup(e.masked, i, '{', '}')
+		if !ok {
+			continue
+		}
+
+		text := cleanText(arg)
+		if text == "" {
+			continue
+		}
+		h := raw{level: sectionLevels[cmd], text: text, line: e.lineAt(loc[0]), pos: e.index.Position(loc[0], n)}
+		if m := labelRe.FindStringSubmatch(e.masked[n:]); m != nil {
+			h.id = m[1]
+		}
+		found = append(found, h)
+		top = min(top, h.level)
+	}
+
+	lastLine := len(e.lineStarts)
+	if loc := endDocumentRe.FindStringIndex(e.masked); loc != nil {
+		lastLine = e.lineAt(loc[0]) - 1
+	}
+
+	var stack []*mq.Section
+	for _, r := range found {
+		h := &mq.Heading{
+			Level: min(r.level-top+1, 6),
+			Text:  r.text,
+			ID:    r.id,
+		}
+		h.SetPosition(r.pos)
+		e.headings = append(e.headings, h)
+
+		s := &mq.Section{Heading: h, Start: r.line}
+		for len(stack) > 0 && stack[len(stack)-1].Heading.Level >= h.Level {
+			closed := stack[len(stack)-1]
+			closed.End = e.trimEnd(closed.Start, r.line-1)
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			s.Parent = parent
+			parent.Children = append(parent.Children, s)
+		}
+		stack = append(stack, s)
+		e.sections = append(e.sections, s)
+	}
+	for _, s := range stack {
+		s.End = e.trimEnd(s.Start, lastLine)
+	}
+}
+
+// attachCodeBlocks adds each code block to the innermost section holding it.
+func (e *extractor) attachCodeBlocks() {
+	for i, cb := range e.codeBlocks {
+		var owner *mq.Section
+		for _, s := range e.sections {
+			if s.Start <= e.codeLines[i] && e.codeLines[i] <= s.End {
+				owner = s // later sections are nested deeper
+			}
+		}
+		if owner != nil {
+			owner.AddCodeBlock(cb)
+		}
+	}
+}
+
+// trimEnd moves a section end back over trailing blank lines.
+func (e *extractor) trimEnd(start, end int) int {
+	for end > start && strings.TrimSpace(e.lineText(end)) == "" {
+		end--
+	}
+	return end
+}
+
+// relocate maps lines of the expanded source back to the files they came
+// from. Sections from an included file read their text from it and name
+// it in their "file" metadata.
+func (e *extractor) relocate() {
+	if e.origins == nil {
+		return
+	}
+
+	for _, h := range e.headings {
+		pos := h.Position()
+		e.relocatePosition(&pos)
+		h.SetPosition(pos)
+	}
+	for _, cb := range e.codeBlocks {
+		e.relocatePosition(&cb.Position)
+	}
+	for _, l := range e.links {
+		e.relocatePosition(&l.Position)
+	}
+	for _, img := range e.images {
+		e.relocatePosition(&img.Position)
+	}
+	for _, t := range e.tables {
+		e.relocatePosition(&t.Position)
+	}
+
+	for _, s := range e.sections {
+		start := e.origins[s.Start-1]
+		s.Start, s.End = start.line, e.lastLineIn(start.file, s.Start, s.End)
+		if start.file != "" {
+			s.Metadata = mq.Metadata{"file": filepath.ToSlash(start.file)}
+			s.SetSource(e.files[start.file])
+		}
+	}
+}
+
+// relocatePosition maps p from the expanded source to the file its first
+// line came from.
+func (e *extractor) relocatePosition(p *mq.Position) {
+	if p.Line < 1 || p.Line > len(e.origins) {
+		return
+	}
+	start := e.origins[p.Line-1]
+	if p.EndLine > 0 {
+		p.EndLine = e.lastLineIn(start.file, p.Line, p.EndLine)
+	}
+	p.Line = start.line
+	p.File = filepath.ToSlash(start.file)
+}
+
+// lastLineIn returns the line in file covering the last expanded line in
+// [first, last] that file contains, so ranges that run past the end of an
+// included file stop there.
+func (e *extractor) lastLineIn(file string, first, last int) int {
+	for l := min(last, len(e.origins)); l > first; l-- {
+		if line, ok := e.origins[l-1].lineIn(file); ok {
+			return line
+		}
+	}
+	return e.origins[first-1].line
+}
+
+var beginTabularRe = regexp.MustCompile(`\\begin\{(tabular\*?|tabularx|longtable)\}`)
+
+var ruleRe = regexp.MustCompile(`\\(hline|toprule|midrule|bottomrule|endhead|endfirsthead|endfoot|endlastfoot)\b|\\cline\s*\{[^}]*\}|\\cmidrule(\([^)]*\))?\s*\{[^}]*\}`)
+
+var multicolumnRe = regexp.MustCompile(`\\multicolumn\s*\{[^}]*\}\s*\{[^}]*\}\s*`)
+
+// extractTables converts tabular-like environments into tables.
+func (e *extractor) extractTables() {
+	for _, loc := range beginTabularRe.FindAllStringSubmatchIndex(e.masked, -1) {
+		env := e.masked[loc[2]:loc[3]]
+		i := loc[1]
+
+		// Skip [pos], then the width (tabular*, tabularx) and column spec.
+		if _, n, ok := readGroup(e.masked, i, '[', ']'); ok {
+			i = n
+		}
+		args := 1
+		if env == "tabular*" || env == "tabularx" {
+			args = 2
+		}
+		for a := 0; a < args; a++ {
+			if _, n, ok := readGroup(e.masked, i, '{', '}'); ok {
+				i = n
+			}
+		}
+
+		endTag := `\end{` + env + `}`
+		end := strings.Index(e.masked[i:], endTag)
+		if end < 0 {
+			continue
+		}
+		body := e.masked[i : i+end]
+
+		var rows [][]string
+		for _, rowText := range splitRows(body) {
+			rowText = ruleRe.ReplaceAllString(rowText, "")
+			rowText = multicolumnRe.ReplaceAllString(rowText, "")
+			if strings.TrimSpace(rowText) == "" {
+				continue
+			}
+			var row []string
+			for _, cell := range splitUnescaped(rowText, '&') {
+				row = append(row, cleanText(cell))
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		e.tables = append(e.tables, &mq.Table{
+			Headers:  rows[0],
+			Rows:     rows[1:],
+			Position: e.index.Position(loc[0], i+end+len(endTag)),
+		})
+	}
+}
+
+// splitRows splits a tabular body on \\ row separators at brace depth 0.
+func splitRows(body string) []string {
+	var rows []string
+	depth, start := 0, 0
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '\\':
+			if i+1 < len(body) && body[i+1] == '\\' && depth == 0 {
+				rows = append(rows, body[start:i])
+				i++
+				// Skip an optional [2pt] spacing argument.
+				if _, n, ok := readGroup(body, i+1, '[', ']'); ok {
+					i = n - 1
+				}
+				start = i + 1
+			} else {
+				i++
+			}
+		}
+	}
+	return append(rows, body[start:])
+}
+
+// splitUnescaped splits on sep, ignoring \sep and separators inside braces.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var (
+	hrefRe     = regexp.MustCompile(`\\href\s*\{`)
+	urlRe      = regexp.MustCompile(`\\url\s*\{([^}]*)\}`)
+	citeRe     = regexp.MustCompile(`\\(?:cite|citep|citet|citealp|citeauthor|autocite|parencite|textcite|footcite)\*?`)
+	graphicsRe = regexp.MustCompile(`\\includegraphics\*?`)
+)
+
+// extractLinks collects \href, \url, \cite and \includegraphics in order.
+func (e *extractor) extractLinks() {
+	type positioned struct {
+		offset int
+		link   *mq.Link
+	}
+	var found []positioned
+
+	for _, loc := range hrefRe.FindAllStringIndex(e.masked, -1) {
+		url, n, ok := readGroup(e.masked, loc[1]-1, '{', '}')
+		if !ok {
+			continue
+		}
+		text, end, ok := readGroup(e.masked, n, '{', '}')
+		if !ok {
+			text, end = url, n
+		}
+		found = append(found, positioned{loc[0], &mq.Link{
+			Text:     cleanText(text),
+			URL:      strings.TrimSpace(url),
+			Position: e.index.Position(loc[0], end),
+		}})
+	}
+
+	for _, m := range urlRe.FindAllStringSubmatchIndex(e.masked, -1) {
+		url := strings.TrimSpace(e.masked[m[2]:m[3]])
+		found = append(found, positioned{m[0], &mq.Link{Text: url, URL: url, Position: e.index.Position(m[0], m[1])}})
+	}
+
+	for _, loc := range citeRe.FindAllStringIndex(e.masked, -1) {
+		i := loc[1]
+		// Up to two optional arguments: \cite[see][p. 4]{key}
+		for k := 0; k < 2; k++ {
+			if _, n, ok := readGroup(e.masked, i, '[', ']'); ok {
+				i = n
+			}
+		}
+		keys, end, ok := readGroup(e.masked, i, '{', '}')
+		if !ok {
+			continue
+		}
+		pos := e.index.Position(loc[0], end)
+		for _, key := range strings.Split(keys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				found = append(found, positioned{loc[0], &mq.Link{Text: key, URL: "cite:" + key, Position: pos}})
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].offset < found[j].offset })
+	for _, f := range found {
+		e.links = append(e.links, f.link)
+	}
+
+	for _, loc := range graphicsRe.FindAllStringIndex(e.masked, -1) {
+		i := loc[1]
+		if _, n, ok := readGroup(e.masked, i, '[', ']'); ok {
+			i = n
+		}
+		file, end, ok := readGroup(e.masked, i, '{', '}')
+		if !ok {
+			continue
+		}
+		e.images = append(e.images, &mq.Image{URL: strings.TrimSpace(file), Position: e.index.Position(loc[0], end)})
+	}
+}
+
+var (
+	titleRe    = regexp.MustCompile(`\\title\s*`)
+	authorRe   = regexp.MustCompile(`\\author\s*`)
+	dateRe     = regexp.MustCompile(`\\date\s*`)
+	classRe    = regexp.MustCompile(`\\documentclass\s*(\[[^\]]*\])?\s*\{([^}]*)\}`)
+	abstractRe = regexp.MustCompile(`(?s)\\begin\{abstract\}(.*?)\\end\{abstract\}`)
+	andRe      = regexp.MustCompile(`\\and\b`)
+)
+
+// extractMetadata reads front matter commands and the abstract.
+func (e *extractor) extractMetadata() {
+	meta := mq.Metadata{}
+
+	command := func(re *regexp.Regexp) string {
+		loc := re.FindStringIndex(e.masked)
+		if loc == nil {
+			return ""
+		}
+		i := loc[1]
+		if _, n, ok := readGroup(e.masked, i, '[', ']'); ok {
+			i = n
+		}
+		arg, _, ok := readGroup(e.masked, i, '{', '}')
+		if !ok {
+			return ""
+		}
+		return arg
+	}
+
+	if t := cleanText(command(titleRe)); t != "" {
+		meta["title"] = t
+	}
+	if a := command(authorRe); a != "" {
+		var authors []string
+		for _, name := range andRe.Split(a, -1) {
+			// Drop affiliations and notes: keep the first line of each author.
+			name = strings.SplitN(name, `\\`, 2)[0]
+			if name = cleanText(name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+		if len(authors) > 0 {
+			meta["author"] = strings.Join(authors, ", ")
+		}
+	}
+	if d := cleanText(command(dateRe)); d != "" {
+		meta["date"] = d
+	}
+	if m := classRe.FindStringSubmatch(e.masked); m != nil {
+		meta["documentclass"] = strings.TrimSpace(m[2])
+	}
+	if m := abstractRe.FindStringSubmatch(e.masked); m != nil {
+		if a := cleanText(m[1]); a != "" {
+			meta["abstract"] = a
+		}
+	}
+
+	e.metadata = meta
+}
+
+var (
+	dropCmdRe   = regexp.MustCompile(`[\s~]*\\(label|ref|eqref|pageref|cite\w*|footnote|index|vspace|hspace|thanks)\*?\s*(\[[^\]]*\])?\s*\{[^{}]*\}`)
+	unwrapCmdRe = regexp.MustCompile(`\\[a-zA-Z]+\*?\s*(\[[^\]]*\])?\s*\{([^{}]*)\}`)
+	bareCmdRe   = regexp.MustCompile(`\\[a-zA-Z]+\*?`)
+	escapeRe    = regexp.MustCompile(`\\([&%$#_{}])`)
+)
+
+// cleanText reduces a LaTeX fragment to plain text: formatting commands
+// are unwrapped, references dropped, and whitespace collapsed.
+func cleanText(s string) string {
+	s = dropCmdRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, `\\`, " ")
+	s = strings.ReplaceAll(s, "~", " ")
+	s = strings.ReplaceAll(s, "``", "\"")
+	s = strings.ReplaceAll(s, "''", "\"")
+	s = strings.ReplaceAll(s, "---", "—")
+	s = strings.ReplaceAll(s, "--", "–")
+
+	// Unwrap innermost commands repeatedly: \textbf{\emph{x}} -> x
+	for i := 0; i < 8; i++ {
+		next := unwrapCmdRe.ReplaceAllString(s, "$2")
+		if next == s {
+			break
+		}
+		s = next
+	}
+	s = escapeRe.ReplaceAllString(s, "\x00$1")
+	s = bareCmdRe.ReplaceAllString(s, "")
+	s = strings.NewReplacer("{", "", "}", "", "$", "").Replace(s)
+	s = strings.ReplaceAll(s, "\x00", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// readGroup reads a delimited group starting at s[i] (after optional
+// whitespace) and returns its content and the offset just past it.
+func readGroup(s string, i int, open, close byte) (string, int, bool) {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	if i >= len(s) || s[i] != open {
+		return "", i, false
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return s[i+1 : j], j + 1, true
+			}
+		}
+	}
+	return "", i, false
+}
+
+func (e *extractor) lineAt(offset int) int {
+	return sort.Search(len(e.lineStarts), func(i int) bool {
+		return e.lineStarts[i] > offset
+	})
+}
+
+func (e *extractor) lineText(line int) string {
+	if line < 1 || line > len(e.lineStarts) {
+		return ""
+	}
+	start := e.lineStarts[line-1]
+	end := len(e.source)
+	if line < len(e.lineStarts) {
+		end = e.lineStarts[line] - 1
+	}
+	return e.source[start:end]
+}
+
+// computeLineStarts returns byte offsets where each line starts.
+func computeLineStarts(source string) []int {
+	starts := []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseLaTeX is a convenience function for quick parsing.
+func ParseLaTeX(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseLaTeXFile is a convenience function for quick file parsing.
+func ParseLaTeXFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,170 @@
+package latex_test
+
+import (
+	"testing"
+
+	"github.com/muqsitnawaz/mq/latex"
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserFormat(t *testing.T) {
+	p := latex.NewParser()
+	assert.Equal(t, mq.FormatLaTeX, p.Format())
+}
+
+func TestParseHeadingsAndSections(t *testing.T) {
+	doc, err := latex.ParseLaTeXFile("testdata/thesis.tex")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatLaTeX, doc.Format())
+	assert.Equal(t, "Consensus in Practice", doc.Title())
+
+	// \chapter is the outermost command used, so it becomes H1;
+	// the included chapter sits between Introduction and Conclusion.
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 3)
+	assert.Equal(t, "Introduction", toc[0].Heading.Text)
+	assert.Equal(t, "ch:intro", toc[0].Heading.ID)
+	assert.Equal(t, "Method", toc[1].Heading.Text)
+	assert.Equal(t, "Conclusion", toc[2].Heading.Text)
+	assert.Equal(t, 1, toc[2].Heading.Level)
+
+	require.Len(t, toc[0].Children, 1)
+	motivation := toc[0].Children[0]
+	assert.Equal(t, "Motivation", motivation.Heading.Text)
+	assert.Equal(t, 2, motivation.Heading.Level)
+	assert.Contains(t, motivation.GetText(), "Prior work")
+	assert.NotContains(t, motivation.GetText(), "Setup")
+
+	// Commented-out commands are ignored
+	_, ok := doc.GetSection("Commented Out")
+	assert.False(t, ok)
+
+	// The conclusion ends before \end{document}
+	assert.NotContains(t, toc[2].GetText(), `\end{document}`)
+}
+
+func TestParseCodeTablesLinks(t *testing.T) {
+	doc, err := latex.ParseLaTeXFile("testdata/thesis.tex")
+	require.NoError(t, err)
+
+	blocks := doc.GetCodeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "go", blocks[0].Language)
+	assert.Contains(t, blocks[0].Content, "// 100% reliable % not a comment")
+
+	setup, ok := doc.GetSection("Setup")
+	require.True(t, ok)
+	assert.Len(t, setup.GetCodeBlocks(), 1)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"Nodes", "Latency (ms)"}, tables[0].Headers)
+	assert.Equal(t, [][]string{{"3", "12"}, {"5", "18"}}, tables[0].Rows)
+
+	var urls []string
+	for _, l := range doc.GetLinks() {
+		urls = append(urls, l.URL)
+	}
+	assert.Equal(t, []string{
+		"cite:ongaro2014",
+		"https://raft.github.io",
+		"cite:lamport1998",
+		"cite:ongaro2014",
+		"https://example.com/results",
+	}, urls)
+	assert.Equal(t, "the Raft site", doc.GetLinks()[1].Text)
+
+	images := doc.GetImages()
+	require.Len(t, images, 1)
+	assert.Equal(t, "figures/cluster.png", images[0].URL)
+}
+
+func TestParseMetadata(t *testing.T) {
+	doc, err := latex.ParseLaTeXFile("testdata/thesis.tex")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "Consensus in Practice", meta["title"])
+	assert.Equal(t, "Ada Byron, Alan Church", meta["author"])
+	assert.Equal(t, "2024", meta["date"])
+	assert.Equal(t, "report", meta["documentclass"])
+	assert.Equal(t, "We study Raft under partitions.", meta["abstract"])
+}
+
+func TestParseIncludeLines(t *testing.T) {
+	doc, err := latex.ParseLaTeXFile("testdata/thesis.tex")
+	require.NoError(t, err)
+
+	// Lines refer to the file each element was written in
+	conclusion, ok := doc.GetSection("Conclusion")
+	require.True(t, ok)
+	assert.Equal(t, 26, conclusion.Heading.Line)
+	assert.Equal(t, 26, conclusion.Start)
+	assert.Equal(t, 27, conclusion.End)
+	assert.Nil(t, conclusion.Metadata)
+	assert.Contains(t, conclusion.GetText(), `\chapter*{Conclusion}`)
+
+	links := doc.GetLinks()
+	assert.Equal(t, 27, links[len(links)-1].Line)
+
+	// Included sections name their file and read their text from it
+	method, ok := doc.GetSection("Method")
+	require.True(t, ok)
+	assert.Equal(t, "testdata/chapters/method.tex", method.Metadata["file"])
+	assert.Equal(t, 1, method.Start)
+	assert.Equal(t, 23, method.End)
+
+	setup, ok := doc.GetSection("Setup")
+	require.True(t, ok)
+	assert.Equal(t, 3, setup.Heading.Line)
+	assert.Equal(t, "testdata/chapters/method.tex", setup.Heading.File)
+	assert.Equal(t, `\section{Setup}`, setup.GetHeadingLine())
+	assert.Empty(t, method.GetBody(), "ends before its first child")
+	assert.Empty(t, conclusion.Heading.File)
+	assert.Equal(t, "testdata/chapters/method.tex", doc.GetCodeBlocks()[0].File)
+	assert.Equal(t, 4, doc.GetCodeBlocks()[0].Line)
+	assert.Equal(t, 9, doc.GetCodeBlocks()[0].EndLine)
+	assert.Equal(t, 12, doc.GetTables()[0].Line)
+
+	assert.Contains(t, doc.BuildTree(mq.TreeModeDefault).String(), "# Conclusion (26-27)")
+
+	// Search reports matches in included sections against their file
+	results := doc.Search("latency")
+	require.NotEmpty(t, results.Matches)
+	for _, match := range results.Matches {
+		assert.Equal(t, "testdata/chapters/method.tex", match.File, match.Section)
+		assert.Equal(t, 14, match.Line, match.Section)
+	}
+	assert.Equal(t, "1-23", results.Matches[0].Lines)
+}
+
+func TestParseWithoutIncludes(t *testing.T) {
+	doc, err := latex.NewParser(latex.WithIncludes(false)).ParseFile("testdata/thesis.tex")
+	require.NoError(t, err)
+
+	_, ok := doc.GetSection("Method")
+	assert.False(t, ok)
+	assert.Empty(t, doc.GetCodeBlocks())
+}
+
+func TestParseArticleLevels(t *testing.T) {
+	src := `\documentclass{article}
+\begin{document}
+\section{Intro}
+\subsection[Short]{A \textbf{bold} start}
+\paragraph{Aside}
+\end{document}
+`
+	doc, err := latex.ParseLaTeX([]byte(src), "paper.tex")
+	require.NoError(t, err)
+
+	h1s := doc.GetHeadings(1)
+	require.Len(t, h1s, 1)
+	assert.Equal(t, "Intro", h1s[0].Text)
+	assert.Equal(t, "A bold start", doc.GetHeadings(2)[0].Text)
+	assert.Equal(t, "Aside", doc.GetHeadings(4)[0].Text)
+	assert.Equal(t, "Intro", doc.Title(), "title falls back to the first heading")
+}
@@ -0,0 +1,448 @@
+package mq
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/muqsitnawaz/mq/internal/zippart"
+)
+
+// Compressed files and archives.
+//
+// Single compressed files (notes.md.gz, api.json.zst) are decompressed
+// transparently by MultiFormatEngine and detected by their inner extension.
+//
+// Archives (.zip, .tar, .tar.gz, ...) are virtual directories: directory
+// tree and search walk into them, and a path inside an archive such as
+// docs.zip/guide/intro.md can be loaded like a regular file. Nothing is
+// extracted to disk. Archives inside archives are left out of listings.
+//
+// Decompressed files and archive members are capped at maxMemberSize,
+// and a tar archive, which is held in memory, at maxArchiveSize in total.
+
+const (
+	maxMemberSize  = zippart.MaxSize
+	maxArchiveSize = 256 << 20
+)
+
+// compressionExtensions are single-file compression suffixes.
+var compressionExtensions = map[string]struct{}{
+	".gz":  {},
+	".zst": {},
+	".bz2": {},
+}
+
+// archiveSuffixes are the file name suffixes treated as archives.
+var archiveSuffixes = []string{
+	".zip",
+	".tar",
+	".tar.gz", ".tgz",
+	".tar.zst", ".tzst",
+	".tar.bz2", ".tbz2", ".tbz",
+}
+
+// IsArchive reports whether path names an archive that can be browsed
+// as a directory.
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(lower) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCompression removes a single-file compression suffix:
+// "notes.md.gz" becomes "notes.md". Archives are left unchanged.
+func stripCompression(path string) string {
+	if IsArchive(path) {
+		return path
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := compressionExtensions[ext]; ok {
+		return path[:len(path)-len(ext)]
+	}
+	return path
+}
+
+// Magic numbers of supported compression formats.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+// isCompressed reports whether content starts with a supported
+// compression magic number.
+func isCompressed(content []byte) bool {
+	return bytes.HasPrefix(content, gzipMagic) ||
+		bytes.HasPrefix(content, zstdMagic) ||
+		bytes.HasPrefix(content, bzip2Magic)
+}
+
+// decompress inflates gzip, zstd or bzip2 content. Other content is
+// returned unchanged.
+func decompress(content []byte) ([]byte, error) {
+	r, err := decompressReader(bytes.NewReader(content), content)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return content, nil
+	}
+	return zippart.ReadLimited(r, maxMemberSize, "decompressed output")
+}
+
+// decompressReader wraps r in a decompressor chosen by the magic number
+// at the start of head; it returns nil when head is not compressed.
+func decompressReader(r io.Reader, head []byte) (io.Reader, error) {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(head, zstdMagic):
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case bytes.HasPrefix(head, bzip2Magic):
+		return bzip2.NewReader(r), nil
+	}
+	return nil, nil
+}
+
+// openArchive reads an archive into a read-only file system.
+func openArchive(path string) (fs.FS, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) || bytes.HasPrefix(content, []byte("PK\x05\x06")) {
+		return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	}
+
+	var r io.Reader = bytes.NewReader(content)
+	if dr, err := decompressReader(r, content); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	} else if dr != nil {
+		r = dr
+	}
+	return readTar(r, maxMemberSize, maxArchiveSize)
+}
+
+// readTar loads the regular files of a tar stream into memory, failing
+// when a member inflates past memberLimit or all of them past totalLimit.
+func readTar(r io.Reader, memberLimit, totalLimit int64) (fs.FS, error) {
+	m := newMemFS()
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := zippart.ReadLimited(tr, memberLimit, header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if total += int64(len(data)); total > totalLimit {
+			return nil, fmt.Errorf("archive larger than %d bytes", totalLimit)
+		}
+		m.add(header.Name, data, header.ModTime)
+	}
+	if len(m.files) == 0 {
+		return nil, errors.New("empty or unrecognized archive")
+	}
+	return m, nil
+}
+
+// archiveCache keeps the most recently opened archive, since directory
+// traversal loads its members one at a time.
+var archiveCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	fsys    fs.FS
+}
+
+// cachedArchive opens an archive, reusing the last one when unchanged.
+func cachedArchive(path string) (fs.FS, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveCache.Lock()
+	defer archiveCache.Unlock()
+
+	if archiveCache.path == path && archiveCache.modTime.Equal(info.ModTime()) {
+		return archiveCache.fsys, nil
+	}
+	fsys, err := openArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	archiveCache.path, archiveCache.modTime, archiveCache.fsys = path, info.ModTime(), fsys
+	return fsys, nil
+}
+
+// splitArchivePath splits "docs.zip/guide/intro.md" into the archive
+// path and the member name. ok is false when no leading component of
+// path is an archive file.
+func splitArchivePath(p string) (archive, member string, ok bool) {
+	clean := filepath.Clean(p)
+	for i := len(clean) - 1; i > 0; i-- {
+		if clean[i] != filepath.Separator {
+			continue
+		}
+		prefix := clean[:i]
+		if !IsArchive(prefix) {
+			continue
+		}
+		if info, err := os.Stat(prefix); err == nil && !info.IsDir() {
+			return prefix, filepath.ToSlash(clean[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// readFile reads a file from disk or from inside an archive.
+func readFile(p string) ([]byte, error) {
+	content, err := os.ReadFile(p)
+	if err == nil {
+		return content, nil
+	}
+	archive, member, ok := splitArchivePath(p)
+	if !ok {
+		return nil, err
+	}
+	fsys, aerr := cachedArchive(archive)
+	if aerr != nil {
+		return nil, aerr
+	}
+	f, err := fsys.Open(member)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return zippart.ReadLimited(f, maxMemberSize, member)
+}
+
+// statPath describes a path on disk or inside an archive. Archives
+// themselves report as directories.
+func statPath(p string) (name string, isDir bool, err error) {
+	info, err := os.Stat(p)
+	if err == nil {
+		return info.Name(), info.IsDir() || IsArchive(p), nil
+	}
+	archive, member, ok := splitArchivePath(p)
+	if !ok {
+		return "", false, err
+	}
+	fsys, err := cachedArchive(archive)
+	if err != nil {
+		return "", false, err
+	}
+	info, err = fs.Stat(fsys, member)
+	if err != nil {
+		return "", false, err
+	}
+	return info.Name(), info.IsDir(), nil
+}
+
+// readDirPath lists a directory on disk, an archive's root, or a
+// directory inside an archive.
+func readDirPath(p string) ([]fs.DirEntry, error) {
+	if info, err := os.Stat(p); err == nil {
+		if !info.IsDir() && IsArchive(p) {
+			fsys, err := cachedArchive(p)
+			if err != nil {
+				return nil, err
+			}
+			return readArchiveDir(fsys, ".")
+		}
+		return os.ReadDir(p)
+	}
+	archive, member, ok := splitArchivePath(p)
+	if !ok {
+		return os.ReadDir(p)
+	}
+	fsys, err := cachedArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	return readArchiveDir(fsys, member)
+}
+
+// readArchiveDir lists a directory inside an archive, leaving out nested
+// archives, which can't be browsed.
+func readArchiveDir(fsys fs.FS, dir string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.IsDir() || !IsArchive(entry.Name()) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// IsDirectory reports whether path can be browsed as a directory: a
+// directory on disk, an archive, or a directory inside an archive.
+func IsDirectory(path string) bool {
+	_, isDir, err := statPath(path)
+	return err == nil && isDir
+}
+
+// isDirEntry reports whether a listed entry should be walked into.
+func isDirEntry(entry fs.DirEntry) bool {
+	return entry.IsDir() || IsArchive(entry.Name())
+}
+
+// memFS is a read-only in-memory file system for tar archives.
+type memFS struct {
+	files map[string]*memFile
+	dirs  map[string][]string // dir -> sorted child names
+}
+
+type memFile struct {
+	name    string
+	data    []byte
+	modTime time.Time
+	isDir   bool
+}
+
+func newMemFS() *memFS {
+	return &memFS{
+		files: make(map[string]*memFile),
+		dirs:  map[string][]string{".": nil},
+	}
+}
+
+// add stores a file and registers its parent directories.
+func (m *memFS) add(name string, data []byte, modTime time.Time) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || strings.HasPrefix(name, "../") {
+		return
+	}
+	m.files[name] = &memFile{name: path.Base(name), data: data, modTime: modTime}
+
+	for child := name; child != "."; child = path.Dir(child) {
+		dir := path.Dir(child)
+		m.dirs[dir] = insertSorted(m.dirs[dir], path.Base(child))
+		if _, ok := m.files[dir]; !ok && dir != "." {
+			m.files[dir] = &memFile{name: path.Base(dir), modTime: modTime, isDir: true}
+		}
+	}
+}
+
+// insertSorted adds s to a sorted slice unless already present.
+func insertSorted(list []string, s string) []string {
+	i := sort.SearchStrings(list, s)
+	if i < len(list) && list[i] == s {
+		return list
+	}
+	list = append(list, "")
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	return list
+}
+
+// Open implements fs.FS.
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if children, ok := m.dirs[name]; ok {
+		f := m.files[name]
+		if f == nil {
+			f = &memFile{name: ".", isDir: true}
+		}
+		return &memDir{file: f, fsys: m, path: name, children: children}, nil
+	}
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memReader{file: f, Reader: bytes.NewReader(f.data)}, nil
+}
+
+// memFile implements fs.FileInfo and fs.DirEntry.
+func (f *memFile) Name() string { return f.name }
+func (f *memFile) Size() int64  { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode {
+	if f.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+func (f *memFile) ModTime() time.Time         { return f.modTime }
+func (f *memFile) IsDir() bool                { return f.isDir }
+func (f *memFile) Sys() any                   { return nil }
+func (f *memFile) Type() fs.FileMode          { return f.Mode().Type() }
+func (f *memFile) Info() (fs.FileInfo, error) { return f, nil }
+
+type memReader struct {
+	file *memFile
+	*bytes.Reader
+}
+
+func (r *memReader) Stat() (fs.FileInfo, error) { return r.file, nil }
+func (r *memReader) Close() error               { return nil }
+
+type memDir struct {
+	file     *memFile
+	fsys     *memFS
+	path     string
+	children []string
+	offset   int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.file, nil }
+func (d *memDir) Close() error               { return nil }
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.children[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(remaining) {
+		remaining = remaining[:n]
+	}
+	entries := make([]fs.DirEntry, len(remaining))
+	for i, name := range remaining {
+		entries[i] = d.fsys.files[path.Join(d.path, name)]
+	}
+	d.offset += len(remaining)
+	return entries, nil
+}
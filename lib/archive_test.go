@@ -0,0 +1,43 @@
+package mq
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func tarOf(t *testing.T, files ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i, content := range files {
+		name := "f" + strings.Repeat("x", i) + ".md"
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestReadTarLimits(t *testing.T) {
+	if _, err := readTar(tarOf(t, "# A\n", "# B\n"), 8, 16); err != nil {
+		t.Fatalf("within limits: %v", err)
+	}
+
+	_, err := readTar(tarOf(t, strings.Repeat("a", 9)), 8, 16)
+	if err == nil || !strings.Contains(err.Error(), "f.md: larger than 8 bytes") {
+		t.Errorf("member over limit: got %v", err)
+	}
+
+	_, err = readTar(tarOf(t, "1234567", "1234567", "1234567"), 8, 16)
+	if err == nil || !strings.Contains(err.Error(), "archive larger than 16 bytes") {
+		t.Errorf("total over limit: got %v", err)
+	}
+}
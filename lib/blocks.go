@@ -0,0 +1,354 @@
+package mq
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// blockExtension adds the block syntax goldmark does not know: "$$" math
+// blocks and MkDocs admonitions. GitHub alerts are ordinary blockquotes
+// and are recognized while indexing.
+type blockExtension struct{}
+
+func (blockExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(mathBlockParser{}, 90),
+		util.Prioritized(admonitionParser{}, 90),
+	))
+}
+
+// mathNode is a "$$" block. Its lines hold the TeX source.
+type mathNode struct {
+	ast.BaseBlock
+	start, stop int // Byte range including the delimiters
+	closed      bool
+}
+
+var kindMath = ast.NewNodeKind("Math")
+
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathNode) IsRaw() bool { return true }
+
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	node := &mathNode{start: segment.Start + pos, stop: segment.Stop}
+
+	// Content may start on the opening line, and "$$x$$" is a whole block
+	rest := segment.Start + pos + 2
+	body := bytes.TrimRight(line[pos+2:], " \t\r\n")
+	if bytes.HasSuffix(body, []byte("$$")) {
+		node.closed = true
+		body = body[:len(body)-2]
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		node.Lines().Append(text.NewSegment(rest, rest+len(body)))
+	}
+	reader.AdvanceToEOL()
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	math := node.(*mathNode)
+	if math.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	math.stop = segment.Stop
+
+	body := bytes.TrimRight(line, " \t\r\n")
+	if bytes.HasSuffix(body, []byte("$$")) {
+		math.closed = true
+		body = body[:len(body)-2]
+		if len(bytes.TrimSpace(body)) > 0 {
+			math.Lines().Append(text.NewSegment(segment.Start, segment.Start+len(body)))
+		}
+		reader.AdvanceToEOL()
+		return parser.Close
+	}
+	math.Lines().Append(segment)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// admonitionNode is an MkDocs admonition. Its children are the indented
+// content blocks.
+type admonitionNode struct {
+	ast.BaseBlock
+	kind, title string
+	start       int // Offset of the "!!!" marker
+}
+
+var kindAdmonition = ast.NewNodeKind("Admonition")
+
+func (n *admonitionNode) Kind() ast.NodeKind { return kindAdmonition }
+
+func (n *admonitionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Kind": n.kind}, nil)
+}
+
+// admonitionRe matches "!!! note", "??? tip \"Title\"" and "???+ warning".
+var admonitionRe = regexp.MustCompile(`^(?:!!!|\?\?\?\+?)\s+([\w-]+)(?:\s+"(.*)")?\s*$`)
+
+type admonitionParser struct{}
+
+func (admonitionParser) Trigger() []byte { return []byte{'!', '?'} }
+
+func (admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	m := admonitionRe.FindSubmatch(bytes.TrimRight(line[pos:], "\r\n"))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	node := &admonitionNode{
+		kind:  strings.ToLower(string(m[1])),
+		title: string(m[2]),
+		start: segment.Start + pos,
+	}
+	reader.AdvanceToEOL()
+	return node, parser.HasChildren
+}
+
+func (admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, _ := reader.PeekLine()
+	if util.IsBlank(line) {
+		reader.AdvanceToEOL()
+		return parser.Continue | parser.HasChildren
+	}
+	pos, padding := util.IndentPosition(line, reader.LineOffset(), 4)
+	if pos < 0 {
+		return parser.Close
+	}
+	reader.AdvanceAndSetPadding(pos, padding)
+	return parser.Continue | parser.HasChildren
+}
+
+func (admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (admonitionParser) CanInterruptParagraph() bool { return false }
+
+func (admonitionParser) CanAcceptIndentedLine() bool { return false }
+
+// alertRe matches the first line of a GitHub alert: "> [!WARNING]".
+var alertRe = regexp.MustCompile(`^\[!(\w+)\]\s*$`)
+
+// extractAlert returns the admonition for a GitHub alert blockquote.
+func extractAlert(node *ast.Blockquote, source []byte, idx *LineIndex) (*Admonition, bool) {
+	para, ok := node.FirstChild().(*ast.Paragraph)
+	if !ok || para.Lines().Len() == 0 {
+		return nil, false
+	}
+	first := para.Lines().At(0)
+	m := alertRe.FindSubmatch(bytes.TrimSpace(first.Value(source)))
+	if m == nil {
+		return nil, false
+	}
+
+	// Everything after the marker line
+	content := blockText(node, source)
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		content = strings.TrimSpace(content[i+1:])
+	} else {
+		content = ""
+	}
+	return &Admonition{
+		Kind:     strings.ToLower(string(m[1])),
+		Content:  content,
+		Node:     node,
+		Position: blockPosition(node, idx),
+	}, true
+}
+
+func extractAdmonition(node *admonitionNode, source []byte, idx *LineIndex) *Admonition {
+	first := idx.Line(node.start)
+	last := first
+	if _, stop, ok := nodeSpan(node); ok {
+		last = idx.Line(max(stop-1, node.start))
+	}
+	return &Admonition{
+		Kind:     node.kind,
+		Title:    node.title,
+		Content:  blockText(node, source),
+		Node:     node,
+		Position: idx.LinePosition(first, last),
+	}
+}
+
+func extractMath(node *mathNode, source []byte, idx *LineIndex) *MathBlock {
+	return &MathBlock{
+		Content:  strings.TrimSpace(blockText(node, source)),
+		Node:     node,
+		Position: idx.LinePosition(idx.Line(node.start), idx.Line(max(node.stop-1, node.start))),
+	}
+}
+
+func extractFootnote(node *east.Footnote, source []byte, idx *LineIndex) *Footnote {
+	fn := &Footnote{
+		Label:   string(node.Ref),
+		Content: blockText(node, source),
+		Node:    node,
+	}
+	if start, stop, ok := nodeSpan(node); ok {
+		fn.Position = idx.LinePosition(idx.Line(start), idx.Line(max(stop-1, start)))
+	}
+	return fn
+}
+
+// extractDefinitions returns the terms of a definition list, each with
+// the descriptions that follow it.
+func extractDefinitions(node *east.DefinitionList, source []byte, idx *LineIndex) []*Definition {
+	var defs []*Definition
+	var current *Definition
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch c := child.(type) {
+		case *east.DefinitionTerm:
+			current = &Definition{
+				Term:     strings.TrimSpace(blockText(c, source)),
+				Node:     c,
+				Position: blockPosition(c, idx),
+			}
+			defs = append(defs, current)
+		case *east.DefinitionDescription:
+			if current == nil {
+				continue
+			}
+			current.Descriptions = append(current.Descriptions, blockText(c, source))
+			if _, stop, ok := nodeSpan(c); ok {
+				current.Position = idx.LinePosition(current.Line, idx.Line(max(stop-1, 0)))
+			}
+		}
+	}
+	return defs
+}
+
+// blockText returns the source lines of the leaf blocks under n, one
+// blank line between blocks.
+func blockText(n ast.Node, source []byte) string {
+	var blocks []string
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || c.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+		lines := c.Lines()
+		if lines.Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+		var buf strings.Builder
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			buf.Write(seg.Value(source))
+		}
+		blocks = append(blocks, strings.TrimRight(buf.String(), " \t\r\n"))
+		return ast.WalkSkipChildren, nil
+	})
+	return strings.TrimSpace(strings.Join(blocks, "\n\n"))
+}
+
+// attachFootnotes records footnotes on the sections they are defined in.
+// Goldmark moves footnote definitions to the end of the document, so
+// they are placed by line rather than during the walk.
+func attachFootnotes(notes []*Footnote, sections []*Section) {
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Line < notes[j].Line })
+	for _, fn := range notes {
+		if s := sectionAt(sections, fn.Line); s != nil {
+			s.footnotes = append(s.footnotes, fn)
+		}
+	}
+}
+
+// GetFootnotes returns footnote definitions in document order.
+// Definitions that are never referenced are dropped by the parser.
+func (d *Document) GetFootnotes() []*Footnote {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.footnotes
+}
+
+// GetDefinitions returns definition list terms, optionally only the given
+// terms (case-insensitive).
+func (d *Document) GetDefinitions(terms ...string) []*Definition {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return filterDefinitions(d.definitions, terms)
+}
+
+// GetAdmonitions returns admonitions and GitHub alerts, optionally only
+// the given kinds (case-insensitive).
+func (d *Document) GetAdmonitions(kinds ...string) []*Admonition {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return filterAdmonitions(d.admonitions, kinds)
+}
+
+// GetMath returns display math blocks.
+func (d *Document) GetMath() []*MathBlock {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.math
+}
+
+func filterDefinitions(defs []*Definition, terms []string) []*Definition {
+	if len(terms) == 0 {
+		return defs
+	}
+	var result []*Definition
+	for _, def := range defs {
+		for _, term := range terms {
+			if strings.EqualFold(def.Term, term) {
+				result = append(result, def)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func filterAdmonitions(admonitions []*Admonition, kinds []string) []*Admonition {
+	if len(kinds) == 0 {
+		return admonitions
+	}
+	var result []*Admonition
+	for _, a := range admonitions {
+		for _, kind := range kinds {
+			if strings.EqualFold(a.Kind, kind) {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
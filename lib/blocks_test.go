@@ -0,0 +1,106 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const blocksMarkdown = `# Guide
+
+Caching is on by default.[^cache]
+
+> [!WARNING]
+> Clearing the cache
+> logs everyone out.
+
+## Glossary
+
+TTL
+:   Time to live, in seconds.
+
+Origin
+:   The upstream server.
+:   Also called the backend.
+
+## Math
+
+$$
+E = mc^2
+$$
+
+$$a + b$$
+
+!!! note "Heads up"
+    Admonition body.
+
+    Second paragraph.
+
+Back to normal text.
+
+[^cache]: See the caching guide.
+`
+
+func TestStructuredBlocks(t *testing.T) {
+	doc, err := mq.New().ParseDocument([]byte(blocksMarkdown), "guide.md")
+	require.NoError(t, err)
+
+	notes := doc.GetFootnotes()
+	require.Len(t, notes, 1)
+	assert.Equal(t, "cache", notes[0].Label)
+	assert.Equal(t, "See the caching guide.", notes[0].Content)
+	assert.Equal(t, 33, notes[0].Line)
+
+	defs := doc.GetDefinitions()
+	require.Len(t, defs, 2)
+	assert.Equal(t, "TTL", defs[0].Term)
+	assert.Equal(t, []string{"Time to live, in seconds."}, defs[0].Descriptions)
+	assert.Equal(t, "11-12", defs[0].Range())
+	assert.Equal(t, []string{"The upstream server.", "Also called the backend."}, defs[1].Descriptions)
+
+	origin := doc.GetDefinitions("origin")
+	require.Len(t, origin, 1)
+	assert.Equal(t, "Origin", origin[0].Term)
+
+	admonitions := doc.GetAdmonitions()
+	require.Len(t, admonitions, 2)
+	assert.Equal(t, "warning", admonitions[0].Kind)
+	assert.Equal(t, "Clearing the cache\nlogs everyone out.", admonitions[0].Content)
+	assert.Equal(t, mq.Position{Line: 5, Column: 1, EndLine: 7, EndColumn: 20}, admonitions[0].Position)
+	assert.Equal(t, "note", admonitions[1].Kind)
+	assert.Equal(t, "Heads up", admonitions[1].Title)
+	assert.Equal(t, "Admonition body.\n\nSecond paragraph.", admonitions[1].Content)
+	assert.Equal(t, "26-29", admonitions[1].Range())
+	assert.Len(t, doc.GetAdmonitions("WARNING"), 1)
+
+	math := doc.GetMath()
+	require.Len(t, math, 2)
+	assert.Equal(t, "E = mc^2", math[0].Content)
+	assert.Equal(t, "20-22", math[0].Range())
+	assert.Equal(t, "a + b", math[1].Content)
+	assert.Equal(t, "24", math[1].Range())
+
+	// Elements belong to the section they appear in
+	guide, ok := doc.GetSection("Guide")
+	require.True(t, ok)
+	assert.Len(t, guide.GetAdmonitions(), 2)
+	glossary, _ := doc.GetSection("Glossary")
+	assert.Len(t, glossary.GetDefinitions(), 2)
+	assert.Empty(t, glossary.GetMath())
+	section, _ := doc.GetSection("Math")
+	assert.Len(t, section.GetMath(), 2)
+	assert.Len(t, section.GetFootnotes(), 1)
+}
+
+func TestStructuredBlocksInTree(t *testing.T) {
+	doc, err := mq.New().ParseDocument([]byte(blocksMarkdown), "guide.md")
+	require.NoError(t, err)
+
+	tree := doc.BuildTree(mq.TreeModeDefault).String()
+	assert.Contains(t, tree, "[admonition: warning, 1 block at line 5]")
+	assert.Contains(t, tree, "[definitions: 2 terms at lines 11, 14]")
+	assert.Contains(t, tree, "[math: 2 blocks at lines 20, 24]")
+	assert.Contains(t, tree, "[footnotes: 1 note at line 33]")
+}
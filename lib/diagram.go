@@ -0,0 +1,799 @@
+package mq
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Diagram languages
+const (
+	DiagramMermaid  = "mermaid"
+	DiagramPlantUML = "plantuml"
+	DiagramDot      = "dot"
+)
+
+// diagramLanguage returns the diagram language of a code block's info
+// string, or "" for other code.
+func diagramLanguage(lang string) string {
+	switch strings.ToLower(lang) {
+	case "mermaid", "mmd":
+		return DiagramMermaid
+	case "plantuml", "puml", "uml":
+		return DiagramPlantUML
+	case "dot", "graphviz", "gv":
+		return DiagramDot
+	}
+	return ""
+}
+
+// extractDiagram parses a diagram code block. Nodes and edges report the
+// document line they appear on.
+func extractDiagram(cb *CodeBlock, idx *LineIndex) (*Diagram, bool) {
+	lang := diagramLanguage(cb.Language)
+	if lang == "" {
+		return nil, false
+	}
+
+	first := cb.Line
+	if _, fenced := cb.Node.(*ast.FencedCodeBlock); fenced {
+		first++
+	}
+	src := diagramSource{
+		lines: strings.Split(strings.TrimRight(cb.Content, "\n"), "\n"),
+		first: first,
+		idx:   idx,
+	}
+	g := newGraphBuilder(&Diagram{
+		Language: lang,
+		Code:     cb,
+		Position: cb.Position,
+	})
+
+	switch lang {
+	case DiagramMermaid:
+		parseMermaid(src, g)
+	case DiagramPlantUML:
+		parsePlantUML(src, g)
+	case DiagramDot:
+		parseDot(src, g)
+	}
+	return g.d, true
+}
+
+// diagramSource is the content of a diagram block with the document line
+// of its first line.
+type diagramSource struct {
+	lines []string
+	first int
+	idx   *LineIndex
+}
+
+// pos returns the position of content line i (0-based).
+func (s diagramSource) pos(i int) Position {
+	if s.idx == nil || s.first == 0 {
+		return Position{}
+	}
+	return s.idx.LinePosition(s.first+i, s.first+i)
+}
+
+// graphBuilder collects nodes by ID in order of first appearance.
+type graphBuilder struct {
+	d     *Diagram
+	nodes map[string]*DiagramNode
+}
+
+func newGraphBuilder(d *Diagram) *graphBuilder {
+	return &graphBuilder{d: d, nodes: make(map[string]*DiagramNode)}
+}
+
+// node adds a node, or fills in the label and kind of a known one.
+func (g *graphBuilder) node(id, label, kind string, pos Position) {
+	if id == "" {
+		return
+	}
+	if n, ok := g.nodes[id]; ok {
+		if label != "" && n.Label == n.ID {
+			n.Label = label
+		}
+		if n.Kind == "" {
+			n.Kind = kind
+		}
+		return
+	}
+	if label == "" {
+		label = id
+	}
+	n := &DiagramNode{ID: id, Label: label, Kind: kind, Position: pos}
+	g.nodes[id] = n
+	g.d.Nodes = append(g.d.Nodes, n)
+}
+
+func (g *graphBuilder) edge(from, to, arrow, label string, pos Position) {
+	g.node(from, "", "", pos)
+	g.node(to, "", "", pos)
+	g.d.Edges = append(g.d.Edges, &DiagramEdge{
+		From:     from,
+		To:       to,
+		Label:    label,
+		Arrow:    arrow,
+		Position: pos,
+	})
+}
+
+// unquote strips one pair of surrounding double quotes.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Mermaid
+
+// parseMermaid reads flowcharts, sequence, class and state diagrams.
+// Other diagram types only report their kind.
+func parseMermaid(src diagramSource, g *graphBuilder) {
+	i := 0
+	skipBlank := func() {
+		for i < len(src.lines) {
+			line := strings.TrimSpace(src.lines[i])
+			if line != "" && !strings.HasPrefix(line, "%%") {
+				return
+			}
+			i++
+		}
+	}
+
+	// Optional frontmatter ("---\ntitle: ...\n---")
+	skipBlank()
+	if i < len(src.lines) && strings.TrimSpace(src.lines[i]) == "---" {
+		for i++; i < len(src.lines) && strings.TrimSpace(src.lines[i]) != "---"; i++ {
+		}
+		i++
+		skipBlank()
+	}
+	if i >= len(src.lines) {
+		return
+	}
+
+	header, rest, _ := strings.Cut(strings.TrimSpace(src.lines[i]), ";")
+	fields := strings.Fields(header)
+	statement := g.flowchartStatement
+	switch fields[0] {
+	case "graph", "flowchart":
+		g.d.Kind = "flowchart"
+	case "sequenceDiagram":
+		g.d.Kind = "sequence"
+		statement = g.sequenceStatement
+	case "classDiagram", "classDiagram-v2":
+		g.d.Kind = "class"
+		statement = newClassParser(g).statement
+	case "stateDiagram", "stateDiagram-v2":
+		g.d.Kind = "state"
+		statement = g.stateStatement
+	default:
+		g.d.Kind = fields[0]
+		return
+	}
+
+	// Statements may follow the header: "graph TD; A-->B"
+	for _, stmt := range splitStatements(rest) {
+		statement(stmt, src.pos(i))
+	}
+	for i++; i < len(src.lines); i++ {
+		line := strings.TrimSpace(src.lines[i])
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		for _, stmt := range splitStatements(line) {
+			statement(stmt, src.pos(i))
+		}
+	}
+}
+
+// splitStatements splits a line at semicolons outside quotes and brackets.
+func splitStatements(line string) []string {
+	var stmts []string
+	depth, quoted, start := 0, false, 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case strings.ContainsRune("[({", r):
+			depth++
+		case strings.ContainsRune("])}", r):
+			depth--
+		case r == ';' && depth <= 0:
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, line[start:])
+}
+
+var flowchartKeywords = map[string]bool{
+	"subgraph": true, "end": true, "classDef": true, "class": true, "style": true,
+	"linkStyle": true, "click": true, "direction": true, "accTitle": true, "accDescr": true,
+}
+
+// flowchartStatement reads a chain like "A[Start] -->|go| B & C --> D".
+func (g *graphBuilder) flowchartStatement(stmt string, pos Position) {
+	stmt = strings.TrimSpace(stmt)
+	if word, _, _ := strings.Cut(stmt, " "); stmt == "" || flowchartKeywords[strings.TrimRight(word, ":")] {
+		return
+	}
+
+	s := &flowScanner{rest: stmt}
+	from := s.nodes(g, pos)
+	for len(from) > 0 {
+		arrow, label, ok := s.arrow()
+		if !ok {
+			return
+		}
+		to := s.nodes(g, pos)
+		for _, f := range from {
+			for _, t := range to {
+				g.edge(f, t, arrow, label, pos)
+			}
+		}
+		from = to
+	}
+}
+
+// flowScanner consumes a flowchart statement from the left.
+type flowScanner struct {
+	rest string
+}
+
+// flowShapes are the node shape delimiters, longest first.
+var flowShapes = [][2]string{
+	{"(((", ")))"}, {"([", "])"}, {"[[", "]]"}, {"[(", ")]"}, {"((", "))"},
+	{"{{", "}}"}, {"[/", "/]"}, {"[/", "\\]"}, {"[\\", "\\]"}, {"[\\", "/]"},
+	{"(", ")"}, {"[", "]"}, {"{", "}"}, {">", "]"},
+}
+
+// nodes reads one node or several joined by "&".
+func (s *flowScanner) nodes(g *graphBuilder, pos Position) []string {
+	var ids []string
+	for {
+		id, label, ok := s.node()
+		if !ok {
+			return ids
+		}
+		g.node(id, label, "", pos)
+		ids = append(ids, id)
+
+		rest := strings.TrimLeft(s.rest, " \t")
+		if !strings.HasPrefix(rest, "&") {
+			return ids
+		}
+		s.rest = rest[1:]
+	}
+}
+
+func (s *flowScanner) node() (id, label string, ok bool) {
+	rest := strings.TrimLeft(s.rest, " \t")
+	runes := []rune(rest)
+	n := 0
+	for n < len(runes) && (isIDRune(runes[n]) ||
+		runes[n] == '-' && n > 0 && n+1 < len(runes) && isIDRune(runes[n+1])) {
+		n++
+	}
+	if n == 0 {
+		return "", "", false
+	}
+	id = string(runes[:n])
+	rest = string(runes[n:])
+
+	for _, shape := range flowShapes {
+		if !strings.HasPrefix(rest, shape[0]) {
+			continue
+		}
+		body := rest[len(shape[0]):]
+		// Quoted labels may contain the closing delimiter
+		from := 0
+		if strings.HasPrefix(body, "\"") {
+			if q := strings.IndexByte(body[1:], '"'); q >= 0 {
+				from = q + 2
+			}
+		}
+		end := strings.Index(body[from:], shape[1])
+		if end < 0 {
+			continue
+		}
+		label = strings.Trim(unquote(body[:from+end]), "`")
+		rest = body[from+end+len(shape[1]):]
+		break
+	}
+
+	// Class shorthand: A:::important
+	if strings.HasPrefix(rest, ":::") {
+		rest = strings.TrimLeftFunc(rest[3:], isIDRune)
+	}
+	s.rest = rest
+	return id, label, true
+}
+
+func isIDRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+var (
+	// A -- text --> B, A -. text .-> B, A == text ==> B
+	flowLabeledArrow = regexp.MustCompile(`^(<?)(--|==|-\.)\s*([^\s>|.=-][^>|]*?)\s*(-{2,}[>ox]?|={2,}[>ox]?|\.+-[>ox]?)`)
+	// A --> B, A -.-> B, A ==> B, A ~~~ B, optionally with |text|
+	flowArrow     = regexp.MustCompile(`^<?(-{2,}[>ox]?|={2,}[>ox]?|-\.+-[>ox]?|~{3,})`)
+	flowPipeLabel = regexp.MustCompile(`^\s*\|([^|]*)\|`)
+)
+
+// arrow reads an edge operator and its label.
+func (s *flowScanner) arrow() (arrow, label string, ok bool) {
+	rest := strings.TrimLeft(s.rest, " \t")
+	if m := flowLabeledArrow.FindStringSubmatch(rest); m != nil {
+		s.rest = rest[len(m[0]):]
+		arrow = m[4]
+		if m[2] == "-." {
+			arrow = "-" + arrow
+		}
+		return m[1] + arrow, strings.TrimSpace(unquote(m[3])), true
+	}
+	m := flowArrow.FindString(rest)
+	if m == "" {
+		return "", "", false
+	}
+	rest = rest[len(m):]
+	if p := flowPipeLabel.FindStringSubmatch(rest); p != nil {
+		label = strings.TrimSpace(unquote(p[1]))
+		rest = rest[len(p[0]):]
+	}
+	s.rest = rest
+	return m, label, true
+}
+
+var (
+	sequenceParticipant = regexp.MustCompile(`^(?:create\s+)?(participant|actor)\s+([^\s@]+)(?:\s+as\s+(.+))?`)
+	sequenceMessage     = regexp.MustCompile(`^([^+<>:,;-]+?)\s*(<<-->>|<<->>|-->>|->>|-->|->|--x|-x|--\)|-\))\s*[+-]?\s*([^+<>:,;-]+?)\s*(?::\s*(.*))?$`)
+)
+
+// sequenceStatement reads participants and messages.
+func (g *graphBuilder) sequenceStatement(stmt string, pos Position) {
+	stmt = strings.TrimSpace(stmt)
+	if m := sequenceParticipant.FindStringSubmatch(stmt); m != nil {
+		g.node(m[2], strings.TrimSpace(m[3]), m[1], pos)
+		return
+	}
+	if m := sequenceMessage.FindStringSubmatch(stmt); m != nil {
+		g.node(m[1], "", "participant", pos)
+		g.node(m[3], "", "participant", pos)
+		g.edge(m[1], m[3], m[2], strings.TrimSpace(m[4]), pos)
+	}
+}
+
+var (
+	classDecl     = regexp.MustCompile(`^class\s+(\w+)(?:~[^~]*~)?(?:\["([^"]*)"\])?\s*(\{)?`)
+	classRelation = regexp.MustCompile(`^(\w+)(?:~[^~]*~)?\s*(?:"([^"]*)"\s*)?(<\||\*|o|<)?(--|\.\.)(\|>|\*|o|>)?\s*(?:"([^"]*)"\s*)?(\w+)(?:~[^~]*~)?\s*(?::\s*(.*))?$`)
+)
+
+// classParser tracks whether it is inside a class body.
+type classParser struct {
+	g      *graphBuilder
+	inBody bool
+}
+
+func newClassParser(g *graphBuilder) *classParser {
+	return &classParser{g: g}
+}
+
+// statement reads class declarations and relations. Relations keep the
+// left and right side as written.
+func (p *classParser) statement(stmt string, pos Position) {
+	stmt = strings.TrimSpace(stmt)
+	if p.inBody {
+		p.inBody = !strings.HasPrefix(stmt, "}")
+		return
+	}
+	if m := classDecl.FindStringSubmatch(stmt); m != nil {
+		p.g.node(m[1], m[2], "class", pos)
+		p.inBody = m[3] != "" && !strings.HasSuffix(stmt, "}")
+		return
+	}
+	if m := classRelation.FindStringSubmatch(stmt); m != nil {
+		p.g.node(m[1], "", "class", pos)
+		p.g.node(m[7], "", "class", pos)
+		p.g.edge(m[1], m[7], m[3]+m[4]+m[5], strings.TrimSpace(m[8]), pos)
+	}
+}
+
+var (
+	stateDecl       = regexp.MustCompile(`^state\s+(?:"([^"]*)"\s+as\s+)?(\w+)`)
+	stateTransition = regexp.MustCompile(`^(\[\*\]|[\w.]+)\s*-->\s*(\[\*\]|[\w.]+)\s*(?::\s*(.*))?$`)
+)
+
+// stateStatement reads states and transitions. "[*]" is the start or end
+// state.
+func (g *graphBuilder) stateStatement(stmt string, pos Position) {
+	stmt = strings.TrimSpace(stmt)
+	if m := stateDecl.FindStringSubmatch(stmt); m != nil {
+		g.node(m[2], m[1], "state", pos)
+		return
+	}
+	if m := stateTransition.FindStringSubmatch(stmt); m != nil {
+		g.node(m[1], "", "state", pos)
+		g.node(m[2], "", "state", pos)
+		g.edge(m[1], m[2], "-->", strings.TrimSpace(m[3]), pos)
+	}
+}
+
+// PlantUML
+
+var (
+	plantDecl = regexp.MustCompile(`^(participant|actor|boundary|control|entity|database|collections|queue|` +
+		`abstract\s+class|abstract|class|interface|enum|component|node|usecase|rectangle)\s+` +
+		`("[^"]+"|\[[^\]]+\]|\([^)]+\)|[\w.:]+)(?:\s+as\s+("[^"]+"|[\w.:]+))?`)
+	plantEndpoint = `("[^"]+"|\[[^\]]+\]|\([^)]+\)|[\w.:]+)`
+	plantRelation = regexp.MustCompile(`^` + plantEndpoint + `\s*(?:"([^"]*)"\s*)?` +
+		`([<*o#x}+^|/\\]{0,2}[-.]+(?:(?:\[[^\]]*\]|left|right|up|down|le|ri|do|l|r|u|d)[-.]+)?[>*o#x{+^|/\\]{0,2})` +
+		`\s*(?:"([^"]*)"\s*)?` + plantEndpoint + `\s*(?::\s*(.*))?$`)
+)
+
+// plantKinds maps declaration keywords to the diagram kind they imply.
+var plantKinds = map[string]string{
+	"class": "class", "abstract": "class", "interface": "class", "enum": "class",
+	"component": "component", "node": "component", "rectangle": "component",
+	"usecase": "usecase",
+}
+
+// parsePlantUML reads sequence, class, component and use case diagrams
+// between @startuml and @enduml. The kind is inferred from the
+// declarations; other @start blocks only report their kind.
+func parsePlantUML(src diagramSource, g *graphBuilder) {
+	inBody, comment := false, false
+	for i, line := range src.lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case comment:
+			comment = !strings.HasSuffix(line, "'/")
+			continue
+		case strings.HasPrefix(line, "/'"):
+			comment = !strings.HasSuffix(line, "'/")
+			continue
+		case line == "" || strings.HasPrefix(line, "'"):
+			continue
+		case strings.HasPrefix(line, "@start"):
+			if kind := strings.TrimPrefix(strings.Fields(line)[0], "@start"); kind != "uml" {
+				g.d.Kind = kind
+				return
+			}
+			continue
+		case strings.HasPrefix(line, "@end"):
+			continue
+		case inBody:
+			inBody = !strings.HasPrefix(line, "}")
+			continue
+		}
+
+		pos := src.pos(i)
+		if m := plantDecl.FindStringSubmatch(line); m != nil {
+			kind := strings.Fields(m[1])[0]
+			id, label := plantName(m[2]), ""
+			if m[3] != "" {
+				id, label = plantName(m[3]), plantName(m[2])
+				if strings.HasPrefix(m[3], "\"") {
+					id, label = label, id
+				}
+			}
+			g.node(id, label, kind, pos)
+			if k := plantKinds[kind]; k != "" && g.d.Kind == "" {
+				g.d.Kind = k
+			}
+			inBody = strings.HasSuffix(line, "{")
+			continue
+		}
+		if m := plantRelation.FindStringSubmatch(line); m != nil {
+			from, to := plantName(m[1]), plantName(m[5])
+			g.node(from, "", plantEndpointKind(m[1]), pos)
+			g.node(to, "", plantEndpointKind(m[5]), pos)
+			g.edge(from, to, m[3], strings.TrimSpace(m[6]), pos)
+			if g.d.Kind == "" && strings.ContainsAny(m[3], "|*o") && !strings.Contains(m[3], ">>") {
+				g.d.Kind = "class"
+			}
+		}
+	}
+	if g.d.Kind == "" {
+		g.d.Kind = "sequence"
+	}
+}
+
+// plantName strips quotes, [component] brackets and (use case) parens.
+func plantName(s string) string {
+	if len(s) >= 2 {
+		switch s[0] {
+		case '"', '[', '(':
+			return strings.TrimSpace(s[1 : len(s)-1])
+		}
+	}
+	return s
+}
+
+func plantEndpointKind(s string) string {
+	switch {
+	case strings.HasPrefix(s, "["):
+		return "component"
+	case strings.HasPrefix(s, "("):
+		return "usecase"
+	}
+	return ""
+}
+
+// Graphviz
+
+// dotToken is an ID, quoted string or operator of a DOT graph.
+type dotToken struct {
+	text   string
+	quoted bool
+	line   int // Content line, 0-based
+}
+
+// dotTokens splits DOT source into tokens, dropping comments.
+func dotTokens(lines []string) []dotToken {
+	var tokens []dotToken
+	comment := false
+	for ln, line := range lines {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "#") {
+			continue // Preprocessor output lines
+		}
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case comment:
+				end := strings.Index(line[i:], "*/")
+				if end < 0 {
+					i = len(line)
+					continue
+				}
+				comment = false
+				i += end + 2
+			case strings.HasPrefix(line[i:], "/*"):
+				comment = true
+				i += 2
+			case strings.HasPrefix(line[i:], "//"):
+				i = len(line)
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+			case c == '"':
+				var b strings.Builder
+				j := i + 1
+				for ; j < len(line) && line[j] != '"'; j++ {
+					if line[j] == '\\' && j+1 < len(line) && line[j+1] == '"' {
+						j++
+					}
+					b.WriteByte(line[j])
+				}
+				tokens = append(tokens, dotToken{text: b.String(), quoted: true, line: ln})
+				i = j + 1
+			case c == '<':
+				// HTML label: <...> with nesting
+				depth, j := 0, i
+				for ; j < len(line); j++ {
+					if line[j] == '<' {
+						depth++
+					} else if line[j] == '>' {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+				tokens = append(tokens, dotToken{text: line[i+1 : min(j, len(line))], quoted: true, line: ln})
+				i = j + 1
+			case strings.HasPrefix(line[i:], "->") || strings.HasPrefix(line[i:], "--"):
+				tokens = append(tokens, dotToken{text: line[i : i+2], line: ln})
+				i += 2
+			case strings.IndexByte("{}[];,=:", c) >= 0:
+				tokens = append(tokens, dotToken{text: string(c), line: ln})
+				i++
+			default:
+				j := i
+				for j < len(line) && (line[j] == '_' || line[j] == '.' || line[j] >= 0x80 ||
+					'a' <= line[j] && line[j] <= 'z' || 'A' <= line[j] && line[j] <= 'Z' ||
+					'0' <= line[j] && line[j] <= '9' || line[j] == '-' && j == i) {
+					j++
+				}
+				if j == i {
+					j++ // Unknown byte
+				}
+				tokens = append(tokens, dotToken{text: line[i:j], line: ln})
+				i = j
+			}
+		}
+	}
+	return tokens
+}
+
+// dotParser reads statements from DOT tokens, flattening subgraphs.
+type dotParser struct {
+	tokens []dotToken
+	i      int
+	src    diagramSource
+	g      *graphBuilder
+}
+
+func (p *dotParser) peek() (dotToken, bool) {
+	if p.i >= len(p.tokens) {
+		return dotToken{}, false
+	}
+	return p.tokens[p.i], true
+}
+
+// is reports whether the next token is the operator op.
+func (p *dotParser) is(op string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && t.text == op
+}
+
+func (p *dotParser) keyword(word string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// parseDot reads nodes, edges and their labels from a graph or digraph.
+func parseDot(src diagramSource, g *graphBuilder) {
+	p := &dotParser{tokens: dotTokens(src.lines), src: src, g: g}
+
+	if p.keyword("strict") {
+		p.i++
+	}
+	switch {
+	case p.keyword("digraph"):
+		g.d.Kind = "digraph"
+	case p.keyword("graph"):
+		g.d.Kind = "graph"
+	default:
+		return
+	}
+	p.i++
+	if !p.is("{") {
+		p.i++ // Graph name
+	}
+
+	for p.i < len(p.tokens) {
+		p.statement()
+	}
+}
+
+func (p *dotParser) statement() {
+	t, _ := p.peek()
+	switch {
+	case p.is("{") || p.is("}") || p.is(";") || p.is(","):
+		p.i++
+		return
+	case p.keyword("subgraph"):
+		p.i++
+		if !p.is("{") {
+			p.i++ // Subgraph name
+		}
+		return
+	case p.keyword("graph") || p.keyword("node") || p.keyword("edge"):
+		p.i++
+		p.attrs()
+		return
+	case !t.quoted && strings.IndexByte("[]=:", t.text[0]) >= 0:
+		p.i++
+		return
+	}
+
+	// "ID = ID" graph attribute
+	if p.i+1 < len(p.tokens) && p.tokens[p.i+1].text == "=" && !p.tokens[p.i+1].quoted {
+		p.i += 3
+		return
+	}
+
+	pos := p.src.pos(t.line)
+	chain := [][]string{p.operand()}
+	var arrows []string
+	for p.is("->") || p.is("--") {
+		arrow, _ := p.peek()
+		p.i++
+		arrows = append(arrows, arrow.text)
+		chain = append(chain, p.operand())
+	}
+	attrs := p.attrs()
+
+	if len(chain) == 1 {
+		for _, id := range chain[0] {
+			p.g.node(id, attrs["label"], "", pos)
+		}
+		return
+	}
+	for i, arrow := range arrows {
+		for _, from := range chain[i] {
+			for _, to := range chain[i+1] {
+				p.g.edge(from, to, arrow, attrs["label"], pos)
+			}
+		}
+	}
+}
+
+// operand reads a node ID (dropping ports) or a "{A B}" node set.
+func (p *dotParser) operand() []string {
+	if p.keyword("subgraph") {
+		p.i++
+		if !p.is("{") {
+			p.i++
+		}
+	}
+	if p.is("{") {
+		p.i++
+		var ids []string
+		for p.i < len(p.tokens) && !p.is("}") {
+			if p.is("[") {
+				p.attrs()
+				continue
+			}
+			t, _ := p.peek()
+			p.i++
+			if t.quoted || strings.IndexByte("{};,=:", t.text[0]) < 0 {
+				ids = append(ids, t.text)
+			}
+		}
+		p.i++
+		return ids
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return nil
+	}
+	p.i++
+	for p.is(":") { // Port and compass point
+		p.i += 2
+	}
+	p.g.node(t.text, "", "", p.src.pos(t.line))
+	return []string{t.text}
+}
+
+// attrs reads attribute lists like [label="x", color=red][style=bold].
+func (p *dotParser) attrs() map[string]string {
+	attrs := make(map[string]string)
+	for p.is("[") {
+		p.i++
+		for p.i < len(p.tokens) && !p.is("]") {
+			key, _ := p.peek()
+			p.i++
+			if p.is("=") && p.i+1 < len(p.tokens) {
+				attrs[key.text] = p.tokens[p.i+1].text
+				p.i += 2
+			}
+		}
+		p.i++
+	}
+	return attrs
+}
+
+// GetDiagrams returns the diagram code blocks, optionally only the given
+// languages (mermaid, plantuml, dot) or kinds (flowchart, sequence, ...).
+func (d *Document) GetDiagrams(kinds ...string) []*Diagram {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return filterDiagrams(d.diagrams, kinds)
+}
+
+func filterDiagrams(diagrams []*Diagram, kinds []string) []*Diagram {
+	if len(kinds) == 0 {
+		return diagrams
+	}
+	var result []*Diagram
+	for _, diagram := range diagrams {
+		for _, kind := range kinds {
+			if strings.EqualFold(diagram.Kind, kind) || diagramLanguage(kind) == diagram.Language {
+				result = append(result, diagram)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diagramsMarkdown = "# Architecture\n" +
+	"\n" +
+	"```mermaid\n" +
+	"flowchart LR\n" +
+	"  %% Request path\n" +
+	"  Web[Web app] -->|HTTPS| API(API gateway)\n" +
+	"  API --> Billing & Users[(Users DB)]\n" +
+	"  Billing -. retries .-> Queue{{Queue}}; Queue ==> Billing\n" +
+	"  subgraph internal\n" +
+	"  end\n" +
+	"```\n" +
+	"\n" +
+	"## Checkout\n" +
+	"\n" +
+	"```mermaid\n" +
+	"sequenceDiagram\n" +
+	"  participant C as Client\n" +
+	"  actor Ops\n" +
+	"  C->>+API: POST /checkout\n" +
+	"  API-->>-C: 201 Created\n" +
+	"  Note over C,API: idempotent\n" +
+	"```\n" +
+	"\n" +
+	"```mermaid\n" +
+	"classDiagram\n" +
+	"  class Invoice {\n" +
+	"    +total()\n" +
+	"  }\n" +
+	"  Document <|-- Invoice\n" +
+	"  Invoice \"1\" *-- \"many\" Line : contains\n" +
+	"```\n" +
+	"\n" +
+	"```dot\n" +
+	"digraph deps {\n" +
+	"  // services\n" +
+	"  api [label=\"API\"];\n" +
+	"  api -> billing -> ledger [label=\"writes\"];\n" +
+	"  subgraph cluster_a { api -> {cache db} }\n" +
+	"}\n" +
+	"```\n" +
+	"\n" +
+	"```plantuml\n" +
+	"@startuml\n" +
+	"actor User\n" +
+	"participant \"Billing Service\" as B\n" +
+	"User -> B : pay\n" +
+	"B --> User : receipt\n" +
+	"@enduml\n" +
+	"```\n"
+
+func TestDiagrams(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(diagramsMarkdown), "arch.md")
+	require.NoError(t, err)
+
+	diagrams := doc.GetDiagrams()
+	require.Len(t, diagrams, 5)
+
+	type edge struct{ From, To, Arrow, Label string }
+	edges := func(d *mq.Diagram) []edge {
+		var got []edge
+		for _, e := range d.Edges {
+			got = append(got, edge{e.From, e.To, e.Arrow, e.Label})
+		}
+		return got
+	}
+	labels := func(d *mq.Diagram) map[string]string {
+		got := make(map[string]string)
+		for _, n := range d.Nodes {
+			got[n.ID] = n.Label
+		}
+		return got
+	}
+
+	flow := diagrams[0]
+	assert.Equal(t, mq.DiagramMermaid, flow.Language)
+	assert.Equal(t, "flowchart", flow.Kind)
+	assert.Equal(t, []edge{
+		{"Web", "API", "-->", "HTTPS"},
+		{"API", "Billing", "-->", ""},
+		{"API", "Users", "-->", ""},
+		{"Billing", "Queue", "-.->", "retries"},
+		{"Queue", "Billing", "==>", ""},
+	}, edges(flow))
+	assert.Equal(t, map[string]string{
+		"Web": "Web app", "API": "API gateway", "Billing": "Billing", "Users": "Users DB", "Queue": "Queue",
+	}, labels(flow))
+	assert.Equal(t, 6, flow.Edges[0].Line)
+	assert.Equal(t, 8, flow.Edges[3].Line)
+
+	seq := diagrams[1]
+	assert.Equal(t, "sequence", seq.Kind)
+	assert.Equal(t, []edge{{"C", "API", "->>", "POST /checkout"}, {"API", "C", "-->>", "201 Created"}}, edges(seq))
+	assert.Equal(t, "Client", seq.Nodes[0].Label)
+	assert.Equal(t, "actor", seq.Nodes[1].Kind)
+
+	class := diagrams[2]
+	assert.Equal(t, "class", class.Kind)
+	assert.Equal(t, []edge{{"Document", "Invoice", "<|--", ""}, {"Invoice", "Line", "*--", "contains"}}, edges(class))
+
+	dot := diagrams[3]
+	assert.Equal(t, "digraph", dot.Kind)
+	assert.Equal(t, []edge{
+		{"api", "billing", "->", "writes"},
+		{"billing", "ledger", "->", "writes"},
+		{"api", "cache", "->", ""},
+		{"api", "db", "->", ""},
+	}, edges(dot))
+	assert.Equal(t, "API", labels(dot)["api"])
+
+	uml := diagrams[4]
+	assert.Equal(t, mq.DiagramPlantUML, uml.Language)
+	assert.Equal(t, "sequence", uml.Kind)
+	assert.Equal(t, []edge{{"User", "B", "->", "pay"}, {"B", "User", "-->", "receipt"}}, edges(uml))
+	assert.Equal(t, "Billing Service", labels(uml)["B"])
+
+	// Filter by language or kind, per section
+	assert.Len(t, doc.GetDiagrams("mermaid"), 3)
+	assert.Len(t, doc.GetDiagrams("sequence"), 2)
+	section, ok := doc.GetSection("Checkout")
+	require.True(t, ok)
+	assert.Len(t, section.GetDiagrams(), 4)
+
+	// Diagrams are still code blocks
+	assert.Len(t, doc.GetCodeBlocks("mermaid"), 3)
+}
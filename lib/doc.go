@@ -1,7 +1,7 @@
 // Package mq provides a markdown processing and query evaluation engine.
 // It enables efficient extraction and manipulation of markdown content
-// with support for YAML frontmatter metadata and pre-computed indexes
-// for fast lookups.
+// with support for YAML, TOML and JSON frontmatter metadata and
+// pre-computed indexes for fast lookups.
 //
 // The library provides multiple ways to interact with markdown documents:
 //   - Direct API calls for type-safe access
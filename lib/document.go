@@ -1,6 +1,8 @@
 package mq
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/yuin/goldmark/ast"
@@ -30,12 +32,28 @@ type Document struct {
 	headingIndex    map[string]*Heading     // by text
 	headingsByLevel map[int][]*Heading      // by level
 	sectionIndex    map[string]*Section     // by title
+	sections        []*Section              // all sections in document order
+	preamble        *Section                // content before the first heading
 	codeBlocks      []*CodeBlock            // all code blocks
 	codeByLang      map[string][]*CodeBlock // by language
 	links           []*Link                 // all links
+	references      []*Link                 // link reference definitions
 	images          []*Image                // all images
 	tables          []*Table                // all tables
 	lists           []*List                 // all lists
+	inlineCode      []*InlineCode           // all code spans
+	footnotes       []*Footnote             // footnote definitions
+	definitions     []*Definition           // definition list terms
+	admonitions     []*Admonition           // admonitions and GitHub alerts
+	math            []*MathBlock            // display math blocks
+	diagrams        []*Diagram              // mermaid, plantuml and dot blocks
+
+	// API specs (OpenAPI/Swagger): operations and named schemas
+	endpoints []*Endpoint
+	schemas   []*Schema
+
+	// MDX: JSX components in document order
+	components []*Component
 }
 
 // NewDocument creates a Document from pre-extracted structural elements.
@@ -68,6 +86,7 @@ func NewDocument(
 		headingIndex:    make(map[string]*Heading),
 		headingsByLevel: make(map[int][]*Heading),
 		sectionIndex:    make(map[string]*Section),
+		sections:        sections,
 		codeBlocks:      codeBlocks,
 		codeByLang:      make(map[string][]*CodeBlock),
 		links:           links,
@@ -84,6 +103,9 @@ func NewDocument(
 
 	// Build section index
 	for _, s := range sections {
+		if s.source == nil {
+			s.source = source
+		}
 		if s.Heading != nil {
 			doc.sectionIndex[s.Heading.Text] = s
 		}
@@ -132,6 +154,21 @@ func (d *Document) Source() []byte {
 	return d.source
 }
 
+// Lines returns source lines start through end, 1-based and inclusive.
+// The range is clamped to the document; ok is false if it is empty.
+func (d *Document) Lines(start, end int) (string, bool) {
+	lines := strings.Split(string(d.source), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1] // trailing newline
+	}
+	start = max(start, 1)
+	end = min(end, len(lines))
+	if start > end {
+		return "", false
+	}
+	return strings.Join(lines[start-1:end], "\n"), true
+}
+
 // Title returns the document title.
 // For HTML: <title> tag
 // For PDF: document metadata
@@ -164,18 +201,35 @@ func (d *Document) AST() ast.Node {
 	return d.root
 }
 
+// GetPreamble returns the content before the first heading (badges,
+// intro paragraphs) as a section with a level 0 heading, or nil if there
+// is none. Markdown and MDX only. It is not part of GetSections.
+func (d *Document) GetPreamble() *Section {
+	return d.preamble
+}
+
 // Metadata returns the document's frontmatter metadata.
 func (d *Document) Metadata() Metadata {
 	return d.metadata
 }
 
-// GetMetadataField retrieves a specific metadata field.
+// SetMetadata replaces the document's metadata.
+// Parsers for formats with document properties (XML root attributes,
+// office core properties, book metadata) use this after NewDocument.
+func (d *Document) SetMetadata(m Metadata) {
+	d.metadata = m
+}
+
+// GetMetadataField retrieves a metadata field. Keys that aren't present
+// verbatim are looked up as paths, e.g. "authors[0].email".
 func (d *Document) GetMetadataField(key string) (interface{}, bool) {
 	if d.metadata == nil {
 		return nil, false
 	}
-	val, ok := d.metadata[key]
-	return val, ok
+	if val, ok := d.metadata[key]; ok {
+		return val, true
+	}
+	return d.metadata.Lookup(key)
 }
 
 // GetOwner returns the owner from metadata.
@@ -201,10 +255,19 @@ func (d *Document) GetTags() []string {
 		return nil
 	}
 
-	// Handle different possible formats from YAML
+	// Handle different possible formats from YAML, TOML and JSON
 	switch v := val.(type) {
 	case []string:
 		return v
+	case string:
+		// Comma-separated, as some site generators allow
+		var tags []string
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
 	case []interface{}:
 		tags := make([]string, 0, len(v))
 		for _, item := range v {
@@ -224,8 +287,14 @@ func (d *Document) GetPriority() (string, bool) {
 	if !ok {
 		return "", false
 	}
-	priority, ok := val.(string)
-	return priority, ok
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case int, int64, float64:
+		// priority: 1 is a number in every frontmatter syntax
+		return fmt.Sprint(v), true
+	}
+	return "", false
 }
 
 // GetHeadings returns headings, optionally filtered by level.
@@ -261,24 +330,39 @@ func (d *Document) GetHeadingByText(text string) (*Heading, bool) {
 	return heading, ok
 }
 
-// GetSection returns a section by title.
+// GetSection returns a section by title, or by heading ID when title is
+// an anchor like "#getting-started".
 func (d *Document) GetSection(title string) (*Section, bool) {
 	d.mu.RLock()
-	defer d.mu.RUnlock()
-
 	section, ok := d.sectionIndex[title]
+	d.mu.RUnlock()
+
+	if !ok && strings.HasPrefix(title, "#") {
+		return d.GetSectionByID(strings.TrimPrefix(title, "#"))
+	}
 	return section, ok
 }
 
-// GetSections returns all sections.
-func (d *Document) GetSections() []*Section {
+// GetSectionByID returns the section whose heading has the given ID.
+func (d *Document) GetSectionByID(id string) (*Section, bool) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	sections := make([]*Section, 0, len(d.sectionIndex))
-	for _, section := range d.sectionIndex {
-		sections = append(sections, section)
+	for _, s := range d.sections {
+		if s.Heading.ID == id {
+			return s, true
+		}
 	}
+	return nil, false
+}
+
+// GetSections returns all sections in document order.
+func (d *Document) GetSections() []*Section {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	sections := make([]*Section, len(d.sections))
+	copy(sections, d.sections)
 	return sections
 }
 
@@ -347,7 +431,7 @@ func (d *Document) GetTableOfContents() []*Section {
 
 	// Return top-level sections
 	var toc []*Section
-	for _, section := range d.sectionIndex {
+	for _, section := range d.sections {
 		if section.Parent == nil {
 			toc = append(toc, section)
 		}
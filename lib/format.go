@@ -23,8 +23,10 @@
 package mq
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -39,25 +41,250 @@ const (
 	FormatJSON
 	FormatJSONL
 	FormatYAML
+	FormatTOML
+	FormatXML
+	FormatDOCX
+	FormatEPUB
+	FormatGo
+	FormatLaTeX
+	FormatMan
+	FormatEmail
+	FormatMDX
+	FormatText
+	FormatLog
 )
 
+// String returns the format's registered name, e.g. "markdown".
 func (f Format) String() string {
-	switch f {
-	case FormatMarkdown:
-		return "markdown"
-	case FormatHTML:
-		return "html"
-	case FormatPDF:
-		return "pdf"
-	case FormatJSON:
-		return "json"
-	case FormatJSONL:
-		return "jsonl"
-	case FormatYAML:
-		return "yaml"
-	default:
-		return "unknown"
+	if info, ok := formatInfo(f); ok {
+		return info.Name
 	}
+	return "unknown"
+}
+
+// builtinFormats describes the formats parsed by this module, in Format
+// order. They are registered before any other format.
+var builtinFormats = []FormatInfo{
+	{
+		Name:       "markdown",
+		Aliases:    []string{"md", "mdown"},
+		Extensions: []string{".md", ".markdown", ".mdown", ".mkd"},
+		MIMETypes:  []string{"text/markdown", "text/x-markdown"},
+		Label:      markdownLabel,
+	},
+	{
+		Name:       "html",
+		Aliases:    []string{"htm", "xhtml"},
+		Extensions: []string{".html", ".htm", ".xhtml"},
+		MIMETypes:  []string{"text/html", "application/xhtml+xml"},
+		Sniff: func(content []byte) bool {
+			head := sniffHead(content)
+			if strings.HasPrefix(head, "<!") || strings.HasPrefix(head, "<html") || strings.HasPrefix(head, "<HTML") {
+				return true
+			}
+			// XHTML: an XML declaration followed by an html root
+			return strings.HasPrefix(head, "<?xml") && strings.Contains(strings.ToLower(head), "<html")
+		},
+	},
+	{
+		Name:       "pdf",
+		Extensions: []string{".pdf"},
+		MIMETypes:  []string{"application/pdf"},
+		Sniff: func(content []byte) bool {
+			return bytes.HasPrefix(content, []byte("%PDF"))
+		},
+	},
+	{
+		Name:       "json",
+		Extensions: []string{".json"},
+		MIMETypes:  []string{"application/json"},
+		Sniff: func(content []byte) bool {
+			head := sniffHead(content)
+			return strings.HasPrefix(head, "{") || strings.HasPrefix(head, "[")
+		},
+		Label: keyLabel,
+		Count: countTopLevel,
+		Unit:  "key",
+		Units: "keys",
+	},
+	{
+		Name:       "jsonl",
+		Aliases:    []string{"ndjson"},
+		Extensions: []string{".jsonl", ".ndjson"},
+		MIMETypes:  []string{"application/jsonl", "application/x-ndjson"},
+		Label: func(h *Heading) string {
+			return fmt.Sprintf("field %s", h.Text)
+		},
+		Count: func(doc *Document) int { return countJSONLRecords(doc.Source()) },
+		Unit:  "record",
+		Units: "records",
+	},
+	{
+		Name:       "yaml",
+		Aliases:    []string{"yml"},
+		Extensions: []string{".yaml", ".yml"},
+		MIMETypes:  []string{"application/yaml", "application/x-yaml", "text/yaml"},
+		Sniff: func(content []byte) bool {
+			return strings.HasPrefix(sniffHead(content), "---")
+		},
+		Label: keyLabel,
+		Count: countTopLevel,
+		Unit:  "key",
+		Units: "keys",
+	},
+	{
+		Name:       "toml",
+		Extensions: []string{".toml"},
+		MIMETypes:  []string{"application/toml"},
+		Label:      keyLabel,
+		Count:      countTopLevel,
+		Unit:       "key",
+		Units:      "keys",
+	},
+	{
+		Name:       "xml",
+		Extensions: []string{".xml", ".pom", ".rss", ".atom", ".xsd", ".wsdl"},
+		MIMETypes:  []string{"application/xml", "text/xml", "application/rss+xml", "application/atom+xml"},
+		Sniff: func(content []byte) bool {
+			head := sniffHead(content)
+			return strings.HasPrefix(head, "<?xml") && !strings.Contains(strings.ToLower(head), "<html")
+		},
+		Label: func(h *Heading) string {
+			return fmt.Sprintf("<%s>", h.Text)
+		},
+		Unit:  "element",
+		Units: "elements",
+	},
+	{
+		Name:       "docx",
+		Extensions: []string{".docx"},
+		MIMETypes:  []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		// Zip packages containing a Word document part
+		Sniff: func(content []byte) bool {
+			return bytes.HasPrefix(content, []byte("PK\x03\x04")) && bytes.Contains(content, []byte("word/document.xml"))
+		},
+	},
+	{
+		Name:       "epub",
+		Extensions: []string{".epub"},
+		MIMETypes:  []string{"application/epub+zip"},
+		// Zip packages storing the EPUB mimetype first
+		Sniff: func(content []byte) bool {
+			return bytes.HasPrefix(content, []byte("PK\x03\x04")) &&
+				bytes.Contains(content[:min(len(content), 128)], []byte("application/epub+zip"))
+		},
+	},
+	{
+		Name:       "go",
+		Aliases:    []string{"golang"},
+		Extensions: []string{".go"},
+		MIMETypes:  []string{"text/x-go"},
+		Label:      plainLabel,
+		Unit:       "decl",
+		Units:      "decls",
+	},
+	{
+		Name:       "latex",
+		Aliases:    []string{"tex"},
+		Extensions: []string{".tex", ".latex", ".ltx"},
+		MIMETypes:  []string{"application/x-latex", "text/x-tex"},
+		Sniff: func(content []byte) bool {
+			return strings.HasPrefix(sniffHead(content), `\documentclass`)
+		},
+	},
+	{
+		Name:       "man",
+		Aliases:    []string{"roff", "mdoc"},
+		Extensions: []string{".man", ".mdoc"},
+		MIMETypes:  []string{"text/troff", "application/x-troff-man"},
+		// Man pages: man1/tar.1, man3/printf.3p, man1/tar.1.gz
+		Match: isManPage,
+		Sniff: func(content []byte) bool {
+			head := sniffHead(content)
+			return strings.HasPrefix(head, ".TH ") || strings.HasPrefix(head, ".Dd") ||
+				strings.HasPrefix(head, `.\"`) || strings.HasPrefix(head, `'\"`)
+		},
+		Label: func(h *Heading) string {
+			if h.Level <= 1 {
+				return fmt.Sprintf(".SH %s", h.Text)
+			}
+			return fmt.Sprintf(".SS %s", h.Text)
+		},
+	},
+	{
+		Name:       "email",
+		Aliases:    []string{"eml", "mbox"},
+		Extensions: []string{".eml", ".mbox"},
+		MIMETypes:  []string{"message/rfc822", "application/mbox"},
+		// An mbox separator, or a header block with the fields every
+		// message carries
+		Sniff: func(content []byte) bool {
+			return looksLikeEmail(sniffHead(content))
+		},
+		Label: plainLabel,
+		Unit:  "message",
+		Units: "messages",
+	},
+	{
+		Name:       "mdx",
+		Extensions: []string{".mdx"},
+		MIMETypes:  []string{"text/mdx"},
+		Label:      markdownLabel,
+	},
+	{
+		Name:       "text",
+		Aliases:    []string{"txt", "plain", "plaintext"},
+		Extensions: []string{".txt", ".text"},
+		MIMETypes:  []string{"text/plain"},
+		Label:      plainLabel,
+	},
+	{
+		Name:       "log",
+		Extensions: []string{".log"},
+		MIMETypes:  []string{"text/x-log"},
+		Match:      isRotatedLog,
+		Sniff:      looksLikeLog,
+		Label:      plainLabel,
+		Unit:       "period",
+		Units:      "periods",
+	},
+}
+
+func init() {
+	for i, info := range builtinFormats {
+		if f := RegisterFormat(info); f != Format(i+1) {
+			panic(fmt.Sprintf("mq: built-in format %s registered as %d", info.Name, f))
+		}
+	}
+}
+
+// sniffHead returns the start of content with surrounding whitespace
+// trimmed, for prefix checks.
+func sniffHead(content []byte) string {
+	return strings.TrimSpace(string(content[:min(len(content), 1024)]))
+}
+
+// markdownLabel labels headings the way Markdown writes them.
+func markdownLabel(h *Heading) string {
+	return fmt.Sprintf("%s %s", strings.Repeat("#", h.Level), h.Text)
+}
+
+// keyLabel labels data-format keys in directory trees.
+func keyLabel(h *Heading) string {
+	if h.Level <= 1 {
+		return fmt.Sprintf("key %s", h.Text)
+	}
+	return fmt.Sprintf("subkey %s", h.Text)
+}
+
+// plainLabel uses the heading text as is.
+func plainLabel(h *Heading) string {
+	return h.Text
+}
+
+// countTopLevel counts top-level sections, e.g. keys of a data file.
+func countTopLevel(doc *Document) int {
+	return len(doc.GetTableOfContents())
 }
 
 // FormatParser converts raw content into a unified Document structure.
@@ -103,53 +330,110 @@ func (r *ParserRegistry) Get(f Format) (FormatParser, bool) {
 	return p, ok
 }
 
-// DetectFormat determines the format from file extension or content.
+// DetectFormat determines the format from file extension or content,
+// consulting every registered format. Unrecognized content is Markdown.
 func DetectFormat(path string, content []byte) Format {
-	// First try extension
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".md", ".markdown", ".mdown", ".mkd":
-		return FormatMarkdown
-	case ".html", ".htm", ".xhtml":
-		return FormatHTML
-	case ".pdf":
-		return FormatPDF
-	case ".json":
-		return FormatJSON
-	case ".jsonl", ".ndjson":
-		return FormatJSONL
-	case ".yaml", ".yml":
-		return FormatYAML
+	if f := formatForPath(path); f != FormatUnknown {
+		return f
 	}
 
 	// Fall back to content sniffing
 	if len(content) > 0 {
-		// Check for HTML
-		trimmed := strings.TrimSpace(string(content[:min(len(content), 1024)]))
-		if strings.HasPrefix(trimmed, "<!") ||
-			strings.HasPrefix(trimmed, "<html") ||
-			strings.HasPrefix(trimmed, "<HTML") {
-			return FormatHTML
+		if f := sniffFormat(content); f != FormatUnknown {
+			return f
 		}
+	}
 
-		// Check for PDF magic bytes
-		if len(content) >= 4 && string(content[:4]) == "%PDF" {
-			return FormatPDF
-		}
+	// Default to markdown (most permissive)
+	return FormatMarkdown
+}
+
+// rotatedLogRe matches rotated log names such as app.log.1 and
+// app.log.2024-05-01.
+var rotatedLogRe = regexp.MustCompile(`\.log\.[\d-]+$`)
 
-		// Check for JSON (starts with { or [)
-		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
-			return FormatJSON
+func isRotatedLog(path string) bool {
+	return rotatedLogRe.MatchString(filepath.Base(path))
+}
+
+// logTimestampRe matches the common timestamp prefixes of log lines:
+// ISO 8601, Go's log package and syslog. Like the log parser, it requires
+// seconds, so "2024-05-01 14:03" notes are not taken for a log.
+var logTimestampRe = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}|\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`)
+
+// looksLikeLog reports whether most of the first lines of content start
+// with a timestamp.
+func looksLikeLog(content []byte) bool {
+	lines, stamped := 0, 0
+	for _, line := range strings.Split(sniffHead(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
+		lines++
+		if logTimestampRe.MatchString(line) {
+			stamped++
+		}
+		if lines == 10 {
+			break
+		}
+	}
+	return stamped >= 3 && stamped*2 >= lines
+}
+
+var mailHeaderRe = regexp.MustCompile(`(?m)^(From|Date|Subject|Message-I[Dd]|Received|Return-Path|MIME-Version):`)
+
+// looksLikeEmail reports whether content starts like an mbox or an
+// RFC 5322 message.
+func looksLikeEmail(content string) bool {
+	head, _, _ := strings.Cut(content, "\n\n")
+	if strings.HasPrefix(head, "From ") {
+		_, head, _ = strings.Cut(head, "\n")
+	}
+	if !mailHeaderRe.MatchString(head) || !strings.HasPrefix(head, mailHeaderRe.FindString(head)) {
+		return false
+	}
+	found := map[string]bool{}
+	for _, m := range mailHeaderRe.FindAllStringSubmatch(head, -1) {
+		found[strings.ToLower(m[1])] = true
+	}
+	return found["from"] && (found["date"] || found["message-id"]) && (found["subject"] || found["received"])
+}
 
-		// Check for YAML (starts with --- or key:)
-		if strings.HasPrefix(trimmed, "---") {
-			return FormatYAML
+// isManPage reports whether path looks like a manual page: a section
+// number extension (.1-.9, optionally suffixed, e.g. .3p or .1ssl),
+// possibly gzip-compressed, in a man or manN directory. Elsewhere such
+// names are mostly versioned libraries (libz.so.1), so they need a roff
+// sniff of the content.
+func isManPage(path string) bool {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(path)), ".gz")
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || ext[1] < '1' || ext[1] > '9' || len(name) == len(ext) {
+		return false
+	}
+	for _, c := range ext[2:] {
+		if c < 'a' || c > 'z' {
+			return false
 		}
 	}
+	return inManDir(path)
+}
 
-	// Default to markdown (most permissive)
-	return FormatMarkdown
+// manDirRe matches man page directories: man, man1, man3p, mann.
+var manDirRe = regexp.MustCompile(`^man([1-9n][a-z]*)?$`)
+
+// inManDir reports whether a directory above path is a man directory.
+func inManDir(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if manDirRe.MatchString(strings.ToLower(filepath.Base(dir))) {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
 }
 
 // ParseError wraps parsing errors with format context.
@@ -0,0 +1,318 @@
+package mq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muqsitnawaz/mq/internal/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontmatterDecoder decodes the content between a frontmatter block's
+// fences into metadata values.
+type FrontmatterDecoder func(content []byte) (map[string]interface{}, error)
+
+// frontmatterRegistry maps fence lines and language names to decoders.
+var frontmatterRegistry = struct {
+	sync.RWMutex
+	fences   map[string]string // opening fence -> language
+	decoders map[string]FrontmatterDecoder
+}{
+	fences: map[string]string{"---": "yaml", "+++": "toml"},
+	decoders: map[string]FrontmatterDecoder{
+		"yaml": decodeYAMLFrontmatter,
+		"toml": decodeTOMLFrontmatter,
+		"json": decodeJSONFrontmatter,
+	},
+}
+
+// RegisterFrontmatter adds a frontmatter syntax for Markdown documents.
+// A block opened by fence and closed by the same line is decoded with
+// decode, and so is a "---lang" block closed by "---". YAML (---), TOML
+// (+++) and JSON (a leading { ... } object) are built in. Call it from an
+// init function.
+func RegisterFrontmatter(lang, fence string, decode FrontmatterDecoder) {
+	r := &frontmatterRegistry
+	r.Lock()
+	defer r.Unlock()
+
+	lang = strings.ToLower(lang)
+	if fence != "" {
+		r.fences[fence] = lang
+	}
+	r.decoders[lang] = decode
+}
+
+// frontmatter is a metadata block at the start of a Markdown file.
+type frontmatter struct {
+	lang    string
+	content []byte
+	end     int // Offset just past the closing line
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// findFrontmatter locates the frontmatter block, if the source starts
+// with one.
+func findFrontmatter(source []byte) (frontmatter, bool) {
+	start := 0
+	if bytes.HasPrefix(source, utf8BOM) {
+		start = len(utf8BOM)
+	}
+	first, next := lineAt(source, start)
+	if len(first) == 0 {
+		return frontmatter{}, false
+	}
+	if first[0] == '{' {
+		return findJSONFrontmatter(source, start)
+	}
+
+	lang, closing := frontmatterFence(string(first))
+	if lang == "" {
+		return frontmatter{}, false
+	}
+	for off := next; off < len(source); {
+		line, n := lineAt(source, off)
+		if string(line) == closing || (closing == "---" && string(line) == "...") {
+			return frontmatter{lang: lang, content: source[next:off], end: n}, true
+		}
+		off = n
+	}
+	return frontmatter{}, false
+}
+
+// frontmatterFence returns the language and closing line for an opening
+// fence line.
+func frontmatterFence(line string) (lang, closing string) {
+	r := &frontmatterRegistry
+	r.RLock()
+	defer r.RUnlock()
+
+	if lang, ok := r.fences[line]; ok {
+		return lang, line
+	}
+	if name, ok := strings.CutPrefix(line, "---"); ok {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := r.decoders[name]; ok {
+			return name, "---"
+		}
+	}
+	return "", ""
+}
+
+// findJSONFrontmatter reads a JSON object at start. The object must end
+// its line, so prose starting with "{" is left alone.
+func findJSONFrontmatter(source []byte, start int) (frontmatter, bool) {
+	dec := json.NewDecoder(bytes.NewReader(source[start:]))
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return frontmatter{}, false
+	}
+	end := start + int(dec.InputOffset())
+	rest, next := lineAt(source, end)
+	if len(rest) > 0 {
+		return frontmatter{}, false
+	}
+	return frontmatter{lang: "json", content: source[start:end], end: next}, true
+}
+
+// lineAt returns the line starting at off without trailing whitespace,
+// and the offset of the next line.
+func lineAt(source []byte, off int) ([]byte, int) {
+	end := bytes.IndexByte(source[off:], '\n')
+	if end < 0 {
+		return bytes.TrimRight(source[off:], " \t\r"), len(source)
+	}
+	return bytes.TrimRight(source[off:off+end], " \t\r"), off + end + 1
+}
+
+// decode returns the block's metadata.
+func (f frontmatter) decode() (Metadata, error) {
+	frontmatterRegistry.RLock()
+	decode := frontmatterRegistry.decoders[f.lang]
+	frontmatterRegistry.RUnlock()
+
+	data, err := decode(f.content)
+	if err != nil {
+		return nil, fmt.Errorf("%s frontmatter: %w", f.lang, err)
+	}
+	return Metadata(data), nil
+}
+
+// blankFrontmatter returns source with the frontmatter replaced by blank
+// lines, so the Markdown parser skips it and byte offsets stay valid.
+func blankFrontmatter(source []byte, end int) []byte {
+	blanked := append([]byte(nil), source...)
+	for i := 0; i < end; i++ {
+		if blanked[i] != '\n' {
+			blanked[i] = ' '
+		}
+	}
+	return blanked
+}
+
+func decodeTOMLFrontmatter(content []byte) (map[string]interface{}, error) {
+	data, _, err := toml.Decode(content)
+	return data, err
+}
+
+func decodeJSONFrontmatter(content []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decodeYAMLFrontmatter decodes YAML with string keys throughout and
+// timestamps as time.Time, so dates compare as dates.
+func decodeYAMLFrontmatter(content []byte) (map[string]interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	value, err := yamlValue(node.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping, got %s", node.Content[0].ShortTag())
+	}
+	return data, nil
+}
+
+// yamlTimeLayouts are the timestamp forms of the YAML 1.1 timestamp type.
+var yamlTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02t15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func yamlValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			value, err := yamlValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = value
+		}
+		return m, nil
+
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			value, err := yamlValue(c)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!timestamp" {
+			for _, layout := range yamlTimeLayouts {
+				if t, err := time.Parse(layout, n.Value); err == nil {
+					return t, nil
+				}
+			}
+		}
+		var value interface{}
+		if err := n.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+// Lookup returns the value at a path such as "author", "params.series"
+// or "authors[0].email". Negative indexes count from the end.
+func (m Metadata) Lookup(path string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(m)
+	for _, part := range strings.Split(path, ".") {
+		key, indexes, ok := splitIndexes(part)
+		if !ok {
+			return nil, false
+		}
+		if key != "" {
+			if current, ok = metadataKey(current, key); !ok {
+				return nil, false
+			}
+		}
+		for _, i := range indexes {
+			if current, ok = metadataIndex(current, i); !ok {
+				return nil, false
+			}
+		}
+	}
+	return current, true
+}
+
+// splitIndexes splits "authors[0][1]" into "authors" and [0 1].
+func splitIndexes(part string) (string, []int, bool) {
+	key, rest, found := strings.Cut(part, "[")
+	if !found {
+		return part, nil, true
+	}
+	var indexes []int
+	for _, field := range strings.Split(strings.TrimSuffix(rest, "]"), "][") {
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			return "", nil, false
+		}
+		indexes = append(indexes, i)
+	}
+	return key, indexes, true
+}
+
+func metadataKey(value interface{}, key string) (interface{}, bool) {
+	switch m := value.(type) {
+	case Metadata:
+		v, ok := m[key]
+		return v, ok
+	case map[string]interface{}:
+		v, ok := m[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+func metadataIndex(value interface{}, i int) (interface{}, bool) {
+	switch list := value.(type) {
+	case []interface{}:
+		if i < 0 {
+			i += len(list)
+		}
+		if i >= 0 && i < len(list) {
+			return list[i], true
+		}
+	case []string:
+		if i < 0 {
+			i += len(list)
+		}
+		if i >= 0 && i < len(list) {
+			return list[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,114 @@
+package mq_test
+
+import (
+	"testing"
+	"time"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlFrontmatter = `---
+title: Release notes
+date: 2024-03-01
+priority: 2
+draft: false
+tags: [go, y]
+authors:
+  - name: Ann
+    email: ann@example.com
+  - name: Bo
+---
+# Notes
+`
+
+func TestYAMLFrontmatter(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(yamlFrontmatter), "notes.md")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "Release notes", meta["title"])
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), meta["date"])
+	assert.Equal(t, 2, meta["priority"])
+	assert.Equal(t, false, meta["draft"])
+	assert.Equal(t, []string{"go", "y"}, doc.GetTags(), "y stays a string")
+
+	priority, ok := doc.GetPriority()
+	assert.True(t, ok)
+	assert.Equal(t, "2", priority)
+
+	email, ok := doc.GetMetadataField("authors[0].email")
+	assert.True(t, ok)
+	assert.Equal(t, "ann@example.com", email)
+
+	// Lines after the frontmatter keep their numbers
+	headings := doc.GetHeadings()
+	require.Len(t, headings, 1)
+	assert.Equal(t, 12, headings[0].Line)
+}
+
+func TestTOMLFrontmatter(t *testing.T) {
+	src := "+++\ntitle = \"Launch\"\ndate = 2024-03-01\n\n[[authors]]\nname = \"Ann\"\n+++\n\n# Launch\n"
+	doc, err := mq.NewParser().Parse([]byte(src), "launch.md")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "Launch", meta["title"])
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), meta["date"])
+	name, ok := meta.Lookup("authors[0].name")
+	assert.True(t, ok)
+	assert.Equal(t, "Ann", name)
+
+	headings := doc.GetHeadings()
+	require.Len(t, headings, 1)
+	assert.Equal(t, 9, headings[0].Line)
+
+	// An invalid block is skipped and leaves no metadata
+	doc, err = mq.NewParser().Parse([]byte("+++\ntitle = \n+++\n# Draft\n"), "bad.md")
+	require.NoError(t, err)
+	assert.Nil(t, doc.Metadata())
+	assert.Len(t, doc.GetHeadings(), 1)
+}
+
+func TestJSONFrontmatter(t *testing.T) {
+	src := "{\n  \"title\": \"API\",\n  \"weight\": 3,\n  \"params\": {\"series\": [\"a\", \"b\"]}\n}\n\n# API\n"
+	doc, err := mq.NewParser().Parse([]byte(src), "api.md")
+	require.NoError(t, err)
+
+	assert.Equal(t, "API", doc.Metadata()["title"])
+	assert.Equal(t, 3.0, doc.Metadata()["weight"])
+	series, ok := doc.Metadata().Lookup("params.series[-1]")
+	assert.True(t, ok)
+	assert.Equal(t, "b", series)
+	assert.Len(t, doc.GetHeadings(), 1)
+
+	// Prose that merely starts with a brace is not frontmatter
+	doc, err = mq.NewParser().Parse([]byte("{{< hugo-shortcode >}}\n\n# Page\n"), "page.md")
+	require.NoError(t, err)
+	assert.Nil(t, doc.Metadata())
+}
+
+func TestMetadataLookup(t *testing.T) {
+	meta := mq.Metadata{
+		"params": map[string]interface{}{"authors": []interface{}{
+			map[string]interface{}{"name": "Ann"},
+		}},
+	}
+	tests := []struct {
+		path string
+		want interface{}
+		ok   bool
+	}{
+		{"params.authors[0].name", "Ann", true},
+		{"params.authors[-1].name", "Ann", true},
+		{"params.authors[1].name", nil, false},
+		{"params.missing", nil, false},
+		{"params.authors[x]", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := meta.Lookup(tt.path)
+		assert.Equal(t, tt.ok, ok, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+}
@@ -0,0 +1,118 @@
+package mq
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// mergeHTMLBlock parses a raw HTML block with the HTML parser and adds
+// its elements to the document as if they were written in Markdown.
+// Headings open sections, so a <h2> inside <details> nests like "## ".
+func (p *Parser) mergeHTMLBlock(doc *Document, node *ast.HTMLBlock, idx *LineIndex, openSection func(*Heading), addCode func(*CodeBlock)) {
+	lines := node.Lines()
+	if p.html == nil || lines.Len() == 0 {
+		return
+	}
+
+	var fragment bytes.Buffer
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		fragment.Write(line.Value(doc.source))
+	}
+	if node.HasClosure() {
+		fragment.Write(node.ClosureLine.Value(doc.source))
+	}
+
+	sub, err := p.html.Parse(fragment.Bytes(), doc.path)
+	if err != nil {
+		return // Malformed HTML stays opaque
+	}
+
+	// The fragment starts at a line start, so only lines move
+	offset := idx.Line(lines.At(0).Start) - 1
+	for _, s := range sub.GetSections() {
+		h := s.Heading
+		pos := h.Position()
+		pos.shift(offset)
+		h.SetPosition(pos)
+		h.Node = node
+		openSection(h)
+	}
+	for _, cb := range sub.GetCodeBlocks() {
+		cb.shift(offset)
+		cb.Node = node
+		addCode(cb)
+	}
+	for _, t := range sub.GetTables() {
+		t.shift(offset)
+		t.Node = node
+		doc.tables = append(doc.tables, t)
+	}
+	for _, l := range sub.GetLinks() {
+		l.shift(offset)
+		l.Node = node
+		doc.links = append(doc.links, l)
+	}
+	for _, img := range sub.GetImages() {
+		img.shift(offset)
+		img.Node = node
+		doc.images = append(doc.images, img)
+	}
+	for _, l := range sub.GetLists(nil) {
+		l.shift(offset)
+		l.Node = node
+		shiftItems(l.Items, offset)
+		doc.lists = append(doc.lists, l)
+	}
+}
+
+func shiftItems(items []ListItem, n int) {
+	for i := range items {
+		items[i].shift(n)
+		shiftItems(items[i].Children, n)
+	}
+}
+
+// extractInlineCode extracts a code span, positioned over its backticks.
+func extractInlineCode(node *ast.CodeSpan, idx *LineIndex) *InlineCode {
+	var text bytes.Buffer
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if t, ok := child.(*ast.Text); ok {
+			text.Write(t.Segment.Value(idx.source))
+		}
+	}
+	code := &InlineCode{Text: text.String(), Node: node}
+
+	start, stop, ok := nodeSpan(node)
+	if !ok {
+		return code
+	}
+	// Padding spaces ("`` `x` ``") sit between the content and backticks
+	src := idx.source
+	i := start
+	for i > 0 && src[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && src[i-1] == '`' {
+		i--
+		start = i
+	}
+	j := stop
+	for j < len(src) && src[j] == ' ' {
+		j++
+	}
+	for j < len(src) && src[j] == '`' {
+		j++
+		stop = j
+	}
+	code.Position = idx.Position(start, stop)
+	return code
+}
+
+// GetInlineCode returns the backtick code spans in document order.
+func (d *Document) GetInlineCode() []*InlineCode {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.inlineCode
+}
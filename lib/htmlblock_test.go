@@ -0,0 +1,88 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const changelogMarkdown = "# Changelog\n" +
+	"\n" +
+	"Call `mq.New()` or `` `Parse` ``.\n" +
+	"\n" +
+	"    go get example.com/mq\n" +
+	"    mq doc.md .tree\n" +
+	"\n" +
+	"<details>\n" +
+	"<h2>v0.1</h2>\n" +
+	"<table><tr><td>Initial release</td></tr></table>\n" +
+	"</details>\n"
+
+// fragmentParser stands in for the HTML parser: every fragment holds an
+// H2 on its second line and a table on its third.
+type fragmentParser struct{ fragments []string }
+
+func (p *fragmentParser) Parse(content []byte, path string) (*mq.Document, error) {
+	p.fragments = append(p.fragments, string(content))
+	heading := &mq.Heading{Level: 2, Text: "v0.1", Line: 2, Column: 1, EndLine: 2, EndColumn: 13}
+	section := &mq.Section{Heading: heading, Start: 2, End: 3}
+	table := &mq.Table{Rows: [][]string{{"Initial release"}}, Position: mq.Position{Line: 3, Column: 1, EndLine: 3, EndColumn: 48}}
+	return mq.NewDocument(content, path, mq.FormatHTML, "", []*mq.Heading{heading}, []*mq.Section{section},
+		nil, nil, nil, []*mq.Table{table}, nil, ""), nil
+}
+
+func (p *fragmentParser) ParseFile(path string) (*mq.Document, error) { return nil, nil }
+
+func (p *fragmentParser) Format() mq.Format { return mq.FormatHTML }
+
+func TestIndentedCodeBlocks(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(changelogMarkdown), "CHANGELOG.md")
+	require.NoError(t, err)
+
+	code := doc.GetCodeBlocks()
+	require.Len(t, code, 1)
+	assert.True(t, code[0].Indented)
+	assert.Equal(t, "", code[0].Language)
+	assert.Equal(t, "go get example.com/mq\nmq doc.md .tree\n", code[0].Content)
+	assert.Equal(t, "5-6", code[0].Range())
+}
+
+func TestInlineCode(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(changelogMarkdown), "CHANGELOG.md")
+	require.NoError(t, err)
+
+	spans := doc.GetInlineCode()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "mq.New()", spans[0].Text)
+	assert.Equal(t, mq.Position{Line: 3, Column: 6, EndLine: 3, EndColumn: 15}, spans[0].Position)
+	// Padding spaces and double backticks are part of the span
+	assert.Equal(t, "`Parse`", spans[1].Text)
+	assert.Equal(t, mq.Position{Line: 3, Column: 20, EndLine: 3, EndColumn: 32}, spans[1].Position)
+}
+
+func TestHTMLBlocks(t *testing.T) {
+	// Without an HTML parser, HTML blocks are opaque
+	doc, err := mq.NewParser().Parse([]byte(changelogMarkdown), "CHANGELOG.md")
+	require.NoError(t, err)
+	assert.Len(t, doc.GetSections(), 1)
+	assert.Empty(t, doc.GetTables())
+
+	html := &fragmentParser{}
+	doc, err = mq.NewParser(mq.WithHTMLParser(html)).Parse([]byte(changelogMarkdown), "CHANGELOG.md")
+	require.NoError(t, err)
+	require.Len(t, html.fragments, 1)
+	assert.Equal(t, "<details>\n<h2>v0.1</h2>\n<table><tr><td>Initial release</td></tr></table>\n</details>\n", html.fragments[0])
+
+	// Fragment lines are shifted to the Markdown source
+	sections := doc.GetSections()
+	require.Len(t, sections, 2)
+	assert.Equal(t, "v0.1", sections[1].Heading.Text)
+	assert.Equal(t, 9, sections[1].Start)
+	assert.Equal(t, sections[0], sections[1].Parent)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, 10, tables[0].Line)
+}
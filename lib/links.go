@@ -0,0 +1,227 @@
+package mq
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+// WithLinkify turns bare URLs and www. addresses into autolinks in the
+// AST, as GitHub renders them. Bare URLs are reported by GetLinks either
+// way.
+func WithLinkify() ParserOption {
+	return func(p *Parser) {
+		extension.Linkify.Extend(p.md)
+	}
+}
+
+// inlineText returns the text of an inline container with emphasis, code
+// spans and nested links flattened.
+func inlineText(n ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			buf.Write(c.Segment.Value(source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(c.Value)
+		case *ast.AutoLink:
+			buf.Write(c.Label(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+// linkKind tells inline links from references by what follows the link
+// text, and returns the reference label.
+func linkKind(n *ast.Link, idx *LineIndex) (kind, label string) {
+	start, stop, ok := inlineSpan(n, idx)
+	src := idx.source
+	if !ok || src[stop-1] == ')' {
+		return LinkInline, ""
+	}
+	text := closingDelim(src, start, '[', ']')
+	if text < stop && src[text] == '[' && stop-text > 2 {
+		return LinkReference, string(src[text+1 : stop-1]) // [text][label]
+	}
+	return LinkReference, string(src[start+1 : text-1]) // [label][] or [label]
+}
+
+// extractAutoLink extracts an autolink. Goldmark hides its source
+// offset, so the label is looked for after the previous sibling.
+func extractAutoLink(n *ast.AutoLink, idx *LineIndex) *Link {
+	src := idx.source
+	label := n.Label(src)
+	link := &Link{
+		Text: string(label),
+		URL:  string(n.URL(src)),
+		Kind: LinkBare,
+		Node: n,
+	}
+
+	from := 0
+	if stop, ok := precedingStop(n, idx); ok {
+		from = stop
+	}
+	i := bytes.Index(src[from:], label)
+	if i < 0 {
+		return link
+	}
+	start, stop := from+i, from+i+len(label)
+	if start > 0 && src[start-1] == '<' && stop < len(src) && src[stop] == '>' {
+		link.Kind = LinkAutolink
+		start, stop = start-1, stop+1
+	}
+	link.Position = idx.Position(start, stop)
+	return link
+}
+
+// precedingStop returns where the nearest inline before n ends, or where
+// its block starts when n comes first.
+func precedingStop(n ast.Node, idx *LineIndex) (int, bool) {
+	for c := n; c != nil; c = c.Parent() {
+		if prev := c.PreviousSibling(); prev != nil && prev.Type() == ast.TypeInline {
+			var stop int
+			var ok bool
+			switch prev.(type) {
+			case *ast.Link, *ast.Image:
+				_, stop, ok = inlineSpan(prev, idx) // Include the destination
+			default:
+				_, stop, ok = nodeSpan(prev)
+			}
+			return stop, ok
+		}
+		if c.Type() == ast.TypeBlock {
+			if lines := c.Lines(); lines.Len() > 0 {
+				return lines.At(0).Start, true
+			}
+			break
+		}
+	}
+	return 0, false
+}
+
+// bareURLRe matches URLs in plain text. Trailing punctuation is trimmed
+// separately.
+var bareURLRe = regexp.MustCompile(`https?://[^\s<>"'\x60\[\]]+`)
+
+// bareLinks finds URLs in the run of plain text starting at t. Runs are
+// scanned once, from their first node, and text inside links, images and
+// code is skipped.
+func bareLinks(t *ast.Text, idx *LineIndex) []*Link {
+	if prev, ok := t.PreviousSibling().(*ast.Text); ok && prev.Segment.Stop == t.Segment.Start {
+		return nil
+	}
+	for p := t.Parent(); p != nil; p = p.Parent() {
+		switch p.(type) {
+		case *ast.Link, *ast.Image, *ast.CodeSpan, *ast.AutoLink, *wikiLinkNode:
+			return nil
+		}
+	}
+
+	start, stop := t.Segment.Start, t.Segment.Stop
+	for next, ok := t.NextSibling().(*ast.Text); ok && next.Segment.Start == stop; next, ok = next.NextSibling().(*ast.Text) {
+		stop = next.Segment.Stop
+	}
+
+	var links []*Link
+	for _, m := range bareURLRe.FindAllIndex(idx.source[start:stop], -1) {
+		url := trimURL(string(idx.source[start+m[0] : start+m[1]]))
+		links = append(links, &Link{
+			Text:     url,
+			URL:      url,
+			Kind:     LinkBare,
+			Node:     t,
+			Position: idx.Position(start+m[0], start+m[0]+len(url)),
+		})
+	}
+	return links
+}
+
+// trimURL drops sentence punctuation after a URL, and a closing
+// parenthesis the URL did not open.
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_~", last) >= 0:
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
+
+// linkDefinitionRe matches the start of a link reference definition.
+var linkDefinitionRe = regexp.MustCompile(`(?m)^ {0,3}\[((?:[^\]\\]|\\.)+)\]:`)
+
+// extractReferences returns the link reference definitions goldmark
+// collected while parsing, in source order.
+func extractReferences(pc parser.Context, doc *Document) []*Link {
+	source := doc.source
+	refs := make(map[string]parser.Reference)
+	for _, ref := range pc.References() {
+		refs[normalizeLabel(string(ref.Label()))] = ref
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	idx := NewLineIndex(source)
+	var links []*Link
+	seen := make(map[string]bool)
+	for _, m := range linkDefinitionRe.FindAllSubmatchIndex(source, -1) {
+		key := normalizeLabel(string(source[m[2]:m[3]]))
+		line := idx.Line(m[0])
+		ref, ok := refs[key]
+		if !ok || seen[key] || inCode(doc.codeBlocks, line) {
+			continue // Shadowed by an earlier definition, or not one at all
+		}
+		seen[key] = true
+		links = append(links, &Link{
+			URL:      string(ref.Destination()),
+			Title:    string(ref.Title()),
+			Label:    string(source[m[2]:m[3]]),
+			Kind:     LinkDefinition,
+			Position: idx.LinePosition(line, line),
+		})
+	}
+	return links
+}
+
+func inCode(blocks []*CodeBlock, line int) bool {
+	for _, cb := range blocks {
+		if line >= cb.Line && line <= cb.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLabel matches reference labels case-insensitively, ignoring
+// runs of whitespace.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// GetReferences returns the link reference definitions ("[id]: url")
+// in source order. Links that use them are reported by GetLinks with
+// Kind LinkReference.
+func (d *Document) GetReferences() []*Link {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.references
+}
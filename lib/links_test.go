@@ -0,0 +1,77 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const linksMarkdown = "# Links\n" +
+	"\n" +
+	"Read [the *fancy* `docs`](https://a.dev/docs \"Docs\"), [guide][g] or [Guide][].\n" +
+	"Plain https://b.dev/path, <https://c.dev> and (https://d.dev/x).\n" +
+	"\n" +
+	"```\n" +
+	"[g]: https://not.a/definition\n" +
+	"```\n" +
+	"\n" +
+	"[g]: https://g.dev \"Guide\"\n" +
+	"[guide]: https://guide.dev\n"
+
+func TestLinkKinds(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(linksMarkdown), "links.md")
+	require.NoError(t, err)
+
+	type link struct {
+		Kind, Text, URL, Label string
+		Column                 int
+	}
+	var got []link
+	for _, l := range doc.GetLinks() {
+		got = append(got, link{l.Kind, l.Text, l.URL, l.Label, l.Column})
+	}
+	assert.Equal(t, []link{
+		{mq.LinkInline, "the fancy docs", "https://a.dev/docs", "", 6},
+		{mq.LinkReference, "guide", "https://g.dev", "g", 55},
+		{mq.LinkReference, "Guide", "https://guide.dev", "Guide", 69},
+		{mq.LinkBare, "https://b.dev/path", "https://b.dev/path", "", 7},
+		{mq.LinkAutolink, "https://c.dev", "https://c.dev", "", 27},
+		{mq.LinkBare, "https://d.dev/x", "https://d.dev/x", "", 48},
+	}, got)
+
+	links := doc.GetLinks()
+	assert.Equal(t, "Docs", links[0].Title)
+	assert.Equal(t, mq.Position{Line: 4, Column: 27, EndLine: 4, EndColumn: 41}, links[4].Position)
+
+	// Definitions inside code blocks are not definitions
+	refs := doc.GetReferences()
+	require.Len(t, refs, 2)
+	assert.Equal(t, "g", refs[0].Label)
+	assert.Equal(t, "https://g.dev", refs[0].URL)
+	assert.Equal(t, "Guide", refs[0].Title)
+	assert.Equal(t, 10, refs[0].Line)
+	assert.Equal(t, mq.LinkDefinition, refs[1].Kind)
+	assert.Equal(t, 11, refs[1].Line)
+}
+
+func TestLinkify(t *testing.T) {
+	source := []byte("Visit https://b.dev/path or www.example.com.\n")
+
+	// Bare URLs are links with or without Linkify
+	doc, err := mq.NewParser().Parse(source, "a.md")
+	require.NoError(t, err)
+	require.Len(t, doc.GetLinks(), 1)
+	assert.Equal(t, mq.LinkBare, doc.GetLinks()[0].Kind)
+
+	doc, err = mq.NewParser(mq.WithLinkify()).Parse(source, "a.md")
+	require.NoError(t, err)
+	links := doc.GetLinks()
+	require.Len(t, links, 2)
+	assert.Equal(t, "https://b.dev/path", links[0].URL)
+	assert.Equal(t, mq.Position{Line: 1, Column: 7, EndLine: 1, EndColumn: 24}, links[0].Position)
+	assert.Equal(t, "http://www.example.com", links[1].URL)
+	assert.Equal(t, mq.LinkBare, links[1].Kind)
+	assert.Equal(t, 29, links[1].Column)
+}
@@ -0,0 +1,608 @@
+package mq
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// Component is a JSX component in an MDX document, e.g. <Callout type="warn">.
+type Component struct {
+	Name     string            // Component name, e.g. "Callout" or "Tabs.Item"
+	Props    map[string]string // Attribute values; expressions keep their braces, bare attributes are "true"
+	Content  string            // Inner markdown, dedented; empty for self-closing tags
+	Start    int               // Line of the opening tag
+	End      int               // Line of the closing tag
+	Parent   *Component        // Enclosing component, if nested
+	Children []*Component      // Directly nested components
+}
+
+// SetComponents attaches JSX components, in document order.
+func (d *Document) SetComponents(components []*Component) {
+	d.components = components
+}
+
+// GetComponents returns JSX components in document order, optionally
+// filtered by name.
+func (d *Document) GetComponents(names ...string) []*Component {
+	if len(names) == 0 {
+		return d.components
+	}
+	var result []*Component
+	for _, c := range d.components {
+		for _, name := range names {
+			if c.Name == name {
+				result = append(result, c)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// MDXParser parses MDX: Markdown with JSX components and ESM
+// import/export statements.
+//
+// Import/export statements and component tags are blanked out before the
+// Markdown pass, keeping every line in place, so markdown inside
+// components (headings included) joins the section tree and line numbers
+// refer to the original file. Indented code blocks are disabled, as in
+// MDX itself, since component content is usually indented. Heading IDs
+// follow Docusaurus.
+type MDXParser struct {
+	md    goldmark.Markdown
+	slugs SlugStyle
+}
+
+// NewMDXParser creates an MDX parser.
+func NewMDXParser() *MDXParser {
+	// The default block parsers minus indented code
+	var blocks []util.PrioritizedValue
+	indented := reflect.TypeOf(parser.NewCodeBlockParser())
+	for _, v := range parser.DefaultBlockParsers() {
+		if reflect.TypeOf(v.Value) != indented {
+			blocks = append(blocks, v)
+		}
+	}
+
+	md := goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(blocks...),
+			parser.WithInlineParsers(parser.DefaultInlineParsers()...),
+			parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+		)),
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.TaskList,
+			extension.Strikethrough,
+			extension.Footnote,
+			extension.DefinitionList,
+			blockExtension{},
+			wikiLinkExtension{},
+		),
+		goldmark.WithParserOptions(
+			parser.WithHeadingAttribute(),
+		),
+	)
+	return &MDXParser{md: md, slugs: SlugDocusaurus}
+}
+
+// Format implements FormatParser.
+func (p *MDXParser) Format() Format {
+	return FormatMDX
+}
+
+// ParseFile reads and parses an MDX file.
+func (p *MDXParser) ParseFile(path string) (*Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ParseError{Format: FormatMDX, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses MDX content.
+func (p *MDXParser) Parse(source []byte, path string) (*Document, error) {
+	stripped, cut, components, imports := stripMDX(source)
+
+	doc, err := (&Parser{md: p.md, slugs: p.slugs}).Parse(stripped, path)
+	if err != nil {
+		return nil, &ParseError{Format: FormatMDX, Path: path, Err: err}
+	}
+
+	// Line numbers are unchanged, so sections can show the original
+	// text. The AST keeps referring to the stripped text.
+	doc.format = FormatMDX
+	doc.source = source
+	for _, s := range doc.sections {
+		s.source = source
+	}
+	if doc.preamble != nil {
+		doc.preamble.source = source
+	}
+	doc.components = components
+	shiftColumns(doc, cut)
+	if len(imports) > 0 {
+		if doc.metadata == nil {
+			doc.metadata = Metadata{}
+		}
+		doc.metadata["imports"] = imports
+	}
+	return doc, nil
+}
+
+var (
+	// componentTagRe matches the start of a component tag: <Name or </Name.
+	// Lowercase tags are HTML and left to the Markdown parser.
+	componentTagRe = regexp.MustCompile(`^<(/?)([A-Z][\w]*(?:\.[\w]+)*)`)
+
+	// importFromRe finds the module of an import statement.
+	importFromRe = regexp.MustCompile(`(?:from\s+|^import\s+)["']([^"']+)["']`)
+)
+
+// mdxScanner blanks MDX syntax out of a source, recording components.
+type mdxScanner struct {
+	src   []byte
+	out   []byte      // src with MDX syntax blanked
+	lines []int       // byte offset of each line start
+	cut   map[int]int // indentation removed, by line index
+
+	stack      []*mdxOpen
+	components []*Component
+	imports    []string
+}
+
+// mdxOpen is a component whose closing tag has not been seen.
+type mdxOpen struct {
+	component    *Component
+	contentStart int // byte offset just past the opening tag
+	indent       int // indentation of the first content line, -1 until seen
+}
+
+// stripMDX returns source with ESM statements, JSX expression lines and
+// component tags replaced by spaces, and content inside components
+// dedented, without changing the number of lines. cut holds the
+// indentation removed from each line, by line index.
+func stripMDX(source []byte) (stripped []byte, cut map[int]int, components []*Component, imports []string) {
+	s := &mdxScanner{
+		src: source,
+		out: append([]byte(nil), source...),
+		cut: make(map[int]int),
+	}
+	s.lines = computeLineStarts(source)
+
+	var fence string // open fence marker, if inside fenced code
+	inESM := false
+	for i := s.skipFrontmatter(); i < len(s.lines); i++ {
+		start, end := s.lineBounds(i)
+		line := string(source[start:end])
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			s.dedent(start, end)
+			continue
+		}
+		if f := fenceMarker(trimmed); f != "" {
+			fence = f
+			s.dedent(start, end)
+			continue
+		}
+
+		// ESM runs from an import/export line to the next blank line
+		if inESM && strings.HasPrefix(trimmed, "#") {
+			inESM = false
+		}
+		if inESM || (len(s.stack) == 0 && (strings.HasPrefix(line, "import ") || strings.HasPrefix(line, "export "))) {
+			inESM = trimmed != ""
+			if m := importFromRe.FindStringSubmatch(trimmed); m != nil {
+				s.imports = append(s.imports, m[1])
+			}
+			s.blank(start, end)
+			continue
+		}
+
+		// {expression} and {/* comment */} lines
+		if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+			s.blank(start, end)
+			continue
+		}
+
+		if componentTagRe.MatchString(trimmed) {
+			i = s.tags(start+strings.Index(line, "<"), i+1) - 1
+			continue
+		}
+		s.closingAtEnd(start, end, i+1)
+		s.dedent(start, end)
+	}
+
+	// Unclosed components run to the end of the document
+	for len(s.stack) > 0 {
+		s.close(len(source), len(s.lines))
+	}
+	var out bytes.Buffer
+	for i := range s.lines {
+		start, end := s.lineBounds(i)
+		out.Write(s.out[start+s.cut[i] : end])
+		if end < len(s.src) {
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), s.cut, s.components, s.imports
+}
+
+// shiftColumns maps element columns in the dedented text back to the
+// original source.
+func shiftColumns(doc *Document, cut map[int]int) {
+	shift := func(p *Position) {
+		if p.Line > 0 {
+			p.Column += cut[p.Line-1]
+			p.EndColumn += cut[p.End()-1]
+		}
+	}
+	var shiftItems func(items []ListItem)
+	shiftItems = func(items []ListItem) {
+		for i := range items {
+			shift(&items[i].Position)
+			shiftItems(items[i].Children)
+		}
+	}
+
+	for _, hs := range doc.headingsByLevel {
+		for _, h := range hs {
+			pos := h.Position()
+			shift(&pos)
+			h.SetPosition(pos)
+		}
+	}
+	for _, cb := range doc.codeBlocks {
+		shift(&cb.Position)
+	}
+	for _, l := range doc.links {
+		shift(&l.Position)
+	}
+	for _, img := range doc.images {
+		shift(&img.Position)
+	}
+	for _, t := range doc.tables {
+		shift(&t.Position)
+	}
+	for _, l := range doc.lists {
+		shift(&l.Position)
+		shiftItems(l.Items)
+	}
+}
+
+// skipFrontmatter returns the index of the first line after the
+// frontmatter, which the Markdown pass handles.
+func (s *mdxScanner) skipFrontmatter() int {
+	fm, ok := findFrontmatter(s.src)
+	if !ok {
+		return 0
+	}
+	i, _ := slices.BinarySearch(s.lines, fm.end)
+	return i
+}
+
+// lineBounds returns the byte range of line index i, without its newline.
+func (s *mdxScanner) lineBounds(i int) (int, int) {
+	end := len(s.src)
+	if i+1 < len(s.lines) {
+		end = s.lines[i+1] - 1
+	}
+	return s.lines[i], end
+}
+
+// fenceMarker returns the fence of a fenced code opening line, or "".
+func fenceMarker(trimmed string) string {
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, f) {
+			n := len(trimmed) - len(strings.TrimLeft(trimmed, f[:1]))
+			return strings.Repeat(f[:1], n)
+		}
+	}
+	return ""
+}
+
+// tags consumes component tags at pos on line lineNum, which may span
+// lines, and any content following them on the last line. It returns the
+// last line consumed.
+func (s *mdxScanner) tags(pos, lineNum int) int {
+	_, lineEnd := s.lineBounds(lineNum - 1)
+	for pos < len(s.src) {
+		m := componentTagRe.FindSubmatch(s.src[pos:])
+		if m == nil {
+			break
+		}
+		closing, name := len(m[1]) > 0, string(m[2])
+
+		tagEnd := scanTagEnd(s.src, pos+len(m[0]))
+		attrs := string(s.src[pos+len(m[0]) : tagEnd])
+		selfClosing := strings.HasSuffix(strings.TrimSpace(attrs), "/")
+		s.blank(pos, tagEnd+1)
+
+		// The tag may end on a later line
+		for lineNum < len(s.lines) && s.lines[lineNum] <= tagEnd {
+			lineNum++
+		}
+		_, lineEnd = s.lineBounds(lineNum - 1)
+
+		switch {
+		case closing:
+			s.closeNamed(name, pos, lineNum)
+		default:
+			c := &Component{
+				Name:  name,
+				Props: parseProps(strings.TrimSuffix(strings.TrimSpace(attrs), "/")),
+				Start: s.lineOf(pos),
+				End:   lineNum,
+			}
+			if len(s.stack) > 0 {
+				parent := s.stack[len(s.stack)-1].component
+				c.Parent = parent
+				parent.Children = append(parent.Children, c)
+			}
+			s.components = append(s.components, c)
+			if !selfClosing {
+				s.stack = append(s.stack, &mdxOpen{component: c, contentStart: tagEnd + 1, indent: -1})
+			}
+		}
+
+		pos = tagEnd + 1
+		for pos < lineEnd && (s.src[pos] == ' ' || s.src[pos] == '\t') {
+			pos++
+		}
+		if pos >= lineEnd {
+			return lineNum
+		}
+		if !componentTagRe.Match(s.src[pos:]) {
+			break
+		}
+	}
+
+	// Inline content after the tag, possibly with the closing tag
+	s.closingAtEnd(pos, lineEnd, lineNum)
+	s.dedent(s.lines[lineNum-1], lineEnd)
+	return lineNum
+}
+
+// closingAtEnd handles "text</Name>" lines, where content and the
+// closing tag of the innermost component share a line.
+func (s *mdxScanner) closingAtEnd(start, end, lineNum int) {
+	if len(s.stack) == 0 {
+		return
+	}
+	name := s.stack[len(s.stack)-1].component.Name
+	line := strings.TrimRight(string(s.src[start:end]), " \t")
+	closing := "</" + name + ">"
+	if !strings.HasSuffix(line, closing) {
+		return
+	}
+	pos := start + len(line) - len(closing)
+	s.blank(pos, pos+len(closing))
+	s.close(pos, lineNum)
+}
+
+// closeNamed closes the innermost open component called name, closing
+// any unclosed components nested inside it. Stray closing tags are
+// ignored.
+func (s *mdxScanner) closeNamed(name string, pos, lineNum int) {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		if s.stack[i].component.Name == name {
+			for len(s.stack) > i {
+				s.close(pos, lineNum)
+			}
+			return
+		}
+	}
+}
+
+// close ends the innermost open component at byte offset pos.
+func (s *mdxScanner) close(pos, lineNum int) {
+	open := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	open.component.End = lineNum
+	if open.contentStart < pos {
+		open.component.Content = dedentBlock(string(s.src[open.contentStart:pos]))
+	}
+}
+
+// dedent moves a content line left by the indentation of the innermost
+// component's first content line.
+func (s *mdxScanner) dedent(start, end int) {
+	if len(s.stack) == 0 {
+		return
+	}
+	open := s.stack[len(s.stack)-1]
+	line := s.out[start:end]
+	indent := len(line) - len(bytes.TrimLeft(line, " \t"))
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	if open.indent < 0 {
+		open.indent = indent
+	}
+	s.cut[s.lineOf(start)-1] = min(indent, open.indent)
+}
+
+// blank replaces out[start:end] with spaces, keeping newlines.
+func (s *mdxScanner) blank(start, end int) {
+	for i := start; i < end && i < len(s.out); i++ {
+		if s.out[i] != '\n' {
+			s.out[i] = ' '
+		}
+	}
+}
+
+// lineOf returns the 1-based line of a byte offset.
+func (s *mdxScanner) lineOf(pos int) int {
+	return getLineNumber(s.lines, pos)
+}
+
+// scanTagEnd returns the offset of the '>' ending a tag whose attributes
+// start at pos, skipping quoted strings and {expressions}.
+func scanTagEnd(src []byte, pos int) int {
+	depth := 0
+	var quote byte
+	for i := pos; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || (c == '`' && depth > 0):
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '>' && depth == 0:
+			return i
+		}
+	}
+	return len(src) - 1
+}
+
+// parseProps parses JSX attributes: name="value", name='value',
+// name={expression} and bare names.
+func parseProps(attrs string) map[string]string {
+	props := make(map[string]string)
+	i := 0
+	for i < len(attrs) {
+		for i < len(attrs) && strings.ContainsRune(" \t\r\n", rune(attrs[i])) {
+			i++
+		}
+		start := i
+		for i < len(attrs) && !strings.ContainsRune(" \t\r\n={", rune(attrs[i])) {
+			i++
+		}
+		name := attrs[start:i]
+		if i < len(attrs) && attrs[i] == '{' && name == "" {
+			// {...spread}
+			end := matchBrace(attrs, i)
+			props[attrs[i:end]] = attrs[i:end]
+			i = end
+			continue
+		}
+		if name == "" {
+			if i < len(attrs) {
+				i++
+			}
+			continue
+		}
+		if i >= len(attrs) || attrs[i] != '=' {
+			props[name] = "true"
+			continue
+		}
+		i++ // '='
+		if i >= len(attrs) {
+			props[name] = ""
+			break
+		}
+		switch attrs[i] {
+		case '"', '\'':
+			q := attrs[i]
+			end := strings.IndexByte(attrs[i+1:], q)
+			if end < 0 {
+				props[name] = attrs[i+1:]
+				return props
+			}
+			props[name] = attrs[i+1 : i+1+end]
+			i += end + 2
+		case '{':
+			end := matchBrace(attrs, i)
+			props[name] = attrs[i:end]
+			i = end
+		default:
+			start := i
+			for i < len(attrs) && !strings.ContainsRune(" \t\r\n", rune(attrs[i])) {
+				i++
+			}
+			props[name] = attrs[start:i]
+		}
+	}
+	return props
+}
+
+// matchBrace returns the offset just past the brace matching s[open].
+func matchBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// dedentBlock trims blank edge lines and removes common indentation.
+func dedentBlock(text string) string {
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= common && common > 0 {
+			lines[i] = line[common:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String renders the opening tag, e.g. <Callout type="warn">.
+func (c *Component) String() string {
+	var buf strings.Builder
+	buf.WriteString("<" + c.Name)
+	for _, k := range slices.Sorted(maps.Keys(c.Props)) {
+		v := c.Props[k]
+		switch {
+		case strings.HasPrefix(v, "{"):
+			if k == v {
+				fmt.Fprintf(&buf, " %s", v)
+			} else {
+				fmt.Fprintf(&buf, " %s=%s", k, v)
+			}
+		case v == "true":
+			fmt.Fprintf(&buf, " %s", k)
+		default:
+			fmt.Fprintf(&buf, " %s=%q", k, v)
+		}
+	}
+	buf.WriteString(">")
+	return buf.String()
+}
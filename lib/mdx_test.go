@@ -0,0 +1,118 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const guideMDX = `---
+title: Install guide
+---
+import { Tabs, Tab } from '@theme/Tabs'
+import Callout from '../components/Callout'
+export const meta = {
+  draft: false,
+}
+
+# Install
+
+{/* reviewers: keep this short */}
+
+<Callout type="warn" dismissible>
+  Back up your config first.
+</Callout>
+
+<Tabs defaultValue="mac">
+  <Tab value="mac" label="macOS">
+    ## Homebrew
+
+    ` + "```bash" + `
+    brew install mq
+    ` + "```" + `
+  </Tab>
+  <Tab
+    value="linux"
+    label={"Linux"}
+  >
+    ## From source
+  </Tab>
+</Tabs>
+
+<Badge text="new" />
+
+## Usage
+
+<Callout>Inline callout text</Callout>
+`
+
+func TestMDXParser(t *testing.T) {
+	doc, err := mq.NewMDXParser().Parse([]byte(guideMDX), "guide.mdx")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatMDX, doc.Format())
+	assert.Equal(t, "Install guide", doc.Metadata()["title"])
+	assert.Equal(t, []string{"@theme/Tabs", "../components/Callout"}, doc.Metadata()["imports"])
+
+	// Headings inside components join the section tree
+	install, ok := doc.GetSection("Install")
+	require.True(t, ok)
+	var children []string
+	for _, c := range install.Children {
+		children = append(children, c.Heading.Text)
+	}
+	assert.Equal(t, []string{"Homebrew", "From source", "Usage"}, children)
+
+	homebrew, ok := doc.GetSection("Homebrew")
+	require.True(t, ok)
+	assert.Equal(t, 20, homebrew.Start)
+	assert.Contains(t, homebrew.GetText(), "<Tab") // original source
+
+	// Indented fences inside components are still code
+	code := doc.GetCodeBlocks("bash")
+	require.Len(t, code, 1)
+	assert.Equal(t, "brew install mq\n", code[0].Content)
+	assert.Empty(t, doc.GetCodeBlocks(""))
+}
+
+func TestMDXComponents(t *testing.T) {
+	doc, err := mq.NewMDXParser().Parse([]byte(guideMDX), "guide.mdx")
+	require.NoError(t, err)
+
+	var names []string
+	for _, c := range doc.GetComponents() {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"Callout", "Tabs", "Tab", "Tab", "Badge", "Callout"}, names)
+
+	callouts := doc.GetComponents("Callout")
+	require.Len(t, callouts, 2)
+	assert.Equal(t, map[string]string{"type": "warn", "dismissible": "true"}, callouts[0].Props)
+	assert.Equal(t, "Back up your config first.", callouts[0].Content)
+	assert.Equal(t, 14, callouts[0].Start)
+	assert.Equal(t, 16, callouts[0].End)
+	assert.Equal(t, "Inline callout text", callouts[1].Content)
+	assert.Equal(t, 38, callouts[1].Start)
+
+	tabs := doc.GetComponents("Tabs")[0]
+	require.Len(t, tabs.Children, 2)
+	assert.Equal(t, "## Homebrew\n\n```bash\nbrew install mq\n```", tabs.Children[0].Content)
+	assert.Equal(t, `{"Linux"}`, tabs.Children[1].Props["label"])
+	assert.Equal(t, tabs, tabs.Children[1].Parent)
+
+	badge := doc.GetComponents("Badge")[0]
+	assert.Equal(t, "", badge.Content)
+	assert.Equal(t, badge.Start, badge.End)
+}
+
+func TestMDXDetection(t *testing.T) {
+	assert.Equal(t, mq.FormatMDX, mq.DetectFormat("docs/guide.mdx", nil))
+
+	engine := mq.NewMultiFormatEngine()
+	doc, err := engine.Parse([]byte(guideMDX), "guide.mdx")
+	require.NoError(t, err)
+	assert.Equal(t, mq.FormatMDX, doc.Format())
+	assert.Len(t, doc.GetComponents(), 6)
+}
@@ -2,7 +2,8 @@ package mq
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 )
 
 // MultiFormatEngine is an engine that automatically detects and parses
@@ -25,6 +26,9 @@ type MultiFormatEngine struct {
 
 	// Default parser for unknown formats
 	defaultFormat Format
+
+	// Heading ID style for Markdown and MDX, if set
+	slugs SlugStyle
 }
 
 // MultiEngineOption configures the multi-format engine.
@@ -34,26 +38,58 @@ type MultiEngineOption func(*MultiFormatEngine)
 //
 // By default, this registers:
 //   - Markdown parser (default for unknown formats)
-//   - HTML parser (with Readability enabled)
-//   - PDF parser (with structure inference)
+//   - Parsers added with RegisterDefaultParser
 //
-// To register custom parsers or override defaults, use WithParser option.
+// To register custom parsers or override defaults, use WithFormatParser option.
 func NewMultiFormatEngine(opts ...MultiEngineOption) *MultiFormatEngine {
 	e := &MultiFormatEngine{
 		registry:      NewParserRegistry(),
 		defaultFormat: FormatMarkdown,
 	}
 
-	// Register default Markdown parser
+	// Register default Markdown and MDX parsers
 	e.registry.Register(&markdownParserAdapter{parser: NewParser()})
+	e.registry.Register(NewMDXParser())
+	for _, p := range defaultParsers() {
+		e.registry.Register(p)
+	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
 
+	// Raw HTML blocks in Markdown go through the HTML parser, if any
+	if html, ok := e.registry.Get(FormatHTML); ok {
+		if md, ok := e.registry.Get(FormatMarkdown); ok {
+			if a, ok := md.(*markdownParserAdapter); ok {
+				WithHTMLParser(html)(a.parser)
+			}
+		}
+	}
+
+	if e.slugs != "" {
+		if md, ok := e.registry.Get(FormatMarkdown); ok {
+			if a, ok := md.(*markdownParserAdapter); ok {
+				WithSlugStyle(e.slugs)(a.parser)
+			}
+		}
+		if mdx, ok := e.registry.Get(FormatMDX); ok {
+			if p, ok := mdx.(*MDXParser); ok {
+				p.slugs = e.slugs
+			}
+		}
+	}
+
 	return e
 }
 
+// WithSlugs sets how the Markdown and MDX parsers generate heading IDs.
+func WithSlugs(style SlugStyle) MultiEngineOption {
+	return func(e *MultiFormatEngine) {
+		e.slugs = style
+	}
+}
+
 // WithFormatParser registers a custom parser for a format.
 func WithFormatParser(p FormatParser) MultiEngineOption {
 	return func(e *MultiFormatEngine) {
@@ -70,8 +106,11 @@ func WithDefaultFormat(f Format) MultiEngineOption {
 
 // Load reads a file and parses it using the appropriate parser.
 // Format is auto-detected from the file extension.
+//
+// Compressed files (.gz, .zst, .bz2) are decompressed transparently, and
+// paths inside archives (docs.zip/guide/intro.md) are read from the archive.
 func (e *MultiFormatEngine) Load(path string) (*Document, error) {
-	content, err := os.ReadFile(path)
+	content, err := readFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -80,9 +119,20 @@ func (e *MultiFormatEngine) Load(path string) (*Document, error) {
 }
 
 // Parse parses content using the appropriate parser.
-// Format is auto-detected from path extension and content.
+// Format is auto-detected from path extension and content; compressed
+// content is detected by the extension inside the compression suffix.
+// Only paths with a compression suffix, or unnamed input, are
+// decompressed.
 func (e *MultiFormatEngine) Parse(content []byte, path string) (*Document, error) {
-	format := DetectFormat(path, content)
+	if !isManPage(path) {
+		inflated, err := inflate(content, path)
+		if err != nil {
+			return nil, err
+		}
+		content = inflated
+	}
+
+	format := DetectFormat(stripCompression(path), content)
 
 	parser, ok := e.registry.Get(format)
 	if !ok {
@@ -96,16 +146,59 @@ func (e *MultiFormatEngine) Parse(content []byte, path string) (*Document, error
 	return parser.Parse(content, path)
 }
 
+// LoadWithFormat reads a file and parses it with a specific parser,
+// skipping format detection.
+func (e *MultiFormatEngine) LoadWithFormat(path string, format Format) (*Document, error) {
+	content, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	return e.ParseWithFormat(content, path, format)
+}
+
 // ParseWithFormat parses content using a specific parser.
+// Compressed content is decompressed first, except for man pages, whose
+// parser reads gzip itself.
 func (e *MultiFormatEngine) ParseWithFormat(content []byte, path string, format Format) (*Document, error) {
 	parser, ok := e.registry.Get(format)
 	if !ok {
 		return nil, fmt.Errorf("no parser registered for format: %s", format)
 	}
 
+	if format != FormatMan {
+		inflated, err := inflate(content, path)
+		if err != nil {
+			return nil, err
+		}
+		content = inflated
+	}
+
 	return parser.Parse(content, path)
 }
 
+// inflate decompresses content whose path has a compression suffix
+// (notes.md.gz). Unnamed input, such as stdin without --name, has no
+// extension to go by: it is decompressed if it starts with a compression
+// magic number, and kept as is if that fails. Other content is left
+// alone, so a Markdown file starting with "BZh9" stays text.
+func inflate(content []byte, path string) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, compressed := compressionExtensions[ext]; compressed && !IsArchive(path) {
+		inflated, err := decompress(content)
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s: %w", path, err)
+		}
+		return inflated, nil
+	}
+	if ext == "" && isCompressed(content) {
+		if inflated, err := decompress(content); err == nil {
+			return inflated, nil
+		}
+	}
+	return content, nil
+}
+
 // RegisterParser adds a parser for a format.
 func (e *MultiFormatEngine) RegisterParser(p FormatParser) {
 	e.registry.Register(p)
@@ -1,9 +1,13 @@
 package mq_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/muqsitnawaz/mq/html"
 	mq "github.com/muqsitnawaz/mq/lib"
 	"github.com/muqsitnawaz/mq/pdf"
@@ -68,14 +72,48 @@ func TestDetectFormat(t *testing.T) {
 		{"html .htm", "page.htm", nil, mq.FormatHTML},
 		{"html .xhtml", "page.xhtml", nil, mq.FormatHTML},
 		{"pdf .pdf", "doc.pdf", nil, mq.FormatPDF},
+		{"toml .toml", "Cargo.toml", nil, mq.FormatTOML},
+		{"xml .xml", "config.xml", nil, mq.FormatXML},
+		{"xml .pom", "project.pom", nil, mq.FormatXML},
+		{"xml .rss", "feed.rss", nil, mq.FormatXML},
+		{"docx .docx", "spec.docx", nil, mq.FormatDOCX},
+		{"epub .epub", "book.epub", nil, mq.FormatEPUB},
+		{"go .go", "engine.go", nil, mq.FormatGo},
+		{"latex .tex", "thesis.tex", nil, mq.FormatLaTeX},
+		{"email .eml", "notice.eml", nil, mq.FormatEmail},
+		{"email .mbox", "incident.mbox", nil, mq.FormatEmail},
+		{"man .1", "man/tar.1", nil, mq.FormatMan},
+		{"man .3p", "share/man/man3/printf.3p", nil, mq.FormatMan},
+		{"man .1 outside man dir", "tar.1", []byte(".TH TAR 1\n"), mq.FormatMan},
+		{"versioned library", "lib/libx.so.1", []byte("\x7fELF\x02\x01\x01"), mq.FormatMarkdown},
+		{"man .1.gz", "/usr/share/man/man1/tar.1.gz", nil, mq.FormatMan},
+		{"not man .1", ".1", nil, mq.FormatMarkdown},
+		{"mdx .mdx", "guide.mdx", nil, mq.FormatMDX},
+		{"text .txt", "notes.txt", nil, mq.FormatText},
+		{"log .log", "app.log", nil, mq.FormatLog},
+		{"log rotated", "/var/log/syslog.log.1", nil, mq.FormatLog},
 
 		// Content-based detection
 		{"html content doctype", "unknown", []byte("<!DOCTYPE html><html>"), mq.FormatHTML},
+		{"email content headers", "unknown", []byte("From: a@example.com\nDate: Mon, 4 Mar 2024 09:00:00 +0000\nSubject: hi\n\nbody"), mq.FormatEmail},
+		{"email content mbox", "unknown", []byte("From a@example.com Mon Mar  4 09:00:00 2024\nFrom: a@example.com\nMessage-ID: <1@x>\nSubject: hi\n\nbody"), mq.FormatEmail},
+		{"not email prose", "unknown", []byte("From: the team\n\nWe shipped it."), mq.FormatMarkdown},
+		{"man content .TH", "unknown", []byte(".TH TAR 1\n"), mq.FormatMan},
+		{"latex content documentclass", "unknown", []byte("\\documentclass{article}\n"), mq.FormatLaTeX},
 		{"html content tag", "unknown", []byte("<html><body>"), mq.FormatHTML},
 		{"pdf content magic", "unknown", []byte("%PDF-1.4"), mq.FormatPDF},
+		{"xml content declaration", "unknown", []byte("<?xml version=\"1.0\"?><project/>"), mq.FormatXML},
+		{"epub content zip", "unknown", []byte("PK\x03\x04....mimetypeapplication/epub+zip"), mq.FormatEPUB},
+		{"docx content zip", "unknown", []byte("PK\x03\x04....word/document.xml"), mq.FormatDOCX},
+		{"xhtml content declaration", "unknown", []byte("<?xml version=\"1.0\"?><html>"), mq.FormatHTML},
+		{"log content iso", "unknown", []byte("2024-05-01T10:00:00Z INFO start\n2024-05-01T10:00:01Z WARN slow\n2024-05-01T10:00:02Z INFO done\n"), mq.FormatLog},
+		{"log content syslog", "unknown", []byte("May  1 10:00:00 host sshd[1]: a\nMay  1 10:00:01 host sshd[1]: b\nMay  1 10:00:02 host cron[2]: c\n"), mq.FormatLog},
+		{"log content go", "unknown", []byte("2024/05/01 10:00:00 start\n2024/05/01 10:00:01 listening\n2024/05/01 10:00:02 ready\n"), mq.FormatLog},
+		{"minute timestamps not log", "unknown", []byte("2024-05-01 10:00 standup\n2024-05-01 10:15 review\n2024-05-01 11:00 lunch\n"), mq.FormatMarkdown},
+		{"not log one timestamp", "unknown", []byte("2024-05-01 10:00 release notes\n\nWe shipped it.\n"), mq.FormatMarkdown},
 
 		// Default to markdown
-		{"unknown extension", "file.txt", nil, mq.FormatMarkdown},
+		{"unknown extension", "file.bin", nil, mq.FormatMarkdown},
 		{"no extension", "README", nil, mq.FormatMarkdown},
 	}
 
@@ -87,6 +125,32 @@ func TestDetectFormat(t *testing.T) {
 	}
 }
 
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want mq.Format
+		ok   bool
+	}{
+		{"markdown", mq.FormatMarkdown, true},
+		{"md", mq.FormatMarkdown, true},
+		{"HTML", mq.FormatHTML, true},
+		{".yml", mq.FormatYAML, true},
+		{"ndjson", mq.FormatJSONL, true},
+		{"tex", mq.FormatLaTeX, true},
+		{"email", mq.FormatEmail, true},
+		{"unknown", mq.FormatUnknown, false},
+		{"docz", mq.FormatUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mq.ParseFormat(tt.name)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestLoadAny(t *testing.T) {
 	// Test with markdown content
 	mdPath := filepath.Join("..", "mql", "testdata", "api-reference.md")
@@ -95,6 +159,64 @@ func TestLoadAny(t *testing.T) {
 	assert.Equal(t, mq.FormatMarkdown, doc.Format())
 }
 
+func TestLoadCompressed(t *testing.T) {
+	engine := mq.NewMultiFormatEngine(mq.WithFormatParser(html.NewParser()))
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write([]byte("<html><body><h1>Release Notes</h1></body></html>"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	gzPath := filepath.Join(dir, "notes.html.gz")
+	require.NoError(t, os.WriteFile(gzPath, gz.Bytes(), 0o644))
+
+	// Detected by the extension inside the compression suffix
+	doc, err := engine.Load(gzPath)
+	require.NoError(t, err)
+	assert.Equal(t, mq.FormatHTML, doc.Format())
+	assert.Equal(t, gzPath, doc.Path())
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstPath := filepath.Join(dir, "notes.md.zst")
+	require.NoError(t, os.WriteFile(zstPath, enc.EncodeAll([]byte("# Changelog\n\n## v2\n"), nil), 0o644))
+
+	doc, err = engine.Load(zstPath)
+	require.NoError(t, err)
+	assert.Equal(t, mq.FormatMarkdown, doc.Format())
+	assert.Len(t, doc.GetHeadings(2), 1)
+
+	// An explicit format still decompresses
+	doc, err = engine.ParseWithFormat(gz.Bytes(), "-", mq.FormatHTML)
+	require.NoError(t, err)
+	assert.Equal(t, "Release Notes", doc.GetHeadings(1)[0].Text)
+
+	_, err = engine.Parse([]byte{0x1f, 0x8b, 0x08, 0x00}, "broken.md.gz")
+	assert.ErrorContains(t, err, "decompress")
+}
+
+func TestTextWithCompressionMagic(t *testing.T) {
+	// "BZh" is the bzip2 magic number, but these are Markdown documents
+	engine := mq.NewMultiFormatEngine()
+	content := []byte("BZh9 is a model name\n\n# Heading\n")
+
+	path := filepath.Join(t.TempDir(), "bz.md")
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+	doc, err := engine.Load(path)
+	require.NoError(t, err)
+	assert.Len(t, doc.GetHeadings(1), 1)
+
+	doc, err = engine.ParseWithFormat(content, "notes.md", mq.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Len(t, doc.GetHeadings(1), 1)
+
+	// Unnamed stdin falls back to the raw bytes
+	doc, err = engine.ParseWithFormat(content, "<stdin>", mq.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Len(t, doc.GetHeadings(1), 1)
+}
+
 func TestParseAny(t *testing.T) {
 	// ParseAny uses default engine which only has Markdown parser
 	// So all formats fall back to Markdown
@@ -224,3 +346,23 @@ Description here
 
 	assert.Equal(t, mdSection.Heading.Text, htmlSection.Heading.Text)
 }
+
+func TestSearchDirSkipsVersionedLibraries(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "man", "man1"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "man", "man1", "tool.1"), []byte(".TH TOOL 1\n.SH NAME\ntool \\- ELF inspector\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "libx.so.1"), []byte("\x7fELF\x02\x01\x01 binary"), 0o644))
+
+	engine := mq.NewMultiFormatEngine()
+	results, err := mq.SearchDirWithLoader(dir, "ELF", engine.Load)
+	require.NoError(t, err)
+	for _, m := range results.Matches {
+		assert.NotContains(t, m.File, "libx")
+	}
+
+	tree, err := mq.BuildDirTreeWithLoader(dir, mq.TreeModeDefault, engine.Load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tree.TotalFiles)
+	assert.Contains(t, tree.String(), "tool.1")
+}
@@ -0,0 +1,56 @@
+package mq
+
+// Endpoint is one operation of an OpenAPI or Swagger spec, e.g. POST /users.
+type Endpoint struct {
+	Method      string   // Upper-case HTTP method
+	Path        string   // Path template, e.g. "/users/{id}"
+	OperationID string   // operationId, if any
+	Summary     string   // Short summary
+	Description string   // Longer description
+	Tags        []string // Tags grouping the operation
+	Deprecated  bool     // Marked deprecated
+	Parameters  *Table   // Name, In, Type, Required, Description; the request body is an "In: body" row
+	Responses   *Table   // Status, Description, Type
+	Section     *Section // Section for the operation in the document
+}
+
+// Schema is a named schema from components/schemas (OpenAPI 3) or
+// definitions (Swagger 2).
+type Schema struct {
+	Name        string   // Schema name, e.g. "User"
+	Type        string   // Rendered type, e.g. "object" or "[]Pet"
+	Description string   // Schema description
+	Required    []string // Required property names
+	Properties  *Table   // Property, Type, Required, Description
+	Section     *Section // Section for the schema in the document
+}
+
+// SetEndpoints attaches API operations, in document order.
+func (d *Document) SetEndpoints(endpoints []*Endpoint) {
+	d.endpoints = endpoints
+}
+
+// GetEndpoints returns the API operations of an OpenAPI or Swagger spec.
+func (d *Document) GetEndpoints() []*Endpoint {
+	return d.endpoints
+}
+
+// SetSchemas attaches named API schemas, in document order.
+func (d *Document) SetSchemas(schemas []*Schema) {
+	d.schemas = schemas
+}
+
+// GetSchemas returns the named schemas of an OpenAPI or Swagger spec.
+func (d *Document) GetSchemas() []*Schema {
+	return d.schemas
+}
+
+// GetSchema returns a named schema by exact name.
+func (d *Document) GetSchema(name string) (*Schema, bool) {
+	for _, s := range d.schemas {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/yuin/goldmark"
-	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	east "github.com/yuin/goldmark/extension/ast"
@@ -17,7 +16,9 @@ import (
 
 // Parser parses markdown documents with frontmatter support.
 type Parser struct {
-	md goldmark.Markdown
+	md    goldmark.Markdown
+	html  FormatParser // Parses raw HTML blocks, if set
+	slugs SlugStyle    // Heading ID generation, GitHub by default
 }
 
 // ParserOption configures the parser.
@@ -27,13 +28,16 @@ type ParserOption func(*Parser)
 func NewParser(opts ...ParserOption) *Parser {
 	md := goldmark.New(
 		goldmark.WithExtensions(
-			meta.New(meta.WithStoresInDocument()),
 			extension.Table,
 			extension.TaskList,
 			extension.Strikethrough,
+			extension.Footnote,
+			extension.DefinitionList,
+			blockExtension{},
+			wikiLinkExtension{},
 		),
 		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
+			parser.WithHeadingAttribute(),
 		),
 	)
 
@@ -51,18 +55,31 @@ func WithExtensions(exts ...goldmark.Extender) ParserOption {
 	return func(p *Parser) {
 		p.md = goldmark.New(
 			goldmark.WithExtensions(append([]goldmark.Extender{
-				meta.New(meta.WithStoresInDocument()),
 				extension.Table,
 				extension.TaskList,
 				extension.Strikethrough,
+				extension.Footnote,
+				extension.DefinitionList,
+				blockExtension{},
+				wikiLinkExtension{},
 			}, exts...)...),
 			goldmark.WithParserOptions(
-				parser.WithAutoHeadingID(),
+				parser.WithHeadingAttribute(),
 			),
 		)
 	}
 }
 
+// WithHTMLParser parses raw HTML blocks (<details>, <table>, ...) with p,
+// so the headings, tables, links and code inside them join the document.
+// Without it, HTML blocks are opaque. MultiFormatEngine sets it to its
+// HTML parser.
+func WithHTMLParser(p FormatParser) ParserOption {
+	return func(parser *Parser) {
+		parser.html = p
+	}
+}
+
 // ParseFile parses a markdown file.
 func (p *Parser) ParseFile(path string) (*Document, error) {
 	content, err := os.ReadFile(path)
@@ -75,7 +92,15 @@ func (p *Parser) ParseFile(path string) (*Document, error) {
 
 // Parse parses markdown content.
 func (p *Parser) Parse(source []byte, path string) (*Document, error) {
-	reader := text.NewReader(source)
+	// The frontmatter is blanked out rather than cut so AST offsets still
+	// index into source
+	parsed := source
+	fm, hasFrontmatter := findFrontmatter(source)
+	if hasFrontmatter {
+		parsed = blankFrontmatter(source, fm.end)
+	}
+
+	reader := text.NewReader(parsed)
 	ctx := parser.NewContext()
 	node := p.md.Parser().Parse(reader, parser.WithContext(ctx))
 
@@ -93,30 +118,107 @@ func (p *Parser) Parse(source []byte, path string) (*Document, error) {
 		images:          []*Image{},
 		tables:          []*Table{},
 		lists:           []*List{},
+		inlineCode:      []*InlineCode{},
 	}
 
-	// Extract metadata from frontmatter
-	metaData := meta.Get(ctx)
-	if metaData != nil {
-		doc.metadata = Metadata(metaData)
+	// Extract metadata from frontmatter. A block that fails to decode is
+	// still skipped, but leaves no metadata.
+	if hasFrontmatter {
+		if metadata, err := fm.decode(); err == nil {
+			doc.metadata = metadata
+		}
 	}
 
 	// Build indexes
-	if err := p.buildIndexes(doc); err != nil {
+	firstLine := 1
+	if hasFrontmatter {
+		firstLine = NewLineIndex(source).Line(fm.end)
+	}
+	if err := p.buildIndexes(doc, firstLine); err != nil {
 		return nil, fmt.Errorf("building indexes: %w", err)
 	}
+	doc.references = extractReferences(ctx, doc)
 
 	return doc, nil
 }
 
-// buildIndexes walks the AST and builds document indexes.
-func (p *Parser) buildIndexes(doc *Document) error {
-	var currentSection *Section
+// buildIndexes walks the AST and builds document indexes. firstLine is
+// the first line after the frontmatter.
+func (p *Parser) buildIndexes(doc *Document, firstLine int) error {
 	var sectionStack []*Section
 	var allSections []*Section
 
+	// Content before the first heading belongs to the preamble
+	preamble := &Section{
+		Heading: &Heading{},
+		Content: []ast.Node{},
+		source:  doc.source,
+	}
+	currentSection := preamble
+
 	// Pre-compute line starts for efficient line number lookups
-	lineStarts := computeLineStarts(doc.source)
+	idx := NewLineIndex(doc.source)
+
+	// openSection starts the section of a heading, closing the sections
+	// at the same or a deeper level.
+	openSection := func(heading *Heading) {
+		// Add to heading indexes
+		doc.headingIndex[heading.Text] = heading
+		doc.headingsByLevel[heading.Level] = append(
+			doc.headingsByLevel[heading.Level],
+			heading,
+		)
+
+		// Create section
+		section := &Section{
+			Heading: heading,
+			Start:   heading.Line,
+			Content: []ast.Node{},
+			source:  doc.source,
+		}
+
+		// Manage section hierarchy
+		for len(sectionStack) > 0 && sectionStack[len(sectionStack)-1].Heading.Level >= heading.Level {
+			// Close previous section at the line before this heading
+			prev := sectionStack[len(sectionStack)-1]
+			if heading.Line > 0 {
+				prev.End = heading.Line - 1
+			}
+			// If heading.Line is 0, leave prev.End as 0 - it will be fixed in the final cleanup
+			sectionStack = sectionStack[:len(sectionStack)-1]
+		}
+
+		// Set parent if exists
+		if len(sectionStack) > 0 {
+			parent := sectionStack[len(sectionStack)-1]
+			section.Parent = parent
+			parent.Children = append(parent.Children, section)
+		}
+
+		sectionStack = append(sectionStack, section)
+		currentSection = section
+		allSections = append(allSections, section)
+		doc.sectionIndex[heading.Text] = section
+	}
+
+	addCode := func(cb *CodeBlock) {
+		doc.codeBlocks = append(doc.codeBlocks, cb)
+		if cb.Language != "" {
+			doc.codeByLang[cb.Language] = append(
+				doc.codeByLang[cb.Language],
+				cb,
+			)
+		}
+		if currentSection != nil {
+			currentSection.AddCodeBlock(cb) // Store reference in section
+		}
+		if diagram, ok := extractDiagram(cb, idx); ok {
+			doc.diagrams = append(doc.diagrams, diagram)
+			if currentSection != nil {
+				currentSection.diagrams = append(currentSection.diagrams, diagram)
+			}
+		}
+	}
 
 	err := ast.Walk(doc.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
@@ -126,72 +228,68 @@ func (p *Parser) buildIndexes(doc *Document) error {
 		switch node := n.(type) {
 		case *ast.Heading:
 			heading := p.extractHeading(node, doc.source)
-			// Get line number from AST node's byte offset
-			if lines := node.Lines(); lines.Len() > 0 {
-				heading.Line = getLineNumber(lineStarts, lines.At(0).Start)
-			}
-
-			// Add to heading indexes
-			doc.headingIndex[heading.Text] = heading
-			doc.headingsByLevel[heading.Level] = append(
-				doc.headingsByLevel[heading.Level],
-				heading,
-			)
+			heading.SetPosition(headingPosition(node, idx))
+			openSection(heading)
 
-			// Create section
-			section := &Section{
-				Heading: heading,
-				Start:   heading.Line,
-				Content: []ast.Node{},
-				source:  doc.source,
+		case *ast.FencedCodeBlock:
+			cb := p.extractCodeBlock(node, doc.source)
+			cb.Position = codePosition(node, idx)
+			addCode(cb)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
 			}
 
-			// Manage section hierarchy
-			for len(sectionStack) > 0 && sectionStack[len(sectionStack)-1].Heading.Level >= heading.Level {
-				// Close previous section at the line before this heading
-				prev := sectionStack[len(sectionStack)-1]
-				if heading.Line > 0 {
-					prev.End = heading.Line - 1
-				}
-				// If heading.Line is 0, leave prev.End as 0 - it will be fixed in the final cleanup
-				sectionStack = sectionStack[:len(sectionStack)-1]
+		case *ast.CodeBlock:
+			cb := p.extractIndentedCode(node, doc.source)
+			cb.Position = blockPosition(node, idx)
+			addCode(cb)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
 			}
 
-			// Set parent if exists
-			if len(sectionStack) > 0 {
-				parent := sectionStack[len(sectionStack)-1]
-				section.Parent = parent
-				parent.Children = append(parent.Children, section)
+		case *ast.CodeSpan:
+			doc.inlineCode = append(doc.inlineCode, extractInlineCode(node, idx))
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
 			}
 
-			sectionStack = append(sectionStack, section)
-			currentSection = section
-			allSections = append(allSections, section)
-			doc.sectionIndex[heading.Text] = section
-
-		case *ast.FencedCodeBlock:
-			cb := p.extractCodeBlock(node, doc.source)
-			doc.codeBlocks = append(doc.codeBlocks, cb)
-			if cb.Language != "" {
-				doc.codeByLang[cb.Language] = append(
-					doc.codeByLang[cb.Language],
-					cb,
-				)
-			}
+		case *ast.HTMLBlock:
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
-				currentSection.AddCodeBlock(cb) // Store reference in section
 			}
+			p.mergeHTMLBlock(doc, node, idx, openSection, addCode)
 
 		case *ast.Link:
 			link := p.extractLink(node, doc.source)
+			link.Position = inlinePosition(node, idx)
+			link.Kind, link.Label = linkKind(node, idx)
 			doc.links = append(doc.links, link)
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
 			}
 
+		case *ast.Text:
+			// URLs in plain text, outside links and code
+			doc.links = append(doc.links, bareLinks(node, idx)...)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+			}
+
+		case *wikiLinkNode:
+			doc.links = append(doc.links, extractWikiLink(node, idx))
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+			}
+
+		case *ast.AutoLink:
+			doc.links = append(doc.links, extractAutoLink(node, idx))
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+			}
+
 		case *ast.Image:
 			image := p.extractImage(node, doc.source)
+			image.Position = inlinePosition(node, idx)
 			doc.images = append(doc.images, image)
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
@@ -199,18 +297,58 @@ func (p *Parser) buildIndexes(doc *Document) error {
 
 		case *east.Table:
 			table := p.extractTable(node, doc.source)
+			table.Position = blockPosition(node, idx)
 			doc.tables = append(doc.tables, table)
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
 			}
 
 		case *ast.List:
-			list := p.extractList(node, doc.source)
+			list := p.extractList(node, idx)
 			doc.lists = append(doc.lists, list)
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
 			}
 
+		case *ast.Blockquote:
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+			}
+			if alert, ok := extractAlert(node, doc.source, idx); ok {
+				doc.admonitions = append(doc.admonitions, alert)
+				if currentSection != nil {
+					currentSection.admonitions = append(currentSection.admonitions, alert)
+				}
+			}
+
+		case *admonitionNode:
+			admonition := extractAdmonition(node, doc.source, idx)
+			doc.admonitions = append(doc.admonitions, admonition)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+				currentSection.admonitions = append(currentSection.admonitions, admonition)
+			}
+
+		case *mathNode:
+			math := extractMath(node, doc.source, idx)
+			doc.math = append(doc.math, math)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+				currentSection.math = append(currentSection.math, math)
+			}
+
+		case *east.DefinitionList:
+			defs := extractDefinitions(node, doc.source, idx)
+			doc.definitions = append(doc.definitions, defs...)
+			if currentSection != nil {
+				currentSection.Content = append(currentSection.Content, node)
+				currentSection.definitions = append(currentSection.definitions, defs...)
+			}
+
+		case *east.Footnote:
+			// Attached to sections by line below
+			doc.footnotes = append(doc.footnotes, extractFootnote(node, doc.source, idx))
+
 		case *ast.Paragraph:
 			if currentSection != nil {
 				currentSection.Content = append(currentSection.Content, node)
@@ -227,12 +365,31 @@ func (p *Parser) buildIndexes(doc *Document) error {
 	})
 
 	// Fix any sections with invalid End values (0 or negative)
-	totalLines := len(lineStarts)
+	totalLines := idx.Lines()
 	for _, section := range allSections {
 		if section.End <= 0 {
 			section.End = totalLines
 		}
 	}
+	doc.sections = allSections
+
+	preambleEnd := totalLines
+	if len(allSections) > 0 && allSections[0].Start > 0 {
+		preambleEnd = allSections[0].Start - 1
+	}
+	for firstLine <= preambleEnd && len(bytes.TrimSpace(idx.line(firstLine))) == 0 {
+		firstLine++
+	}
+	for preambleEnd >= firstLine && len(bytes.TrimSpace(idx.line(preambleEnd))) == 0 {
+		preambleEnd--
+	}
+	if firstLine <= preambleEnd {
+		preamble.Start, preamble.End = firstLine, preambleEnd
+		doc.preamble = preamble
+	}
+
+	attachFootnotes(doc.footnotes, allSections)
+	assignHeadingIDs(allSections, p.slugs)
 
 	return err
 }
@@ -321,33 +478,38 @@ func (p *Parser) extractCodeBlock(node *ast.FencedCodeBlock, source []byte) *Cod
 	}
 }
 
-// extractLink extracts link information from an AST node.
-func (p *Parser) extractLink(node *ast.Link, source []byte) *Link {
-	var text bytes.Buffer
-	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-		if t, ok := child.(*ast.Text); ok {
-			text.Write(t.Segment.Value(source))
-		}
+// extractIndentedCode extracts an indented code block, which has no
+// language.
+func (p *Parser) extractIndentedCode(node *ast.CodeBlock, source []byte) *CodeBlock {
+	var content bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		content.Write(line.Value(source))
 	}
 
+	return &CodeBlock{
+		Content:  content.String(),
+		Node:     node,
+		Lines:    lines.Len(),
+		Indented: true,
+	}
+}
+
+// extractLink extracts link information from an AST node.
+func (p *Parser) extractLink(node *ast.Link, source []byte) *Link {
 	return &Link{
-		Text: text.String(),
-		URL:  string(node.Destination),
-		Node: node,
+		Text:  inlineText(node, source),
+		URL:   string(node.Destination),
+		Title: string(node.Title),
+		Node:  node,
 	}
 }
 
 // extractImage extracts image information from an AST node.
 func (p *Parser) extractImage(node *ast.Image, source []byte) *Image {
-	var altText bytes.Buffer
-	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-		if t, ok := child.(*ast.Text); ok {
-			altText.Write(t.Segment.Value(source))
-		}
-	}
-
 	return &Image{
-		AltText: altText.String(),
+		AltText: inlineText(node, source),
 		URL:     string(node.Destination),
 		Title:   string(node.Title),
 		Node:    node,
@@ -400,15 +562,16 @@ func (p *Parser) extractTable(node *east.Table, source []byte) *Table {
 }
 
 // extractList extracts list information from an AST node.
-func (p *Parser) extractList(node *ast.List, source []byte) *List {
+func (p *Parser) extractList(node *ast.List, idx *LineIndex) *List {
 	list := &List{
-		Ordered: node.IsOrdered(),
-		Node:    node,
+		Ordered:  node.IsOrdered(),
+		Node:     node,
+		Position: blockPosition(node, idx),
 	}
 
 	for item := node.FirstChild(); item != nil; item = item.NextSibling() {
 		if li, ok := item.(*ast.ListItem); ok {
-			listItem := p.extractListItem(li, source)
+			listItem := p.extractListItem(li, idx)
 			list.Items = append(list.Items, listItem)
 		}
 	}
@@ -417,40 +580,192 @@ func (p *Parser) extractList(node *ast.List, source []byte) *List {
 }
 
 // extractListItem extracts list item information.
-func (p *Parser) extractListItem(node *ast.ListItem, source []byte) ListItem {
-	item := ListItem{}
+func (p *Parser) extractListItem(node *ast.ListItem, idx *LineIndex) ListItem {
+	source := idx.source
+	item := ListItem{Position: blockPosition(node, idx)}
 
-	// Check if it's a task list item
 	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-		if tl, ok := child.(*east.TaskCheckBox); ok {
-			checked := tl.IsChecked
-			item.Checked = &checked
+		// Nested lists become children, not part of the item's text
+		if list, ok := child.(*ast.List); ok {
+			for subItem := list.FirstChild(); subItem != nil; subItem = subItem.NextSibling() {
+				if li, ok := subItem.(*ast.ListItem); ok {
+					item.Children = append(item.Children, p.extractListItem(li, idx))
+				}
+			}
 			continue
 		}
 
-		// Extract text
+		// Extract text; the task checkbox sits inside the item's first block
 		var text bytes.Buffer
 		ast.Walk(child, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 			if entering {
-				if t, ok := n.(*ast.Text); ok {
-					text.Write(t.Segment.Value(source))
+				switch n := n.(type) {
+				case *east.TaskCheckBox:
+					checked := n.IsChecked
+					item.Checked = &checked
+				case *ast.Text:
+					text.Write(n.Segment.Value(source))
+					if n.SoftLineBreak() {
+						text.WriteByte(' ')
+					}
 				}
 			}
 			return ast.WalkContinue, nil
 		})
-		if text.Len() > 0 {
-			item.Text += text.String()
+		// Paragraphs of a loose item are joined into one line
+		if trimmed := bytes.TrimSpace(text.Bytes()); len(trimmed) > 0 {
+			if item.Text != "" {
+				item.Text += " "
+			}
+			item.Text += string(trimmed)
 		}
+	}
 
-		// Handle nested lists
-		if list, ok := child.(*ast.List); ok {
-			for subItem := list.FirstChild(); subItem != nil; subItem = subItem.NextSibling() {
-				if li, ok := subItem.(*ast.ListItem); ok {
-					item.Children = append(item.Children, p.extractListItem(li, source))
-				}
+	return item
+}
+
+// nodeSpan returns the byte range covered by the source segments of n and
+// its descendants. Container blocks such as lists have no segments of
+// their own.
+func nodeSpan(n ast.Node) (start, stop int, ok bool) {
+	add := func(seg text.Segment) {
+		if !ok || seg.Start < start {
+			start = seg.Start
+		}
+		if !ok || seg.Stop > stop {
+			stop = seg.Stop
+		}
+		ok = true
+	}
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			add(c.Segment)
+		case *ast.FencedCodeBlock:
+			if c.Info != nil {
+				add(c.Info.Segment)
 			}
 		}
+		if c.Type() == ast.TypeBlock {
+			lines := c.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				add(lines.At(i))
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return start, stop, ok
+}
+
+// blockPosition covers the whole lines of a block element.
+func blockPosition(n ast.Node, idx *LineIndex) Position {
+	start, stop, ok := nodeSpan(n)
+	if !ok {
+		return Position{}
 	}
+	return idx.LinePosition(idx.Line(start), idx.Line(max(stop-1, start)))
+}
 
-	return item
+// headingPosition covers an ATX heading line, or a setext heading's text
+// and underline.
+func headingPosition(n *ast.Heading, idx *LineIndex) Position {
+	start, stop, ok := nodeSpan(n)
+	if !ok {
+		return Position{}
+	}
+	first, last := idx.Line(start), idx.Line(max(stop-1, start))
+	if !bytes.HasPrefix(trimLeftSpace(idx.line(first)), []byte("#")) {
+		last++ // setext underline
+	}
+	return idx.LinePosition(first, last)
+}
+
+// codePosition covers a fenced code block including both fences.
+func codePosition(n *ast.FencedCodeBlock, idx *LineIndex) Position {
+	start, stop, ok := nodeSpan(n)
+	if !ok {
+		return Position{}
+	}
+	first, last := idx.Line(start), idx.Line(max(stop-1, start))
+	if n.Info == nil {
+		first-- // the fence line has no info string
+	}
+	if last < idx.Lines() && isFence(idx.line(last+1)) {
+		last++
+	}
+	return idx.LinePosition(first, last)
+}
+
+func isFence(line []byte) bool {
+	line = trimLeftSpace(line)
+	return bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~"))
+}
+
+// inlinePosition finds the exact extent of a link or image, from "[" or
+// "![" through the closing ")" or "]". Without link text to anchor on, it
+// falls back to the enclosing block's first line.
+func inlinePosition(n ast.Node, idx *LineIndex) Position {
+	start, stop, ok := inlineSpan(n, idx)
+	if !ok {
+		if block := n.Parent(); block != nil {
+			pos := blockPosition(block, idx)
+			return Position{Line: pos.Line, EndLine: pos.Line}
+		}
+		return Position{}
+	}
+	return idx.Position(start, stop)
+}
+
+// inlineSpan returns the byte range of a link or image.
+func inlineSpan(n ast.Node, idx *LineIndex) (start, stop int, ok bool) {
+	src := idx.source
+	start, stop, ok = nodeSpan(n)
+	if !ok {
+		return 0, 0, false
+	}
+
+	for start > 0 && src[start-1] != '[' {
+		start--
+	}
+	start = max(start-1, 0)
+	if _, image := n.(*ast.Image); image && start > 0 && src[start-1] == '!' {
+		start--
+	}
+
+	for stop < len(src) && src[stop] != ']' {
+		stop++
+	}
+	stop = min(stop+1, len(src))
+	if stop < len(src) {
+		switch src[stop] {
+		case '(':
+			stop = closingDelim(src, stop, '(', ')')
+		case '[':
+			stop = closingDelim(src, stop, '[', ']')
+		}
+	}
+	return start, stop, true
+}
+
+// closingDelim returns the offset after the delimiter closing the one at
+// src[open], allowing nesting.
+func closingDelim(src []byte, open int, left, right byte) int {
+	depth := 0
+	for i := open; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case left:
+			depth++
+		case right:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(src)
 }
@@ -0,0 +1,118 @@
+package mq
+
+import "fmt"
+
+// Position locates an element in the document source. Lines and columns
+// are 1-based and columns count bytes; zero means unknown. Formats whose
+// source is converted to text (DOCX, EPUB, PDF, man pages, email) report
+// lines in ReadableText instead. File names the source file when it is
+// not the document itself, as for LaTeX \input files.
+type Position struct {
+	Line      int    // Starting line
+	Column    int    // Starting column
+	EndLine   int    // Ending line, inclusive
+	EndColumn int    // Column of the last byte, inclusive
+	File      string // File the lines are in, "" for the document's own
+}
+
+// End returns the ending line, falling back to the starting line.
+func (p Position) End() int {
+	if p.EndLine == 0 {
+		return p.Line
+	}
+	return p.EndLine
+}
+
+// Range renders the line range as "12" or "12-18" for citations.
+func (p Position) Range() string {
+	if p.End() > p.Line {
+		return fmt.Sprintf("%d-%d", p.Line, p.End())
+	}
+	return fmt.Sprint(p.Line)
+}
+
+// LineIndex converts byte offsets in a source to line and column
+// positions. Format parsers share it so every element type reports
+// positions the same way.
+type LineIndex struct {
+	source []byte
+	starts []int
+}
+
+// NewLineIndex indexes the line starts of source.
+func NewLineIndex(source []byte) *LineIndex {
+	return &LineIndex{source: source, starts: computeLineStarts(source)}
+}
+
+// Line returns the 1-based line containing offset.
+func (x *LineIndex) Line(offset int) int {
+	return getLineNumber(x.starts, offset)
+}
+
+// Lines returns the number of lines in the source.
+func (x *LineIndex) Lines() int {
+	return len(x.starts)
+}
+
+// Position returns the position of the bytes in [start, end).
+func (x *LineIndex) Position(start, end int) Position {
+	end = max(end, start+1)
+	pos := Position{Line: x.Line(start), EndLine: x.Line(end - 1)}
+	pos.Column = start - x.starts[pos.Line-1] + 1
+	pos.EndColumn = end - 1 - x.starts[pos.EndLine-1] + 1
+	return pos
+}
+
+// LinePosition returns the position of whole lines start through end,
+// from the first non-blank byte of start to the last byte of end. Block
+// elements such as tables and lists use it.
+func (x *LineIndex) LinePosition(start, end int) Position {
+	if start < 1 || start > len(x.starts) {
+		return Position{}
+	}
+	end = min(max(end, start), len(x.starts))
+	pos := Position{Line: start, Column: 1, EndLine: end}
+
+	first := x.line(start)
+	pos.Column += len(first) - len(trimLeftSpace(first))
+	pos.EndColumn = max(len(x.line(end)), 1)
+	return pos
+}
+
+// lineEnd returns the offset just past a 1-based line, its line ending
+// included.
+func (x *LineIndex) lineEnd(n int) int {
+	if n < len(x.starts) {
+		return x.starts[n]
+	}
+	return len(x.source)
+}
+
+// line returns the text of a 1-based line without its line ending.
+func (x *LineIndex) line(n int) []byte {
+	start := x.starts[n-1]
+	end := len(x.source)
+	if n < len(x.starts) {
+		end = x.starts[n] - 1
+	}
+	line := x.source[start:end]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+func trimLeftSpace(b []byte) []byte {
+	for len(b) > 0 && (b[0] == ' ' || b[0] == '\t') {
+		b = b[1:]
+	}
+	return b
+}
+
+// shift moves a known position down by n lines.
+func (p *Position) shift(n int) {
+	if p.Line > 0 {
+		p.Line += n
+		p.EndLine += n
+	}
+}
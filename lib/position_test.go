@@ -0,0 +1,93 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const positionsMarkdown = `# Guide
+
+See [the docs](https://example.com/docs) and ![logo](logo.png).
+
+Setup
+-----
+
+` + "```go" + `
+fmt.Println("hi")
+` + "```" + `
+
+| Key | Value |
+| --- | ----- |
+| a   | 1     |
+
+- one
+- two
+  - nested
+`
+
+func TestMarkdownPositions(t *testing.T) {
+	doc, err := mq.New().ParseDocument([]byte(positionsMarkdown), "guide.md")
+	require.NoError(t, err)
+
+	sections := doc.GetSections()
+	require.Len(t, sections, 2)
+	assert.Equal(t, mq.Position{Line: 1, Column: 1, EndLine: 1, EndColumn: 7}, sections[0].Heading.Position())
+	// Setext headings include their underline
+	assert.Equal(t, mq.Position{Line: 5, Column: 1, EndLine: 6, EndColumn: 5}, sections[1].Heading.Position())
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, mq.Position{Line: 3, Column: 5, EndLine: 3, EndColumn: 40}, links[0].Position)
+
+	images := doc.GetImages()
+	require.Len(t, images, 1)
+	assert.Equal(t, mq.Position{Line: 3, Column: 46, EndLine: 3, EndColumn: 62}, images[0].Position)
+
+	// Code blocks span their fences
+	code := doc.GetCodeBlocks()
+	require.Len(t, code, 1)
+	assert.Equal(t, 8, code[0].Line)
+	assert.Equal(t, 10, code[0].EndLine)
+	assert.Equal(t, "8-10", code[0].Range())
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, 12, tables[0].Line)
+	assert.Equal(t, 14, tables[0].EndLine)
+
+	// Nested lists are reported on their own as well
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 2)
+	assert.Equal(t, 16, lists[0].Line)
+	assert.Equal(t, 18, lists[0].EndLine)
+	require.Len(t, lists[0].Items, 2)
+	assert.Equal(t, mq.Position{Line: 16, Column: 1, EndLine: 16, EndColumn: 5}, lists[0].Items[0].Position)
+	require.Len(t, lists[0].Items[1].Children, 1)
+	assert.Equal(t, mq.Position{Line: 18, Column: 3, EndLine: 18, EndColumn: 10}, lists[0].Items[1].Children[0].Position)
+}
+
+func TestLineIndex(t *testing.T) {
+	idx := mq.NewLineIndex([]byte("ab\n  cd\n\nef"))
+	assert.Equal(t, 4, idx.Lines())
+	assert.Equal(t, 2, idx.Line(4))
+	assert.Equal(t, mq.Position{Line: 2, Column: 3, EndLine: 2, EndColumn: 4}, idx.Position(5, 7))
+	// Whole lines start at the first non-blank column
+	assert.Equal(t, mq.Position{Line: 2, Column: 3, EndLine: 4, EndColumn: 2}, idx.LinePosition(2, 4))
+
+	assert.Equal(t, "2", mq.Position{Line: 2}.Range())
+	assert.Equal(t, 2, mq.Position{Line: 2}.End())
+}
+
+func TestHeadingPosition(t *testing.T) {
+	// Line stays a plain field, so headings can be built by line alone
+	h := &mq.Heading{Level: 1, Text: "x", Line: 3}
+	assert.Equal(t, mq.Position{Line: 3}, h.Position())
+	assert.Equal(t, "3", h.Range())
+
+	h.SetPosition(mq.Position{Line: 3, Column: 1, EndLine: 4, EndColumn: 5})
+	assert.Equal(t, 4, h.EndLine)
+	assert.Equal(t, "3-4", h.Range())
+}
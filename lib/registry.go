@@ -0,0 +1,195 @@
+package mq
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FormatInfo describes a document format to the registry: how to name
+// it, how to recognize it, and how directory mode labels its structure.
+//
+// Formats defined outside this module register once, usually from a
+// package-level var, and implement a FormatParser that returns the
+// allocated Format:
+//
+//	var FormatOrg = mq.RegisterFormat(mq.FormatInfo{
+//		Name:       "org",
+//		Extensions: []string{".org"},
+//		MIMETypes:  []string{"text/org"},
+//		Sniff:      func(b []byte) bool { return bytes.HasPrefix(b, []byte("#+TITLE:")) },
+//		Label:      func(h *mq.Heading) string { return strings.Repeat("*", h.Level) + " " + h.Text },
+//	})
+//
+//	func init() { mq.RegisterDefaultParser(NewParser()) }
+type FormatInfo struct {
+	// Name is the canonical lowercase name returned by Format.String.
+	Name string
+
+	// Aliases are extra names accepted by ParseFormat, e.g. "md" or "yml".
+	Aliases []string
+
+	// Extensions are file extensions including the dot, e.g. ".md".
+	Extensions []string
+
+	// MIMETypes are media types resolved by FormatByMIME.
+	MIMETypes []string
+
+	// Match recognizes paths that extensions cannot, e.g. man pages
+	// named tar.1. Optional.
+	Match func(path string) bool
+
+	// Sniff recognizes content when the path is inconclusive. Optional.
+	Sniff func(content []byte) bool
+
+	// Label renders a heading in directory trees. Defaults to "H<level> text".
+	Label func(h *Heading) string
+
+	// Count returns the number of structural units shown for a file in
+	// directory trees. Defaults to the number of sections.
+	Count func(doc *Document) int
+
+	// Unit and Units name one and many structural units. Default to
+	// "section" and "sections".
+	Unit, Units string
+}
+
+// formatRegistry holds every known format, indexed by Format value.
+var formatRegistry = struct {
+	sync.RWMutex
+	formats    []FormatInfo      // index is the Format value
+	names      map[string]Format // names and aliases
+	extensions map[string]Format
+	mimeTypes  map[string]Format
+	parsers    []FormatParser // registered with RegisterDefaultParser
+}{
+	formats:    []FormatInfo{{Name: "unknown"}},
+	names:      make(map[string]Format),
+	extensions: make(map[string]Format),
+	mimeTypes:  make(map[string]Format),
+}
+
+// RegisterFormat adds a format to the registry and returns its Format
+// value. Extensions and MIME types registered later take precedence over
+// earlier ones, and sniffers of later formats run first, so a new format
+// can claim content that a built-in would otherwise match.
+//
+// RegisterFormat panics if Name is empty or already registered.
+func RegisterFormat(info FormatInfo) Format {
+	r := &formatRegistry
+	r.Lock()
+	defer r.Unlock()
+
+	name := strings.ToLower(info.Name)
+	if name == "" {
+		panic("mq: RegisterFormat with empty name")
+	}
+	if _, dup := r.names[name]; dup {
+		panic(fmt.Sprintf("mq: format %q registered twice", name))
+	}
+	info.Name = name
+
+	f := Format(len(r.formats))
+	r.formats = append(r.formats, info)
+
+	r.names[name] = f
+	for _, alias := range info.Aliases {
+		r.names[strings.ToLower(alias)] = f
+	}
+	for _, ext := range info.Extensions {
+		r.extensions[strings.ToLower(ext)] = f
+	}
+	for _, mt := range info.MIMETypes {
+		r.mimeTypes[strings.ToLower(mt)] = f
+	}
+	return f
+}
+
+// RegisterDefaultParser makes p available to every MultiFormatEngine
+// created afterwards, including the engines behind the mql package and
+// directory mode. Call it from an init function.
+func RegisterDefaultParser(p FormatParser) {
+	formatRegistry.Lock()
+	defer formatRegistry.Unlock()
+	formatRegistry.parsers = append(formatRegistry.parsers, p)
+}
+
+// defaultParsers returns the parsers added with RegisterDefaultParser.
+func defaultParsers() []FormatParser {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	return append([]FormatParser(nil), formatRegistry.parsers...)
+}
+
+// formatInfo returns the registry entry for f.
+func formatInfo(f Format) (FormatInfo, bool) {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	if f <= FormatUnknown || int(f) >= len(formatRegistry.formats) {
+		return FormatInfo{}, false
+	}
+	return formatRegistry.formats[f], true
+}
+
+// ParseFormat resolves a format name such as "markdown", "md" or "yml".
+// Names are case-insensitive and may have a leading dot.
+func ParseFormat(name string) (Format, bool) {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), ".")
+
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	f, ok := formatRegistry.names[name]
+	return f, ok
+}
+
+// FormatByMIME resolves a media type such as "text/html; charset=utf-8".
+func FormatByMIME(mimeType string) (Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(mimeType))
+	}
+
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	f, ok := formatRegistry.mimeTypes[mediaType]
+	return f, ok
+}
+
+// formatForPath identifies a format from the path alone: its extension,
+// then each format's Match function. It returns FormatUnknown otherwise.
+func formatForPath(path string) Format {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+
+	if f, ok := formatRegistry.extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return f
+	}
+	for i := len(formatRegistry.formats) - 1; i > 0; i-- {
+		if match := formatRegistry.formats[i].Match; match != nil && match(path) {
+			return Format(i)
+		}
+	}
+	return FormatUnknown
+}
+
+// IsRecognized reports whether a registered format claims path by its
+// extension or name, looking past compression suffixes (notes.md.gz).
+func IsRecognized(path string) bool {
+	return formatForPath(path) != FormatUnknown || formatForPath(stripCompression(path)) != FormatUnknown
+}
+
+// sniffFormat identifies a format from content, newest registration
+// first. It returns FormatUnknown when no sniffer matches.
+func sniffFormat(content []byte) Format {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+
+	for i := len(formatRegistry.formats) - 1; i > 0; i-- {
+		if sniff := formatRegistry.formats[i].Sniff; sniff != nil && sniff(content) {
+			return Format(i)
+		}
+	}
+	return FormatUnknown
+}
@@ -0,0 +1,108 @@
+package mq_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatOrg is an Org-mode format registered the way an out-of-module
+// package would.
+var formatOrg = mq.RegisterFormat(mq.FormatInfo{
+	Name:       "org",
+	Aliases:    []string{"orgmode"},
+	Extensions: []string{".org"},
+	MIMETypes:  []string{"text/org"},
+	Sniff:      func(b []byte) bool { return bytes.HasPrefix(b, []byte("#+TITLE:")) },
+	Label:      func(h *mq.Heading) string { return strings.Repeat("*", h.Level) + " " + h.Text },
+	Unit:       "outline",
+	Units:      "outlines",
+})
+
+// orgParser treats "* " lines as headings, one star per level.
+type orgParser struct{}
+
+func (orgParser) Format() mq.Format { return formatOrg }
+
+func (p orgParser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(content, path)
+}
+
+func (orgParser) Parse(content []byte, path string) (*mq.Document, error) {
+	var headings []*mq.Heading
+	var sections []*mq.Section
+	for i, line := range strings.Split(string(content), "\n") {
+		stars := len(line) - len(strings.TrimLeft(line, "*"))
+		if stars == 0 || !strings.HasPrefix(line[stars:], " ") {
+			continue
+		}
+		h := &mq.Heading{Level: stars, Text: strings.TrimSpace(line[stars:]), Line: i + 1}
+		headings = append(headings, h)
+		sections = append(sections, &mq.Section{Heading: h, Start: i + 1, End: i + 1})
+	}
+	return mq.NewDocument(content, path, formatOrg, "", headings, sections, nil, nil, nil, nil, nil, string(content)), nil
+}
+
+func init() {
+	mq.RegisterDefaultParser(orgParser{})
+}
+
+func TestRegisterFormat(t *testing.T) {
+	assert.Equal(t, "org", formatOrg.String())
+	assert.Greater(t, formatOrg, mq.FormatEmail)
+
+	f, ok := mq.ParseFormat("OrgMode")
+	require.True(t, ok)
+	assert.Equal(t, formatOrg, f)
+
+	f, ok = mq.FormatByMIME("text/org; charset=utf-8")
+	require.True(t, ok)
+	assert.Equal(t, formatOrg, f)
+
+	f, ok = mq.FormatByMIME("text/html")
+	require.True(t, ok)
+	assert.Equal(t, mq.FormatHTML, f)
+
+	assert.Equal(t, formatOrg, mq.DetectFormat("todo.org", nil))
+	assert.Equal(t, formatOrg, mq.DetectFormat("unknown", []byte("#+TITLE: Plans\n* Q1\n")))
+
+	assert.Panics(t, func() { mq.RegisterFormat(mq.FormatInfo{Name: "markdown"}) })
+	assert.Panics(t, func() { mq.RegisterFormat(mq.FormatInfo{}) })
+}
+
+func TestRegisterDefaultParser(t *testing.T) {
+	engine := mq.NewMultiFormatEngine()
+	assert.True(t, engine.HasParser(formatOrg))
+
+	doc, err := engine.Parse([]byte("* Plans\n** Q1\n"), "todo.org")
+	require.NoError(t, err)
+	assert.Equal(t, formatOrg, doc.Format())
+	assert.Len(t, doc.GetHeadings(2), 1)
+}
+
+func TestRegisteredFormatInDirectoryTree(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "todo.org"), []byte("* Plans\n** Q1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.bin"), []byte("* not org\n"), 0o644))
+
+	engine := mq.NewMultiFormatEngine()
+	tree, err := mq.BuildDirTreeWithLoader(dir, mq.TreeModePreview, engine.Load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tree.TotalFiles)
+
+	rendered := tree.String()
+	assert.Contains(t, rendered, "todo.org (3 lines, 2 outlines)")
+	assert.Contains(t, rendered, "* Plans")
+	assert.Contains(t, rendered, "** Q1")
+	assert.NotContains(t, rendered, "notes.bin")
+}
@@ -0,0 +1,206 @@
+package mq
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// TextOption filters text extracted from a section.
+type TextOption func(*textOptions)
+
+type textOptions struct {
+	noCode     bool
+	noComments bool
+}
+
+// WithoutCode drops fenced code blocks, fences included.
+func WithoutCode() TextOption {
+	return func(o *textOptions) {
+		o.noCode = true
+	}
+}
+
+// WithoutComments drops HTML comments (<!-- ... -->). Comments inside
+// code blocks and code spans are kept.
+func WithoutComments() TextOption {
+	return func(o *textOptions) {
+		o.noComments = true
+	}
+}
+
+// SetSource sets the text the section's Start and End lines refer to,
+// for sections taken from another file than their document, such as
+// LaTeX \input files. Sections default to the document's source.
+func (s *Section) SetSource(source []byte) {
+	s.source = source
+}
+
+// GetBody returns the section's own content: the text between its heading
+// and its first child section, without surrounding blank lines.
+func (s *Section) GetBody(opts ...TextOption) string {
+	end := s.End
+	if len(s.Children) > 0 && s.Children[0].Start > 0 && s.Children[0].file() == s.file() {
+		end = s.Children[0].Start - 1
+	}
+	return trimBlankLines(filterText(s.lines(s.headingEnd()+1, end), opts))
+}
+
+// GetHeadingLine returns the heading's source: the ATX line, or a setext
+// heading's text and underline. It is empty for the preamble.
+func (s *Section) GetHeadingLine() string {
+	return s.lines(s.Start, s.headingEnd())
+}
+
+// headingEnd returns the last line of the section's heading.
+func (s *Section) headingEnd() int {
+	switch {
+	case s.Heading == nil || s.Heading.Level == 0:
+		return s.Start - 1
+	case s.Heading.EndLine >= s.Start && s.Heading.EndLine <= s.End:
+		return s.Heading.EndLine
+	default:
+		return s.Start
+	}
+}
+
+// file returns the file a section's lines are in when it is not the
+// document itself, as named by its "file" metadata.
+func (s *Section) file() string {
+	file, _ := s.Metadata["file"].(string)
+	return file
+}
+
+// lines returns source lines first through last (1-based, inclusive).
+func (s *Section) lines(first, last int) string {
+	if s.source == nil || first <= 0 || last < first {
+		return ""
+	}
+
+	lines := strings.Split(string(s.source), "\n")
+	if first > len(lines) {
+		return ""
+	}
+	last = min(last, len(lines))
+	return strings.Join(lines[first-1:last], "\n")
+}
+
+// filterText applies text options to markdown text. The text is parsed
+// so only real code blocks and comments are removed, not look-alikes in
+// indented code or code spans. Lines emptied by a removal are dropped and
+// runs of blank lines collapse to one.
+func filterText(content string, opts []TextOption) string {
+	var o textOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.noCode && !o.noComments {
+		return content
+	}
+
+	src := []byte(content)
+	idx := NewLineIndex(src)
+	cut := make([]bool, len(src)) // bytes to remove
+	remove := func(start, stop int) {
+		for i := start; i < stop; i++ {
+			cut[i] = true
+		}
+	}
+
+	doc := goldmark.DefaultParser().Parse(text.NewReader(src))
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock:
+			if pos := codePosition(n, idx); o.noCode && pos.Line > 0 {
+				remove(idx.starts[pos.Line-1], idx.lineEnd(pos.EndLine))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock, *ast.CodeSpan:
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock:
+			if o.noComments && n.HTMLBlockType == ast.HTMLBlockType2 {
+				start, stop, ok := nodeSpan(n)
+				if n.HasClosure() {
+					start, stop = min(start, n.ClosureLine.Start), max(stop, n.ClosureLine.Stop)
+					ok = true
+				}
+				if ok {
+					for _, c := range commentSpans(src, start, stop) {
+						remove(c[0], c[1])
+					}
+				}
+			}
+		case *ast.RawHTML:
+			if o.noComments && n.Segments.Len() > 0 && bytes.HasPrefix(src[n.Segments.At(0).Start:], []byte("<!--")) {
+				for i := 0; i < n.Segments.Len(); i++ {
+					remove(n.Segments.At(i).Start, n.Segments.At(i).Stop)
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var out []string
+	for i := 1; i <= idx.Lines(); i++ {
+		start, stop := idx.starts[i-1], idx.lineEnd(i)
+		var line []byte
+		edited := false
+		for j := start; j < stop; j++ {
+			if cut[j] {
+				edited = true
+			} else if src[j] != '\n' {
+				line = append(line, src[j])
+			}
+		}
+		if edited {
+			if strings.TrimSpace(string(line)) == "" {
+				continue
+			}
+			line = bytes.TrimRight(line, " \t")
+		}
+		if len(bytes.TrimSpace(line)) == 0 && (len(out) == 0 || strings.TrimSpace(out[len(out)-1]) == "") {
+			continue
+		}
+		out = append(out, string(line))
+	}
+	return trimBlankLines(strings.Join(out, "\n"))
+}
+
+// commentSpans returns the byte ranges of HTML comments in src[start:stop].
+// A comment left open runs to stop.
+func commentSpans(src []byte, start, stop int) [][2]int {
+	var spans [][2]int
+	for start < stop {
+		open := bytes.Index(src[start:stop], []byte("<!--"))
+		if open < 0 {
+			break
+		}
+		open += start
+		end := stop
+		if close := bytes.Index(src[open+len("<!--"):stop], []byte("-->")); close >= 0 {
+			end = open + len("<!--") + close + len("-->")
+		}
+		spans = append(spans, [2]int{open, end})
+		start = end
+	}
+	return spans
+}
+
+// trimBlankLines removes leading and trailing blank lines.
+func trimBlankLines(text string) string {
+	lines := strings.Split(text, "\n")
+	first, last := 0, len(lines)
+	for first < last && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	for last > first && strings.TrimSpace(lines[last-1]) == "" {
+		last--
+	}
+	return strings.Join(lines[first:last], "\n")
+}
@@ -0,0 +1,86 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bodyMarkdown = `---
+title: Guide
+---
+
+[![CI](https://ci.example.com/badge.svg)](https://ci.example.com)
+
+Intro paragraph.
+
+# Guide
+
+Own prose. <!-- reviewer note -->
+
+` + "```sh\nmake <!-- kept -->\n```" + `
+
+<!--
+Draft paragraph.
+-->
+More prose.
+
+## Install
+
+Install steps.
+
+Usage
+-----
+
+Run it.
+`
+
+func TestSectionBody(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(bodyMarkdown), "guide.md")
+	require.NoError(t, err)
+
+	guide, ok := doc.GetSection("Guide")
+	require.True(t, ok)
+	assert.Equal(t, "# Guide", guide.GetHeadingLine())
+	assert.Equal(t, "Own prose. <!-- reviewer note -->\n\n```sh\nmake <!-- kept -->\n```\n\n<!--\nDraft paragraph.\n-->\nMore prose.", guide.GetBody())
+	assert.Contains(t, guide.GetText(), "Install steps.", "text covers child sections")
+
+	assert.Equal(t, "Own prose.\n\nMore prose.", guide.GetBody(mq.WithoutCode(), mq.WithoutComments()))
+	assert.Equal(t, "Own prose.\n\n```sh\nmake <!-- kept -->\n```\n\nMore prose.", guide.GetBody(mq.WithoutComments()))
+	assert.NotContains(t, guide.GetText(mq.WithoutCode()), "make")
+
+	usage, ok := doc.GetSection("Usage")
+	require.True(t, ok)
+	assert.Equal(t, "Usage\n-----", usage.GetHeadingLine())
+	assert.Equal(t, "Run it.", usage.GetBody())
+}
+
+func TestSectionTextWithoutCommentsKeepsCode(t *testing.T) {
+	src := "# Setup\n\nWrite `<!-- marker -->` first.\n\n    <!-- indented code -->\n    make\n\n<!-- note -->\n\nDone.\n"
+	doc, err := mq.NewParser().Parse([]byte(src), "setup.md")
+	require.NoError(t, err)
+
+	setup, ok := doc.GetSection("Setup")
+	require.True(t, ok)
+	assert.Equal(t, "Write `<!-- marker -->` first.\n\n    <!-- indented code -->\n    make\n\nDone.", setup.GetBody(mq.WithoutComments()))
+}
+
+func TestPreamble(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(bodyMarkdown), "guide.md")
+	require.NoError(t, err)
+
+	preamble := doc.GetPreamble()
+	require.NotNil(t, preamble)
+	assert.Equal(t, 0, preamble.Heading.Level)
+	assert.Equal(t, 5, preamble.Start)
+	assert.Equal(t, 7, preamble.End)
+	assert.Equal(t, "", preamble.GetHeadingLine())
+	assert.Equal(t, "[![CI](https://ci.example.com/badge.svg)](https://ci.example.com)\n\nIntro paragraph.", preamble.GetBody())
+	assert.NotContains(t, doc.GetSections(), preamble)
+
+	doc, err = mq.NewParser().Parse([]byte("# Title\n\nText.\n"), "title.md")
+	require.NoError(t, err)
+	assert.Nil(t, doc.GetPreamble())
+}
@@ -0,0 +1,193 @@
+package mq
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// SlugStyle selects how heading anchors are generated from heading text.
+type SlugStyle string
+
+const (
+	SlugGitHub     SlugStyle = "github"     // Lowercase, punctuation dropped, spaces to "-"
+	SlugGitLab     SlugStyle = "gitlab"     // As GitHub, with runs of "-" collapsed
+	SlugMkDocs     SlugStyle = "mkdocs"     // ASCII only, "_1" suffixes for duplicates
+	SlugDocusaurus SlugStyle = "docusaurus" // Same algorithm as GitHub
+)
+
+// ParseSlugStyle returns the slug style with the given name.
+func ParseSlugStyle(name string) (SlugStyle, bool) {
+	switch style := SlugStyle(strings.ToLower(name)); style {
+	case SlugGitHub, SlugGitLab, SlugMkDocs, SlugDocusaurus:
+		return style, true
+	}
+	return "", false
+}
+
+// WithSlugStyle sets how the parser generates heading IDs. The default is
+// SlugGitHub. Explicit IDs ({#custom-id}) are kept as written.
+func WithSlugStyle(style SlugStyle) ParserOption {
+	return func(p *Parser) {
+		p.slugs = style
+	}
+}
+
+// Slugify converts heading text to an anchor without duplicate handling.
+func Slugify(text string, style SlugStyle) string {
+	switch style {
+	case SlugGitLab:
+		return collapseHyphens(githubSlug(strings.TrimSpace(text)))
+	case SlugMkDocs:
+		return mkdocsSlug(text)
+	default:
+		return githubSlug(text)
+	}
+}
+
+// githubSlug follows github-slugger: lowercase, keep letters, marks,
+// numbers, "_", "-" and spaces, then turn each space into "-".
+func githubSlug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_',
+			unicode.IsLetter(r), unicode.IsMark(r), unicode.IsNumber(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func collapseHyphens(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return s
+}
+
+// mkdocsSlug follows Python-Markdown's toc slugify: fold to ASCII, drop
+// everything but word characters, spaces and "-", then join words with a
+// single "-".
+func mkdocsSlug(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r >= unicode.MaxASCII {
+			r = foldLatin(r)
+		}
+		switch {
+		case r == 0:
+		case r == '-' || r == '_' || unicode.IsSpace(r),
+			'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return strings.Join(strings.FieldsFunc(b.String(), func(r rune) bool {
+		return r == '-' || unicode.IsSpace(r)
+	}), "-")
+}
+
+// latinFolds maps accented Latin letters to the ASCII letter NFKD
+// decomposition leaves behind.
+var latinFolds = map[string]string{
+	"a": "àáâãäåāăą", "A": "ÀÁÂÃÄÅĀĂĄ",
+	"c": "çćĉċč", "C": "ÇĆĈĊČ",
+	"d": "ď", "D": "Ď",
+	"e": "èéêëēĕėęě", "E": "ÈÉÊËĒĔĖĘĚ",
+	"g": "ĝğġģ", "G": "ĜĞĠĢ",
+	"i": "ìíîïĩīĭį", "I": "ÌÍÎÏĨĪĬĮİ",
+	"n": "ñńņň", "N": "ÑŃŅŇ",
+	"o": "òóôõöōŏő", "O": "ÒÓÔÕÖŌŎŐ",
+	"r": "ŕŗř", "R": "ŔŖŘ",
+	"s": "śŝşš", "S": "ŚŜŞŠ",
+	"t": "ţť", "T": "ŢŤ",
+	"u": "ùúûüũūŭůűų", "U": "ÙÚÛÜŨŪŬŮŰŲ",
+	"y": "ýÿ", "Y": "ÝŸ",
+	"z": "źżž", "Z": "ŹŻŽ",
+}
+
+var latinFold = func() map[rune]rune {
+	m := make(map[rune]rune)
+	for ascii, accented := range latinFolds {
+		for _, r := range accented {
+			m[r] = rune(ascii[0])
+		}
+	}
+	return m
+}()
+
+// foldLatin returns the ASCII base of an accented letter, or 0.
+func foldLatin(r rune) rune {
+	return latinFold[r]
+}
+
+// Slugger generates unique heading anchors, suffixing duplicates the way
+// the selected style's site generator does.
+type Slugger struct {
+	style SlugStyle
+	used  map[string]bool
+	count map[string]int
+}
+
+// NewSlugger creates a slugger for a style.
+func NewSlugger(style SlugStyle) *Slugger {
+	return &Slugger{
+		style: style,
+		used:  make(map[string]bool),
+		count: make(map[string]int),
+	}
+}
+
+// Reserve marks an explicit ID as taken.
+func (s *Slugger) Reserve(id string) {
+	s.used[id] = true
+}
+
+var mkdocsCount = regexp.MustCompile(`^(.*)_([0-9]+)$`)
+
+// Slug returns a unique anchor for text: "setup", "setup-1", "setup-2"
+// ("setup_1" for MkDocs).
+func (s *Slugger) Slug(text string) string {
+	base := Slugify(text, s.style)
+	id := base
+	if s.style == SlugMkDocs {
+		// Python-Markdown bumps an existing counter and never
+		// returns an empty ID
+		for s.used[id] || id == "" {
+			if m := mkdocsCount.FindStringSubmatch(id); m != nil {
+				n, _ := strconv.Atoi(m[2])
+				id = m[1] + "_" + strconv.Itoa(n+1)
+			} else {
+				id += "_1"
+			}
+		}
+	} else {
+		for s.used[id] {
+			s.count[base]++
+			id = base + "-" + strconv.Itoa(s.count[base])
+		}
+	}
+	s.used[id] = true
+	return id
+}
+
+// assignHeadingIDs gives headings without an explicit ID a unique slug.
+// Explicit IDs are reserved first so generated ones never collide.
+func assignHeadingIDs(sections []*Section, style SlugStyle) {
+	slugger := NewSlugger(style)
+	for _, s := range sections {
+		if s.Heading.ID != "" {
+			slugger.Reserve(s.Heading.ID)
+		}
+	}
+	for _, s := range sections {
+		if _, markdown := s.Heading.Node.(*ast.Heading); markdown && s.Heading.ID == "" {
+			s.Heading.ID = slugger.Slug(s.Heading.Text)
+		}
+	}
+}
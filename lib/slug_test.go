@@ -0,0 +1,69 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text                   string
+		github, gitlab, mkdocs string
+	}{
+		{"Getting Started", "getting-started", "getting-started", "getting-started"},
+		{"What's new in v2.0?", "whats-new-in-v20", "whats-new-in-v20", "whats-new-in-v20"},
+		{"Foo -- Bar", "foo----bar", "foo-bar", "foo-bar"},
+		{"🚀 Launch", "-launch", "-launch", "launch"},
+		{"Café & Crème", "café--crème", "café-crème", "cafe-creme"},
+		{"snake_case API", "snake_case-api", "snake_case-api", "snake_case-api"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.github, mq.Slugify(tt.text, mq.SlugGitHub), tt.text)
+		assert.Equal(t, tt.github, mq.Slugify(tt.text, mq.SlugDocusaurus), tt.text)
+		assert.Equal(t, tt.gitlab, mq.Slugify(tt.text, mq.SlugGitLab), tt.text)
+		assert.Equal(t, tt.mkdocs, mq.Slugify(tt.text, mq.SlugMkDocs), tt.text)
+	}
+}
+
+const anchorsMarkdown = `# Setup
+
+## Install
+
+## Install
+
+## Custom heading {#install-1}
+
+## Install
+
+## *Styled* ` + "`code`" + ` heading
+`
+
+func TestHeadingIDs(t *testing.T) {
+	ids := func(doc *mq.Document) []string {
+		var got []string
+		for _, h := range doc.GetHeadings() {
+			got = append(got, h.ID)
+		}
+		return got
+	}
+
+	doc, err := mq.NewParser().Parse([]byte(anchorsMarkdown), "setup.md")
+	require.NoError(t, err)
+	// Explicit IDs are reserved before duplicates are numbered
+	assert.Equal(t, []string{"setup", "install", "install-2", "install-1", "install-3", "styled-code-heading"}, ids(doc))
+	assert.Equal(t, "Custom heading", doc.GetHeadings()[3].Text)
+
+	doc, err = mq.NewParser(mq.WithSlugStyle(mq.SlugMkDocs)).Parse([]byte(anchorsMarkdown), "setup.md")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"setup", "install", "install_1", "install-1", "install_2", "styled-code-heading"}, ids(doc))
+
+	// Sections resolve by anchor as well as by title
+	section, ok := doc.GetSection("#install-1")
+	require.True(t, ok)
+	assert.Equal(t, "Custom heading", section.Heading.Text)
+	_, ok = doc.GetSection("#missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,158 @@
+package mq
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Task is a checklist item ("- [ ] ship it") flattened out of its list.
+type Task struct {
+	Text    string
+	Checked bool
+	Depth   int      // 0 for top-level items, 1 for their children, ...
+	Section *Section // Innermost enclosing section, nil before any heading
+	File    string   // Path of the document
+	Position
+}
+
+// SectionTitle returns the heading text of the enclosing section, or "".
+func (t *Task) SectionTitle() string {
+	if t.Section == nil {
+		return ""
+	}
+	return t.Section.Heading.Text
+}
+
+// GetTasks returns the task list items of the document in order. Items
+// of nested lists appear once, at their nesting depth.
+func (d *Document) GetTasks() []*Task {
+	var tasks []*Task
+	seen := make(map[[2]int]bool)
+	sections := d.GetSections()
+
+	var walk func(items []ListItem, depth int)
+	walk = func(items []ListItem, depth int) {
+		for _, item := range items {
+			// Nested lists are also listed on their own
+			key := [2]int{item.Line, item.Column}
+			if item.Line > 0 && seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if item.Checked != nil {
+				tasks = append(tasks, &Task{
+					Text:     item.Text,
+					Checked:  *item.Checked,
+					Depth:    depth,
+					Section:  sectionAt(sections, item.Line),
+					File:     d.path,
+					Position: item.Position,
+				})
+			}
+			walk(item.Children, depth+1)
+		}
+	}
+	for _, list := range d.GetLists(nil) {
+		walk(list.Items, 0)
+	}
+	return tasks
+}
+
+// sectionAt returns the innermost section containing line.
+func sectionAt(sections []*Section, line int) *Section {
+	var found *Section
+	for _, s := range sections {
+		if line >= s.Start && line <= s.End {
+			found = s // later sections are nested deeper
+		}
+	}
+	return found
+}
+
+// TaskResults holds tasks collected across a directory.
+type TaskResults struct {
+	Tasks []*Task
+	Files int // Files with at least one task
+}
+
+// Open returns the number of unchecked tasks.
+func (r *TaskResults) Open() int {
+	open := 0
+	for _, t := range r.Tasks {
+		if !t.Checked {
+			open++
+		}
+	}
+	return open
+}
+
+// String renders the rollup followed by open tasks grouped by file.
+func (r *TaskResults) String() string {
+	if len(r.Tasks) == 0 {
+		return "No tasks found\n"
+	}
+
+	var buf strings.Builder
+	open := r.Open()
+	buf.WriteString(fmt.Sprintf("%d open %s across %d %s (%d done)\n",
+		open, plural(open, "task", "tasks"), r.Files, plural(r.Files, "file", "files"), len(r.Tasks)-open))
+
+	currentFile := ""
+	for _, t := range r.Tasks {
+		if t.Checked {
+			continue
+		}
+		if t.File != currentFile {
+			buf.WriteString(fmt.Sprintf("\n%s:\n", t.File))
+			currentFile = t.File
+		}
+		buf.WriteString(fmt.Sprintf("  %s- [ ] %s", strings.Repeat("  ", t.Depth), t.Text))
+		if t.Line > 0 {
+			buf.WriteString(fmt.Sprintf(" (line %d", t.Line))
+			if title := t.SectionTitle(); title != "" {
+				buf.WriteString(", " + title)
+			}
+			buf.WriteString(")")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// TasksDir collects task list items from all supported files in a directory.
+func TasksDir(dirPath string) (*TaskResults, error) {
+	parser := NewParser()
+	return TasksDirWithLoader(dirPath, parser.ParseFile)
+}
+
+// TasksDirWithLoader collects task list items using a custom loader.
+func TasksDirWithLoader(dirPath string, load documentLoaderFunc) (*TaskResults, error) {
+	results := &TaskResults{}
+
+	err := walkFiles(dirPath, func(path string, d fs.DirEntry) {
+		if !isTraversalFile(path) || strings.HasPrefix(d.Name(), ".") {
+			return
+		}
+
+		doc, err := load(path)
+		if err != nil {
+			return // Skip unparseable files
+		}
+
+		if tasks := doc.GetTasks(); len(tasks) > 0 {
+			results.Tasks = append(results.Tasks, tasks...)
+			results.Files++
+		}
+	})
+
+	return results, err
+}
@@ -0,0 +1,84 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checklistMarkdown = `# Release
+
+- [ ] loose item
+
+## Before
+
+- [x] Bump version
+- [ ] Update changelog
+  - [ ] Link PRs
+  - [x] Credit contributors
+- plain item
+
+## After
+
+1. [ ] Announce
+`
+
+func TestGetTasks(t *testing.T) {
+	doc, err := mq.New().ParseDocument([]byte(checklistMarkdown), "RELEASE.md")
+	require.NoError(t, err)
+
+	type task struct {
+		Text    string
+		Checked bool
+		Depth   int
+		Section string
+		Line    int
+	}
+	var got []task
+	for _, tk := range doc.GetTasks() {
+		got = append(got, task{tk.Text, tk.Checked, tk.Depth, tk.SectionTitle(), tk.Line})
+		assert.Equal(t, "RELEASE.md", tk.File)
+	}
+	// Nested items appear once; plain items are not tasks
+	assert.Equal(t, []task{
+		{"loose item", false, 0, "Release", 3},
+		{"Bump version", true, 0, "Before", 7},
+		{"Update changelog", false, 0, "Before", 8},
+		{"Link PRs", false, 1, "Before", 9},
+		{"Credit contributors", true, 1, "Before", 10},
+		{"Announce", false, 0, "After", 15},
+	}, got)
+
+	// The parent keeps its own text; children are separate items
+	lists := doc.GetLists(nil)
+	require.NotEmpty(t, lists)
+	assert.Equal(t, "Update changelog", lists[1].Items[1].Text)
+	require.Len(t, lists[1].Items[1].Children, 2)
+}
+
+func TestListItemParagraphs(t *testing.T) {
+	src := "- [ ] Ship it\n\n  Tell support first.\n\n- Done\n"
+	doc, err := mq.New().ParseDocument([]byte(src), "todo.md")
+	require.NoError(t, err)
+
+	// Paragraphs of a loose item are joined with a space
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 1)
+	assert.Equal(t, "Ship it Tell support first.", lists[0].Items[0].Text)
+	assert.Equal(t, "Done", lists[0].Items[1].Text)
+	assert.Equal(t, "Ship it Tell support first.", doc.GetTasks()[0].Text)
+}
+
+func TestTaskResultsString(t *testing.T) {
+	results := &mq.TaskResults{
+		Files: 1,
+		Tasks: []*mq.Task{
+			{Text: "Bump version", Checked: true, File: "RELEASE.md", Position: mq.Position{Line: 3}},
+			{Text: "Update changelog", File: "RELEASE.md", Position: mq.Position{Line: 4}},
+		},
+	}
+	assert.Equal(t, 1, results.Open())
+	assert.Equal(t, "1 open task across 1 file (1 done)\n\nRELEASE.md:\n  - [ ] Update changelog (line 4)\n", results.String())
+}
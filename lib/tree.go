@@ -3,7 +3,6 @@ package mq
 import (
 	"fmt"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -21,13 +20,13 @@ const (
 
 // TreeNode represents a node in the document structure tree.
 type TreeNode struct {
-	Type     string      // "section", "code", "table", "list", "link", "image", "frontmatter"
+	Type     string      // "section", "code", "admonition", "math", "table", "list", "link", "image", "frontmatter"
 	Text     string      // Display text (heading text, language, etc.)
 	Preview  string      // First few words of section content
 	Start    int         // Starting line number
 	End      int         // Ending line number
 	Level    int         // Heading level (1-6) for sections
-	Meta     string      // Additional metadata (e.g., "3 blocks", "5 items")
+	Meta     string      // Additional metadata (e.g., "3 blocks", "5 items", a section's file)
 	Children []*TreeNode // Child nodes
 }
 
@@ -76,10 +75,12 @@ func (d *Document) buildSectionTree(section *Section, mode TreeMode) *TreeNode {
 		End:   section.End,
 		Level: section.Heading.Level,
 	}
+	// Sections from another file (LaTeX includes) say which
+	node.Meta = section.file()
 
 	// Add preview text for preview/full modes
 	if mode == TreeModePreview || mode == TreeModeFull {
-		node.Preview = ExtractPreview(section.GetText(), 50)
+		node.Preview = sectionPreview(section, 50)
 	}
 
 	// Add child sections
@@ -91,20 +92,8 @@ func (d *Document) buildSectionTree(section *Section, mode TreeMode) *TreeNode {
 	// Add special elements (only in default mode)
 	if mode == TreeModeDefault {
 		// Code blocks in this section (not children)
-		codeBlocks := section.codeBlocks
-		if len(codeBlocks) > 0 {
-			for lang, count := range CountCodeByLanguage(codeBlocks) {
-				meta := fmt.Sprintf("%d block", count)
-				if count > 1 {
-					meta = fmt.Sprintf("%d blocks", count)
-				}
-				node.Children = append(node.Children, &TreeNode{
-					Type: "code",
-					Text: lang,
-					Meta: meta,
-				})
-			}
-		}
+		node.Children = append(node.Children, CodeTreeNodes(section.codeBlocks)...)
+		node.Children = append(node.Children, SectionTreeNodes(section)...)
 
 		// Tables, lists, links, images would need to be tracked per-section
 		// For now, we'll add them at the document level analysis
@@ -113,6 +102,92 @@ func (d *Document) buildSectionTree(section *Section, mode TreeMode) *TreeNode {
 	return node
 }
 
+// CodeTreeNodes groups code blocks by language, in order of first
+// appearance, with the lines where each language's blocks start.
+func CodeTreeNodes(blocks []*CodeBlock) []*TreeNode {
+	var group treeGroup
+	for _, cb := range blocks {
+		lang := cb.Language
+		if lang == "" {
+			lang = "plain"
+		}
+		group.add("code", lang, cb.Position)
+	}
+	return group.nodes("block", "blocks")
+}
+
+// SectionTreeNodes returns the nodes for the admonitions, math blocks,
+// definitions and footnotes of a section (not its children).
+func SectionTreeNodes(s *Section) []*TreeNode {
+	var admonitions, math, definitions, footnotes treeGroup
+	for _, a := range s.admonitions {
+		admonitions.add("admonition", a.Kind, a.Position)
+	}
+	for _, m := range s.math {
+		math.add("math", "", m.Position)
+	}
+	for _, d := range s.definitions {
+		definitions.add("definitions", "", d.Position)
+	}
+	for _, f := range s.footnotes {
+		footnotes.add("footnotes", "", f.Position)
+	}
+
+	nodes := admonitions.nodes("block", "blocks")
+	nodes = append(nodes, math.nodes("block", "blocks")...)
+	nodes = append(nodes, definitions.nodes("term", "terms")...)
+	return append(nodes, footnotes.nodes("note", "notes")...)
+}
+
+// treeGroup counts elements by label, in order of first appearance.
+type treeGroup struct {
+	order  []*TreeNode
+	byText map[string]*TreeNode
+	counts map[string]int
+	lines  map[string][]string
+}
+
+func (g *treeGroup) add(typ, label string, pos Position) {
+	if g.byText == nil {
+		g.byText = make(map[string]*TreeNode)
+		g.counts = make(map[string]int)
+		g.lines = make(map[string][]string)
+	}
+	node, ok := g.byText[label]
+	if !ok {
+		node = &TreeNode{Type: typ, Text: label, Start: pos.Line, End: pos.End()}
+		g.byText[label] = node
+		g.order = append(g.order, node)
+	}
+	if pos.Line > 0 {
+		g.lines[label] = append(g.lines[label], fmt.Sprint(pos.Line))
+	}
+	g.counts[label]++
+}
+
+// nodes sets each node's Meta to e.g. "2 blocks at lines 47, 59".
+func (g *treeGroup) nodes(one, many string) []*TreeNode {
+	for _, node := range g.order {
+		count, at := g.counts[node.Text], g.lines[node.Text]
+		node.Meta = fmt.Sprintf("%d %s", count, plural(count, one, many))
+		switch {
+		case len(at) == 1 && count == 1:
+			node.Meta += " at line " + at[0]
+		case len(at) == count:
+			node.Meta += " at lines " + strings.Join(at, ", ")
+		}
+	}
+	return g.order
+}
+
+// sectionPreview prefers a section's doc summary over its leading content.
+func sectionPreview(s *Section, maxChars int) string {
+	if s.Doc != "" {
+		return ExtractPreview("\n"+s.Doc, maxChars)
+	}
+	return ExtractPreview(s.GetText(), maxChars)
+}
+
 // ExtractPreview extracts the first few words from section content.
 func ExtractPreview(text string, maxChars int) string {
 	// Skip the heading line
@@ -198,8 +273,12 @@ func (t *TreeResult) renderNode(buf *strings.Builder, node *TreeNode, prefix str
 	switch node.Type {
 	case "section":
 		levelPrefix := strings.Repeat("#", node.Level)
-		buf.WriteString(fmt.Sprintf("%s%s%s %s (%d-%d)\n",
-			prefix, connector, levelPrefix, node.Text, node.Start, node.End))
+		lines := fmt.Sprintf("%d-%d", node.Start, node.End)
+		if node.Meta != "" {
+			lines = node.Meta + ":" + lines
+		}
+		buf.WriteString(fmt.Sprintf("%s%s%s %s (%s)\n",
+			prefix, connector, levelPrefix, node.Text, lines))
 
 		// Render preview if available
 		if node.Preview != "" {
@@ -226,6 +305,12 @@ func (t *TreeResult) renderNode(buf *strings.Builder, node *TreeNode, prefix str
 	case "image":
 		buf.WriteString(fmt.Sprintf("%s%s[image: %s]\n",
 			prefix, connector, node.Meta))
+	case "admonition":
+		buf.WriteString(fmt.Sprintf("%s%s[admonition: %s, %s]\n",
+			prefix, connector, node.Text, node.Meta))
+	case "math", "definitions", "footnotes":
+		buf.WriteString(fmt.Sprintf("%s%s[%s: %s]\n",
+			prefix, connector, node.Type, node.Meta))
 	}
 
 	// Calculate child prefix
@@ -259,6 +344,7 @@ type SearchResult struct {
 	File    string // File path
 	Section string // Section heading
 	Lines   string // Line range (e.g., "34-89")
+	Line    int    // Line of the first match, 0 if unknown
 	Match   string // Snippet with match context
 }
 
@@ -270,28 +356,13 @@ type SearchResults struct {
 
 type documentLoaderFunc func(path string) (*Document, error)
 
-var traversalExtensions = map[string]struct{}{
-	".md":       {},
-	".markdown": {},
-	".mdown":    {},
-	".mkd":      {},
-	".html":     {},
-	".htm":      {},
-	".xhtml":    {},
-	".pdf":      {},
-	".json":     {},
-	".jsonl":    {},
-	".ndjson":   {},
-	".yaml":     {},
-	".yml":      {},
-}
-
+// isTraversalFile reports whether directory mode parses path: any file
+// whose extension or name a registered format recognizes, including
+// compressed ones such as notes.md.gz.
 func isTraversalFile(path string) bool {
-	_, ok := traversalExtensions[strings.ToLower(filepath.Ext(path))]
-	return ok
+	return IsRecognized(path)
 }
 
-// Search finds sections containing the query term.
 func (d *Document) Search(query string) *SearchResults {
 	results := &SearchResults{Query: query}
 	query = strings.ToLower(query)
@@ -306,10 +377,16 @@ func (d *Document) Search(query string) *SearchResults {
 		if strings.Contains(strings.ToLower(text), query) {
 			// Find a snippet around the match
 			snippet := extractSnippet(text, query, 60)
+			// Lines of sections from another file (LaTeX includes) are in it
+			file := section.file()
+			if file == "" {
+				file = d.path
+			}
 			results.Matches = append(results.Matches, &SearchResult{
-				File:    d.path,
+				File:    file,
 				Section: section.Heading.Text,
 				Lines:   fmt.Sprintf("%d-%d", section.Start, section.End),
+				Line:    section.Start + matchLine(text, query) - 1,
 				Match:   snippet,
 			})
 		}
@@ -324,18 +401,34 @@ func (d *Document) Search(query string) *SearchResults {
 			if section == "" {
 				section = "Document"
 			}
-			results.Matches = append(results.Matches, &SearchResult{
+			result := &SearchResult{
 				File:    d.path,
 				Section: section,
 				Lines:   "n/a",
 				Match:   extractSnippet(text, query, 60),
-			})
+			}
+			// The readable text may be the source itself (plain text)
+			if line := matchLine(string(d.source), query); line > 0 {
+				result.Line = line
+				result.Lines = fmt.Sprint(line)
+			}
+			results.Matches = append(results.Matches, result)
 		}
 	}
 
 	return results
 }
 
+// matchLine returns the 1-based line of the first case-insensitive match
+// of query in text, or 0.
+func matchLine(text, query string) int {
+	idx := strings.Index(strings.ToLower(text), strings.ToLower(query))
+	if idx < 0 {
+		return 0
+	}
+	return strings.Count(text[:idx], "\n") + 1
+}
+
 // extractSnippet extracts text around the first match.
 func extractSnippet(text, query string, contextLen int) string {
 	lower := strings.ToLower(text)
@@ -386,7 +479,11 @@ func (r *SearchResults) String() string {
 			buf.WriteString(fmt.Sprintf("%s:\n", m.File))
 			currentFile = m.File
 		}
-		buf.WriteString(fmt.Sprintf("  ## %s (lines %s)\n", m.Section, m.Lines))
+		if m.Line > 0 && m.Lines != fmt.Sprint(m.Line) {
+			buf.WriteString(fmt.Sprintf("  ## %s (lines %s, match at line %d)\n", m.Section, m.Lines, m.Line))
+		} else {
+			buf.WriteString(fmt.Sprintf("  ## %s (lines %s)\n", m.Section, m.Lines))
+		}
 		if m.Match != "" {
 			buf.WriteString(fmt.Sprintf("     %q\n", m.Match))
 		}
@@ -405,30 +502,45 @@ func SearchDir(dirPath string, query string) (*SearchResults, error) {
 func SearchDirWithLoader(dirPath string, query string, load documentLoaderFunc) (*SearchResults, error) {
 	results := &SearchResults{Query: query}
 
-	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip errors
-		}
-		if d.IsDir() || !isTraversalFile(path) {
-			return nil
-		}
-		if strings.HasPrefix(d.Name(), ".") {
-			return nil
+	err := walkFiles(dirPath, func(path string, d fs.DirEntry) {
+		if !isTraversalFile(path) || strings.HasPrefix(d.Name(), ".") {
+			return
 		}
 
 		doc, err := load(path)
 		if err != nil {
-			return nil // Skip unparseable files
+			return // Skip unparseable files
 		}
 
 		fileResults := doc.Search(query)
 		results.Matches = append(results.Matches, fileResults.Matches...)
-		return nil
 	})
 
 	return results, err
 }
 
+// walkFiles calls fn for every file under dirPath in lexical order,
+// descending into subdirectories and archives. Unreadable subdirectories
+// are skipped.
+func walkFiles(dirPath string, fn func(path string, d fs.DirEntry)) error {
+	entries, err := readDirPath(dirPath)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		path := filepath.Join(dirPath, entry.Name())
+		if isDirEntry(entry) {
+			_ = walkFiles(path, fn)
+			continue
+		}
+		fn(path, entry)
+	}
+	return nil
+}
+
 // DirHeading represents a heading with optional preview.
 type DirHeading struct {
 	Text    string // Heading text with level prefix (e.g., "## Installation")
@@ -482,18 +594,18 @@ func BuildDirTreeWithLoader(dirPath string, mode TreeMode, load documentLoaderFu
 
 // buildDirNode recursively builds directory tree nodes.
 func buildDirNode(path string, mode TreeMode, result *DirTreeResult, load documentLoaderFunc) (*DirFileNode, error) {
-	info, err := os.Stat(path)
+	name, isDir, err := statPath(path)
 	if err != nil {
 		return nil, err
 	}
 
 	node := &DirFileNode{
-		Name:  info.Name(),
+		Name:  name,
 		Path:  path,
-		IsDir: info.IsDir(),
+		IsDir: isDir,
 	}
 
-	if !info.IsDir() {
+	if !isDir {
 		// It's a file - parse it
 		if isTraversalFile(path) {
 			doc, err := load(path)
@@ -522,7 +634,7 @@ func buildDirNode(path string, mode TreeMode, result *DirTreeResult, load docume
 					}
 					// Add preview for full mode
 					if mode == TreeModeFull {
-						heading.Preview = ExtractPreview(section.GetText(), 50)
+						heading.Preview = sectionPreview(section, 50)
 					}
 					node.TopHeadings = append(node.TopHeadings, heading)
 
@@ -533,7 +645,7 @@ func buildDirNode(path string, mode TreeMode, result *DirTreeResult, load docume
 								Text: formatTreeLabel(doc.Format(), child.Heading),
 							}
 							if mode == TreeModeFull {
-								childHeading.Preview = ExtractPreview(child.GetText(), 50)
+								childHeading.Preview = sectionPreview(child, 50)
 							}
 							node.TopHeadings = append(node.TopHeadings, childHeading)
 						}
@@ -544,16 +656,16 @@ func buildDirNode(path string, mode TreeMode, result *DirTreeResult, load docume
 		return node, nil
 	}
 
-	// It's a directory - read entries
-	entries, err := os.ReadDir(path)
+	// It's a directory (or an archive) - read entries
+	entries, err := readDirPath(path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Sort: directories first, then files, both alphabetically
 	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].IsDir() != entries[j].IsDir() {
-			return entries[i].IsDir()
+		if isDirEntry(entries[i]) != isDirEntry(entries[j]) {
+			return isDirEntry(entries[i])
 		}
 		return entries[i].Name() < entries[j].Name()
 	})
@@ -567,7 +679,7 @@ func buildDirNode(path string, mode TreeMode, result *DirTreeResult, load docume
 		childPath := filepath.Join(path, entry.Name())
 
 		// For files, only include supported formats
-		if !entry.IsDir() && !isTraversalFile(entry.Name()) {
+		if !isDirEntry(entry) && !isTraversalFile(childPath) {
 			continue
 		}
 
@@ -625,7 +737,7 @@ func (t *DirTreeResult) renderNode(buf *strings.Builder, node *DirFileNode, pref
 			case node.Count == 0:
 				buf.WriteString(fmt.Sprintf("%s%s%s (%d lines, no %s)\n", prefix, connector, node.Name, node.Lines, label))
 			case node.Count == 1:
-				buf.WriteString(fmt.Sprintf("%s%s%s (%d lines, 1 %s)\n", prefix, connector, node.Name, node.Lines, singularLabel(label)))
+				buf.WriteString(fmt.Sprintf("%s%s%s (%d lines, 1 %s)\n", prefix, connector, node.Name, node.Lines, singularLabel(node.Format, label)))
 			default:
 				buf.WriteString(fmt.Sprintf("%s%s%s (%d lines, %d %s)\n", prefix, connector, node.Name, node.Lines, node.Count, label))
 			}
@@ -671,33 +783,26 @@ func (t *DirTreeResult) renderNode(buf *strings.Builder, node *DirFileNode, pref
 	}
 }
 
+// formatTreeLabel renders a heading with its format's registered Label.
 func formatTreeLabel(format Format, h *Heading) string {
-	switch format {
-	case FormatMarkdown:
-		return fmt.Sprintf("%s %s", strings.Repeat("#", h.Level), h.Text)
-	case FormatHTML, FormatPDF:
-		return fmt.Sprintf("H%d %s", h.Level, h.Text)
-	case FormatJSON, FormatYAML:
-		if h.Level <= 1 {
-			return fmt.Sprintf("key %s", h.Text)
-		}
-		return fmt.Sprintf("subkey %s", h.Text)
-	case FormatJSONL:
-		return fmt.Sprintf("field %s", h.Text)
-	default:
-		return fmt.Sprintf("H%d %s", h.Level, h.Text)
+	if info, ok := formatInfo(format); ok && info.Label != nil {
+		return info.Label(h)
 	}
+	return fmt.Sprintf("H%d %s", h.Level, h.Text)
 }
 
+// describeStructure counts a document's structural units and names them
+// in the plural, using its format's registered Count and Units.
 func describeStructure(doc *Document) (int, string) {
-	switch doc.Format() {
-	case FormatJSON, FormatYAML:
-		return len(doc.GetSections()), "keys"
-	case FormatJSONL:
-		return countJSONLRecords(doc.Source()), "records"
-	default:
-		return len(doc.GetSections()), "sections"
+	info, _ := formatInfo(doc.Format())
+	count := len(doc.GetSections())
+	if info.Count != nil {
+		count = info.Count(doc)
 	}
+	if info.Units == "" {
+		return count, "sections"
+	}
+	return count, info.Units
 }
 
 func countJSONLRecords(source []byte) int {
@@ -723,15 +828,11 @@ func countJSONLRecords(source []byte) int {
 	return count
 }
 
-var pluralToSingular = map[string]string{
-	"keys":     "key",
-	"records":  "record",
-	"sections": "section",
-}
-
-func singularLabel(label string) string {
-	if s, ok := pluralToSingular[label]; ok {
-		return s
+// singularLabel names one structural unit of format, given the plural
+// label from describeStructure.
+func singularLabel(format Format, label string) string {
+	if info, ok := formatInfo(format); ok && info.Units == label && info.Unit != "" {
+		return info.Unit
 	}
-	return label
+	return strings.TrimSuffix(label, "s")
 }
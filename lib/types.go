@@ -7,16 +7,43 @@ import (
 	"github.com/yuin/goldmark/ast"
 )
 
-// Metadata represents YAML frontmatter in a markdown document.
+// Metadata represents a document's frontmatter (YAML, TOML or JSON) or
+// format-specific properties. Values keep their decoded types: strings,
+// numbers, booleans, time.Time, lists and nested maps.
 type Metadata map[string]interface{}
 
 // Heading represents a markdown heading with metadata.
 type Heading struct {
-	Level int      // 1-6 for H1-H6
+	Level int      // 1-6 for H1-H6, 0 for a document's preamble
 	Text  string   // The heading text
 	ID    string   // Auto-generated or explicit ID for anchoring
 	Node  ast.Node // Reference to the AST node
-	Line  int      // Line number in the document
+
+	Line      int    // Line number in the document
+	Column    int    // Starting column
+	EndLine   int    // Ending line, inclusive
+	EndColumn int    // Column of the last byte, inclusive
+	File      string // File the lines are in, "" for the document's own
+}
+
+// Position returns the heading's source position.
+func (h *Heading) Position() Position {
+	return Position{Line: h.Line, Column: h.Column, EndLine: h.EndLine, EndColumn: h.EndColumn, File: h.File}
+}
+
+// SetPosition sets the heading's source position.
+func (h *Heading) SetPosition(p Position) {
+	h.Line, h.Column, h.EndLine, h.EndColumn, h.File = p.Line, p.Column, p.EndLine, p.EndColumn, p.File
+}
+
+// End returns the ending line, falling back to the starting line.
+func (h *Heading) End() int {
+	return h.Position().End()
+}
+
+// Range renders the line range as "12" or "12-18" for citations.
+func (h *Heading) Range() string {
+	return h.Position().Range()
 }
 
 // Section represents a document section defined by a heading.
@@ -27,31 +54,23 @@ type Section struct {
 	Children []*Section // Child sections
 	Start    int        // Starting line number
 	End      int        // Ending line number
+	Metadata Metadata   // Format-specific attributes (e.g., XML element attributes)
+	Doc      string     // Summary shown as the tree preview (e.g., Go doc comments)
 	source   []byte     // Reference to document source for text extraction
 
 	// Store references to extracted elements for this section
-	codeBlocks []*CodeBlock // Code blocks in this section (not children)
+	codeBlocks  []*CodeBlock // Code blocks in this section (not children)
+	footnotes   []*Footnote
+	definitions []*Definition
+	admonitions []*Admonition
+	math        []*MathBlock
+	diagrams    []*Diagram
 }
 
-// GetText extracts the raw markdown content from the section using line numbers.
-func (s *Section) GetText() string {
-	if s.source == nil || s.Start == 0 || s.End == 0 {
-		return ""
-	}
-
-	lines := strings.Split(string(s.source), "\n")
-	if s.Start > len(lines) {
-		return ""
-	}
-
-	end := s.End
-	if end > len(lines) {
-		end = len(lines)
-	}
-
-	// Extract lines (1-indexed to 0-indexed)
-	sectionLines := lines[s.Start-1 : end]
-	return strings.Join(sectionLines, "\n")
+// GetText extracts the raw markdown content of the section, heading and
+// child sections included, using line numbers.
+func (s *Section) GetText(opts ...TextOption) string {
+	return filterText(s.lines(s.Start, s.End), opts)
 }
 
 // GetCodeBlocks returns all code blocks in this section and its children.
@@ -79,12 +98,62 @@ func (s *Section) AddCodeBlock(cb *CodeBlock) {
 	s.codeBlocks = append(s.codeBlocks, cb)
 }
 
-// CodeBlock represents a fenced code block.
+// GetFootnotes returns footnote definitions in this section and its children.
+func (s *Section) GetFootnotes() []*Footnote {
+	notes := s.footnotes
+	for _, child := range s.Children {
+		notes = append(notes, child.GetFootnotes()...)
+	}
+	return notes
+}
+
+// GetDefinitions returns definition list terms in this section and its
+// children, optionally only the given terms (case-insensitive).
+func (s *Section) GetDefinitions(terms ...string) []*Definition {
+	defs := filterDefinitions(s.definitions, terms)
+	for _, child := range s.Children {
+		defs = append(defs, child.GetDefinitions(terms...)...)
+	}
+	return defs
+}
+
+// GetAdmonitions returns admonitions in this section and its children,
+// optionally only the given kinds (case-insensitive).
+func (s *Section) GetAdmonitions(kinds ...string) []*Admonition {
+	admonitions := filterAdmonitions(s.admonitions, kinds)
+	for _, child := range s.Children {
+		admonitions = append(admonitions, child.GetAdmonitions(kinds...)...)
+	}
+	return admonitions
+}
+
+// GetMath returns math blocks in this section and its children.
+func (s *Section) GetMath() []*MathBlock {
+	blocks := s.math
+	for _, child := range s.Children {
+		blocks = append(blocks, child.GetMath()...)
+	}
+	return blocks
+}
+
+// GetDiagrams returns diagrams in this section and its children,
+// optionally only the given languages or kinds.
+func (s *Section) GetDiagrams(kinds ...string) []*Diagram {
+	diagrams := filterDiagrams(s.diagrams, kinds)
+	for _, child := range s.Children {
+		diagrams = append(diagrams, child.GetDiagrams(kinds...)...)
+	}
+	return diagrams
+}
+
+// CodeBlock represents a fenced or indented code block.
 type CodeBlock struct {
 	Language string   // Programming language identifier
 	Content  string   // The code content
 	Node     ast.Node // Reference to the AST node
 	Lines    int      // Number of lines in the code block
+	Indented bool     // Indented (4-space) block; these have no language
+	Position          // Fences included
 }
 
 // GetLines returns the number of lines in the code block.
@@ -95,11 +164,39 @@ func (c *CodeBlock) GetLines() int {
 	return c.Lines
 }
 
+// InlineCode is a backtick code span, which usually names an API,
+// command or file.
+type InlineCode struct {
+	Text string // Content without the backticks
+	Node ast.Node
+	Position
+}
+
+// Link kinds. Formats that don't distinguish them leave Kind empty.
+const (
+	LinkInline     = "inline"     // [text](url)
+	LinkReference  = "reference"  // [text][label], [label][] or [label]
+	LinkAutolink   = "autolink"   // <https://...>
+	LinkBare       = "bare"       // https://... in plain text
+	LinkDefinition = "definition" // [label]: url "title"
+	LinkWiki       = "wikilink"   // [[Page#Heading|alias]]
+	LinkEmbed      = "embed"      // ![[Page]]
+)
+
 // Link represents a markdown link.
 type Link struct {
-	Text string // Display text
-	URL  string // Target URL
-	Node ast.Node
+	Text  string // Display text, with emphasis and code spans flattened
+	URL   string // Target URL
+	Title string // Optional title
+	Label string // Reference label, for references and definitions
+	Kind  string // One of the Link* kinds
+
+	// Wikilinks and embeds: target page and heading anchor. URL is
+	// "Page#Heading" and Text the alias, or the URL without one.
+	Page   string
+	Anchor string
+	Node   ast.Node
+	Position
 }
 
 // Image represents a markdown image.
@@ -108,6 +205,7 @@ type Image struct {
 	URL     string // Image URL
 	Title   string // Optional title
 	Node    ast.Node
+	Position
 }
 
 // Table represents a markdown table.
@@ -115,6 +213,70 @@ type Table struct {
 	Headers []string
 	Rows    [][]string
 	Node    ast.Node
+	Position
+}
+
+// Footnote is a footnote definition ("[^1]: ...").
+type Footnote struct {
+	Label   string // Reference label, without "[^" and "]"
+	Content string
+	Node    ast.Node
+	Position
+}
+
+// Definition is a term of a definition list with its descriptions.
+type Definition struct {
+	Term         string
+	Descriptions []string
+	Node         ast.Node
+	Position
+}
+
+// Admonition is a callout block: a GitHub alert ("> [!WARNING]") or an
+// MkDocs admonition ("!!! note \"Title\"").
+type Admonition struct {
+	Kind    string // Lowercase kind: note, tip, warning, ...
+	Title   string // Explicit title, if any
+	Content string
+	Node    ast.Node
+	Position
+}
+
+// MathBlock is a display math block delimited by "$$".
+type MathBlock struct {
+	Content string // TeX source without the delimiters
+	Node    ast.Node
+	Position
+}
+
+// Diagram is a Mermaid, PlantUML or Graphviz code block parsed into
+// nodes and edges. The block stays available as a CodeBlock too.
+type Diagram struct {
+	Language string // mermaid, plantuml or dot
+	Kind     string // flowchart, sequence, class, state, graph, digraph, ...
+	Nodes    []*DiagramNode
+	Edges    []*DiagramEdge
+	Code     *CodeBlock
+	Position
+}
+
+// DiagramNode is a node, participant, class or state, positioned where
+// it first appears.
+type DiagramNode struct {
+	ID    string
+	Label string // Display text, the ID when there is none
+	Kind  string // participant, actor, class, state, ...; "" for plain nodes
+	Position
+}
+
+// DiagramEdge is an arrow, message or relation. From and To are node IDs
+// as written left to right.
+type DiagramEdge struct {
+	From  string
+	To    string
+	Label string // Edge label or message text
+	Arrow string // Operator as written: "-->", "->>", "<|--", "->"
+	Position
 }
 
 // List represents a markdown list.
@@ -122,6 +284,7 @@ type List struct {
 	Ordered bool       // true for numbered lists
 	Items   []ListItem // List items
 	Node    ast.Node
+	Position
 }
 
 // ListItem represents an item in a list.
@@ -129,6 +292,7 @@ type ListItem struct {
 	Text     string
 	Checked  *bool // For task lists (nil if not a task item)
 	Children []ListItem
+	Position
 }
 
 // helper functions
@@ -0,0 +1,356 @@
+package mq
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// wikiLinkExtension parses Obsidian-style [[Page#Heading|alias]] links
+// and ![[embeds]].
+type wikiLinkExtension struct{}
+
+func (wikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		// Before links, which would take "[[" as a nested bracket
+		util.Prioritized(wikiLinkParser{}, 150),
+	))
+}
+
+// wikiLinkNode is a wikilink or embed. Its text child is the alias, or
+// the target when there is none.
+type wikiLinkNode struct {
+	ast.BaseInline
+	page, anchor, alias string
+	embed               bool
+	start, stop         int // Byte range including the brackets
+}
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+func (n *wikiLinkNode) Kind() ast.NodeKind { return kindWikiLink }
+
+func (n *wikiLinkNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Page": n.page}, nil)
+}
+
+type wikiLinkParser struct{}
+
+func (wikiLinkParser) Trigger() []byte { return []byte{'!', '['} }
+
+func (wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	open := 2
+	if bytes.HasPrefix(line, []byte("![[")) {
+		open = 3
+	} else if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[open:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	inner := line[open : open+end]
+	if len(bytes.TrimSpace(inner)) == 0 || bytes.ContainsAny(inner, "[]\n") {
+		return nil
+	}
+
+	node := &wikiLinkNode{
+		embed: open == 3,
+		start: segment.Start,
+		stop:  segment.Start + open + end + 2,
+	}
+	target, display := inner, segment.Start+open
+	if i := bytes.IndexByte(inner, '|'); i >= 0 {
+		target = inner[:i]
+		node.alias = strings.TrimSpace(string(inner[i+1:]))
+		display += i + 1
+	}
+	page, anchor, _ := strings.Cut(string(target), "#")
+	node.page = strings.TrimSpace(page)
+	node.anchor = strings.TrimSpace(anchor)
+
+	node.AppendChild(node, ast.NewTextSegment(text.NewSegment(display, segment.Start+open+end)))
+	block.Advance(open + end + 2)
+	return node
+}
+
+func extractWikiLink(n *wikiLinkNode, idx *LineIndex) *Link {
+	link := &Link{
+		Text:     n.alias,
+		URL:      n.page,
+		Page:     n.page,
+		Anchor:   n.anchor,
+		Kind:     LinkWiki,
+		Node:     n,
+		Position: idx.Position(n.start, n.stop),
+	}
+	if n.anchor != "" {
+		link.URL += "#" + n.anchor
+	}
+	if link.Text == "" {
+		link.Text = link.URL
+	}
+	if n.embed {
+		link.Kind = LinkEmbed
+	}
+	return link
+}
+
+// GetWikiLinks returns the wikilinks and embeds of the document.
+func (d *Document) GetWikiLinks() []*Link {
+	var links []*Link
+	for _, l := range d.GetLinks() {
+		if l.Kind == LinkWiki || l.Kind == LinkEmbed {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// wikiGraph resolves wikilink targets in a directory to files, by file
+// name without extension and by frontmatter aliases.
+type wikiGraph struct {
+	files []string           // Documents in walk order
+	pages map[string]string  // Normalized page name or alias -> file
+	links map[string][]*Link // File -> its wikilinks and embeds
+	docs  map[string]*Document
+}
+
+func loadWikiGraph(dirPath string, load documentLoaderFunc) (*wikiGraph, error) {
+	g := &wikiGraph{
+		pages: make(map[string]string),
+		links: make(map[string][]*Link),
+		docs:  make(map[string]*Document),
+	}
+
+	err := walkFiles(dirPath, func(path string, d fs.DirEntry) {
+		if !isTraversalFile(path) || strings.HasPrefix(d.Name(), ".") {
+			return
+		}
+
+		doc, err := load(path)
+		if err != nil {
+			return // Skip unparseable files
+		}
+
+		g.files = append(g.files, path)
+		g.docs[path] = doc
+		g.links[path] = doc.GetWikiLinks()
+
+		// Paths relative to the directory win over bare names
+		if rel, err := filepath.Rel(dirPath, path); err == nil {
+			g.add(pageName(rel), path)
+		}
+		g.add(pageName(filepath.Base(path)), path)
+		for _, alias := range stringList(doc.metadata["aliases"]) {
+			g.add(normalizePage(alias), path)
+		}
+	})
+
+	return g, err
+}
+
+func (g *wikiGraph) add(name, file string) {
+	if _, taken := g.pages[name]; !taken && name != "" {
+		g.pages[name] = file
+	}
+}
+
+// resolve returns the file a wikilink target refers to.
+func (g *wikiGraph) resolve(target string) (string, bool) {
+	name := normalizePage(target)
+	if file, ok := g.pages[name]; ok {
+		return file, true
+	}
+	file, ok := g.pages[pageName(name)]
+	if !ok {
+		file, ok = g.pages[pageName(filepath.Base(name))]
+	}
+	return file, ok
+}
+
+// pageName is a path without its extension, normalized for lookup.
+func pageName(path string) string {
+	path = filepath.ToSlash(stripCompression(path))
+	return normalizePage(strings.TrimSuffix(path, filepath.Ext(path)))
+}
+
+func normalizePage(name string) string {
+	return strings.ToLower(strings.TrimSpace(filepath.ToSlash(name)))
+}
+
+// stringList reads a frontmatter value that is a string or a list.
+func stringList(val interface{}) []string {
+	switch v := val.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// Backlink is a wikilink pointing at a page.
+type Backlink struct {
+	File    string // File containing the link
+	Section string // Heading of the enclosing section, if any
+	Link    *Link
+}
+
+// BacklinkResults holds the backlinks of a page across a directory.
+type BacklinkResults struct {
+	Page      string
+	File      string // File the page resolved to, "" if none
+	Backlinks []*Backlink
+}
+
+// String renders the backlinks grouped by file.
+func (r *BacklinkResults) String() string {
+	if len(r.Backlinks) == 0 {
+		return fmt.Sprintf("No backlinks to %s\n", r.Page)
+	}
+
+	var buf strings.Builder
+	files := make(map[string]bool)
+	for _, b := range r.Backlinks {
+		files[b.File] = true
+	}
+	target := r.Page
+	if r.File != "" {
+		target += " (" + r.File + ")"
+	}
+	buf.WriteString(fmt.Sprintf("%d %s to %s from %d %s\n", len(r.Backlinks), plural(len(r.Backlinks), "backlink", "backlinks"),
+		target, len(files), plural(len(files), "file", "files")))
+
+	currentFile := ""
+	for _, b := range r.Backlinks {
+		if b.File != currentFile {
+			buf.WriteString(fmt.Sprintf("\n%s:\n", b.File))
+			currentFile = b.File
+		}
+		buf.WriteString(fmt.Sprintf("  [[%s]]", b.Link.URL))
+		if b.Link.Line > 0 {
+			buf.WriteString(fmt.Sprintf(" (line %d", b.Link.Line))
+			if b.Section != "" {
+				buf.WriteString(", " + b.Section)
+			}
+			buf.WriteString(")")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// BacklinksDir finds the wikilinks and embeds pointing at page in a
+// directory. page is a file name without extension, a path or an alias.
+func BacklinksDir(dirPath, page string) (*BacklinkResults, error) {
+	parser := NewParser()
+	return BacklinksDirWithLoader(dirPath, page, parser.ParseFile)
+}
+
+// BacklinksDirWithLoader finds backlinks using a custom loader.
+func BacklinksDirWithLoader(dirPath, page string, load documentLoaderFunc) (*BacklinkResults, error) {
+	g, err := loadWikiGraph(dirPath, load)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &BacklinkResults{Page: page}
+	file, resolved := g.resolve(page)
+	if resolved {
+		results.File = file
+	}
+	for _, path := range g.files {
+		sections := g.docs[path].GetSections()
+		for _, link := range g.links[path] {
+			target, ok := g.resolve(link.Page)
+			switch {
+			case resolved && (!ok || target != file):
+				continue
+			case !resolved && normalizePage(link.Page) != normalizePage(page):
+				continue // Links to a page that doesn't exist yet
+			case ok && target == path:
+				continue // Links within the page itself
+			}
+			b := &Backlink{File: path, Link: link}
+			if s := sectionAt(sections, link.Line); s != nil {
+				b.Section = s.Heading.Text
+			}
+			results.Backlinks = append(results.Backlinks, b)
+		}
+	}
+	return results, nil
+}
+
+// OrphanResults holds the Markdown files no wikilink points at.
+type OrphanResults struct {
+	Files []string
+	Total int // Markdown files considered
+}
+
+// String lists the orphaned files.
+func (r *OrphanResults) String() string {
+	if len(r.Files) == 0 {
+		return fmt.Sprintf("No orphans among %d %s\n", r.Total, plural(r.Total, "page", "pages"))
+	}
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("%d of %d %s not linked from any other page:\n",
+		len(r.Files), r.Total, plural(r.Total, "page", "pages")))
+	for _, f := range r.Files {
+		buf.WriteString("  " + f + "\n")
+	}
+	return buf.String()
+}
+
+// OrphansDir finds the Markdown files in a directory that no wikilink or
+// embed in another file resolves to.
+func OrphansDir(dirPath string) (*OrphanResults, error) {
+	parser := NewParser()
+	return OrphansDirWithLoader(dirPath, parser.ParseFile)
+}
+
+// OrphansDirWithLoader finds orphans using a custom loader.
+func OrphansDirWithLoader(dirPath string, load documentLoaderFunc) (*OrphanResults, error) {
+	g, err := loadWikiGraph(dirPath, load)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make(map[string]bool)
+	for _, path := range g.files {
+		for _, link := range g.links[path] {
+			if target, ok := g.resolve(link.Page); ok && target != path {
+				linked[target] = true
+			}
+		}
+	}
+
+	results := &OrphanResults{}
+	for _, path := range g.files {
+		if f := g.docs[path].Format(); f != FormatMarkdown && f != FormatMDX {
+			continue
+		}
+		results.Total++
+		if !linked[path] {
+			results.Files = append(results.Files, path)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,36 @@
+package mq_test
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const wikiMarkdown = "# Notes\n" +
+	"\n" +
+	"See [[Project Plan#Goals|the goals]] and [[Glossary]].\n" +
+	"![[diagram.png]] but not `[[code]]` or [[]].\n"
+
+func TestWikiLinks(t *testing.T) {
+	doc, err := mq.NewParser().Parse([]byte(wikiMarkdown), "notes.md")
+	require.NoError(t, err)
+
+	type link struct {
+		Kind, Text, URL, Page, Anchor string
+		Line, Column                  int
+	}
+	var got []link
+	for _, l := range doc.GetWikiLinks() {
+		got = append(got, link{l.Kind, l.Text, l.URL, l.Page, l.Anchor, l.Line, l.Column})
+	}
+	assert.Equal(t, []link{
+		{mq.LinkWiki, "the goals", "Project Plan#Goals", "Project Plan", "Goals", 3, 5},
+		{mq.LinkWiki, "Glossary", "Glossary", "Glossary", "", 3, 42},
+		{mq.LinkEmbed, "diagram.png", "diagram.png", "diagram.png", "", 4, 1},
+	}, got)
+
+	// The alias is the visible text of the section
+	assert.Contains(t, doc.GetSections()[0].GetText(), "the goals")
+}
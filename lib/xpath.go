@@ -0,0 +1,220 @@
+package mq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// XPath selects sections with a small subset of XPath evaluated against
+// the section tree, where each step matches a section's heading text.
+// For XML documents heading text is the element name, so paths read
+// like ordinary XPath; for other formats it walks headings.
+//
+// Supported syntax:
+//
+//	/project/dependencies/dependency   child steps from the root
+//	//dependency                       descendants at any depth
+//	/rss/channel/*                     any child
+//	//item[1], //item[last()]          position among siblings (1-based)
+//	//dependency[@scope]               has attribute
+//	//dependency[@scope='test']        attribute equals value
+//
+// A path without a leading slash is treated as //path.
+func (d *Document) XPath(expr string) ([]*Section, error) {
+	steps, err := parseXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A virtual root whose children are the top-level sections.
+	root := &Section{Children: d.GetTableOfContents()}
+	context := []*Section{root}
+
+	for _, step := range steps {
+		var next []*Section
+		seen := make(map[*Section]bool)
+
+		for _, node := range context {
+			var candidates []*Section
+			if step.descendant {
+				candidates = descendants(node)
+			} else {
+				candidates = node.Children
+			}
+
+			matched := step.apply(candidates)
+			for _, s := range matched {
+				if !seen[s] {
+					seen[s] = true
+					next = append(next, s)
+				}
+			}
+		}
+		context = next
+	}
+
+	return context, nil
+}
+
+// xpathStep is one location step, e.g. //item[@id='x'][2].
+type xpathStep struct {
+	descendant bool
+	name       string // heading text or "*"
+	predicates []xpathPredicate
+}
+
+// xpathPredicate filters step results.
+type xpathPredicate struct {
+	position int    // 1-based position, -1 for last(), 0 if unused
+	attr     string // attribute name for @attr tests
+	value    string // required attribute value
+	hasValue bool
+}
+
+// apply filters candidates by name, then applies predicates in order.
+// Positional predicates are evaluated per parent, as in XPath.
+func (s xpathStep) apply(candidates []*Section) []*Section {
+	var groups [][]*Section
+	var byParent = make(map[*Section]int)
+
+	for _, c := range candidates {
+		if c.Heading == nil || (s.name != "*" && c.Heading.Text != s.name) {
+			continue
+		}
+		idx, ok := byParent[c.Parent]
+		if !ok {
+			idx = len(groups)
+			byParent[c.Parent] = idx
+			groups = append(groups, nil)
+		}
+		groups[idx] = append(groups[idx], c)
+	}
+
+	var result []*Section
+	for _, group := range groups {
+		for _, p := range s.predicates {
+			group = p.filter(group)
+		}
+		result = append(result, group...)
+	}
+	return result
+}
+
+func (p xpathPredicate) filter(sections []*Section) []*Section {
+	switch {
+	case p.position == -1:
+		if len(sections) == 0 {
+			return nil
+		}
+		return sections[len(sections)-1:]
+	case p.position > 0:
+		if p.position > len(sections) {
+			return nil
+		}
+		return sections[p.position-1 : p.position]
+	}
+
+	var result []*Section
+	for _, s := range sections {
+		val, ok := s.Metadata[p.attr]
+		if !ok {
+			continue
+		}
+		if p.hasValue && fmt.Sprint(val) != p.value {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// descendants returns every section below s in document order.
+func descendants(s *Section) []*Section {
+	var result []*Section
+	for _, child := range s.Children {
+		result = append(result, child)
+		result = append(result, descendants(child)...)
+	}
+	return result
+}
+
+// parseXPath splits an expression into location steps.
+func parseXPath(expr string) ([]xpathStep, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("xpath: empty expression")
+	}
+	if !strings.HasPrefix(expr, "/") {
+		expr = "//" + expr
+	}
+
+	var steps []xpathStep
+	i := 0
+	for i < len(expr) {
+		if expr[i] != '/' {
+			return nil, fmt.Errorf("xpath: expected '/' at offset %d in %q", i, expr)
+		}
+		step := xpathStep{}
+		i++
+		if i < len(expr) && expr[i] == '/' {
+			step.descendant = true
+			i++
+		}
+
+		// Name runs until a predicate or the next step.
+		start := i
+		for i < len(expr) && expr[i] != '/' && expr[i] != '[' {
+			i++
+		}
+		step.name = strings.TrimSpace(expr[start:i])
+		if step.name == "" {
+			return nil, fmt.Errorf("xpath: missing step name at offset %d in %q", start, expr)
+		}
+
+		for i < len(expr) && expr[i] == '[' {
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("xpath: unclosed '[' in %q", expr)
+			}
+			pred, err := parseXPathPredicate(expr[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			step.predicates = append(step.predicates, pred)
+			i += end + 1
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func parseXPathPredicate(s string) (xpathPredicate, error) {
+	s = strings.TrimSpace(s)
+	if s == "last()" {
+		return xpathPredicate{position: -1}, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return xpathPredicate{}, fmt.Errorf("xpath: position must be >= 1, got %d", n)
+		}
+		return xpathPredicate{position: n}, nil
+	}
+	if !strings.HasPrefix(s, "@") {
+		return xpathPredicate{}, fmt.Errorf("xpath: unsupported predicate [%s]\nSupported: [n], [last()], [@attr], [@attr='value']", s)
+	}
+
+	name, value, hasValue := strings.Cut(s[1:], "=")
+	pred := xpathPredicate{attr: strings.TrimSpace(name)}
+	if hasValue {
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		pred.value = value
+		pred.hasValue = true
+	}
+	return pred, nil
+}
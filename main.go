@@ -11,12 +11,15 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	mq "github.com/muqsitnawaz/mq/lib"
 	"github.com/muqsitnawaz/mq/mql"
+	"github.com/muqsitnawaz/mq/plugin"
 )
 
 var version = "dev"
@@ -53,26 +56,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	path := os.Args[1]
-	query := ""
-	if len(os.Args) >= 3 {
-		query = os.Args[2]
+	args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	// Check if path is a directory
-	info, err := os.Stat(path)
-	if err != nil {
-		log.Fatalf("Failed to stat path: %v", err)
+	// External parsers: mq-parser-<name> on PATH and plugins.json
+	if needsPlugins(args) {
+		registerPlugins()
 	}
+	path, query := args.path, args.query
 
-	if info.IsDir() {
+	// Directories and archives (docs.zip, bundle.tar.gz) use directory mode
+	if path != stdinPath && mq.IsDirectory(path) {
+		if args.format != "" {
+			log.Fatalf("--format is not supported for directories")
+		}
+		if args.slugs != "" {
+			log.Fatalf("--slugs is not supported for directories")
+		}
 		handleDirectory(path, query)
 		return
 	}
 
-	// Load the document (auto-detect format)
-	engine := mql.New()
-	doc, err := engine.LoadDocument(path)
+	// Load the document (auto-detect format, decompressing .gz/.zst/.bz2;
+	// paths inside archives like docs.zip/guide/intro.md are read in place)
+	var opts []mq.MultiEngineOption
+	if args.slugs != "" {
+		style, ok := mq.ParseSlugStyle(args.slugs)
+		if !ok {
+			log.Fatalf("Unknown slug style: %q. Use: github, gitlab, mkdocs, docusaurus", args.slugs)
+		}
+		opts = append(opts, mq.WithSlugs(style))
+	}
+	engine := mql.New(opts...)
+	doc, err := loadDocument(engine, args)
 	if err != nil {
 		log.Fatalf("Failed to load document: %v", err)
 	}
@@ -93,9 +111,134 @@ func main() {
 	displayResult(result)
 }
 
+// registerPlugins makes discovered plugin parsers available to every
+// engine. Broken plugins are reported but don't stop mq.
+func registerPlugins() {
+	parsers, err := plugin.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%swarning: %v%s\n", yellow, err, reset)
+	}
+	for _, p := range parsers {
+		mq.RegisterDefaultParser(p)
+	}
+}
+
+// needsPlugins reports whether plugin discovery can change how args is
+// read. Discovery may run plugin executables, so it is skipped when a
+// built-in format already claims the input: a known --format, or a file
+// name a format recognizes. Directories may hold files of any format.
+func needsPlugins(args cliArgs) bool {
+	switch {
+	case args.format != "":
+		_, ok := mq.ParseFormat(args.format)
+		return !ok
+	case args.path == stdinPath:
+		return args.name == "" || !mq.IsRecognized(args.name)
+	case mq.IsDirectory(args.path):
+		return true
+	}
+	return !mq.IsRecognized(args.path)
+}
+
+// stdinPath is the path argument that reads the document from stdin.
+const stdinPath = "-"
+
+// stdinName is the path reported for stdin documents without --name.
+const stdinName = "<stdin>"
+
+// cliArgs holds the parsed command line.
+type cliArgs struct {
+	path   string
+	query  string
+	format string // --format override, e.g. "md" or "html"
+	name   string // --name reported as the document path (stdin only)
+	slugs  string // --slugs heading ID style, e.g. "gitlab"
+}
+
+// parseArgs parses "<path> [query]" with --format, --name and --slugs flags,
+// which may appear anywhere as "--flag value" or "--flag=value".
+func parseArgs(argv []string) (cliArgs, error) {
+	var args cliArgs
+	var positional []string
+
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		flag, value, hasValue := strings.Cut(arg, "=")
+		if flag != "--format" && flag != "--name" && flag != "--slugs" {
+			positional = append(positional, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(argv) {
+				return args, fmt.Errorf("%s requires a value", flag)
+			}
+			i++
+			value = argv[i]
+		}
+		switch flag {
+		case "--format":
+			args.format = value
+		case "--name":
+			args.name = value
+		case "--slugs":
+			args.slugs = value
+		}
+	}
+
+	switch len(positional) {
+	case 0:
+		return args, fmt.Errorf("missing file, directory or - for stdin")
+	case 1:
+		args.path = positional[0]
+	case 2:
+		args.path, args.query = positional[0], positional[1]
+	default:
+		return args, fmt.Errorf("unexpected argument: %q", positional[2])
+	}
+
+	if args.name != "" && args.path != stdinPath {
+		return args, fmt.Errorf("--name only applies when reading stdin (-)")
+	}
+	return args, nil
+}
+
+// loadDocument reads the document named by args from a file or stdin.
+// Without --format the format is detected from the path (or --name) and
+// content.
+func loadDocument(engine *mql.Engine, args cliArgs) (*mq.Document, error) {
+	format := mq.FormatUnknown
+	if args.format != "" {
+		f, ok := mq.ParseFormat(args.format)
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q", args.format)
+		}
+		format = f
+	}
+
+	if args.path != stdinPath {
+		if format != mq.FormatUnknown {
+			return engine.LoadDocumentAs(args.path, format)
+		}
+		return engine.LoadDocument(args.path)
+	}
+
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("read stdin: %w", err)
+	}
+	name := args.name
+	if name == "" {
+		name = stdinName
+	}
+	if format != mq.FormatUnknown {
+		return engine.ParseDocumentAs(content, name, format)
+	}
+	return engine.ParseDocument(content, name)
+}
+
 func printUsage() {
 	fmt.Printf("mq %s - Query structured documents without reading entire contents\n\n", version)
-	fmt.Println("Usage: mq <file|directory> [query]")
+	fmt.Println("Usage: mq <file|directory|-> [query] [--format fmt] [--name path] [--slugs style]")
 	fmt.Println("\nWorkflow:")
 	fmt.Println("  1. See structure:  mq <path> '.tree(\"full\")'")
 	fmt.Println("  2. Extract content: mq <file> '.section(\"Name\") | .text'")
@@ -108,11 +251,12 @@ func printUsage() {
 	fmt.Println("  .tree(\"full\")      Structure + previews (best for directories)")
 	fmt.Println("")
 	fmt.Println("Selectors:")
-	fmt.Println("  .section(\"Name\")   Get section by heading")
+	fmt.Println("  .section(\"Name\")   Get section by heading (or \"#anchor-id\")")
 	fmt.Println("  .search(\"term\")    Find sections containing term")
 	fmt.Println("  .code(\"lang\")      Get code blocks by language")
 	fmt.Println("  .headings          Get all headings")
 	fmt.Println("  .links             Get all links")
+	fmt.Println("  .xpath(\"/a/b\")     Get sections by element path (XML)")
 	fmt.Println("")
 	fmt.Println("Pipes:")
 	fmt.Println("  | .text            Extract raw content")
@@ -122,6 +266,7 @@ func printUsage() {
 	fmt.Println("  mq docs/ '.tree(\"full\")'                    # See all docs structure")
 	fmt.Println("  mq README.md '.section(\"Install\") | .text'  # Get install instructions")
 	fmt.Println("  mq src/ '.search(\"auth\")'                   # Find auth-related sections")
+	fmt.Println("  git show HEAD~5:README.md | mq - --format md .tree  # Read stdin")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  upgrade            Upgrade to latest version")
@@ -129,6 +274,9 @@ func printUsage() {
 	fmt.Println("Flags:")
 	fmt.Println("  -h, --help         Show this help")
 	fmt.Println("  -v, --version      Show version")
+	fmt.Println("  --format fmt       Parse as fmt (md, html, json, yaml, ...) instead of detecting")
+	fmt.Println("  --name path        Path reported for stdin input; its extension guides detection")
+	fmt.Println("  --slugs style      Heading IDs as github (default), gitlab, mkdocs or docusaurus")
 }
 
 func checkForUpdates() {
@@ -391,14 +539,29 @@ func parseMethodCall(query string) (method string, arg string, ok bool) {
 }
 
 func handleDirectory(path string, query string) {
-	// Directory mode supports .tree and .search queries
+	// Directory mode supports .tree, .search, .tasks (optionally piped on),
+	// .backlinks and .orphans queries
 	if query == "" {
 		query = ".tree"
 	}
 
+	// Tasks can be piped on, as in .tasks | filter(.checked == false)
+	if head, _, piped := strings.Cut(query, "|"); piped && strings.TrimSpace(head) == ".tasks" {
+		result, err := mql.QueryTasksDir(path, query)
+		if err != nil {
+			log.Fatalf("Query failed: %v", err)
+		}
+		if tasks, ok := result.(*mq.TaskResults); ok {
+			fmt.Print(tasks.String())
+			return
+		}
+		displayResult(result)
+		return
+	}
+
 	method, arg, ok := parseMethodCall(query)
 	if !ok {
-		log.Fatalf("Invalid query format. Supported: .tree, .tree(\"mode\"), .search(\"term\")")
+		log.Fatalf("Invalid query format. Supported: .tree, .tree(\"mode\"), .search(\"term\"), .tasks, .backlinks(\"page\"), .orphans")
 	}
 
 	switch method {
@@ -430,11 +593,44 @@ func handleDirectory(path string, query string) {
 		}
 		fmt.Print(result.String())
 
+	case "tasks":
+		result, err := mql.TasksDir(path)
+		if err != nil {
+			log.Fatalf("Collecting tasks failed: %v", err)
+		}
+		fmt.Print(result.String())
+
+	case "backlinks":
+		if arg == "" {
+			log.Fatalf("Backlinks requires a page: .backlinks(\"page\")")
+		}
+		result, err := mql.BacklinksDir(path, arg)
+		if err != nil {
+			log.Fatalf("Collecting backlinks failed: %v", err)
+		}
+		fmt.Print(result.String())
+
+	case "orphans":
+		result, err := mql.OrphansDir(path)
+		if err != nil {
+			log.Fatalf("Finding orphans failed: %v", err)
+		}
+		fmt.Print(result.String())
+
 	default:
-		log.Fatalf("Unknown method: .%s. Supported: .tree, .search", method)
+		log.Fatalf("Unknown method: .%s. Supported: .tree, .search, .tasks, .backlinks, .orphans", method)
 	}
 }
 
+// printDiagramEdge prints "  API -> Billing: charge (line 12)".
+func printDiagramEdge(e *mq.DiagramEdge) {
+	fmt.Printf("  %s %s %s", e.From, e.Arrow, e.To)
+	if e.Label != "" {
+		fmt.Printf(": %s", e.Label)
+	}
+	fmt.Printf(" (line %d)\n", e.Line)
+}
+
 func showDocumentInfo(doc *mq.Document) {
 	fmt.Printf("Document: %s\n", doc.Path())
 	fmt.Printf("Format: %s\n", doc.Format())
@@ -454,9 +650,9 @@ func showDocumentInfo(doc *mq.Document) {
 		}
 	}
 
-	// For data formats (JSON, JSONL, YAML), show data-specific info
+	// For data formats (JSON, JSONL, YAML, TOML), show data-specific info
 	format := doc.Format()
-	if format == mq.FormatJSON || format == mq.FormatJSONL || format == mq.FormatYAML {
+	if format == mq.FormatJSON || format == mq.FormatJSONL || format == mq.FormatYAML || format == mq.FormatTOML {
 		showDataInfo(doc)
 		return
 	}
@@ -584,7 +780,11 @@ func displayResult(result interface{}) {
 		}
 
 	case *mq.Section:
-		fmt.Printf("Section: %s\n", v.Heading.Text)
+		if v.Heading.Level == 0 {
+			fmt.Println("Preamble")
+		} else {
+			fmt.Printf("Section: %s\n", v.Heading.Text)
+		}
 		fmt.Printf("Lines: %d-%d\n", v.Start, v.End)
 		if len(v.Children) > 0 {
 			fmt.Printf("Children: %d\n", len(v.Children))
@@ -606,16 +806,29 @@ func displayResult(result interface{}) {
 			if lang == "" {
 				lang = "plain"
 			}
+			if cb.Indented {
+				lang += ", indented"
+			}
 			fmt.Printf("\n%d. [%s] %d lines\n", i+1, lang, cb.GetLines())
 			fmt.Println("---")
 			fmt.Println(cb.Content)
 			fmt.Println("---")
 		}
 
+	case []*mq.InlineCode:
+		fmt.Printf("Found %d code spans:\n", len(v))
+		for i, c := range v {
+			fmt.Printf("%d. %s (line %d)\n", i+1, c.Text, c.Line)
+		}
+
 	case []*mq.Link:
 		fmt.Printf("Found %d links:\n", len(v))
 		for i, link := range v {
-			fmt.Printf("%d. %s -> %s\n", i+1, link.Text, link.URL)
+			text := link.Text
+			if link.Kind == mq.LinkDefinition {
+				text = "[" + link.Label + "]"
+			}
+			fmt.Printf("%d. %s -> %s\n", i+1, text, link.URL)
 		}
 
 	case []*mq.Image:
@@ -631,11 +844,199 @@ func displayResult(result interface{}) {
 			fmt.Printf("Headers: %v\n", table.Headers)
 		}
 
+	case *mq.Table:
+		printTable(v, "")
+
+	case []*mq.List:
+		fmt.Printf("Found %d lists:\n", len(v))
+		for i, l := range v {
+			kind := "bulleted"
+			if l.Ordered {
+				kind = "numbered"
+			}
+			items := "items"
+			if len(l.Items) == 1 {
+				items = "item"
+			}
+			fmt.Printf("\n%d. %s list, %d %s", i+1, kind, len(l.Items), items)
+			switch {
+			case l.End() > l.Line:
+				fmt.Printf(" (lines %s)", l.Range())
+			case l.Line > 0:
+				fmt.Printf(" (line %d)", l.Line)
+			}
+			fmt.Println()
+			printListItems(l.Items, "   ")
+		}
+
+	case []mq.ListItem:
+		items := "items"
+		if len(v) == 1 {
+			items = "item"
+		}
+		fmt.Printf("Found %d %s:\n", len(v), items)
+		printListItems(v, "")
+
+	case []*mq.Task:
+		open := 0
+		for _, t := range v {
+			if !t.Checked {
+				open++
+			}
+		}
+		fmt.Printf("Found %d tasks (%d open):\n", len(v), open)
+		for i, t := range v {
+			fmt.Printf("%d. %s%s %s", i+1, strings.Repeat("  ", t.Depth), checkbox(t.Checked), t.Text)
+			if t.Line > 0 {
+				fmt.Printf(" (line %d", t.Line)
+				if title := t.SectionTitle(); title != "" {
+					fmt.Printf(", %s", title)
+				}
+				fmt.Print(")")
+			}
+			fmt.Println()
+		}
+
+	case []*mq.Footnote:
+		fmt.Printf("Found %d footnotes:\n", len(v))
+		for i, f := range v {
+			fmt.Printf("%d. [^%s] %s (line %d)\n", i+1, f.Label, f.Content, f.Line)
+		}
+
+	case []*mq.Definition:
+		fmt.Printf("Found %d definitions:\n", len(v))
+		for i, d := range v {
+			fmt.Printf("%d. %s (line %d)\n", i+1, d.Term, d.Line)
+			for _, desc := range d.Descriptions {
+				fmt.Printf("   : %s\n", desc)
+			}
+		}
+
+	case []*mq.Admonition:
+		fmt.Printf("Found %d admonitions:\n", len(v))
+		for i, a := range v {
+			fmt.Printf("\n%d. [%s]", i+1, a.Kind)
+			if a.Title != "" {
+				fmt.Printf(" %s", a.Title)
+			}
+			fmt.Printf(" (lines %s)\n", a.Range())
+			fmt.Println(a.Content)
+		}
+
+	case []*mq.MathBlock:
+		fmt.Printf("Found %d math blocks:\n", len(v))
+		for i, m := range v {
+			fmt.Printf("\n%d. lines %s\n", i+1, m.Range())
+			fmt.Println("---")
+			fmt.Println(m.Content)
+			fmt.Println("---")
+		}
+
+	case []*mq.Diagram:
+		fmt.Printf("Found %d diagrams:\n", len(v))
+		for i, d := range v {
+			fmt.Printf("\n%d. %s %s (lines %s): %d nodes, %d edges\n", i+1, d.Language, d.Kind, d.Range(), len(d.Nodes), len(d.Edges))
+			for _, e := range d.Edges {
+				printDiagramEdge(e)
+			}
+		}
+
+	case []*mq.DiagramNode:
+		fmt.Printf("Found %d nodes:\n", len(v))
+		for i, n := range v {
+			fmt.Printf("%d. %s", i+1, n.ID)
+			if n.Label != n.ID {
+				fmt.Printf(" %q", n.Label)
+			}
+			if n.Kind != "" {
+				fmt.Printf(" [%s]", n.Kind)
+			}
+			fmt.Printf(" (line %d)\n", n.Line)
+		}
+
+	case []*mq.DiagramEdge:
+		fmt.Printf("Found %d edges:\n", len(v))
+		for _, e := range v {
+			printDiagramEdge(e)
+		}
+
+	case []*mq.Endpoint:
+		fmt.Printf("Found %d endpoints:\n", len(v))
+		for i, e := range v {
+			fmt.Printf("%d. %s %s", i+1, e.Method, e.Path)
+			if e.Summary != "" {
+				fmt.Printf(" - %s", e.Summary)
+			}
+			if e.Deprecated {
+				fmt.Print(" (deprecated)")
+			}
+			if e.Section != nil {
+				fmt.Printf(" (lines %d-%d)", e.Section.Start, e.Section.End)
+			}
+			fmt.Println()
+		}
+
+	case *mq.Endpoint:
+		fmt.Printf("Endpoint: %s %s\n", v.Method, v.Path)
+		if v.OperationID != "" {
+			fmt.Printf("Operation: %s\n", v.OperationID)
+		}
+		if v.Summary != "" {
+			fmt.Printf("Summary: %s\n", v.Summary)
+		}
+		if v.Parameters != nil {
+			fmt.Println("\nParameters:")
+			printTable(v.Parameters, "  ")
+		}
+		if v.Responses != nil {
+			fmt.Println("\nResponses:")
+			printTable(v.Responses, "  ")
+		}
+
+	case []*mq.Component:
+		fmt.Printf("Found %d components:\n", len(v))
+		for i, c := range v {
+			fmt.Printf("%d. %s (lines %d-%d)\n", i+1, c, c.Start, c.End)
+		}
+
+	case *mq.Component:
+		fmt.Printf("Component: %s\n", v)
+		fmt.Printf("Lines: %d-%d\n", v.Start, v.End)
+		if v.Content != "" {
+			fmt.Println("---")
+			fmt.Println(v.Content)
+			fmt.Println("---")
+		}
+
+	case []*mq.Schema:
+		fmt.Printf("Found %d schemas:\n", len(v))
+		for i, s := range v {
+			fmt.Printf("%d. %s (%s)\n", i+1, s.Name, s.Type)
+		}
+
+	case *mq.Schema:
+		fmt.Printf("Schema: %s (%s)\n", v.Name, v.Type)
+		if v.Description != "" {
+			fmt.Println(v.Description)
+		}
+		if v.Properties != nil {
+			fmt.Println("\nProperties:")
+			printTable(v.Properties, "  ")
+		}
+
 	case mq.Metadata:
 		fmt.Println("Metadata:")
-		for key, value := range v {
-			fmt.Printf("  %s: %v\n", key, value)
-		}
+		printFields(v)
+
+	case map[string]interface{}:
+		// Nested metadata, like .metadata.authors[0]
+		printFields(v)
+
+	case time.Time:
+		fmt.Println(formatValue(v))
+
+	case nil:
+		fmt.Println("null")
 
 	case string:
 		fmt.Println(v)
@@ -651,8 +1052,105 @@ func displayResult(result interface{}) {
 	case *mq.SearchResults:
 		fmt.Print(v.String())
 
+	case []interface{}:
+		// map results that are lists themselves, like .diagrams | map(.nodes)
+		if !allSlices(v) {
+			fmt.Printf("Result type: %T\n", result)
+			fmt.Printf("Result: %+v\n", result)
+			return
+		}
+		for i, item := range v {
+			if i > 0 {
+				fmt.Println()
+			}
+			displayResult(item)
+		}
+
 	default:
 		fmt.Printf("Result type: %T\n", result)
 		fmt.Printf("Result: %+v\n", result)
 	}
 }
+
+// printFields prints map entries sorted by key.
+func printFields(fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  %s: %s\n", key, formatValue(fields[key]))
+	}
+}
+
+// formatValue formats a metadata value, showing dates without a
+// midnight UTC time.
+func formatValue(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// allSlices reports whether every item is itself a slice.
+func allSlices(items []interface{}) bool {
+	for _, item := range items {
+		if reflect.ValueOf(item).Kind() != reflect.Slice {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// printListItems prints list items as a nested bullet list.
+func printListItems(items []mq.ListItem, indent string) {
+	for _, item := range items {
+		marker := "-"
+		if item.Checked != nil {
+			marker += " " + checkbox(*item.Checked)
+		}
+		fmt.Printf("%s%s %s\n", indent, marker, item.Text)
+		printListItems(item.Children, indent+"  ")
+	}
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// printTable prints a table with aligned columns.
+func printTable(t *mq.Table, indent string) {
+	widths := make([]int, len(t.Headers))
+	for i, h := range t.Headers {
+		widths[i] = len(h)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	printRow := func(cells []string) {
+		var parts []string
+		for i, cell := range cells {
+			if i < len(widths) {
+				cell = fmt.Sprintf("%-*s", widths[i], cell)
+			}
+			parts = append(parts, cell)
+		}
+		fmt.Println(indent + strings.TrimRight(strings.Join(parts, "  "), " "))
+	}
+	printRow(t.Headers)
+	for _, row := range t.Rows {
+		printRow(row)
+	}
+}
@@ -1,6 +1,12 @@
 package main
 
-import "testing"
+import (
+	"io"
+	"os"
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+)
 
 func TestParseMethodCall(t *testing.T) {
 	tests := []struct {
@@ -54,3 +60,101 @@ func TestParseMethodCall(t *testing.T) {
 		})
 	}
 }
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		argv    []string
+		want    cliArgs
+		wantErr bool
+	}{
+		{"path only", []string{"README.md"}, cliArgs{path: "README.md"}, false},
+		{"path and query", []string{"README.md", ".tree"}, cliArgs{path: "README.md", query: ".tree"}, false},
+		{"stdin with format", []string{"-", "--format", "md", ".tree"}, cliArgs{path: "-", query: ".tree", format: "md"}, false},
+		{"flags with equals", []string{"--format=html", "-", "--name=page.html"}, cliArgs{path: "-", format: "html", name: "page.html"}, false},
+		{"flags after query", []string{"-", ".headings", "--name", "notes.md"}, cliArgs{path: "-", query: ".headings", name: "notes.md"}, false},
+		{"format on file", []string{"notes.txt", "--format", "md"}, cliArgs{path: "notes.txt", format: "md"}, false},
+		{"slug style", []string{"docs.md", "--slugs=gitlab", ".headings"}, cliArgs{path: "docs.md", query: ".headings", slugs: "gitlab"}, false},
+
+		{"missing flag value", []string{"-", "--format"}, cliArgs{}, true},
+		{"name without stdin", []string{"README.md", "--name", "x.md"}, cliArgs{}, true},
+		{"no path", []string{"--format", "md"}, cliArgs{}, true},
+		{"extra argument", []string{"a.md", ".tree", "extra"}, cliArgs{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArgs(tt.argv)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseArgs(%q) expected error, got %+v", tt.argv, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs(%q) error: %v", tt.argv, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseArgs(%q) = %+v, want %+v", tt.argv, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsPlugins(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		args cliArgs
+		want bool
+	}{
+		{"markdown file", cliArgs{path: "README.md"}, false},
+		{"compressed file", cliArgs{path: "notes.md.gz"}, false},
+		{"unknown extension", cliArgs{path: "page.cxml"}, true},
+		{"built-in format", cliArgs{path: "page.cxml", format: "md"}, false},
+		{"plugin format", cliArgs{path: "page.cxml", format: "confluence"}, true},
+		{"named stdin", cliArgs{path: "-", name: "notes.md"}, false},
+		{"unnamed stdin", cliArgs{path: "-"}, true},
+		{"directory", cliArgs{path: dir}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsPlugins(tt.args); got != tt.want {
+				t.Errorf("needsPlugins(%+v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayListItems(t *testing.T) {
+	checked := true
+	items := []mq.ListItem{
+		{Text: "a", Children: []mq.ListItem{{Text: "b"}}},
+		{Text: "c", Checked: &checked},
+	}
+	want := "Found 2 items:\n- a\n  - b\n- [x] c\n"
+	if got := captureStdout(t, func() { displayResult(items) }); got != want {
+		t.Errorf("displayResult() = %q, want %q", got, want)
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
@@ -0,0 +1,1071 @@
+// Package man provides Unix manual page parsing for mq.
+//
+// Man pages are written in roff using either the man(7) or mdoc(7) macro
+// package. The parser understands both and maps them onto mq's unified types:
+//   - Headings: .SH/.Sh as H1, .SS/.Ss as H2
+//   - Lists: .TP, .IP and mdoc .It entries ("-v, --verbose: description"),
+//     one list per run of entries
+//   - Code blocks: .EX/.EE, .Bd -literal/.Ed and .Dl
+//   - Tables: tbl(1) .TS/.TE blocks
+//   - Links: .UR/.UE, .MT/.ME and mdoc .Lk
+//   - Metadata: name, section, date, source and manual from .TH (or
+//     .Dt/.Dd/.Os), plus the one-line description from NAME
+//
+// Gzip-compressed pages (tar.1.gz) are decompressed transparently.
+//
+// Roff has no meaningful text lines, so the document source is a plain
+// rendering of the page, close to what man(1) prints. Section line ranges
+// and GetText refer to that rendering.
+//
+// Example:
+//
+//	parser := man.NewParser()
+//	doc, _ := parser.ParseFile("/usr/share/man/man1/tar.1.gz")
+//
+//	options, _ := doc.GetSection("OPTIONS")
+//	flags := doc.GetLists(nil)
+package man
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/muqsitnawaz/mq/internal/outline"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Parser parses man pages into mq.Document.
+type Parser struct {
+	// Options
+	indent string // Indentation for entry descriptions in the rendering
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new man page parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		indent: "    ",
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithIndent sets the indentation used for entry descriptions
+// in the rendered text.
+func WithIndent(indent string) Option {
+	return func(p *Parser) {
+		p.indent = indent
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatMan
+}
+
+// ParseFile reads and parses a man page, compressed or not.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatMan, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses roff content and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	if len(content) >= 2 && content[0] == 0x1f && content[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, &mq.ParseError{Format: mq.FormatMan, Path: path, Err: err}
+		}
+		content, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, &mq.ParseError{Format: mq.FormatMan, Path: path, Err: err}
+		}
+	}
+
+	ext := &extractor{
+		parser: p,
+		path:   path,
+		meta:   mq.Metadata{},
+	}
+	return ext.extract(string(content)), nil
+}
+
+// extractor interprets roff requests and renders plain text while
+// collecting structural elements.
+type extractor struct {
+	parser *Parser
+	path   string
+	meta   mq.Metadata
+
+	// Rendered output
+	out  strings.Builder
+	line int // lines written so far
+
+	// Extracted elements
+	headings   []*mq.Heading
+	sections   []*mq.Section
+	codeBlocks []*mq.CodeBlock
+	links      []*mq.Link
+	tables     []*mq.Table
+	lists      []*mq.List
+
+	// Paragraph being filled
+	para   []string
+	noFill bool
+
+	// Tagged entry being filled (.TP, .IP, .It)
+	entry      *entry
+	pendingTag bool // next text line is the .TP tag
+	appendTag  bool // the tag continues the previous one (.TQ)
+	list       *mq.List
+	rsDepth    int // .RS nesting
+	blDepth    int // mdoc .Bl nesting
+	entryDepth int // .RS nesting when the entry started
+
+	// Multi-line mdoc tag (.It ... Xo / .Xc)
+	inXo       bool
+	spacingOff bool // .Sm off
+
+	// Pending heading from a bare .SH/.SS
+	pendingLevel int
+
+	// Example block being collected (.EX, .Bd -literal)
+	example []string
+	inCode  bool
+	codeEnd string
+
+	// Hyperlink being collected (.UR/.UE, .MT/.ME)
+	linkURL  string
+	linkText []string
+	inLink   bool
+	pending  []*mq.Link // links whose text isn't written yet
+
+	// Current section name, for NAME description handling
+	section  string
+	name     string
+	nameLine string
+}
+
+// entry is a tagged paragraph: a flag and its description.
+type entry struct {
+	tag  string
+	desc []string
+	code []*mq.CodeBlock // examples in the description
+}
+
+func (e *extractor) extract(content string) *mq.Document {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		i = e.handleLine(lines, i)
+	}
+	e.flushAll()
+
+	source := strings.TrimRight(e.out.String(), "\n")
+	e.sections = outline.Nest(e.headings, strings.Count(source, "\n")+1, 1)
+
+	doc := mq.NewDocument(
+		[]byte(source),
+		e.path,
+		mq.FormatMan,
+		e.title(),
+		e.headings,
+		e.sections,
+		e.codeBlocks,
+		e.links,
+		nil, // images
+		e.tables,
+		e.lists,
+		source,
+	)
+	if len(e.meta) > 0 {
+		doc.SetMetadata(e.meta)
+	}
+	return doc
+}
+
+// title prefers the NAME line ("tar - an archiving utility"), then name(section).
+func (e *extractor) title() string {
+	if e.nameLine != "" {
+		return e.nameLine
+	}
+	name, _ := e.meta["name"].(string)
+	if sec, ok := e.meta["section"].(string); ok && name != "" {
+		return name + "(" + sec + ")"
+	}
+	if len(e.headings) > 0 {
+		return e.headings[0].Text
+	}
+	return name
+}
+
+// handleLine processes lines[i] and returns the index of the last line consumed.
+func (e *extractor) handleLine(lines []string, i int) int {
+	line := lines[i]
+
+	if e.inCode {
+		if isRequest(line, e.codeEnd) {
+			e.flushCode()
+			return i
+		}
+		if isComment(line) {
+			return i
+		}
+		if name, args := parseRequest(line); name != "" {
+			// Font macros inside examples still carry text.
+			if text := e.fontMacro(name, args); text != "" {
+				e.example = append(e.example, text)
+			}
+			return i
+		}
+		e.example = append(e.example, unescape(line))
+		return i
+	}
+
+	if isComment(line) {
+		return i
+	}
+
+	if len(line) == 0 || (line[0] != '.' && line[0] != '\'') {
+		e.text(line)
+		return i
+	}
+
+	name, args := parseRequest(line)
+	if e.inXo && e.entry != nil {
+		e.extendTag(name, args)
+		return i
+	}
+
+	switch name {
+	case "":
+		return i
+
+	case "de", "de1", "am", "ig":
+		// Skip macro definitions and ignored blocks.
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ".."; i++ {
+		}
+		return i
+
+	// Page header
+	case "TH":
+		keys := []string{"name", "section", "date", "source", "manual"}
+		for k, arg := range args {
+			if k < len(keys) && arg != "" {
+				e.meta[keys[k]] = unescape(arg)
+			}
+		}
+		if n, ok := e.meta["name"].(string); ok {
+			e.name = strings.ToLower(n)
+		}
+	case "Dt":
+		if len(args) > 0 && e.meta["name"] == nil {
+			e.meta["name"] = args[0]
+		}
+		if len(args) > 1 {
+			e.meta["section"] = args[1]
+		}
+	case "Dd":
+		if d := unescape(strings.Join(args, " ")); d != "" && d != "$Mdocdate$" {
+			e.meta["date"] = strings.TrimSuffix(strings.TrimPrefix(d, "$Mdocdate: "), " $")
+		}
+	case "Os":
+		if len(args) > 0 {
+			e.meta["source"] = unescape(strings.Join(args, " "))
+		}
+
+	// Sections
+	case "SH", "Sh":
+		e.heading(1, args)
+	case "SS", "Ss":
+		e.heading(2, args)
+
+	// Paragraphs
+	case "PP", "P", "LP", "Pp", "sp", "HP":
+		if e.entry != nil && (e.rsDepth > e.entryDepth || e.blDepth > 0) {
+			e.entry.desc = append(e.entry.desc, "")
+			return i
+		}
+		e.flushAll()
+	case "br":
+		e.text("\n")
+	case "nf":
+		e.flushPara()
+		e.noFill = true
+	case "fi":
+		e.flushPara()
+		e.noFill = false
+	case "RS":
+		e.rsDepth++
+	case "RE":
+		if e.rsDepth > 0 {
+			e.rsDepth--
+		}
+
+	// Tagged entries
+	case "TP":
+		e.startEntry("")
+		e.pendingTag = true
+	case "TQ":
+		e.pendingTag = true
+		e.appendTag = true
+	case "IP":
+		tag := ""
+		if len(args) > 0 {
+			tag = unescape(args[0])
+		}
+		if isBullet(tag) {
+			tag = ""
+		}
+		e.startEntry(tag)
+	case "Bl":
+		e.flushPara()
+		e.blDepth++
+	case "El":
+		e.flushEntry()
+		e.flushList()
+		if e.blDepth > 0 {
+			e.blDepth--
+		}
+	case "It":
+		e.startEntry(e.mdoc(args))
+		// .It ... Xo continues the tag on following lines until .Xc
+		e.inXo = len(args) > 0 && args[len(args)-1] == "Xo"
+		if e.inXo {
+			e.entry.tag += " "
+		}
+
+	// Examples
+	case "EX":
+		e.flushPara()
+		e.inCode, e.codeEnd = true, "EE"
+	case "Bd":
+		e.flushPara()
+		if len(args) > 0 && (args[0] == "-literal" || args[0] == "-unfilled") {
+			e.inCode, e.codeEnd = true, "Ed"
+		}
+	case "Dl":
+		e.flushPara()
+		e.example = []string{e.mdoc(args)}
+		e.flushCode()
+
+	// Tables
+	case "TS":
+		return e.table(lines, i)
+
+	// Links
+	case "UR", "MT":
+		e.inLink = true
+		e.linkText = nil
+		e.linkURL = ""
+		if len(args) > 0 {
+			e.linkURL = args[0]
+			if name == "MT" {
+				e.linkURL = "mailto:" + args[0]
+			}
+		}
+	case "UE", "ME":
+		if e.inLink {
+			text := strings.Join(strings.Fields(strings.Join(e.linkText, " ")), " ")
+			if text == "" {
+				text = strings.TrimPrefix(e.linkURL, "mailto:")
+				e.text(text)
+			}
+			e.addLink(&mq.Link{Text: text, URL: e.linkURL})
+			e.inLink = false
+		}
+		if len(args) > 0 {
+			e.appendWord(unescape(args[0]))
+		}
+	case "Lk":
+		if len(args) > 0 {
+			text := args[0]
+			if len(args) > 1 {
+				text = unescape(strings.Join(args[1:], " "))
+			}
+			e.addLink(&mq.Link{Text: text, URL: args[0]})
+			e.text(text)
+		}
+
+	// NAME section
+	case "Nd":
+		e.text("- " + e.mdoc(args))
+
+	default:
+		if text := e.fontMacro(name, args); text != "" {
+			e.text(text)
+		} else if isMdocMacro(name) {
+			e.text(e.mdoc(append([]string{name}, args...)))
+		}
+	}
+	return i
+}
+
+// heading starts a new section; a bare .SH takes its text from the next line.
+func (e *extractor) heading(level int, args []string) {
+	e.flushAll()
+	e.pendingTag = false
+	e.rsDepth = 0
+	text := strings.TrimSpace(unescape(strings.Join(args, " ")))
+	if text == "" {
+		e.pendingLevel = level
+		return
+	}
+	e.addHeading(level, text)
+}
+
+func (e *extractor) addHeading(level int, text string) {
+	h := &mq.Heading{
+		Level: level,
+		Text:  text,
+		ID:    strings.ToLower(strings.ReplaceAll(text, " ", "-")),
+	}
+	e.headings = append(e.headings, h)
+	if level == 1 {
+		e.section = strings.ToUpper(text)
+	}
+	h.SetPosition(e.writeBlock(strings.Repeat("#", level) + " " + text))
+}
+
+// text adds a text line to the open paragraph, entry or tag.
+func (e *extractor) text(raw string) {
+	if e.pendingLevel > 0 {
+		level := e.pendingLevel
+		e.pendingLevel = 0
+		e.addHeading(level, strings.TrimSpace(unescape(raw)))
+		return
+	}
+
+	s := unescape(raw)
+	if raw == "\n" {
+		s = "\n"
+	}
+	if e.pendingTag {
+		e.pendingTag = false
+		if e.entry == nil {
+			e.startEntry("")
+		}
+		if e.appendTag && e.entry.tag != "" {
+			e.entry.tag += ", " + strings.TrimSpace(s)
+		} else {
+			e.entry.tag = strings.TrimSpace(s)
+		}
+		e.appendTag = false
+		return
+	}
+	if e.inLink {
+		e.linkText = append(e.linkText, s)
+	}
+	if e.entry != nil {
+		e.entry.desc = append(e.entry.desc, s)
+		return
+	}
+	e.para = append(e.para, s)
+}
+
+// appendWord attaches trailing punctuation after a link without a space.
+func (e *extractor) appendWord(s string) {
+	switch {
+	case e.entry != nil && len(e.entry.desc) > 0:
+		e.entry.desc[len(e.entry.desc)-1] += s
+	case len(e.para) > 0:
+		e.para[len(e.para)-1] += s
+	default:
+		e.text(s)
+	}
+}
+
+// extendTag appends a macro line to a multi-line mdoc tag, honoring .Sm.
+func (e *extractor) extendTag(name string, args []string) {
+	switch name {
+	case "Sm":
+		e.spacingOff = len(args) > 0 && args[0] == "off"
+		return
+	case "Xc":
+		e.inXo = false
+		return
+	}
+
+	text := e.mdoc(append([]string{name}, args...))
+	if e.spacingOff {
+		text = strings.ReplaceAll(text, " ", "")
+	}
+	if text == "" {
+		return
+	}
+	if e.entry.tag != "" && !strings.HasSuffix(e.entry.tag, " ") && !e.spacingOff {
+		e.entry.tag += " "
+	}
+	e.entry.tag += text
+}
+
+// fontMacro renders man(7) font macros; it returns "" for other requests.
+func (e *extractor) fontMacro(name string, args []string) string {
+	switch name {
+	case "B", "I", "SM", "SB":
+		return unescape(strings.Join(args, " "))
+	case "BR", "RB", "BI", "IB", "IR", "RI":
+		var buf strings.Builder
+		for _, a := range args {
+			buf.WriteString(unescape(a))
+		}
+		return buf.String()
+	}
+	return ""
+}
+
+func (e *extractor) startEntry(tag string) {
+	// Consecutive tags without a description share the next one.
+	if tag != "" && e.entry != nil && e.entry.tag != "" &&
+		strings.TrimSpace(strings.Join(e.entry.desc, "")) == "" {
+		e.entry.tag = strings.TrimSpace(e.entry.tag) + ", " + tag
+		return
+	}
+	e.flushPara()
+	e.flushEntry()
+	if e.list == nil {
+		e.list = &mq.List{}
+	}
+	e.entry = &entry{tag: tag}
+	e.entryDepth = e.rsDepth
+}
+
+func (e *extractor) flushPara() {
+	if len(e.para) == 0 {
+		return
+	}
+	text := e.fill(e.para)
+	e.para = nil
+	if text == "" {
+		return
+	}
+	if e.section == "NAME" && e.nameLine == "" {
+		// "tar \- an archiving utility"
+		e.nameLine = strings.Join(strings.Fields(text), " ")
+		if _, desc, ok := strings.Cut(e.nameLine, " - "); ok {
+			e.meta["description"] = strings.TrimSpace(desc)
+		}
+	}
+	e.writeBlock(text)
+}
+
+func (e *extractor) flushEntry() {
+	if e.entry == nil {
+		return
+	}
+	en := e.entry
+	e.entry = nil
+	en.tag = strings.TrimSpace(en.tag)
+
+	desc := e.fill(en.desc)
+	var block strings.Builder
+	if en.tag != "" {
+		block.WriteString(en.tag)
+		for _, l := range strings.Split(desc, "\n") {
+			if desc == "" {
+				break
+			}
+			block.WriteString("\n")
+			if l != "" {
+				block.WriteString(e.parser.indent + l)
+			}
+		}
+	} else {
+		block.WriteString("- " + desc)
+	}
+	if en.tag == "" && desc == "" {
+		return
+	}
+	pos := e.writeBlock(block.String())
+	for _, cb := range en.code {
+		cb.Position = pos
+	}
+
+	item := en.tag
+	if desc != "" {
+		flat := strings.Join(strings.Fields(desc), " ")
+		if item != "" {
+			item += ": " + flat
+		} else {
+			item = flat
+		}
+	}
+	e.list.Items = append(e.list.Items, mq.ListItem{Text: item, Position: pos})
+}
+
+func (e *extractor) flushList() {
+	if e.list != nil && len(e.list.Items) > 0 {
+		first, last := e.list.Items[0].Position, e.list.Items[len(e.list.Items)-1].Position
+		e.list.Position = mq.Position{Line: first.Line, Column: first.Column, EndLine: last.EndLine, EndColumn: last.EndColumn}
+		e.lists = append(e.lists, e.list)
+	}
+	e.list = nil
+}
+
+func (e *extractor) flushAll() {
+	e.flushPara()
+	e.flushEntry()
+	e.flushList()
+	if e.inCode {
+		e.flushCode()
+	}
+}
+
+func (e *extractor) flushCode() {
+	content := strings.Trim(strings.Join(e.example, "\n"), "\n")
+	e.example = nil
+	e.inCode = false
+	if content == "" {
+		return
+	}
+	cb := &mq.CodeBlock{Content: content}
+	e.codeBlocks = append(e.codeBlocks, cb)
+
+	// Examples inside an entry stay part of its description.
+	if e.entry != nil {
+		e.entry.desc = append(e.entry.desc, "", content, "")
+		e.entry.code = append(e.entry.code, cb)
+		return
+	}
+	e.flushPara()
+	cb.Position = e.writeBlock(indentLines(content, e.parser.indent))
+}
+
+// fill joins paragraph lines: filled text is reflowed onto one line per
+// paragraph, no-fill text keeps its breaks.
+func (e *extractor) fill(lines []string) string {
+	if e.noFill {
+		return strings.Trim(strings.Join(lines, "\n"), "\n")
+	}
+	var paras []string
+	var cur []string
+	for _, l := range append(lines, "") {
+		if strings.TrimSpace(l) == "" {
+			if len(cur) > 0 {
+				paras = append(paras, strings.Join(cur, " "))
+				cur = nil
+			}
+			continue
+		}
+		for _, part := range strings.Split(l, "\n") {
+			if f := strings.Fields(part); len(f) > 0 {
+				cur = append(cur, strings.Join(f, " "))
+			}
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// table parses a tbl(1) block starting at lines[i] (.TS) and returns the
+// index of the .TE line.
+func (e *extractor) table(lines []string, i int) int {
+	e.flushPara()
+	sep := "\t"
+	j := i + 1
+
+	// Global options end with ';', format lines end with '.'.
+	if j < len(lines) && strings.HasSuffix(strings.TrimSpace(lines[j]), ";") {
+		opts := lines[j]
+		if k := strings.Index(opts, "tab("); k >= 0 && k+5 < len(opts) {
+			sep = string(opts[k+4])
+		}
+		j++
+	}
+	for ; j < len(lines); j++ {
+		if strings.HasSuffix(strings.TrimSpace(lines[j]), ".") {
+			j++
+			break
+		}
+	}
+
+	var rows [][]string
+	for ; j < len(lines); j++ {
+		l := lines[j]
+		if isRequest(l, "TE") {
+			break
+		}
+		if isComment(l) || strings.HasPrefix(l, ".") || strings.TrimSpace(l) == "_" || strings.TrimSpace(l) == "=" {
+			continue
+		}
+		var row []string
+		for _, cell := range strings.Split(l, sep) {
+			cell = strings.TrimSpace(unescape(strings.NewReplacer("T{", "", "T}", "").Replace(cell)))
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) > 0 {
+		t := &mq.Table{Headers: rows[0], Rows: rows[1:]}
+		e.tables = append(e.tables, t)
+
+		var block strings.Builder
+		for k, row := range rows {
+			if k > 0 {
+				block.WriteString("\n")
+			}
+			block.WriteString(strings.Join(row, "  "))
+		}
+		t.Position = e.writeBlock(block.String())
+	}
+	return j
+}
+
+// writeBlock appends a block of rendered text and returns its position.
+// Links waiting for their text are located inside the block.
+func (e *extractor) writeBlock(text string) mq.Position {
+	index := mq.NewLineIndex([]byte(text))
+	at := func(start, end int) mq.Position {
+		pos := index.Position(start, end)
+		pos.Line += e.line
+		pos.EndLine += e.line
+		return pos
+	}
+	block := at(0, len(text))
+
+	from := 0
+	for _, l := range e.pending {
+		if i := strings.Index(text[from:], l.Text); i >= 0 && l.Text != "" {
+			l.Position = at(from+i, from+i+len(l.Text))
+			from += i + len(l.Text)
+		} else {
+			l.Position = block
+		}
+	}
+	e.pending = nil
+
+	e.out.WriteString(text)
+	e.out.WriteString("\n\n")
+	e.line += strings.Count(text, "\n") + 2
+	return block
+}
+
+// addLink records a link; its position is known once its text is written.
+func (e *extractor) addLink(l *mq.Link) {
+	e.links = append(e.links, l)
+	e.pending = append(e.pending, l)
+}
+
+// mdocCallable lists mdoc macros that may appear inline as arguments.
+var mdocCallable = map[string]bool{
+	"Fl": true, "Ar": true, "Cm": true, "Pa": true, "Ev": true, "Va": true,
+	"Em": true, "Sy": true, "Li": true, "Ic": true, "Dv": true, "Er": true,
+	"Ad": true, "Nm": true, "Op": true, "Oo": true, "Oc": true, "Dq": true,
+	"Qq": true, "Sq": true, "Pq": true, "Ql": true, "Aq": true, "Bq": true,
+	"Xr": true, "Ns": true, "Pf": true, "No": true, "Fa": true, "Fn": true,
+	"Ft": true, "Tn": true, "Lb": true, "Mt": true, "An": true,
+	"Xo": true, "Xc": true, "Ux": true, "Bx": true, "Nx": true, "Fx": true,
+	"Ox": true,
+}
+
+// mdocNames are the text of the operating system name macros.
+var mdocNames = map[string]string{
+	"Ux": "UNIX", "Bx": "BSD", "Nx": "NetBSD", "Fx": "FreeBSD", "Ox": "OpenBSD",
+}
+
+func isMdocMacro(name string) bool {
+	return mdocCallable[name]
+}
+
+// mdoc renders a line of mdoc macro arguments as plain text.
+func (e *extractor) mdoc(args []string) string {
+	var words []string
+	noSpace := false
+	add := func(w string) {
+		if noSpace && len(words) > 0 {
+			words[len(words)-1] += w
+		} else {
+			words = append(words, w)
+		}
+		noSpace = false
+	}
+
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		if !mdocCallable[tok] {
+			if isPunct(tok) && len(words) > 0 {
+				words[len(words)-1] += tok
+				continue
+			}
+			add(unescape(tok))
+			continue
+		}
+
+		switch tok {
+		case "Fl":
+			if i+1 < len(args) && !mdocCallable[args[i+1]] && !isPunct(args[i+1]) {
+				add("-" + unescape(args[i+1]))
+				i++
+			} else {
+				add("-")
+				noSpace = i+1 < len(args) && mdocCallable[args[i+1]]
+			}
+		case "Nm":
+			// The first .Nm with an argument names the page; a bare
+			// .Nm repeats it. The argument itself is added as a word.
+			if i+1 < len(args) && !mdocCallable[args[i+1]] && !isPunct(args[i+1]) {
+				if e.name == "" {
+					e.name = args[i+1]
+					e.meta["name"] = args[i+1]
+				}
+			} else if e.name != "" {
+				add(e.name)
+			}
+		case "Op", "Dq", "Qq", "Sq", "Pq", "Ql", "Aq", "Bq":
+			open, close := quotePair(tok)
+			inner := e.mdoc(args[i+1:])
+			// Trailing punctuation stays outside the quotes.
+			trail := ""
+			for len(inner) > 0 && isPunct(inner[len(inner)-1:]) && inner[len(inner)-1] != ')' && inner[len(inner)-1] != ']' {
+				trail = inner[len(inner)-1:] + trail
+				inner = inner[:len(inner)-1]
+			}
+			add(open + strings.TrimSpace(inner) + close + trail)
+			i = len(args)
+		case "Oo":
+			add("[")
+			noSpace = true
+		case "Oc":
+			if len(words) > 0 {
+				words[len(words)-1] += "]"
+			} else {
+				add("]")
+			}
+		case "Xr":
+			if i+2 < len(args) {
+				add(args[i+1] + "(" + args[i+2] + ")")
+				i += 2
+			}
+		case "Ns":
+			noSpace = true
+		case "Pf":
+			if i+1 < len(args) {
+				add(unescape(args[i+1]))
+				noSpace = true
+				i++
+			}
+		case "Ux", "Bx", "Nx", "Fx", "Ox":
+			add(mdocNames[tok])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func quotePair(macro string) (string, string) {
+	switch macro {
+	case "Op", "Bq":
+		return "[", "]"
+	case "Dq", "Qq":
+		return "\"", "\""
+	case "Sq", "Ql":
+		return "'", "'"
+	case "Pq":
+		return "(", ")"
+	case "Aq":
+		return "<", ">"
+	}
+	return "", ""
+}
+
+func isPunct(s string) bool {
+	switch s {
+	case ".", ",", ";", ":", "?", "!", ")", "]":
+		return true
+	}
+	return false
+}
+
+func isBullet(tag string) bool {
+	switch strings.TrimSpace(tag) {
+	case "•", "*", "-", "o", "·":
+		return true
+	}
+	return false
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(line, `.\"`) || strings.HasPrefix(line, `'\"`) ||
+		strings.HasPrefix(line, `\"`) || strings.HasPrefix(line, `.\\"`)
+}
+
+func isRequest(line, name string) bool {
+	n, _ := parseRequest(line)
+	return n == name
+}
+
+// parseRequest splits a control line into its request name and arguments,
+// honoring double quotes.
+func parseRequest(line string) (string, []string) {
+	if len(line) == 0 || (line[0] != '.' && line[0] != '\'') {
+		return "", nil
+	}
+	rest := strings.TrimLeft(line[1:], " \t")
+	// Trailing \" comments
+	if k := strings.Index(rest, `\"`); k >= 0 {
+		rest = rest[:k]
+	}
+
+	var fields []string
+	var cur strings.Builder
+	inQuote, started := false, false
+	for k := 0; k < len(rest); k++ {
+		c := rest[k]
+		switch {
+		case c == '\\' && k+1 < len(rest):
+			cur.WriteByte(c)
+			cur.WriteByte(rest[k+1])
+			k++
+			started = true
+		case c == '"' && inQuote && k+1 < len(rest) && rest[k+1] == '"':
+			cur.WriteByte('"')
+			k++
+		case c == '"':
+			inQuote = !inQuote
+			started = true
+		case (c == ' ' || c == '\t') && !inQuote:
+			if started {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteByte(c)
+			started = true
+		}
+	}
+	if started {
+		fields = append(fields, cur.String())
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// specialChars maps roff \(xx and \[xx] escapes to text.
+var specialChars = map[string]string{
+	"em": "—", "en": "–", "hy": "-", "mi": "-", "aq": "'", "dq": "\"",
+	"lq": "“", "rq": "”", "oq": "‘", "cq": "’", "bu": "•", "co": "©",
+	"rg": "®", "tm": "™", "de": "°", "mu": "×", "di": "÷", "+-": "±",
+	"<=": "≤", ">=": "≥", "!=": "≠", "->": "→", "<-": "←", "ga": "`",
+	"ti": "~", "ha": "^", "rs": "\\", "sl": "/", "ba": "|", "or": "|",
+}
+
+// unescape removes roff escapes: font changes, special characters and
+// spacing hints.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case '"':
+			return buf.String() // comment to end of line
+		case 'f':
+			// \fB, \f(CW, \f[BI]
+			i = skipArg(s, i+1) - 1
+		case 's':
+			// \s+2, \s-1, \s0
+			i++
+			for i < len(s) && (s[i] == '+' || s[i] == '-' || (s[i] >= '0' && s[i] <= '9')) {
+				i++
+			}
+			i--
+		case '(':
+			if i+2 < len(s) {
+				buf.WriteString(specialChars[s[i+1:i+3]])
+				i += 2
+			}
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				continue
+			}
+			buf.WriteString(specialChars[s[i+1:i+end]])
+			i += end
+		case '*':
+			// Predefined strings: \*(lq, \*R, \*[Tm]
+			start := i + 1
+			i = skipArg(s, start) - 1
+			name := strings.Trim(s[start:i+1], "([]")
+			switch name {
+			case "R":
+				buf.WriteString("®")
+			case "Tm":
+				buf.WriteString("™")
+			case "lq", "rq":
+				buf.WriteString("\"")
+			}
+		case '-', 'e', '\\':
+			if s[i] == 'e' || s[i] == '\\' {
+				buf.WriteByte('\\')
+			} else {
+				buf.WriteByte('-')
+			}
+		case ' ', '~', '0':
+			buf.WriteByte(' ')
+		case '&', '|', '^', 'c', '%', ')', ':', ',', '/':
+			// Zero-width and spacing hints
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
+// skipArg skips a one-character, (xx or [xxx] escape argument at s[i]
+// and returns the index just past it.
+func skipArg(s string, i int) int {
+	if i >= len(s) {
+		return i
+	}
+	switch s[i] {
+	case '(':
+		return min(i+3, len(s))
+	case '[':
+		if end := strings.IndexByte(s[i:], ']'); end >= 0 {
+			return i + end + 1
+		}
+		return len(s)
+	}
+	return i + 1
+}
+
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = indent + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseMan is a convenience function for quick parsing.
+func ParseMan(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseManFile is a convenience function for quick file parsing.
+func ParseManFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,133 @@
+package man_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/muqsitnawaz/mq/man"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserFormat(t *testing.T) {
+	p := man.NewParser()
+	assert.Equal(t, mq.FormatMan, p.Format())
+}
+
+func TestParseManSections(t *testing.T) {
+	doc, err := man.ParseManFile("testdata/widget.1")
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatMan, doc.Format())
+	assert.Equal(t, "widget - assemble and inspect widgets", doc.Title())
+
+	toc := doc.GetTableOfContents()
+	var names []string
+	for _, s := range toc {
+		names = append(names, s.Heading.Text)
+	}
+	assert.Equal(t, []string{"NAME", "SYNOPSIS", "DESCRIPTION", "OPTIONS", "EXAMPLES", "SEE ALSO"}, names)
+
+	options, ok := doc.GetSection("OPTIONS")
+	require.True(t, ok)
+	require.Len(t, options.Children, 2)
+	assert.Equal(t, "Building", options.Children[0].Heading.Text)
+	assert.Equal(t, 2, options.Children[0].Heading.Level)
+
+	text := options.GetText()
+	assert.Contains(t, text, "-o, --output=DIR\n    Write widgets to DIR instead of the current directory.")
+	assert.Contains(t, text, "    Repeat for more detail.")
+	assert.NotContains(t, text, "Build everything")
+
+	desc, ok := doc.GetSection("DESCRIPTION")
+	require.True(t, ok)
+	assert.Contains(t, desc.GetText(), "widget builds widgets from FILEs. See the project page.")
+}
+
+func TestParseManEntries(t *testing.T) {
+	doc, err := man.ParseManFile("testdata/widget.1")
+	require.NoError(t, err)
+
+	// One list per run of .TP entries
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 2)
+	require.Len(t, lists[0].Items, 2)
+	assert.Equal(t, "-o, --output=DIR: Write widgets to DIR instead of the current directory.", lists[0].Items[0].Text)
+	assert.Equal(t, "-v, --verbose: Explain what is being done. Repeat for more detail.", lists[0].Items[1].Text)
+	assert.Equal(t, "--json, --yaml: Select the output format.", lists[1].Items[0].Text)
+
+	blocks := doc.GetCodeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "widget -o out/ *.wid", blocks[0].Content)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"Format", "Extension"}, tables[0].Headers)
+	assert.Equal(t, [][]string{{"json", ".json"}, {"yaml", ".yml"}}, tables[0].Rows)
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, "the project page", links[0].Text)
+	assert.Equal(t, "https://example.com/widget", links[0].URL)
+}
+
+func TestParseManMetadata(t *testing.T) {
+	doc, err := man.ParseManFile("testdata/widget.1")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, "WIDGET", meta["name"])
+	assert.Equal(t, "1", meta["section"])
+	assert.Equal(t, "2024-05-01", meta["date"])
+	assert.Equal(t, "widget 2.3", meta["source"])
+	assert.Equal(t, "User Commands", meta["manual"])
+	assert.Equal(t, "assemble and inspect widgets", meta["description"])
+}
+
+func TestParseMdoc(t *testing.T) {
+	doc, err := man.ParseManFile("testdata/probe.8")
+	require.NoError(t, err)
+
+	assert.Equal(t, "probe - check remote hosts", doc.Title())
+	meta := doc.Metadata()
+	assert.Equal(t, "probe", meta["name"])
+	assert.Equal(t, "8", meta["section"])
+	assert.Equal(t, "March 5 2023", meta["date"])
+
+	synopsis, ok := doc.GetSection("SYNOPSIS")
+	require.True(t, ok)
+	assert.Contains(t, synopsis.GetText(), "probe [-46v] [-c count] host")
+
+	lists := doc.GetLists(nil)
+	require.Len(t, lists, 1)
+	require.Len(t, lists[0].Items, 2)
+	assert.Equal(t, "-c count: Stop after count probes.", lists[0].Items[0].Text)
+	assert.Equal(t, "-p [addr:]port: Probe the given port.", lists[0].Items[1].Text)
+
+	blocks := doc.GetCodeBlocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "$ probe -c 3 example.com", blocks[0].Content)
+}
+
+func TestParseGzip(t *testing.T) {
+	raw, err := os.ReadFile("testdata/widget.1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	doc, err := man.ParseMan(buf.Bytes(), "widget.1.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "widget - assemble and inspect widgets", doc.Title())
+
+	_, err = man.ParseMan([]byte{0x1f, 0x8b, 0x00}, "broken.1.gz")
+	var parseErr *mq.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, mq.FormatMan, parseErr.Format)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	mq "github.com/muqsitnawaz/mq/lib"
 )
@@ -116,6 +117,11 @@ func (v *compilerVisitor) VisitPipe(node *PipeNode) (interface{}, error) {
 func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error) {
 	// Check if selector is a property accessor on current item
 	if v.context.Current != nil && v.context.Current != v.context.Document {
+		// Section text takes options, so it's handled before properties
+		if result, handled, err := v.sectionText(node); handled {
+			return result, err
+		}
+
 		// Try to handle as property access
 		result, handled := v.handlePropertyAccess(node.Name)
 		if handled {
@@ -128,8 +134,9 @@ func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error)
 			return result, nil
 		}
 
-		// Special handling for .code selector on sections
-		if node.Name == "code" {
+		// Special handling for element selectors on sections
+		switch node.Name {
+		case "code", "footnotes", "definitions", "admonitions", "math", "diagrams":
 			if section, ok := v.context.Current.(*mq.Section); ok {
 				// Evaluate arguments if any
 				args := make([]interface{}, len(node.Args))
@@ -140,8 +147,20 @@ func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error)
 					}
 					args[i] = val
 				}
-				langs := extractStringArgs(args)
-				return section.GetCodeBlocks(langs...), nil
+				names := extractStringArgs(args)
+				switch node.Name {
+				case "footnotes":
+					return section.GetFootnotes(), nil
+				case "definitions":
+					return section.GetDefinitions(names...), nil
+				case "admonitions":
+					return section.GetAdmonitions(names...), nil
+				case "math":
+					return section.GetMath(), nil
+				case "diagrams":
+					return section.GetDiagrams(names...), nil
+				}
+				return section.GetCodeBlocks(names...), nil
 			}
 		}
 	}
@@ -185,6 +204,12 @@ func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error)
 	case "sections":
 		return doc.GetSections(), nil
 
+	case "preamble":
+		if preamble := doc.GetPreamble(); preamble != nil {
+			return preamble, nil
+		}
+		return nil, nil
+
 	case "code":
 		langs := extractStringArgs(args)
 		return doc.GetCodeBlocks(langs...), nil
@@ -275,6 +300,82 @@ func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error)
 		}
 		return doc.Search(query), nil
 
+	case "lines":
+		bounds := extractIntArgs(args)
+		if len(bounds) == 0 || len(bounds) > 2 || len(bounds) != len(args) {
+			return nil, fmt.Errorf("Error: .lines requires one or two line numbers\nUsage: .lines(10, 20) or .lines(10)")
+		}
+		start, end := bounds[0], bounds[0]
+		if len(bounds) == 2 {
+			end = bounds[1]
+		}
+		text, ok := doc.Lines(start, end)
+		if !ok {
+			return nil, fmt.Errorf("line range out of bounds: %d-%d", start, end)
+		}
+		return text, nil
+
+	case "xpath":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("Error: .xpath requires a path argument\nUsage: .xpath(\"/project/dependencies/dependency\")")
+		}
+		expr, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("Error: .xpath requires a string path, got %T\nUsage: .xpath(\"/project/dependencies/dependency\")", args[0])
+		}
+		return doc.XPath(expr)
+
+	case "components":
+		names := extractStringArgs(args)
+		return doc.GetComponents(names...), nil
+
+	case "tasks":
+		return doc.GetTasks(), nil
+
+	case "inline_code":
+		return doc.GetInlineCode(), nil
+
+	case "references":
+		return doc.GetReferences(), nil
+
+	case "footnotes":
+		return doc.GetFootnotes(), nil
+
+	case "definitions":
+		terms := extractStringArgs(args)
+		return doc.GetDefinitions(terms...), nil
+
+	case "admonitions":
+		kinds := extractStringArgs(args)
+		return doc.GetAdmonitions(kinds...), nil
+
+	case "math":
+		return doc.GetMath(), nil
+
+	case "diagrams":
+		kinds := extractStringArgs(args)
+		return doc.GetDiagrams(kinds...), nil
+
+	case "endpoints":
+		return doc.GetEndpoints(), nil
+
+	case "schemas":
+		return doc.GetSchemas(), nil
+
+	case "schema":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("Error: .schema requires a name argument\nUsage: .schema(\"User\")\nHint: Use .schemas to list all schemas")
+		}
+		name, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("Error: .schema requires a string name, got %T\nUsage: .schema(\"User\")", args[0])
+		}
+		schema, found := doc.GetSchema(name)
+		if !found {
+			return nil, fmt.Errorf("schema not found: %s", name)
+		}
+		return schema, nil
+
 	default:
 		return nil, formatUnknownSelectorError(node.Name)
 	}
@@ -284,9 +385,11 @@ func (v *compilerVisitor) VisitSelector(node *SelectorNode) (interface{}, error)
 func formatUnknownSelectorError(name string) error {
 	// Known selectors for suggestions
 	knownSelectors := []string{
-		"headings", "section", "sections", "code", "links", "images",
+		"headings", "section", "sections", "preamble", "code", "links", "images",
 		"tables", "lists", "metadata", "owner", "tags", "priority",
-		"text", "length", "tree", "search",
+		"text", "length", "tree", "search", "lines", "xpath", "endpoints",
+		"schemas", "schema", "components", "tasks", "inline_code", "references", "footnotes",
+		"definitions", "admonitions", "math", "diagrams",
 	}
 
 	// Find closest match using simple string distance
@@ -296,7 +399,7 @@ func formatUnknownSelectorError(name string) error {
 		return fmt.Errorf("Error: unknown selector: .%s\nDid you mean: .%s?", name, suggestion)
 	}
 
-	return fmt.Errorf("Error: unknown selector: .%s\nAvailable selectors: .headings, .section(title), .sections, .code, .links, .images, .tables, .lists, .metadata, .owner, .tags, .priority, .text, .length, .tree, .search(query)", name)
+	return fmt.Errorf("Error: unknown selector: .%s\nAvailable selectors: .headings, .section(title), .sections, .preamble, .code, .links, .references, .images, .tables, .lists, .metadata, .owner, .tags, .priority, .text, .length, .tree, .search(query), .lines(start, end), .xpath(path), .endpoints, .schemas, .schema(name), .components, .tasks, .inline_code, .footnotes, .definitions(term), .admonitions(kind), .math, .diagrams(kind)", name)
 }
 
 // findClosestMatch finds the closest matching string using simple heuristics.
@@ -352,120 +455,91 @@ func (v *compilerVisitor) VisitFilter(node *FilterNode) (interface{}, error) {
 	// Handle different collection types
 	switch data := current.(type) {
 	case []*mq.Heading:
-		return v.filterHeadings(data, node.Predicate, v)
+		return filterItems(v, data, node.Predicate)
 
 	case []*mq.Section:
-		return v.filterSections(data, node.Predicate, v)
+		return filterItems(v, data, node.Predicate)
 
 	case []*mq.CodeBlock:
-		return v.filterCodeBlocks(data, node.Predicate, v)
+		return filterItems(v, data, node.Predicate)
 
 	case []*mq.Link:
-		return v.filterLinks(data, node.Predicate, v)
-
-	default:
-		return nil, fmt.Errorf("Error: cannot filter type: %T\nHint: filter works on collections like headings, sections, code blocks, and links", current)
-	}
-}
-
-// filterHeadings filters headings based on predicate.
-func (c *compilerVisitor) filterHeadings(headings []*mq.Heading, predicate QueryNode, v *compilerVisitor) ([]*mq.Heading, error) {
-	var result []*mq.Heading
-
-	for _, heading := range headings {
-		// Set current item for predicate evaluation
-		oldCurrent := v.context.Current
-		v.context.Current = heading
-
-		// Evaluate predicate
-		match, err := predicate.Accept(v)
-		if err != nil {
-			return nil, err
-		}
+		return filterItems(v, data, node.Predicate)
 
-		// Restore context
-		v.context.Current = oldCurrent
+	case []*mq.InlineCode:
+		return filterItems(v, data, node.Predicate)
 
-		// Check if predicate matched
-		if toBool(match) {
-			result = append(result, heading)
-		}
-	}
+	case []*mq.Endpoint:
+		return filterItems(v, data, node.Predicate)
 
-	return result, nil
-}
+	case []*mq.Schema:
+		return filterItems(v, data, node.Predicate)
 
-// filterSections filters sections based on predicate.
-func (c *compilerVisitor) filterSections(sections []*mq.Section, predicate QueryNode, v *compilerVisitor) ([]*mq.Section, error) {
-	var result []*mq.Section
+	case []*mq.Component:
+		return filterItems(v, data, node.Predicate)
 
-	for _, section := range sections {
-		oldCurrent := v.context.Current
-		v.context.Current = section
+	case []*mq.Task:
+		return filterItems(v, data, node.Predicate)
 
-		match, err := predicate.Accept(v)
-		if err != nil {
-			return nil, err
-		}
+	case []*mq.Footnote:
+		return filterItems(v, data, node.Predicate)
 
-		v.context.Current = oldCurrent
+	case []*mq.Definition:
+		return filterItems(v, data, node.Predicate)
 
-		if toBool(match) {
-			result = append(result, section)
-		}
-	}
+	case []*mq.Admonition:
+		return filterItems(v, data, node.Predicate)
 
-	return result, nil
-}
+	case []*mq.MathBlock:
+		return filterItems(v, data, node.Predicate)
 
-// filterCodeBlocks filters code blocks based on predicate.
-func (c *compilerVisitor) filterCodeBlocks(blocks []*mq.CodeBlock, predicate QueryNode, v *compilerVisitor) ([]*mq.CodeBlock, error) {
-	var result []*mq.CodeBlock
+	case []*mq.Diagram:
+		return filterItems(v, data, node.Predicate)
 
-	for _, block := range blocks {
-		oldCurrent := v.context.Current
-		v.context.Current = block
+	case []*mq.DiagramNode:
+		return filterItems(v, data, node.Predicate)
 
-		match, err := predicate.Accept(v)
-		if err != nil {
-			return nil, err
-		}
+	case []*mq.DiagramEdge:
+		return filterItems(v, data, node.Predicate)
 
-		v.context.Current = oldCurrent
+	case []interface{}:
+		return filterItems(v, data, node.Predicate)
 
-		if toBool(match) {
-			result = append(result, block)
-		}
+	default:
+		return nil, fmt.Errorf("Error: cannot filter type: %T\nHint: filter works on collections like headings, sections, code blocks, links, and endpoints", current)
 	}
-
-	return result, nil
 }
 
-// filterLinks filters links based on predicate.
-func (c *compilerVisitor) filterLinks(links []*mq.Link, predicate QueryNode, v *compilerVisitor) ([]*mq.Link, error) {
-	var result []*mq.Link
-
-	for _, link := range links {
-		oldCurrent := v.context.Current
-		v.context.Current = link
+// filterItems keeps the items the predicate holds for, evaluating it
+// with each item as the current value.
+func filterItems[T any](v *compilerVisitor, items []T, predicate QueryNode) ([]T, error) {
+	oldCurrent := v.context.Current
+	defer func() { v.context.Current = oldCurrent }()
 
+	var result []T
+	for _, item := range items {
+		v.context.Current = item
 		match, err := predicate.Accept(v)
 		if err != nil {
 			return nil, err
 		}
-
-		v.context.Current = oldCurrent
-
 		if toBool(match) {
-			result = append(result, link)
+			result = append(result, item)
 		}
 	}
-
 	return result, nil
 }
 
 // VisitFunction compiles a function call.
 func (v *compilerVisitor) VisitFunction(node *FunctionNode) (interface{}, error) {
+	// map evaluates its argument per item, not against the collection
+	if node.Name == "map" {
+		if len(node.Args) != 1 {
+			return nil, fmt.Errorf("Error: map requires 1 argument\nUsage: .collection | map(.property)")
+		}
+		return v.mapOperation(node.Args[0])
+	}
+
 	// Evaluate arguments
 	args := make([]interface{}, len(node.Args))
 	for i, arg := range node.Args {
@@ -478,12 +552,6 @@ func (v *compilerVisitor) VisitFunction(node *FunctionNode) (interface{}, error)
 
 	// Execute function
 	switch node.Name {
-	case "map":
-		if len(node.Args) != 1 {
-			return nil, fmt.Errorf("Error: map requires 1 argument\nUsage: .collection | map(.property)")
-		}
-		return v.mapOperation(node.Args[0])
-
 	case "contains":
 		if len(args) != 1 {
 			return nil, fmt.Errorf("Error: contains requires 1 argument\nUsage: .property | contains(\"substring\")")
@@ -662,14 +730,16 @@ func getProperty(obj interface{}, name string) (interface{}, error) {
 			return v.Text, nil
 		case "id":
 			return v.ID, nil
-		default:
-			available := []string{"level", "text", "id"}
-			suggestion := findClosestMatch(name, available)
-			if suggestion != "" {
-				return nil, fmt.Errorf("Error: heading has no property: .%s\nDid you mean: .%s?\nAvailable: .level, .text, .id", name, suggestion)
-			}
-			return nil, fmt.Errorf("Error: heading has no property: .%s\nAvailable: .level, .text, .id", name)
 		}
+		if value, ok := positionProperty(v.Position(), name); ok {
+			return value, nil
+		}
+		available := append([]string{"level", "text", "id"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: heading has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: heading has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
 
 	case *mq.Section:
 		switch name {
@@ -677,17 +747,23 @@ func getProperty(obj interface{}, name string) (interface{}, error) {
 			return v.Heading, nil
 		case "text":
 			return v.GetText(), nil
+		case "body":
+			return v.GetBody(), nil
+		case "heading_line":
+			return v.GetHeadingLine(), nil
 		case "start":
 			return v.Start, nil
 		case "end":
 			return v.End, nil
+		case "metadata":
+			return v.Metadata, nil
 		default:
-			available := []string{"heading", "text", "start", "end"}
+			available := []string{"heading", "text", "body", "heading_line", "start", "end", "metadata"}
 			suggestion := findClosestMatch(name, available)
 			if suggestion != "" {
-				return nil, fmt.Errorf("Error: section has no property: .%s\nDid you mean: .%s?\nAvailable: .heading, .text, .start, .end", name, suggestion)
+				return nil, fmt.Errorf("Error: section has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
 			}
-			return nil, fmt.Errorf("Error: section has no property: .%s\nAvailable: .heading, .text, .start, .end", name)
+			return nil, fmt.Errorf("Error: section has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
 		}
 
 	case *mq.CodeBlock:
@@ -698,14 +774,32 @@ func getProperty(obj interface{}, name string) (interface{}, error) {
 			return v.Content, nil
 		case "lines":
 			return v.GetLines(), nil
-		default:
-			available := []string{"language", "content", "lines"}
-			suggestion := findClosestMatch(name, available)
-			if suggestion != "" {
-				return nil, fmt.Errorf("Error: code block has no property: .%s\nDid you mean: .%s?\nAvailable: .language, .content, .lines", name, suggestion)
-			}
-			return nil, fmt.Errorf("Error: code block has no property: .%s\nAvailable: .language, .content, .lines", name)
+		case "indented":
+			return v.Indented, nil
+		}
+		if value, ok := positionProperty(v.Position, name); ok {
+			return value, nil
+		}
+		available := append([]string{"language", "content", "lines", "indented"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: code block has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
 		}
+		return nil, fmt.Errorf("Error: code block has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.InlineCode:
+		if name == "text" || name == "content" {
+			return v.Text, nil
+		}
+		if value, ok := positionProperty(v.Position, name); ok {
+			return value, nil
+		}
+		available := append([]string{"text"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: inline code has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: inline code has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
 
 	case *mq.Link:
 		switch name {
@@ -713,14 +807,202 @@ func getProperty(obj interface{}, name string) (interface{}, error) {
 			return v.Text, nil
 		case "url":
 			return v.URL, nil
-		default:
-			available := []string{"text", "url"}
-			suggestion := findClosestMatch(name, available)
-			if suggestion != "" {
-				return nil, fmt.Errorf("Error: link has no property: .%s\nDid you mean: .%s?\nAvailable: .text, .url", name, suggestion)
-			}
-			return nil, fmt.Errorf("Error: link has no property: .%s\nAvailable: .text, .url", name)
+		case "title":
+			return v.Title, nil
+		case "label":
+			return v.Label, nil
+		case "kind":
+			return v.Kind, nil
+		case "page":
+			return v.Page, nil
+		case "anchor":
+			return v.Anchor, nil
+		}
+		if value, ok := positionProperty(v.Position, name); ok {
+			return value, nil
+		}
+		available := append([]string{"text", "url", "title", "label", "kind", "page", "anchor"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: link has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: link has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Image:
+		switch name {
+		case "alt", "alttext", "text":
+			return v.AltText, nil
+		case "url":
+			return v.URL, nil
+		case "title":
+			return v.Title, nil
+		}
+		if value, ok := positionProperty(v.Position, name); ok {
+			return value, nil
+		}
+		available := append([]string{"alt", "url", "title"}, positionProperties...)
+		return nil, fmt.Errorf("Error: image has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Table:
+		switch name {
+		case "headers":
+			return v.Headers, nil
+		case "rows":
+			return v.Rows, nil
+		}
+		if value, ok := positionProperty(v.Position, name); ok {
+			return value, nil
+		}
+		available := append([]string{"headers", "rows"}, positionProperties...)
+		return nil, fmt.Errorf("Error: table has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.List:
+		if value, ok := listProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"ordered", "items"}, positionProperties...)
+		return nil, fmt.Errorf("Error: list has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case mq.ListItem:
+		if value, ok := listItemProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"text", "checked", "children"}, positionProperties...)
+		return nil, fmt.Errorf("Error: list item has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Endpoint:
+		if value, ok := endpointProperty(v, name); ok {
+			return value, nil
+		}
+		available := []string{"method", "path", "operation_id", "summary", "description", "tags", "deprecated", "parameters", "responses", "text"}
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: endpoint has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: endpoint has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Schema:
+		if value, ok := schemaProperty(v, name); ok {
+			return value, nil
+		}
+		available := []string{"name", "type", "description", "required", "properties", "text"}
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: schema has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
 		}
+		return nil, fmt.Errorf("Error: schema has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Task:
+		if value, ok := taskProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"text", "checked", "depth", "section", "file"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: task has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: task has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Footnote:
+		if value, ok := footnoteProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"label", "text"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: footnote has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: footnote has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Definition:
+		if value, ok := definitionProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"term", "descriptions", "text"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: definition has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: definition has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Admonition:
+		if value, ok := admonitionProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"kind", "title", "text"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: admonition has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: admonition has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.MathBlock:
+		if value, ok := mathProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"content"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: math block has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: math block has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Diagram:
+		if value, ok := diagramProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"language", "kind", "nodes", "edges", "content"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: diagram has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: diagram has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.DiagramNode:
+		if value, ok := diagramNodeProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"id", "label", "kind"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: diagram node has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: diagram node has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.DiagramEdge:
+		if value, ok := diagramEdgeProperty(v, name); ok {
+			return value, nil
+		}
+		available := append([]string{"from", "to", "label", "arrow"}, positionProperties...)
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: diagram edge has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: diagram edge has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case *mq.Component:
+		if value, ok := componentProperty(v, name); ok {
+			return value, nil
+		}
+		if value, ok := v.Props[name]; ok {
+			return value, nil
+		}
+		available := []string{"name", "props", "content", "start", "end", "children"}
+		suggestion := findClosestMatch(name, available)
+		if suggestion != "" {
+			return nil, fmt.Errorf("Error: component has no property: .%s\nDid you mean: .%s?\nAvailable: .%s", name, suggestion, strings.Join(available, ", ."))
+		}
+		return nil, fmt.Errorf("Error: component has no property: .%s\nAvailable: .%s", name, strings.Join(available, ", ."))
+
+	case mq.Metadata:
+		return v[name], nil
+
+	case map[string]interface{}:
+		// Nested frontmatter values; missing keys are null, as in jq
+		return v[name], nil
+
+	case nil:
+		return nil, nil
 
 	default:
 		return nil, fmt.Errorf("Error: cannot access property .%s on type %T", name, obj)
@@ -788,6 +1070,11 @@ func equals(a, b interface{}) bool {
 		return na == nb
 	}
 
+	// Dates compare with dates and date strings
+	if ta, tb, ok := toTimes(a, b); ok {
+		return ta.Equal(tb)
+	}
+
 	// Fall back to DeepEqual for other types
 	return reflect.DeepEqual(a, b)
 }
@@ -801,6 +1088,10 @@ func lessThan(a, b interface{}) (bool, error) {
 		return na < nb, nil
 	}
 
+	if ta, tb, ok := toTimes(a, b); ok {
+		return ta.Before(tb), nil
+	}
+
 	// String comparison
 	switch va := a.(type) {
 	case string:
@@ -809,7 +1100,7 @@ func lessThan(a, b interface{}) (bool, error) {
 		}
 	}
 
-	return false, fmt.Errorf("Error: cannot compare %T and %T\nHint: comparison operators work with numbers or strings", a, b)
+	return false, fmt.Errorf("Error: cannot compare %T and %T\nHint: comparison operators work with numbers, strings or dates", a, b)
 }
 
 // toNumber converts various numeric types to float64 for comparison
@@ -827,6 +1118,43 @@ func toNumber(v interface{}) (float64, bool) {
 	return 0, false
 }
 
+// dateLayouts are the date strings a query can compare dates against.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// toTimes converts a and b to times when at least one is a time.Time and
+// the other is a time.Time or a date string.
+func toTimes(a, b interface{}) (time.Time, time.Time, bool) {
+	ta, aIsTime := a.(time.Time)
+	tb, bIsTime := b.(time.Time)
+	switch {
+	case aIsTime && bIsTime:
+		return ta, tb, true
+	case aIsTime:
+		tb, bIsTime = parseDate(b)
+	case bIsTime:
+		ta, aIsTime = parseDate(a)
+	}
+	return ta, tb, aIsTime && bIsTime
+}
+
+func parseDate(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func lessEqual(a, b interface{}) (bool, error) {
 	lt, err := lessThan(a, b)
 	if err != nil {
@@ -904,8 +1232,32 @@ func extractText(obj interface{}) string {
 		return v.GetText()
 	case *mq.CodeBlock:
 		return v.Content
+	case *mq.InlineCode:
+		return v.Text
 	case *mq.Link:
 		return v.Text
+	case *mq.Endpoint:
+		return v.Method + " " + v.Path
+	case *mq.Schema:
+		return v.Name
+	case *mq.Component:
+		return v.Content
+	case *mq.Task:
+		return v.Text
+	case *mq.Footnote:
+		return v.Content
+	case *mq.Definition:
+		return strings.Join(v.Descriptions, "\n")
+	case *mq.Admonition:
+		return v.Content
+	case *mq.MathBlock:
+		return v.Content
+	case *mq.Diagram:
+		return v.Code.Content
+	case *mq.DiagramNode:
+		return v.Label
+	case *mq.DiagramEdge:
+		return v.Label
 	case string:
 		return v
 	default:
@@ -933,6 +1285,21 @@ func (v *compilerVisitor) handleCollectionPropertyAccess(property string) (inter
 			}
 			return results, true
 		}
+	case []*mq.Diagram:
+		switch property {
+		case "nodes":
+			var nodes []*mq.DiagramNode
+			for _, d := range items {
+				nodes = append(nodes, d.Nodes...)
+			}
+			return nodes, true
+		case "edges":
+			var edges []*mq.DiagramEdge
+			for _, d := range items {
+				edges = append(edges, d.Edges...)
+			}
+			return edges, true
+		}
 	case []*mq.Heading:
 		// Already handled by extractTextFromAny for .text
 		// Add other properties if needed
@@ -958,6 +1325,7 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 		case "id":
 			return item.ID, true
 		}
+		return positionProperty(item.Position(), property)
 
 	case *mq.Section:
 		switch property {
@@ -971,6 +1339,8 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 			return item.Start, true
 		case "end":
 			return item.End, true
+		case "metadata":
+			return item.Metadata, true
 			// Note: "code" is handled specially in VisitSelector to support arguments
 		}
 
@@ -982,7 +1352,16 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 			return item.Language, true
 		case "lines":
 			return item.GetLines(), true
+		case "indented":
+			return item.Indented, true
+		}
+		return positionProperty(item.Position, property)
+
+	case *mq.InlineCode:
+		if property == "text" || property == "content" {
+			return item.Text, true
 		}
+		return positionProperty(item.Position, property)
 
 	case *mq.Link:
 		switch property {
@@ -990,7 +1369,18 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 			return item.Text, true
 		case "url":
 			return item.URL, true
-		}
+		case "title":
+			return item.Title, true
+		case "label":
+			return item.Label, true
+		case "kind":
+			return item.Kind, true
+		case "page":
+			return item.Page, true
+		case "anchor":
+			return item.Anchor, true
+		}
+		return positionProperty(item.Position, property)
 
 	case *mq.Image:
 		switch property {
@@ -998,7 +1388,10 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 			return item.AltText, true
 		case "url":
 			return item.URL, true
+		case "title":
+			return item.Title, true
 		}
+		return positionProperty(item.Position, property)
 
 	case *mq.Table:
 		switch property {
@@ -1007,12 +1400,120 @@ func (v *compilerVisitor) handlePropertyAccess(property string) (interface{}, bo
 		case "rows":
 			return item.Rows, true
 		}
+		return positionProperty(item.Position, property)
+
+	case *mq.List:
+		return listProperty(item, property)
+
+	case mq.ListItem:
+		return listItemProperty(item, property)
+
+	case *mq.Task:
+		return taskProperty(item, property)
+
+	case *mq.Footnote:
+		return footnoteProperty(item, property)
+
+	case *mq.Definition:
+		return definitionProperty(item, property)
+
+	case *mq.Admonition:
+		return admonitionProperty(item, property)
+
+	case *mq.MathBlock:
+		return mathProperty(item, property)
+
+	case *mq.Diagram:
+		return diagramProperty(item, property)
+
+	case *mq.DiagramNode:
+		return diagramNodeProperty(item, property)
+
+	case *mq.DiagramEdge:
+		return diagramEdgeProperty(item, property)
+
+	case *mq.Endpoint:
+		return endpointProperty(item, property)
+
+	case *mq.Schema:
+		return schemaProperty(item, property)
+
+	case mq.Metadata:
+		return item[property], true
+
+	case map[string]interface{}:
+		return item[property], true
+
+	case *mq.Component:
+		if value, ok := componentProperty(item, property); ok {
+			return value, true
+		}
+		// Props read like properties: .type for <Callout type="warn">
+		if value, ok := item.Props[property]; ok {
+			return value, true
+		}
 	}
 
 	// Property not handled
 	return nil, false
 }
 
+// textOptionNames maps .text and .body arguments to text options.
+var textOptionNames = map[string]mq.TextOption{
+	"no_code":     mq.WithoutCode(),
+	"no_comments": mq.WithoutComments(),
+}
+
+// sectionText handles .text, .body and .heading_line on a section or a
+// list of sections. .text and .body take options: .body("no_code").
+func (v *compilerVisitor) sectionText(node *SelectorNode) (interface{}, bool, error) {
+	var sections []*mq.Section
+	switch current := v.context.Current.(type) {
+	case *mq.Section:
+		sections = []*mq.Section{current}
+	case []*mq.Section:
+		sections = current
+	default:
+		return nil, false, nil
+	}
+
+	var opts []mq.TextOption
+	switch node.Name {
+	case "text", "body":
+		for _, arg := range node.Args {
+			val, err := arg.Accept(v)
+			if err != nil {
+				return nil, true, err
+			}
+			name, _ := val.(string)
+			opt, ok := textOptionNames[name]
+			if !ok {
+				return nil, true, fmt.Errorf("Error: unknown text option: %v\nAvailable: \"no_code\", \"no_comments\"\nExample: .section(\"API\") | .body(\"no_code\")", val)
+			}
+			opts = append(opts, opt)
+		}
+	case "heading_line":
+	default:
+		return nil, false, nil
+	}
+
+	texts := make([]string, len(sections))
+	for i, s := range sections {
+		switch node.Name {
+		case "text":
+			texts[i] = s.GetText(opts...)
+		case "body":
+			texts[i] = s.GetBody(opts...)
+		case "heading_line":
+			texts[i] = s.GetHeadingLine()
+		}
+	}
+	if _, single := v.context.Current.(*mq.Section); single {
+		return texts[0], true, nil
+	}
+	return texts, true, nil
+}
+
 // mapOperation applies a transformation to each element in a collection
 func (v *compilerVisitor) mapOperation(transform QueryNode) (interface{}, error) {
 	current := v.context.Current
@@ -1097,6 +1598,216 @@ func (v *compilerVisitor) mapOperation(transform QueryNode) (interface{}, error)
 		}
 		return results, nil
 
+	case []*mq.Table:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.List:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []mq.ListItem:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Task:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.InlineCode:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Footnote:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Definition:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Admonition:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.MathBlock:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Diagram:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.DiagramNode:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.DiagramEdge:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Endpoint:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Component:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
+	case []*mq.Schema:
+		results := make([]interface{}, len(data))
+		for i, item := range data {
+			oldCurrent := v.context.Current
+			v.context.Current = item
+			result, err := transform.Accept(v)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = result
+			v.context.Current = oldCurrent
+		}
+		return results, nil
+
 	case []interface{}:
 		results := make([]interface{}, len(data))
 		for i, item := range data {
@@ -1116,6 +1827,234 @@ func (v *compilerVisitor) mapOperation(transform QueryNode) (interface{}, error)
 	}
 }
 
+// endpointProperty returns a property of an API operation.
+func endpointProperty(e *mq.Endpoint, name string) (interface{}, bool) {
+	switch name {
+	case "method":
+		return e.Method, true
+	case "path":
+		return e.Path, true
+	case "operation_id", "operationId":
+		return e.OperationID, true
+	case "summary":
+		return e.Summary, true
+	case "description":
+		return e.Description, true
+	case "tags":
+		return e.Tags, true
+	case "deprecated":
+		return e.Deprecated, true
+	case "parameters":
+		return e.Parameters, true
+	case "responses":
+		return e.Responses, true
+	case "text":
+		return sectionText(e.Section), true
+	}
+	return nil, false
+}
+
+// schemaProperty returns a property of an API schema.
+func schemaProperty(s *mq.Schema, name string) (interface{}, bool) {
+	switch name {
+	case "name":
+		return s.Name, true
+	case "type":
+		return s.Type, true
+	case "description":
+		return s.Description, true
+	case "required":
+		return s.Required, true
+	case "properties":
+		return s.Properties, true
+	case "text":
+		return sectionText(s.Section), true
+	}
+	return nil, false
+}
+
+// positionProperties are the source location properties every extracted
+// element has.
+var positionProperties = []string{"line", "start", "end", "column", "end_column"}
+
+// positionProperty returns a source location property: .line (or .start)
+// and .end are 1-based lines, .column and .end_column byte columns.
+func positionProperty(p mq.Position, name string) (interface{}, bool) {
+	switch name {
+	case "line", "start":
+		return p.Line, true
+	case "end":
+		return p.End(), true
+	case "column":
+		return p.Column, true
+	case "end_column":
+		return p.EndColumn, true
+	}
+	return nil, false
+}
+
+// listProperty returns a property of a list.
+func listProperty(l *mq.List, name string) (interface{}, bool) {
+	switch name {
+	case "ordered":
+		return l.Ordered, true
+	case "items":
+		return l.Items, true
+	}
+	return positionProperty(l.Position, name)
+}
+
+// listItemProperty returns a property of a list item.
+func listItemProperty(item mq.ListItem, name string) (interface{}, bool) {
+	switch name {
+	case "text":
+		return item.Text, true
+	case "checked":
+		if item.Checked == nil {
+			return nil, true
+		}
+		return *item.Checked, true
+	case "children":
+		return item.Children, true
+	}
+	return positionProperty(item.Position, name)
+}
+
+// taskProperty returns a property of a task list item.
+func taskProperty(t *mq.Task, name string) (interface{}, bool) {
+	switch name {
+	case "text":
+		return t.Text, true
+	case "checked", "done":
+		return t.Checked, true
+	case "depth":
+		return t.Depth, true
+	case "section":
+		return t.SectionTitle(), true
+	case "file":
+		return t.File, true
+	}
+	return positionProperty(t.Position, name)
+}
+
+// footnoteProperty returns a property of a footnote definition.
+func footnoteProperty(f *mq.Footnote, name string) (interface{}, bool) {
+	switch name {
+	case "label":
+		return f.Label, true
+	case "text", "content":
+		return f.Content, true
+	}
+	return positionProperty(f.Position, name)
+}
+
+// definitionProperty returns a property of a definition list term.
+func definitionProperty(d *mq.Definition, name string) (interface{}, bool) {
+	switch name {
+	case "term":
+		return d.Term, true
+	case "descriptions":
+		return d.Descriptions, true
+	case "text":
+		return strings.Join(d.Descriptions, "\n"), true
+	}
+	return positionProperty(d.Position, name)
+}
+
+// admonitionProperty returns a property of an admonition or GitHub alert.
+func admonitionProperty(a *mq.Admonition, name string) (interface{}, bool) {
+	switch name {
+	case "kind":
+		return a.Kind, true
+	case "title":
+		return a.Title, true
+	case "text", "content":
+		return a.Content, true
+	}
+	return positionProperty(a.Position, name)
+}
+
+// mathProperty returns a property of a math block.
+func mathProperty(m *mq.MathBlock, name string) (interface{}, bool) {
+	switch name {
+	case "content", "text":
+		return m.Content, true
+	}
+	return positionProperty(m.Position, name)
+}
+
+// diagramProperty returns a property of a diagram.
+func diagramProperty(d *mq.Diagram, name string) (interface{}, bool) {
+	switch name {
+	case "language", "lang":
+		return d.Language, true
+	case "kind":
+		return d.Kind, true
+	case "nodes":
+		return d.Nodes, true
+	case "edges":
+		return d.Edges, true
+	case "content", "text":
+		return d.Code.Content, true
+	}
+	return positionProperty(d.Position, name)
+}
+
+// diagramNodeProperty returns a property of a diagram node.
+func diagramNodeProperty(n *mq.DiagramNode, name string) (interface{}, bool) {
+	switch name {
+	case "id":
+		return n.ID, true
+	case "label", "text":
+		return n.Label, true
+	case "kind":
+		return n.Kind, true
+	}
+	return positionProperty(n.Position, name)
+}
+
+// diagramEdgeProperty returns a property of a diagram edge.
+func diagramEdgeProperty(e *mq.DiagramEdge, name string) (interface{}, bool) {
+	switch name {
+	case "from":
+		return e.From, true
+	case "to":
+		return e.To, true
+	case "label", "text":
+		return e.Label, true
+	case "arrow":
+		return e.Arrow, true
+	}
+	return positionProperty(e.Position, name)
+}
+
+// componentProperty returns a property of an MDX component.
+func componentProperty(c *mq.Component, name string) (interface{}, bool) {
+	switch name {
+	case "name":
+		return c.Name, true
+	case "props":
+		return c.Props, true
+	case "content", "text":
+		return c.Content, true
+	case "start":
+		return c.Start, true
+	case "end":
+		return c.End, true
+	case "children":
+		return c.Children, true
+	}
+	return nil, false
+}
+
+// sectionText returns the source text of a section, or "" for nil.
+func sectionText(s *mq.Section) string {
+	if s == nil {
+		return ""
+	}
+	return s.GetText()
+}
+
 func extractTextFromAny(obj interface{}) interface{} {
 	// Handle collections
 	switch v := obj.(type) {
@@ -1137,6 +2076,12 @@ func extractTextFromAny(obj interface{}) interface{} {
 			results[i] = c.Content
 		}
 		return results
+	case []*mq.InlineCode:
+		results := make([]string, len(v))
+		for i, c := range v {
+			results[i] = c.Text
+		}
+		return results
 	case []*mq.Link:
 		results := make([]string, len(v))
 		for i, l := range v {
@@ -1149,6 +2094,60 @@ func extractTextFromAny(obj interface{}) interface{} {
 			results[i] = img.AltText
 		}
 		return results
+	case []*mq.Endpoint:
+		results := make([]string, len(v))
+		for i, e := range v {
+			results[i] = sectionText(e.Section)
+		}
+		return results
+	case []*mq.Schema:
+		results := make([]string, len(v))
+		for i, s := range v {
+			results[i] = sectionText(s.Section)
+		}
+		return results
+	case []*mq.Component:
+		results := make([]string, len(v))
+		for i, c := range v {
+			results[i] = c.Content
+		}
+		return results
+	case []*mq.Task:
+		results := make([]string, len(v))
+		for i, t := range v {
+			results[i] = t.Text
+		}
+		return results
+	case []*mq.Footnote:
+		results := make([]string, len(v))
+		for i, f := range v {
+			results[i] = f.Content
+		}
+		return results
+	case []*mq.Definition:
+		results := make([]string, len(v))
+		for i, d := range v {
+			results[i] = strings.Join(d.Descriptions, "\n")
+		}
+		return results
+	case []*mq.Admonition:
+		results := make([]string, len(v))
+		for i, a := range v {
+			results[i] = a.Content
+		}
+		return results
+	case []*mq.MathBlock:
+		results := make([]string, len(v))
+		for i, m := range v {
+			results[i] = m.Content
+		}
+		return results
+	case []*mq.Diagram:
+		results := make([]string, len(v))
+		for i, d := range v {
+			results[i] = d.Code.Content
+		}
+		return results
 	case []interface{}:
 		results := make([]string, len(v))
 		for i, item := range v {
@@ -1175,6 +2174,9 @@ func getIndex(obj, index interface{}) (interface{}, error) {
 			}
 		}
 
+		if idx < 0 {
+			idx += rv.Len()
+		}
 		if idx < 0 || idx >= rv.Len() {
 			return nil, fmt.Errorf("index out of range: %d", idx)
 		}
@@ -1189,6 +2191,9 @@ func getIndex(obj, index interface{}) (interface{}, error) {
 		}
 		return value.Interface(), nil
 
+	case reflect.Invalid:
+		return nil, nil // Indexing null, e.g. a missing metadata key
+
 	default:
 		return nil, fmt.Errorf("cannot index type %T", obj)
 	}
@@ -1282,20 +2287,8 @@ func buildSectionNode(section *mq.Section, mode mq.TreeMode) *mq.TreeNode {
 
 	// Add special elements (only in default mode)
 	if mode == mq.TreeModeDefault {
-		codeBlocks := section.GetCodeBlocks()
-		if len(codeBlocks) > 0 {
-			for lang, count := range mq.CountCodeByLanguage(codeBlocks) {
-				meta := fmt.Sprintf("%d block", count)
-				if count > 1 {
-					meta = fmt.Sprintf("%d blocks", count)
-				}
-				node.Children = append(node.Children, &mq.TreeNode{
-					Type: "code",
-					Text: lang,
-					Meta: meta,
-				})
-			}
-		}
+		node.Children = append(node.Children, mq.CodeTreeNodes(section.GetCodeBlocks())...)
+		node.Children = append(node.Children, mq.SectionTreeNodes(section)...)
 	}
 
 	return node
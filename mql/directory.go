@@ -1,6 +1,10 @@
 package mql
 
-import mq "github.com/muqsitnawaz/mq/lib"
+import (
+	"fmt"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+)
 
 // BuildDirTree creates a directory tree across all formats supported by mql.Engine.
 func BuildDirTree(dirPath string, mode mq.TreeMode) (*mq.DirTreeResult, error) {
@@ -13,3 +17,70 @@ func SearchDir(dirPath string, query string) (*mq.SearchResults, error) {
 	engine := New()
 	return mq.SearchDirWithLoader(dirPath, query, engine.LoadDocument)
 }
+
+// TasksDir collects task list items across all formats supported by mql.Engine.
+func TasksDir(dirPath string) (*mq.TaskResults, error) {
+	engine := New()
+	return mq.TasksDirWithLoader(dirPath, engine.LoadDocument)
+}
+
+// QueryTasksDir runs a query that starts with .tasks across a directory:
+// the tasks TasksDir collects are piped through the rest of the query, as
+// in `.tasks | filter(.checked == false)`. A result that is still a list
+// of tasks is returned as *mq.TaskResults.
+func QueryTasksDir(dirPath string, query string) (interface{}, error) {
+	ast, err := ParseString(query)
+	if err != nil {
+		return nil, fmt.Errorf("parsing query: %w", err)
+	}
+	stages := pipeStages(ast)
+	if sel, ok := stages[0].(*SelectorNode); !ok || sel.Name != "tasks" || len(sel.Args) > 0 {
+		return nil, fmt.Errorf("directory query must start with .tasks: %s", query)
+	}
+
+	results, err := TasksDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Later stages see the tasks, not a document
+	compiler := NewCompiler()
+	ctx := &EvalContext{Variables: make(map[string]interface{})}
+	var current interface{} = results.Tasks
+	for _, stage := range stages[1:] {
+		ctx.Current = current
+		if current, err = compiler.Compile(stage)(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, ok := current.([]*mq.Task)
+	if !ok {
+		return current, nil
+	}
+	files := make(map[string]bool)
+	for _, t := range tasks {
+		files[t.File] = true
+	}
+	return &mq.TaskResults{Tasks: tasks, Files: len(files)}, nil
+}
+
+// pipeStages flattens a pipeline into its stages in order.
+func pipeStages(node QueryNode) []QueryNode {
+	if pipe, ok := node.(*PipeNode); ok {
+		return append(pipeStages(pipe.Left), pipeStages(pipe.Right)...)
+	}
+	return []QueryNode{node}
+}
+
+// BacklinksDir finds wikilinks to page across all formats supported by mql.Engine.
+func BacklinksDir(dirPath string, page string) (*mq.BacklinkResults, error) {
+	engine := New()
+	return mq.BacklinksDirWithLoader(dirPath, page, engine.LoadDocument)
+}
+
+// OrphansDir finds unlinked pages across all formats supported by mql.Engine.
+func OrphansDir(dirPath string) (*mq.OrphanResults, error) {
+	engine := New()
+	return mq.OrphansDirWithLoader(dirPath, engine.LoadDocument)
+}
@@ -1,6 +1,10 @@
 package mql_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,7 +22,7 @@ func TestSearchDirSupportsMultipleFormats(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"name":"json doc","content":"Needle in json"}`), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.yaml"), []byte("name: yaml doc\ncontent: Needle in yaml\n"), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte("{\"event\":\"Needle in jsonl\"}\n"), 0o644))
-	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignore.txt"), []byte("Needle in text file"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignore.bin"), []byte("Needle in text file"), 0o644))
 
 	results, err := mql.SearchDir(dir, "needle")
 	require.NoError(t, err)
@@ -32,7 +36,7 @@ func TestSearchDirSupportsMultipleFormats(t *testing.T) {
 	assert.Contains(t, files, "data.json")
 	assert.Contains(t, files, "data.yaml")
 	assert.Contains(t, files, "events.jsonl")
-	assert.NotContains(t, files, "ignore.txt")
+	assert.NotContains(t, files, "ignore.bin")
 	assert.NotContains(t, files, "doc.md")
 }
 
@@ -43,7 +47,7 @@ func TestBuildDirTreeSupportsNonMarkdownFormats(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"name":"json doc","content":"value"}`), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.yaml"), []byte("name: yaml doc\ncontent: value\n"), 0o644))
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte("{\"event\":\"value\"}\n"), 0o644))
-	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignore.txt"), []byte("should be ignored"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignore.bin"), []byte("should be ignored"), 0o644))
 
 	tree, err := mql.BuildDirTree(dir, mq.TreeModePreview)
 	require.NoError(t, err)
@@ -59,7 +63,7 @@ func TestBuildDirTreeSupportsNonMarkdownFormats(t *testing.T) {
 	assert.Contains(t, files, "data.json")
 	assert.Contains(t, files, "data.yaml")
 	assert.Contains(t, files, "events.jsonl")
-	assert.NotContains(t, files, "ignore.txt")
+	assert.NotContains(t, files, "ignore.bin")
 	assert.NotContains(t, files, "doc.md")
 
 	rendered := tree.String()
@@ -74,3 +78,165 @@ func TestBuildDirTreeSupportsNonMarkdownFormats(t *testing.T) {
 	assert.Contains(t, rendered, "H1 Heading")
 	assert.NotContains(t, rendered, "# content")
 }
+
+var bundleFiles = map[string]string{
+	"docs/guide/intro.md": "# Intro\n\nRotate the token weekly.\n",
+	"docs/api.json":       `{"endpoint":"/token"}`,
+	"docs/notes.bin":      "token in an unsupported file",
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+func TestBuildDirTreeBrowsesArchives(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "docs.zip")
+	writeZip(t, archive, bundleFiles)
+
+	tree, err := mql.BuildDirTree(archive, mq.TreeModeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, 2, tree.TotalFiles)
+
+	rendered := tree.String()
+	assert.Contains(t, rendered, "docs/")
+	assert.Contains(t, rendered, "intro.md (4 lines, 1 section)")
+	assert.NotContains(t, rendered, "notes.bin")
+
+	// Archives inside a directory show up as virtual directories
+	tree, err = mql.BuildDirTree(dir, mq.TreeModeDefault)
+	require.NoError(t, err)
+	require.Len(t, tree.Root, 1)
+	assert.Equal(t, "docs.zip", tree.Root[0].Name)
+	assert.True(t, tree.Root[0].IsDir)
+}
+
+func TestBuildDirTreeSkipsNestedArchives(t *testing.T) {
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.zip")
+	writeZip(t, inner, map[string]string{"deep.md": "# Deep\n"})
+	content, err := os.ReadFile(inner)
+	require.NoError(t, err)
+
+	outer := filepath.Join(dir, "outer.zip")
+	writeZip(t, outer, map[string]string{
+		"inner.zip": string(content),
+		"top.md":    "# Top\n",
+	})
+
+	tree, err := mql.BuildDirTree(outer, mq.TreeModeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tree.TotalFiles)
+	assert.NotContains(t, tree.String(), "inner.zip")
+}
+
+func TestSearchDirBrowsesArchives(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.tar.gz")
+	writeTarGz(t, archive, bundleFiles)
+
+	results, err := mql.SearchDir(archive, "token")
+	require.NoError(t, err)
+
+	var files []string
+	for _, match := range results.Matches {
+		files = append(files, match.File)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(archive, "docs", "api.json"),
+		filepath.Join(archive, "docs", "guide", "intro.md"),
+	}, files)
+
+	// Result paths can be loaded directly, without extracting
+	doc, err := mql.New().LoadDocument(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, "Intro", doc.Title())
+}
+
+func TestTasksDir(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release.md"), []byte("# Release\n\n- [x] Tag\n- [ ] Announce\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "todo.md"), []byte("- [ ] Write guide\n- [ ] Add examples\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# Notes\n\n- no tasks here\n"), 0o644))
+
+	results, err := mql.TasksDir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, results.Files)
+	assert.Len(t, results.Tasks, 4)
+	assert.Equal(t, 3, results.Open())
+	assert.Contains(t, results.String(), "3 open tasks across 2 files (1 done)")
+
+	// The tasks can be piped through the rest of a query
+	filtered, err := mql.QueryTasksDir(dir, ".tasks | filter(.checked == true)")
+	require.NoError(t, err)
+	require.IsType(t, &mq.TaskResults{}, filtered)
+	assert.Len(t, filtered.(*mq.TaskResults).Tasks, 1)
+	assert.Equal(t, 1, filtered.(*mq.TaskResults).Files)
+
+	_, err = mql.QueryTasksDir(dir, ".headings | filter(.level == 1)")
+	assert.ErrorContains(t, err, "must start with .tasks")
+}
+
+func TestBacklinksAndOrphansDir(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Project Plan.md"), []byte("---\naliases: [Plan]\n---\n# Plan\n\nSee [[Glossary]].\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "daily"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "daily", "monday.md"), []byte("# Monday\n\n## Work\n\nUpdated [[project plan#Goals|goals]] and ![[Plan]].\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Glossary.md"), []byte("# Glossary\n\nBack to [[Glossary#Terms]].\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Inbox.md"), []byte("# Inbox\n\n[[Someday]]\n"), 0o644))
+
+	// Case-insensitive names and aliases both resolve
+	results, err := mql.BacklinksDir(dir, "Project Plan")
+	require.NoError(t, err)
+	require.Len(t, results.Backlinks, 2)
+	assert.Equal(t, filepath.Join(dir, "daily", "monday.md"), results.Backlinks[0].File)
+	assert.Equal(t, "Work", results.Backlinks[0].Section)
+	assert.Equal(t, mq.LinkEmbed, results.Backlinks[1].Link.Kind)
+	assert.Contains(t, results.String(), "2 backlinks to Project Plan")
+
+	// Self-links don't count
+	results, err = mql.BacklinksDir(dir, "glossary")
+	require.NoError(t, err)
+	require.Len(t, results.Backlinks, 1)
+	assert.Equal(t, filepath.Join(dir, "Project Plan.md"), results.Backlinks[0].File)
+
+	// Pages that don't exist yet are matched by name
+	results, err = mql.BacklinksDir(dir, "Someday")
+	require.NoError(t, err)
+	assert.Empty(t, results.File)
+	assert.Len(t, results.Backlinks, 1)
+
+	orphans, err := mql.OrphansDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 4, orphans.Total)
+	assert.Equal(t, []string{filepath.Join(dir, "Inbox.md"), filepath.Join(dir, "daily", "monday.md")}, orphans.Files)
+}
@@ -2,9 +2,17 @@ package mql
 
 import (
 	"github.com/muqsitnawaz/mq/data"
+	"github.com/muqsitnawaz/mq/docx"
+	"github.com/muqsitnawaz/mq/email"
+	"github.com/muqsitnawaz/mq/epub"
+	"github.com/muqsitnawaz/mq/golang"
 	"github.com/muqsitnawaz/mq/html"
+	"github.com/muqsitnawaz/mq/latex"
 	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/muqsitnawaz/mq/man"
 	"github.com/muqsitnawaz/mq/pdf"
+	"github.com/muqsitnawaz/mq/text"
+	"github.com/muqsitnawaz/mq/xml"
 )
 
 // Engine provides the MQL query language on top of mq.MultiFormatEngine.
@@ -14,17 +22,28 @@ type Engine struct {
 	executor    *QueryExecutor
 }
 
-// New creates a new MQL engine with multi-format support.
-func New() *Engine {
+// New creates a new MQL engine with multi-format support. Options are
+// applied after the built-in parsers are registered.
+func New(opts ...mq.MultiEngineOption) *Engine {
 	return &Engine{
 		mqEngine: mq.New(),
-		multiEngine: mq.NewMultiFormatEngine(
+		multiEngine: mq.NewMultiFormatEngine(append([]mq.MultiEngineOption{
 			mq.WithFormatParser(html.NewParser()),
 			mq.WithFormatParser(pdf.NewParser()),
 			mq.WithFormatParser(data.NewJSONParser()),
 			mq.WithFormatParser(data.NewJSONLParser()),
 			mq.WithFormatParser(data.NewYAMLParser()),
-		),
+			mq.WithFormatParser(data.NewTOMLParser()),
+			mq.WithFormatParser(xml.NewParser()),
+			mq.WithFormatParser(docx.NewParser()),
+			mq.WithFormatParser(epub.NewParser()),
+			mq.WithFormatParser(golang.NewParser()),
+			mq.WithFormatParser(latex.NewParser()),
+			mq.WithFormatParser(man.NewParser()),
+			mq.WithFormatParser(email.NewParser()),
+			mq.WithFormatParser(text.NewParser()),
+			mq.WithFormatParser(text.NewLogParser()),
+		}, opts...)...),
 		executor: NewQueryExecutor(),
 	}
 }
@@ -47,6 +66,16 @@ func (e *Engine) ParseDocument(content []byte, path string) (*mq.Document, error
 	return e.multiEngine.Parse(content, path)
 }
 
+// LoadDocumentAs loads and parses a file with an explicit format.
+func (e *Engine) LoadDocumentAs(path string, format mq.Format) (*mq.Document, error) {
+	return e.multiEngine.LoadWithFormat(path, format)
+}
+
+// ParseDocumentAs parses content with an explicit format.
+func (e *Engine) ParseDocumentAs(content []byte, path string, format mq.Format) (*mq.Document, error) {
+	return e.multiEngine.ParseWithFormat(content, path, format)
+}
+
 // Query executes an MQL query string on a document.
 func (e *Engine) Query(doc *mq.Document, queryStr string) (interface{}, error) {
 	return ExecuteQuery(doc, queryStr)
@@ -1,6 +1,8 @@
 package mql_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	mq "github.com/muqsitnawaz/mq/lib"
@@ -784,3 +786,493 @@ go test ./...
 		}
 	}
 }
+
+func TestXPathQuery(t *testing.T) {
+	const pom = `<?xml version="1.0"?>
+<project>
+  <name>demo</name>
+  <dependencies>
+    <dependency scope="test">
+      <artifactId>junit</artifactId>
+    </dependency>
+    <dependency>
+      <artifactId>guava</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+`
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(pom), "pom.xml")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{`.xpath("/project/dependencies/dependency")`, 2},
+		{`.xpath("//dependency[@scope='test']")`, 1},
+		{`.xpath("dependency[last()]/artifactId")`, 1},
+		{`.xpath("/dependencies")`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := engine.Query(doc, tt.query)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			sections, ok := result.([]*mq.Section)
+			if !ok {
+				t.Fatalf("Expected []*mq.Section, got %T", result)
+			}
+			if len(sections) != tt.expected {
+				t.Errorf("Expected %d sections, got %d", tt.expected, len(sections))
+			}
+		})
+	}
+
+	if _, err := engine.Query(doc, ".xpath()"); err == nil {
+		t.Error("Expected error for .xpath without arguments")
+	}
+}
+
+func TestOpenAPIQuery(t *testing.T) {
+	const spec = `openapi: 3.0.0
+info:
+  title: Accounts
+  version: "1"
+paths:
+  /users:
+    get:
+      tags: [users]
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+    post:
+      tags: [users]
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+      responses:
+        '201':
+          description: Created
+  /users/{id}:
+    delete:
+      tags: [users]
+      responses:
+        '204':
+          description: Deleted
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        email:
+          type: string
+`
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(spec), "openapi.yaml")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	result, err := engine.Query(doc, `.endpoints | filter(.method == "POST")`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	endpoints, ok := result.([]*mq.Endpoint)
+	if !ok {
+		t.Fatalf("Expected []*mq.Endpoint, got %T", result)
+	}
+	if len(endpoints) != 1 || endpoints[0].OperationID != "createUser" {
+		t.Errorf("Expected createUser, got %v", endpoints)
+	}
+
+	result, err = engine.Query(doc, `.endpoints | map(.path)`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if paths, ok := result.([]interface{}); !ok || len(paths) != 3 || paths[2] != "/users/{id}" {
+		t.Errorf("Expected three paths, got %v", result)
+	}
+
+	result, err = engine.Query(doc, `.schema("User") | .properties`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	table, ok := result.(*mq.Table)
+	if !ok {
+		t.Fatalf("Expected *mq.Table, got %T", result)
+	}
+	if len(table.Rows) != 1 || table.Rows[0][0] != "email" {
+		t.Errorf("Expected email property, got %v", table.Rows)
+	}
+
+	if _, err := engine.Query(doc, `.schema("Missing")`); err == nil {
+		t.Error("Expected error for unknown schema")
+	}
+	if _, err := engine.Query(doc, `.endpoints | map(.verb)`); err == nil {
+		t.Error("Expected error for unknown endpoint property")
+	}
+}
+
+func TestMDXComponentsQuery(t *testing.T) {
+	const page = `import Callout from './Callout'
+
+# Guide
+
+<Callout type="warn">
+  Back up first.
+</Callout>
+
+<Tabs>
+  ## Inside tabs
+</Tabs>
+
+<Callout type="info">Tip</Callout>
+`
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(page), "guide.mdx")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	result, err := engine.Query(doc, `.components | filter(.name == "Callout")`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	callouts, ok := result.([]*mq.Component)
+	if !ok {
+		t.Fatalf("Expected []*mq.Component, got %T", result)
+	}
+	if len(callouts) != 2 {
+		t.Fatalf("Expected 2 callouts, got %d", len(callouts))
+	}
+
+	// Props read like properties
+	result, err = engine.Query(doc, `.components("Callout") | filter(.type == "info") | .text`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if texts, ok := result.([]string); !ok || len(texts) != 1 || texts[0] != "Tip" {
+		t.Errorf("Expected [Tip], got %v", result)
+	}
+
+	if _, err := engine.Query(doc, `.section("Inside tabs")`); err != nil {
+		t.Errorf("Expected heading inside component to be a section: %v", err)
+	}
+}
+
+func TestLinesAndSearchLines(t *testing.T) {
+	const notes = `# Notes
+
+Intro paragraph.
+
+## Setup
+
+Install the tool.
+Then configure the proxy.
+`
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(notes), "notes.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	result, err := engine.Query(doc, `.lines(7, 8)`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result != "Install the tool.\nThen configure the proxy." {
+		t.Errorf("Unexpected lines: %q", result)
+	}
+
+	result, err = engine.Query(doc, `.lines(3)`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result != "Intro paragraph." {
+		t.Errorf("Unexpected line: %q", result)
+	}
+
+	if _, err := engine.Query(doc, `.lines(40, 50)`); err == nil {
+		t.Error("Expected error for out-of-range lines")
+	}
+
+	result, err = engine.Query(doc, `.search("proxy")`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	results, ok := result.(*mq.SearchResults)
+	if !ok {
+		t.Fatalf("Expected *mq.SearchResults, got %T", result)
+	}
+	if len(results.Matches) == 0 {
+		t.Fatal("Expected a match")
+	}
+	for _, m := range results.Matches {
+		if m.Line != 8 {
+			t.Errorf("Expected match at line 8 in %q, got %d", m.Section, m.Line)
+		}
+	}
+}
+
+func TestPositionProperties(t *testing.T) {
+	const guide = "# Guide\n\nSee [docs](https://example.com).\n\n```go\nfmt.Println()\n```\n\n- one\n- two\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(guide), "guide.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.headings | map(.line)`, "[1]"},
+		{`.code | map(.start)`, "[5]"},
+		{`.code | map(.end)`, "[7]"},
+		{`.links | map(.column)`, "[5]"},
+		{`.links | map(.end_column)`, "[31]"},
+		{`.lists | map(.line)`, "[9]"},
+		{`.lists | map(.end)`, "[10]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := engine.Query(doc, `.code | map(.col)`); err == nil || !strings.Contains(err.Error(), ".column") {
+		t.Errorf("Expected a suggestion for .column, got %v", err)
+	}
+}
+
+func TestLinkKinds(t *testing.T) {
+	const readme = "See [docs][d], <https://b.dev> and https://c.dev.\n\n[d]: https://a.dev \"Docs\"\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(readme), "README.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.links | map(.kind)`, "[reference autolink bare]"},
+		{`.links | filter(.kind == "bare") | map(.url)`, "[https://c.dev]"},
+		{`.links | filter(.kind == "reference") | map(.title)`, "[Docs]"},
+		{`.references | map(.label)`, "[d]"},
+		{`.references | map(.line)`, "[3]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSpansAndHTMLBlocks(t *testing.T) {
+	const changelog = "# Changelog\n\nSee `Parse` and `Load`.\n\n    make install\n\n<details>\n<h2>v0.1</h2>\n<table><tr><th>Change</th></tr><tr><td>Initial</td></tr></table>\n</details>\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(changelog), "CHANGELOG.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.inline_code | map(.text)`, "[Parse Load]"},
+		{`.inline_code | filter(.text == "Load") | map(.column)`, "[17]"},
+		{`.code | filter(.indented == true) | map(.line)`, "[5]"},
+		{`.headings | map(.text)`, "[Changelog v0.1]"},
+		{`.tables | map(.line)`, "[9]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestStructuredBlocks(t *testing.T) {
+	const notes = "# Setup\n\nRun it.[^1]\n\n> [!WARNING]\n> Back up first.\n\n!!! note \"Tip\"\n    Use a venv.\n\n## Terms\n\nTTL\n:   Time to live.\n\n$$\nx^2\n$$\n\n[^1]: Needs root.\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(notes), "setup.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.footnotes | map(.label)`, "[1]"},
+		{`.footnotes | map(.text)`, "[Needs root.]"},
+		{`.definitions("ttl") | map(.descriptions)`, "[[Time to live.]]"},
+		{`.admonitions | filter(.kind == "warning") | map(.text)`, "[Back up first.]"},
+		{`.admonitions("note") | map(.title)`, "[Tip]"},
+		{`.math | map(.content)`, "[x^2]"},
+		{`.section("Terms") | .math | map(.line)`, "[16]"},
+		{`.section("Terms") | .admonitions | map(.kind)`, "[]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTasks(t *testing.T) {
+	const checklist = "# Release\n\n- [x] Tag\n- [ ] Announce\n  - [ ] Blog post\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(checklist), "release.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.tasks | map(.text)`, "[Tag Announce Blog post]"},
+		{`.tasks | filter(.checked == false) | map(.text)`, "[Announce Blog post]"},
+		{`.tasks | filter(.checked == true) | map(.line)`, "[3]"},
+		{`.tasks | filter(.depth > 0) | map(.section)`, "[Release]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDiagrams(t *testing.T) {
+	const arch = "# Arch\n\n```mermaid\nflowchart LR\n  Web --> API\n  API -->|charge| Billing\n  Cron --> Billing\n```\n\n" +
+		"## Flows\n\n```dot\ndigraph { API -> Ledger }\n```\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(arch), "arch.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.diagrams | map(.kind)`, "[flowchart digraph]"},
+		{`.diagrams("dot") | map(.language)`, "[dot]"},
+		{`.diagrams | .edges | filter(.from == "API") | map(.to)`, "[Billing Ledger]"},
+		{`.diagrams | .edges | filter(.to == "Billing") | map(.from)`, "[API Cron]"},
+		{`.diagrams | .nodes | map(.id)`, "[Web API Billing Cron API Ledger]"},
+		{`.diagrams | .edges | filter(.label == "charge") | map(.line)`, "[6]"},
+		{`.section("Flows") | .diagrams | map(.kind)`, "[digraph]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFrontmatterPaths(t *testing.T) {
+	const hugo = "+++\ntitle = \"Launch\"\ndate = 2024-03-01\nweight = 5\n\n[[authors]]\nname = \"Ann\"\nemail = \"ann@example.com\"\njoined = 2019-06-01\n\n" +
+		"[[authors]]\nname = \"Bo\"\nemail = \"bo@example.com\"\njoined = 2023-01-15\n+++\n\n# Launch\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(hugo), "launch.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.metadata.title`, "Launch"},
+		{`.metadata.authors[0].email`, "ann@example.com"},
+		{`.metadata.authors[-1].name`, "Bo"},
+		{`.metadata.authors | map(.name)`, "[Ann Bo]"},
+		{`.metadata.authors | filter(.joined < "2020-01-01") | map(.name)`, "[Ann]"},
+		{`.metadata.authors | filter(.joined >= .joined) | map(.email)`, "[ann@example.com bo@example.com]"},
+		{`.metadata.missing.email`, "<nil>"},
+		{`.headings[0].text`, "Launch"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSectionBodyAndPreamble(t *testing.T) {
+	const readme = "[![CI](https://ci.example.com/badge.svg)](https://ci.example.com)\n\nA tool.\n\n" +
+		"# Tool\n\nOverview. <!-- todo -->\n\n```sh\ntool run\n```\n\n## Install\n\nRun make.\n"
+	engine := mql.New()
+	doc, err := engine.ParseDocument([]byte(readme), "README.md")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.preamble | .start`, "1"},
+		{`.preamble | .body`, "[![CI](https://ci.example.com/badge.svg)](https://ci.example.com)\n\nA tool."},
+		{`.section("Tool") | .heading_line`, "# Tool"},
+		{`.section("Tool") | .body("no_code", "no_comments")`, "Overview."},
+		{`.section("Tool") | .text("no_code")`, "# Tool\n\nOverview. <!-- todo -->\n\n## Install\n\nRun make."},
+		{`.sections | .body`, "[Overview. <!-- todo -->\n\n```sh\ntool run\n``` Run make.]"},
+		{`.sections | filter(.body == "Run make.") | .heading_line`, "[## Install]"},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(doc, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(result); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := engine.Query(doc, `.section("Tool") | .body("prose")`); err == nil {
+		t.Error("expected an error for an unknown text option")
+	}
+}
@@ -87,6 +87,9 @@ func (p *Parser) parsePrimary() (QueryNode, error) {
 		}
 		// Standalone identifier (for use in predicates)
 		p.advance()
+		if lit, ok := keywordLiteral(token.Value); ok {
+			return lit, nil
+		}
 		return NewIdentifier(token.Value), nil
 
 	case TokenLParen:
@@ -118,6 +121,19 @@ func (p *Parser) parsePrimary() (QueryNode, error) {
 	}
 }
 
+// keywordLiteral returns the literal for true, false and null.
+func keywordLiteral(name string) (QueryNode, bool) {
+	switch name {
+	case "true":
+		return NewLiteral(true, LiteralBoolean), true
+	case "false":
+		return NewLiteral(false, LiteralBoolean), true
+	case "null":
+		return NewLiteral(nil, LiteralNull), true
+	}
+	return nil, false
+}
+
 // parseSelector parses a selector expression (.headings, .code, etc).
 func (p *Parser) parseSelector() (QueryNode, error) {
 	if err := p.expect(TokenDot); err != nil {
@@ -164,7 +180,30 @@ func (p *Parser) parseSelector() (QueryNode, error) {
 
 	default:
 		// Regular selector
-		return NewSelector(name, args...), nil
+		return p.parsePostfix(NewSelector(name, args...))
+	}
+}
+
+// parsePostfix parses field and index access chained onto a selector or
+// property, as in .headings[0] and .metadata.authors[0].email.
+func (p *Parser) parsePostfix(node QueryNode) (QueryNode, error) {
+	for {
+		switch {
+		case p.current().Type == TokenLBracket:
+			var err error
+			node, err = p.parseIndex(node)
+			if err != nil {
+				return nil, err
+			}
+
+		case p.current().Type == TokenDot && p.peek().Type == TokenIdentifier:
+			p.advance() // consume .
+			node = NewPipe(node, NewIdentifier(p.current().Value))
+			p.advance()
+
+		default:
+			return node, nil
+		}
 	}
 }
 
@@ -302,14 +341,6 @@ func (p *Parser) parseProperty() (QueryNode, error) {
 		name := p.current().Value
 		p.advance()
 
-		// Check for further property access or function call
-		node := QueryNode(NewIdentifier(name))
-
-		// Handle array/object indexing
-		for p.current().Type == TokenLBracket {
-			node, _ = p.parseIndex(node)
-		}
-
 		// Handle function calls on properties
 		if p.current().Type == TokenLParen {
 			args, err := p.parseArguments()
@@ -319,11 +350,15 @@ func (p *Parser) parseProperty() (QueryNode, error) {
 			return NewFunction(name, args...), nil
 		}
 
-		return node, nil
+		// Handle indexing and nested access: .authors[0].email
+		return p.parsePostfix(NewIdentifier(name))
 
 	case TokenIdentifier:
 		// Simple identifier
 		p.advance()
+		if lit, ok := keywordLiteral(token.Value); ok {
+			return lit, nil
+		}
 		node := QueryNode(NewIdentifier(token.Value))
 
 		// Handle array/object indexing
@@ -0,0 +1,27 @@
+package pdf
+
+import (
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLocator(t *testing.T) {
+	text := "Report\n\n  Introduction\nIntroduction text.\n\n  Results\n  Name     Score\n  Ada      9\n"
+	loc := newTextLocator(text)
+
+	pos, _ := loc.find("Introduction")
+	assert.Equal(t, mq.Position{Line: 3, Column: 3, EndLine: 3, EndColumn: 14}, pos)
+
+	// Searches continue after the previous match
+	pos, offset := loc.find("Results")
+	assert.Equal(t, 6, pos.Line)
+
+	pos, _ = loc.find("Missing")
+	assert.Zero(t, pos)
+
+	row := loc.findRow([]string{"Name", "Score"}, offset)
+	assert.Equal(t, 7, row.Line)
+	assert.Equal(t, 3, row.Column)
+}
@@ -25,7 +25,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
+	"github.com/muqsitnawaz/mq/internal/outline"
 	mq "github.com/muqsitnawaz/mq/lib"
 )
 
@@ -160,22 +162,33 @@ func (e *extractor) extract() (*mq.Document, error) {
 	if structure != nil {
 		e.title = structure.Title
 
-		// Convert headings
+		// Convert headings, locating each in the extracted text
+		loc := newTextLocator(text)
+		pageStarts := make(map[int]int)
 		for _, h := range structure.Headings {
-			headings = append(headings, &mq.Heading{
-				Level: h.Level,
-				Text:  h.Text,
-			})
+			pos, offset := loc.find(h.Text)
+			if _, ok := pageStarts[h.Page]; !ok && pos.Line > 0 {
+				pageStarts[h.Page] = offset
+			}
+			heading := &mq.Heading{Level: h.Level, Text: h.Text}
+			heading.SetPosition(pos)
+			headings = append(headings, heading)
 		}
 
-		// Build sections from headings
-		sections = e.buildSections(headings)
+		// Build sections from headings; their lines refer to the
+		// extracted text, not the PDF bytes
+		sections = outline.Nest(headings, strings.Count(text, "\n")+1, 0)
+		textSource := []byte(text)
+		for _, s := range sections {
+			s.SetSource(textSource)
+		}
 
 		// Convert tables
 		for _, t := range structure.Tables {
 			tables = append(tables, &mq.Table{
-				Headers: t.Headers,
-				Rows:    nil, // We don't extract full table data yet
+				Headers:  t.Headers,
+				Rows:     nil, // We don't extract full table data yet
+				Position: loc.findRow(t.Headers, pageStarts[t.Page]),
 			})
 		}
 	}
@@ -278,37 +291,6 @@ func (e *extractor) findPythonScript() string {
 	return ""
 }
 
-// buildSections creates section hierarchy from headings.
-func (e *extractor) buildSections(headings []*mq.Heading) []*mq.Section {
-	if len(headings) == 0 {
-		return nil
-	}
-
-	var sections []*mq.Section
-	var stack []*mq.Section
-
-	for _, h := range headings {
-		s := &mq.Section{Heading: h}
-
-		// Pop stack until we find a parent with lower level
-		for len(stack) > 0 && stack[len(stack)-1].Heading.Level >= h.Level {
-			stack = stack[:len(stack)-1]
-		}
-
-		// If stack has items, the top is our parent
-		if len(stack) > 0 {
-			parent := stack[len(stack)-1]
-			s.Parent = parent
-			parent.Children = append(parent.Children, s)
-		}
-
-		stack = append(stack, s)
-		sections = append(sections, s)
-	}
-
-	return sections
-}
-
 // extractBasicText extracts text content from PDF using pdftotext (poppler).
 func (e *extractor) extractBasicText() string {
 	// Check if content looks like a PDF
@@ -335,6 +317,67 @@ func (e *extractor) extractBasicText() string {
 	return stdout.String()
 }
 
+// textLocator finds extracted elements in the pdftotext output, which is
+// the document's readable text. Headings come in reading order, so each
+// search starts after the previous match.
+type textLocator struct {
+	text   string
+	index  *mq.LineIndex
+	cursor int
+}
+
+func newTextLocator(text string) *textLocator {
+	return &textLocator{text: text, index: mq.NewLineIndex([]byte(text))}
+}
+
+// find locates s after the previous match, returning its position and
+// byte offset. Text not found leaves the position zero.
+func (l *textLocator) find(s string) (mq.Position, int) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return mq.Position{}, 0
+	}
+	i := strings.Index(l.text[l.cursor:], s)
+	if i < 0 {
+		return mq.Position{}, 0
+	}
+	start := l.cursor + i
+	l.cursor = start + len(s)
+	return l.index.Position(start, start+len(s)), start
+}
+
+// findRow locates the first line at or after from containing every
+// header, in order.
+func (l *textLocator) findRow(headers []string, from int) mq.Position {
+	if len(headers) == 0 || from > len(l.text) {
+		return mq.Position{}
+	}
+	line := l.index.Line(from)
+	for offset := from; offset < len(l.text); {
+		end := strings.IndexByte(l.text[offset:], '\n')
+		if end < 0 {
+			end = len(l.text) - offset
+		}
+		if containsInOrder(l.text[offset:offset+end], headers) {
+			return l.index.LinePosition(line, line)
+		}
+		offset += end + 1
+		line++
+	}
+	return mq.Position{}
+}
+
+func containsInOrder(s string, parts []string) bool {
+	for _, part := range parts {
+		i := strings.Index(s, strings.TrimSpace(part))
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(strings.TrimSpace(part)):]
+	}
+	return true
+}
+
 // Ensure Parser implements mq.FormatParser
 var _ mq.FormatParser = (*Parser)(nil)
 
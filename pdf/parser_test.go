@@ -90,6 +90,14 @@ func TestParsePDFFile(t *testing.T) {
 	t.Logf("Attention paper: %d chars readable", len(readable))
 	assert.NotEmpty(t, readable)
 
+	// Located headings have line ranges in the extracted text
+	for _, section := range doc.GetSections() {
+		if section.Start > 0 {
+			assert.GreaterOrEqual(t, section.End, section.Start, section.Heading.Text)
+			assert.NotEmpty(t, section.GetText(), section.Heading.Text)
+		}
+	}
+
 	// Check source size
 	source := doc.Source()
 	t.Logf("Attention paper: %d bytes source", len(source))
@@ -0,0 +1,152 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEnv overrides the describe cache location. Set it to "off" to
+// describe every plugin on each run.
+const CacheEnv = "MQ_PLUGIN_CACHE"
+
+// CachePath returns the describe cache location: $MQ_PLUGIN_CACHE, or
+// plugins.json in the user cache directory (~/.cache/mq on Linux). It
+// returns "" when caching is off.
+func CachePath() string {
+	if path := os.Getenv(CacheEnv); path != "" {
+		if path == "off" {
+			return ""
+		}
+		return path
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mq", "plugins.json")
+}
+
+// DescribeCache remembers what PATH executables described, or how they
+// failed to, keyed by path, size and modification time, so discovery only
+// runs plugins that are new or have changed since the last run.
+type DescribeCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+type cacheEntry struct {
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Name       string    `json:"name"`
+	Extensions []string  `json:"extensions,omitempty"`
+	MIMETypes  []string  `json:"mime_types,omitempty"`
+	Magic      []string  `json:"magic,omitempty"`
+	Unit       string    `json:"unit,omitempty"`
+	Units      string    `json:"units,omitempty"`
+	Error      string    `json:"error,omitempty"` // Describe failure
+}
+
+// LoadDescribeCache reads the cache at path. A missing or unreadable
+// file is an empty cache, and an empty path a cache that is never saved.
+func LoadDescribeCache(path string) *DescribeCache {
+	c := &DescribeCache{path: path, entries: make(map[string]cacheEntry)}
+	if path == "" {
+		return c
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		// A corrupt cache is rebuilt
+		if json.Unmarshal(data, &c.entries) != nil {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	return c
+}
+
+// Describe returns the cached description of the executable at path, or
+// runs it with Describe when the executable changed since it was cached.
+// Failures are cached too, so a broken plugin isn't run again until it
+// changes.
+func (c *DescribeCache) Describe(path string, timeout time.Duration) (Spec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		if entry.Error != "" {
+			return Spec{}, errors.New(entry.Error)
+		}
+		return Spec{
+			Name:       entry.Name,
+			Command:    []string{path},
+			Extensions: entry.Extensions,
+			MIMETypes:  entry.MIMETypes,
+			Magic:      entry.Magic,
+			Unit:       entry.Unit,
+			Units:      entry.Units,
+		}, nil
+	}
+
+	spec, err := Describe([]string{path}, timeout)
+	entry = cacheEntry{
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		Name:       spec.Name,
+		Extensions: spec.Extensions,
+		MIMETypes:  spec.MIMETypes,
+		Magic:      spec.Magic,
+		Unit:       spec.Unit,
+		Units:      spec.Units,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.mu.Lock()
+	c.entries[path] = entry
+	c.dirty = true
+	c.mu.Unlock()
+	return spec, err
+}
+
+// Save writes the cache back if it changed, dropping entries for
+// executables that no longer exist.
+func (c *DescribeCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// Write and rename so concurrent runs never read a partial file
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,248 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecutablePrefix names plugin executables found on PATH:
+// mq-parser-confluence parses the "confluence" format.
+const ExecutablePrefix = "mq-parser-"
+
+// ConfigEnv overrides the plugin config file location.
+const ConfigEnv = "MQ_PLUGIN_CONFIG"
+
+// Config is the plugin config file, plugins.json:
+//
+//	{"plugins": [
+//	  {"name": "confluence", "command": ["/opt/wiki/export", "--mq"],
+//	   "extensions": [".cxml"], "timeout": "30s"}
+//	]}
+//
+// Entries that declare a name and extensions or magic are used as is;
+// others are asked to describe themselves.
+type Config struct {
+	Plugins []ConfigEntry `json:"plugins"`
+}
+
+// ConfigEntry configures one plugin.
+type ConfigEntry struct {
+	Name       string   `json:"name"`
+	Command    []string `json:"command"`
+	Extensions []string `json:"extensions"`
+	MIMETypes  []string `json:"mime_types"`
+	Magic      []string `json:"magic"`
+	Unit       string   `json:"unit"`
+	Units      string   `json:"units"`
+	Timeout    string   `json:"timeout"` // Go duration, e.g. "30s"
+}
+
+// ConfigPath returns the plugin config file location: $MQ_PLUGIN_CONFIG,
+// or plugins.json in the user config directory (~/.config/mq on Linux).
+func ConfigPath() string {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mq", "plugins.json")
+}
+
+// LoadConfig reads a plugin config file. A missing file is an empty config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Discover finds plugins in the config file and as mq-parser-<name>
+// executables on PATH, registers their formats and returns their parsers
+// in name order. Config entries win over PATH executables of the same
+// name. PATH executables are described concurrently, and descriptions
+// are cached at CachePath until the executable changes. Plugins that
+// fail to describe themselves are reported in the error, and the rest
+// are still returned.
+func Discover(opts ...Option) ([]*Parser, error) {
+	cfg, err := LoadConfig(ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	return DiscoverFrom(cfg, filepath.SplitList(os.Getenv("PATH")), opts...)
+}
+
+// DiscoverFrom is Discover with an explicit config and search path.
+func DiscoverFrom(cfg *Config, searchPath []string, opts ...Option) ([]*Parser, error) {
+	var errs []error
+	specs := make(map[string]Spec)
+	timeouts := make(map[string]time.Duration)
+
+	cache := LoadDescribeCache(CachePath())
+	executables := findExecutables(searchPath)
+	described := make(map[string]Spec, len(executables))
+	failed := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, path := range executables {
+		wg.Add(1)
+		go func(name, path string) {
+			defer wg.Done()
+			spec, err := cache.Describe(path, DefaultTimeout)
+			if err == nil && spec.Name != name {
+				err = fmt.Errorf("%s: describes format %q, want %q", path, spec.Name, name)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[name] = err
+				return
+			}
+			described[name] = spec
+		}(name, path)
+	}
+	wg.Wait()
+	// The cache only saves startup time, so failing to write it is not an error
+	_ = cache.Save()
+
+	for _, name := range sortedKeys(executables) {
+		if err, ok := failed[name]; ok {
+			errs = append(errs, err)
+			continue
+		}
+		specs[name] = described[name]
+	}
+
+	for _, entry := range cfg.Plugins {
+		spec, timeout, err := entry.spec()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		specs[spec.Name] = spec
+		if timeout > 0 {
+			timeouts[spec.Name] = timeout
+		}
+	}
+
+	var parsers []*Parser
+	for _, name := range sortedKeys(specs) {
+		parserOpts := opts
+		if timeout, ok := timeouts[name]; ok {
+			parserOpts = append(append([]Option(nil), opts...), WithTimeout(timeout))
+		}
+		p, err := NewParser(specs[name], parserOpts...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		parsers = append(parsers, p)
+	}
+	return parsers, errors.Join(errs...)
+}
+
+// spec resolves a config entry, asking the plugin to describe itself
+// when the entry doesn't say how to recognize its files.
+func (c ConfigEntry) spec() (Spec, time.Duration, error) {
+	if len(c.Command) == 0 {
+		return Spec{}, 0, fmt.Errorf("plugin config %q: no command", c.Name)
+	}
+
+	var timeout time.Duration
+	if c.Timeout != "" {
+		d, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return Spec{}, 0, fmt.Errorf("plugin config %q: timeout: %w", c.Name, err)
+		}
+		timeout = d
+	}
+
+	spec := Spec{
+		Name:       c.Name,
+		Command:    c.Command,
+		Extensions: c.Extensions,
+		MIMETypes:  c.MIMETypes,
+		Magic:      c.Magic,
+		Unit:       c.Unit,
+		Units:      c.Units,
+	}
+	if c.Name != "" && (len(c.Extensions) > 0 || len(c.Magic) > 0) {
+		return spec, timeout, nil
+	}
+
+	described, err := Describe(c.Command, max(timeout, DefaultTimeout))
+	if err != nil {
+		return Spec{}, 0, err
+	}
+	// Declared fields override the plugin's own description
+	if c.Name != "" {
+		described.Name = c.Name
+	}
+	if len(c.MIMETypes) > 0 {
+		described.MIMETypes = c.MIMETypes
+	}
+	if c.Unit != "" {
+		described.Unit, described.Units = c.Unit, c.Units
+	}
+	return described, timeout, nil
+}
+
+// findExecutables maps format names to mq-parser-<name> executables,
+// taking the first match along searchPath.
+func findExecutables(searchPath []string) map[string]string {
+	found := make(map[string]string)
+	for _, dir := range searchPath {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), ExecutablePrefix)
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name, ok = strings.CutSuffix(name, ".exe")
+			} else {
+				info, err := entry.Info()
+				ok = err == nil && info.Mode()&0o111 != 0
+			}
+			if !ok || name == "" {
+				continue
+			}
+			if _, seen := found[name]; !seen {
+				found[name] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return found
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
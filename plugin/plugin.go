@@ -0,0 +1,505 @@
+// Package plugin runs external parser executables as mq format parsers.
+//
+// Formats that can't live in this module (proprietary wiki exports,
+// Confluence storage XML) are parsed by a separate executable that speaks
+// a small versioned JSON protocol over stdin and stdout. One request is
+// written to the plugin's stdin per process, and the plugin answers with
+// one JSON object on stdout:
+//
+//	→ {"protocol": 1, "op": "describe"}
+//	← {"protocol": 1, "name": "confluence", "extensions": [".cxml"],
+//	   "mime_types": ["application/vnd.confluence+xml"], "magic": ["<ac:"]}
+//
+//	→ {"protocol": 1, "op": "parse", "path": "space/page.cxml", "content": "<base64>"}
+//	← {"protocol": 1, "title": "Runbook",
+//	   "source": "# Runbook\n\n## Restart\n...",
+//	   "headings": [{"level": 1, "text": "Runbook", "line": 1}],
+//	   "code_blocks": [{"language": "bash", "content": "systemctl restart api", "line": 5}],
+//	   "tables": [{"headers": ["Host"], "rows": [["db1"]]}],
+//	   "links": [{"text": "Dashboard", "url": "https://...", "line": 9, "column": 4}],
+//	   "readable_text": "...", "metadata": {"space": "OPS"}}
+//
+// Every element may carry "line", "column", "end_line" and "end_column"
+// (1-based, columns in bytes); omitted values are unknown. Positions
+// refer to "source" when the plugin returns a rendered text, and to the
+// input otherwise. A plugin reports a parse failure with
+// {"protocol": 1, "error": "..."}. Responses with another protocol
+// version, malformed JSON or a non-zero exit become mq.ParseError values
+// carrying the plugin's stderr.
+//
+// Example:
+//
+//	parsers, err := plugin.Discover()
+//	for _, p := range parsers {
+//		mq.RegisterDefaultParser(p)
+//	}
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/muqsitnawaz/mq/internal/outline"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// ProtocolVersion is the version of the JSON protocol spoken with plugins.
+const ProtocolVersion = 1
+
+// DefaultTimeout bounds a single plugin invocation.
+const DefaultTimeout = 10 * time.Second
+
+// waitDelay bounds how long a killed or exited plugin's output pipes are
+// drained before its I/O is abandoned.
+const waitDelay = time.Second
+
+// maxStderr is how much plugin stderr is kept for error messages.
+const maxStderr = 4 << 10
+
+// maxStdout bounds the response a plugin may write.
+const maxStdout = 64 << 20
+
+// ErrProtocol is wrapped by errors for responses that violate the protocol.
+var ErrProtocol = errors.New("plugin protocol violation")
+
+// Spec describes a plugin executable and the format it parses.
+type Spec struct {
+	Name       string   // Format name, e.g. "confluence"
+	Command    []string // Executable and arguments
+	Extensions []string // File extensions including the dot
+	MIMETypes  []string // Media types
+	Magic      []string // Content prefixes used for sniffing
+	Unit       string   // Structural unit shown in directory trees, e.g. "page"
+	Units      string   // Plural of Unit
+}
+
+// Parser parses documents by running a plugin executable.
+type Parser struct {
+	spec   Spec
+	format mq.Format
+
+	// Options
+	timeout time.Duration // Limit for one plugin invocation
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// WithTimeout sets how long a plugin may run before it is killed.
+func WithTimeout(d time.Duration) Option {
+	return func(p *Parser) {
+		p.timeout = d
+	}
+}
+
+// NewParser registers the plugin's format with mq and returns a parser
+// for it. It fails if the spec has no name or command, or if the name is
+// already taken by another format.
+func NewParser(spec Spec, opts ...Option) (*Parser, error) {
+	if spec.Name == "" {
+		return nil, errors.New("plugin: spec has no name")
+	}
+	if len(spec.Command) == 0 {
+		return nil, fmt.Errorf("plugin %s: spec has no command", spec.Name)
+	}
+	if _, taken := mq.ParseFormat(spec.Name); taken {
+		return nil, fmt.Errorf("plugin %s: format name already registered", spec.Name)
+	}
+
+	p := &Parser{
+		spec:    spec,
+		timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	info := mq.FormatInfo{
+		Name:       spec.Name,
+		Extensions: spec.Extensions,
+		MIMETypes:  spec.MIMETypes,
+		Unit:       spec.Unit,
+		Units:      spec.Units,
+	}
+	if len(spec.Magic) > 0 {
+		magic := spec.Magic
+		info.Sniff = func(content []byte) bool {
+			head := bytes.TrimSpace(content[:min(len(content), 1024)])
+			for _, m := range magic {
+				if bytes.HasPrefix(head, []byte(m)) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	p.format = mq.RegisterFormat(info)
+	return p, nil
+}
+
+// Spec returns the plugin's spec.
+func (p *Parser) Spec() Spec {
+	return p.spec
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return p.format
+}
+
+// ParseFile reads and parses a file with the plugin.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: p.format, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse sends content to the plugin and converts its response.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	var resp response
+	err := call(p.spec.Command, p.timeout, request{
+		Protocol: ProtocolVersion,
+		Op:       "parse",
+		Path:     path,
+		Content:  content,
+	}, &resp)
+	if err != nil {
+		return nil, &mq.ParseError{Format: p.format, Path: path, Err: fmt.Errorf("plugin %s: %w", p.spec.Name, err)}
+	}
+
+	ext := &extractor{
+		format: p.format,
+		source: content,
+		path:   path,
+		resp:   &resp,
+	}
+	return ext.extract()
+}
+
+// Describe asks the plugin executable for its spec.
+func Describe(command []string, timeout time.Duration) (Spec, error) {
+	var resp response
+	if err := call(command, timeout, request{Protocol: ProtocolVersion, Op: "describe"}, &resp); err != nil {
+		return Spec{}, fmt.Errorf("describe %s: %w", command[0], err)
+	}
+	if resp.Name == "" {
+		return Spec{}, fmt.Errorf("describe %s: %w: no name", command[0], ErrProtocol)
+	}
+	return Spec{
+		Name:       resp.Name,
+		Command:    command,
+		Extensions: resp.Extensions,
+		MIMETypes:  resp.MIMETypes,
+		Magic:      resp.Magic,
+		Unit:       resp.Unit,
+		Units:      resp.Units,
+	}, nil
+}
+
+// request is written to the plugin's stdin. Content is base64 in JSON.
+type request struct {
+	Protocol int    `json:"protocol"`
+	Op       string `json:"op"`
+	Path     string `json:"path,omitempty"`
+	Content  []byte `json:"content,omitempty"`
+}
+
+// response is read from the plugin's stdout, for both describe and parse.
+type response struct {
+	Protocol int    `json:"protocol"`
+	Error    string `json:"error"`
+
+	// describe
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`
+	MIMETypes  []string `json:"mime_types"`
+	Magic      []string `json:"magic"`
+	Unit       string   `json:"unit"`
+	Units      string   `json:"units"`
+
+	// parse
+	Title        string                 `json:"title"`
+	Source       *string                `json:"source"`
+	Headings     []respHeading          `json:"headings"`
+	CodeBlocks   []respCodeBlock        `json:"code_blocks"`
+	Tables       []respTable            `json:"tables"`
+	Links        []respLink             `json:"links"`
+	Images       []respImage            `json:"images"`
+	Lists        []respList             `json:"lists"`
+	ReadableText string                 `json:"readable_text"`
+	Metadata     map[string]interface{} `json:"metadata"`
+}
+
+// respPosition is the optional location of an element. Every element
+// accepts it; omitted fields stay unknown.
+type respPosition struct {
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"end_line"`
+	EndColumn int `json:"end_column"`
+}
+
+func (p respPosition) position() mq.Position {
+	return mq.Position{Line: p.Line, Column: p.Column, EndLine: p.EndLine, EndColumn: p.EndColumn}
+}
+
+type respHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+	End   int    `json:"end"` // Last line of the section; inferred when 0
+	respPosition
+}
+
+type respCodeBlock struct {
+	Language string `json:"language"`
+	Content  string `json:"content"`
+	respPosition
+}
+
+type respTable struct {
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+	respPosition
+}
+
+type respLink struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+	respPosition
+}
+
+type respImage struct {
+	Alt   string `json:"alt"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	respPosition
+}
+
+type respList struct {
+	Ordered bool       `json:"ordered"`
+	Items   []respItem `json:"items"`
+	respPosition
+}
+
+type respItem struct {
+	Text     string     `json:"text"`
+	Checked  *bool      `json:"checked"`
+	Children []respItem `json:"children"`
+	respPosition
+}
+
+// call runs command once with req on stdin and decodes its stdout into
+// resp, enforcing timeout and the protocol version.
+func call(command []string, timeout time.Duration, req request, resp *response) error {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	// Don't wait on pipes held open by children of a killed plugin
+	cmd.WaitDelay = waitDelay
+
+	stdout := &limitBuffer{limit: maxStdout}
+	stderr := &tailBuffer{limit: maxStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return withStderr(err, stderr)
+	}
+	if stdout.overflow {
+		return withStderr(fmt.Errorf("%w: response larger than %d bytes", ErrProtocol, maxStdout), stderr)
+	}
+
+	if err := json.Unmarshal(stdout.buf.Bytes(), resp); err != nil {
+		return withStderr(fmt.Errorf("%w: invalid JSON response: %v", ErrProtocol, err), stderr)
+	}
+	if resp.Protocol != ProtocolVersion {
+		return withStderr(fmt.Errorf("%w: protocol version %d, want %d", ErrProtocol, resp.Protocol, ProtocolVersion), stderr)
+	}
+	if resp.Error != "" {
+		return withStderr(errors.New(resp.Error), stderr)
+	}
+	return nil
+}
+
+// withStderr appends captured stderr to err.
+func withStderr(err error, stderr *tailBuffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%w\nstderr: %s", err, msg)
+	}
+	return err
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+// limitBuffer keeps the first limit bytes written to it. Later writes are
+// discarded rather than failed, so the plugin isn't blocked on a full pipe.
+type limitBuffer struct {
+	limit    int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.overflow = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// extractor converts a parse response into an mq.Document.
+type extractor struct {
+	format mq.Format
+	source []byte
+	path   string
+	resp   *response
+}
+
+func (e *extractor) extract() (*mq.Document, error) {
+	r := e.resp
+	source := e.source
+	if r.Source != nil {
+		source = []byte(*r.Source)
+	}
+
+	headings := make([]*mq.Heading, 0, len(r.Headings))
+	for _, h := range r.Headings {
+		if h.Level < 1 || h.Text == "" {
+			return nil, e.violation("heading %q has level %d", h.Text, h.Level)
+		}
+		heading := &mq.Heading{Level: h.Level, Text: h.Text, ID: h.ID}
+		heading.SetPosition(h.position())
+		headings = append(headings, heading)
+	}
+	sections := buildSections(headings, r.Headings, bytes.Count(source, []byte("\n"))+1)
+
+	var codeBlocks []*mq.CodeBlock
+	for _, c := range r.CodeBlocks {
+		cb := &mq.CodeBlock{Language: c.Language, Content: c.Content, Position: c.position()}
+		codeBlocks = append(codeBlocks, cb)
+		if s := innermostSection(sections, c.Line); s != nil {
+			s.AddCodeBlock(cb)
+		}
+	}
+
+	var tables []*mq.Table
+	for _, t := range r.Tables {
+		tables = append(tables, &mq.Table{Headers: t.Headers, Rows: t.Rows, Position: t.position()})
+	}
+
+	var links []*mq.Link
+	for _, l := range r.Links {
+		links = append(links, &mq.Link{Text: l.Text, URL: l.URL, Position: l.position()})
+	}
+
+	var images []*mq.Image
+	for _, img := range r.Images {
+		images = append(images, &mq.Image{AltText: img.Alt, URL: img.URL, Title: img.Title, Position: img.position()})
+	}
+
+	var lists []*mq.List
+	for _, l := range r.Lists {
+		lists = append(lists, &mq.List{Ordered: l.Ordered, Items: convertItems(l.Items), Position: l.position()})
+	}
+
+	readable := r.ReadableText
+	if readable == "" && r.Source != nil {
+		readable = *r.Source
+	}
+
+	doc := mq.NewDocument(
+		source,
+		e.path,
+		e.format,
+		r.Title,
+		headings,
+		sections,
+		codeBlocks,
+		links,
+		images,
+		tables,
+		lists,
+		readable,
+	)
+	if len(r.Metadata) > 0 {
+		doc.SetMetadata(mq.Metadata(r.Metadata))
+	}
+	return doc, nil
+}
+
+func (e *extractor) violation(format string, args ...interface{}) error {
+	return &mq.ParseError{Format: e.format, Path: e.path, Err: fmt.Errorf("%w: "+format, append([]interface{}{ErrProtocol}, args...)...)}
+}
+
+// buildSections nests headings by level. A section ends at the plugin's
+// end line, or before the next heading of the same or a higher level.
+func buildSections(headings []*mq.Heading, raw []respHeading, totalLines int) []*mq.Section {
+	sections := outline.Nest(headings, totalLines, 0)
+	for i, s := range sections {
+		if raw[i].End > 0 {
+			s.End = raw[i].End
+		}
+	}
+	return sections
+}
+
+// innermostSection returns the deepest section containing line.
+func innermostSection(sections []*mq.Section, line int) *mq.Section {
+	var best *mq.Section
+	for _, s := range sections {
+		if line >= s.Start && line <= s.End && s.Start > 0 {
+			if best == nil || s.Heading.Level > best.Heading.Level {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+func convertItems(items []respItem) []mq.ListItem {
+	out := make([]mq.ListItem, 0, len(items))
+	for _, it := range items {
+		out = append(out, mq.ListItem{Text: it.Text, Checked: it.Checked, Children: convertItems(it.Children), Position: it.position()})
+	}
+	return out
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
@@ -0,0 +1,261 @@
+package plugin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/muqsitnawaz/mq/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pluginModeEnv makes the test binary act as a plugin executable.
+const pluginModeEnv = "MQ_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(pluginModeEnv); mode != "" {
+		runFakePlugin(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakePlugin answers one request the way a plugin would.
+func runFakePlugin(mode string) {
+	var req struct {
+		Protocol int    `json:"protocol"`
+		Op       string `json:"op"`
+		Path     string `json:"path"`
+		Content  []byte `json:"content"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(2)
+	}
+
+	var resp any
+	switch mode {
+	case "wiki":
+		if req.Op == "describe" {
+			resp = map[string]any{"protocol": 1, "name": "wiki", "extensions": []string{".wiki"}, "magic": []string{"{{wiki}}"}, "unit": "page", "units": "pages"}
+			break
+		}
+		resp = map[string]any{
+			"protocol": 1,
+			"title":    "Runbook",
+			"source":   "# Runbook\n\n## Restart\n\n    systemctl restart api\n\n## Escalate\n\nPage " + string(req.Content),
+			"headings": []map[string]any{
+				{"level": 1, "text": "Runbook", "line": 1},
+				{"level": 2, "text": "Restart", "line": 3},
+				{"level": 2, "text": "Escalate", "line": 7},
+			},
+			"code_blocks":   []map[string]any{{"language": "bash", "content": "systemctl restart api", "line": 5}},
+			"tables":        []map[string]any{{"headers": []string{"Host"}, "rows": [][]string{{"db1"}}}},
+			"links":         []map[string]any{{"text": "Dashboard", "url": "https://grafana.example.com", "line": 9, "column": 6, "end_line": 9, "end_column": 14}},
+			"lists":         []map[string]any{{"items": []map[string]any{{"text": "check disk", "checked": true}}}},
+			"readable_text": "Runbook Restart Escalate",
+			"metadata":      map[string]any{"space": "OPS", "path": req.Path},
+		}
+	case "version":
+		fmt.Fprintln(os.Stderr, "built for mq 2")
+		resp = map[string]any{"protocol": 2, "name": "future"}
+	case "garbage":
+		fmt.Print("not json")
+		return
+	case "flood":
+		chunk := make([]byte, 1<<20)
+		for i := 0; i < 65; i++ {
+			os.Stdout.Write(chunk)
+		}
+		return
+	case "fail":
+		resp = map[string]any{"protocol": 1, "error": "unsupported export version"}
+	case "crash":
+		fmt.Fprintln(os.Stderr, "panic: wiki export corrupted")
+		os.Exit(3)
+	case "slow":
+		time.Sleep(5 * time.Second)
+	case "badheading":
+		resp = map[string]any{"protocol": 1, "headings": []map[string]any{{"level": 0, "text": "x"}}}
+	}
+	json.NewEncoder(os.Stdout).Encode(resp)
+}
+
+// newFakeParser registers a uniquely named format served by mode.
+func newFakeParser(t *testing.T, mode string, opts ...plugin.Option) *plugin.Parser {
+	t.Helper()
+	t.Setenv(pluginModeEnv, mode)
+	p, err := plugin.NewParser(plugin.Spec{
+		Name:    "fake-" + t.Name(),
+		Command: []string{os.Args[0]},
+	}, opts...)
+	require.NoError(t, err)
+	return p
+}
+
+func TestParse(t *testing.T) {
+	p := newFakeParser(t, "wiki")
+	assert.Equal(t, "fake-testparse", p.Format().String())
+
+	doc, err := p.Parse([]byte("oncall"), "ops/runbook.wiki")
+	require.NoError(t, err)
+
+	assert.Equal(t, p.Format(), doc.Format())
+	assert.Equal(t, "Runbook", doc.Title())
+	assert.Len(t, doc.GetHeadings(2), 2)
+
+	restart, ok := doc.GetSection("Restart")
+	require.True(t, ok)
+	assert.Equal(t, 3, restart.Start)
+	assert.Equal(t, 6, restart.End)
+	assert.Equal(t, "bash", restart.GetCodeBlocks()[0].Language)
+
+	escalate, ok := doc.GetSection("Escalate")
+	require.True(t, ok)
+	assert.Contains(t, escalate.GetText(), "Page oncall")
+
+	require.Len(t, doc.GetTables(), 1)
+	assert.Equal(t, []string{"Host"}, doc.GetTables()[0].Headers)
+	assert.Equal(t, "https://grafana.example.com", doc.GetLinks()[0].URL)
+	assert.Equal(t, mq.Position{Line: 9, Column: 6, EndLine: 9, EndColumn: 14}, doc.GetLinks()[0].Position)
+	assert.Equal(t, 5, restart.GetCodeBlocks()[0].Line)
+	assert.Equal(t, "Runbook Restart Escalate", doc.ReadableText())
+	assert.Equal(t, "ops/runbook.wiki", doc.Metadata()["path"])
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		mode     string
+		protocol bool
+		contains string
+	}{
+		{"version", true, "protocol version 2, want 1\nstderr: built for mq 2"},
+		{"garbage", true, "invalid JSON"},
+		{"flood", true, "response larger than 67108864 bytes"},
+		{"badheading", true, "level 0"},
+		{"fail", false, "unsupported export version"},
+		{"crash", false, "stderr: panic: wiki export corrupted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p := newFakeParser(t, tt.mode)
+			_, err := p.Parse([]byte("x"), "page.wiki")
+
+			var parseErr *mq.ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, p.Format(), parseErr.Format)
+			assert.Equal(t, "page.wiki", parseErr.Path)
+			assert.Equal(t, tt.protocol, errors.Is(err, plugin.ErrProtocol))
+			assert.ErrorContains(t, err, tt.contains)
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	p := newFakeParser(t, "slow", plugin.WithTimeout(100*time.Millisecond))
+
+	start := time.Now()
+	_, err := p.Parse([]byte("x"), "page.wiki")
+	assert.ErrorContains(t, err, "timed out after 100ms")
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestNewParserRejectsTakenName(t *testing.T) {
+	_, err := plugin.NewParser(plugin.Spec{Name: "markdown", Command: []string{"true"}})
+	assert.ErrorContains(t, err, "already registered")
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script wrapper")
+	}
+	t.Setenv(pluginModeEnv, "wiki")
+	t.Setenv(plugin.CacheEnv, filepath.Join(t.TempDir(), "cache.json"))
+
+	// mq-parser-wiki on the search path describes itself
+	bin := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nexec %q \"$@\"\n", os.Args[0])
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "mq-parser-wiki"), []byte(script), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "mq-parser-notexec"), []byte(script), 0o644))
+
+	// A config entry that declares its extensions is not described
+	cfgPath := filepath.Join(t.TempDir(), "plugins.json")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`{"plugins": [
+		{"name": "confluence", "command": ["/nonexistent/export"], "extensions": [".cxml"], "timeout": "2s"},
+		{"name": "broken", "command": []}
+	]}`), 0o644))
+	cfg, err := plugin.LoadConfig(cfgPath)
+	require.NoError(t, err)
+
+	parsers, err := plugin.DiscoverFrom(cfg, []string{bin})
+	assert.ErrorContains(t, err, `plugin config "broken": no command`)
+	require.Len(t, parsers, 2)
+	assert.Equal(t, "confluence", parsers[0].Spec().Name)
+	assert.Equal(t, "wiki", parsers[1].Spec().Name)
+
+	// Registered formats take part in detection
+	wiki := parsers[1].Format()
+	assert.Equal(t, wiki, mq.DetectFormat("home.wiki", nil))
+	assert.Equal(t, wiki, mq.DetectFormat("unknown", []byte("{{wiki}}\n= Home =")))
+	assert.Equal(t, parsers[0].Format(), mq.DetectFormat("page.cxml", nil))
+
+	engine := mq.NewMultiFormatEngine(mq.WithFormatParser(parsers[1]))
+	doc, err := engine.Parse([]byte("oncall"), "home.wiki")
+	require.NoError(t, err)
+	assert.Equal(t, "Runbook", doc.Title())
+}
+
+func TestDescribeCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script wrapper")
+	}
+	t.Setenv(pluginModeEnv, "wiki")
+
+	exe := filepath.Join(t.TempDir(), "mq-parser-wiki")
+	script := fmt.Sprintf("#!/bin/sh\nexec %q \"$@\"\n", os.Args[0])
+	require.NoError(t, os.WriteFile(exe, []byte(script), 0o755))
+	cachePath := filepath.Join(t.TempDir(), "mq", "plugins.json")
+
+	cache := plugin.LoadDescribeCache(cachePath)
+	spec, err := cache.Describe(exe, plugin.DefaultTimeout)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	// A later run answers from the cache without running the plugin
+	t.Setenv(pluginModeEnv, "crash")
+	cached, err := plugin.LoadDescribeCache(cachePath).Describe(exe, plugin.DefaultTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, spec, cached)
+	assert.Equal(t, []string{exe}, cached.Command)
+
+	// A changed executable is described again
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(exe, later, later))
+	cache = plugin.LoadDescribeCache(cachePath)
+	_, err = cache.Describe(exe, plugin.DefaultTimeout)
+	assert.ErrorContains(t, err, "wiki export corrupted")
+	require.NoError(t, cache.Save())
+
+	// and its failure is cached until it changes again
+	t.Setenv(pluginModeEnv, "wiki")
+	_, err = plugin.LoadDescribeCache(cachePath).Describe(exe, plugin.DefaultTimeout)
+	assert.ErrorContains(t, err, "wiki export corrupted")
+
+	require.NoError(t, os.Chtimes(exe, later.Add(time.Minute), later.Add(time.Minute)))
+	_, err = plugin.LoadDescribeCache(cachePath).Describe(exe, plugin.DefaultTimeout)
+	assert.NoError(t, err)
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	cfg, err := plugin.LoadConfig(filepath.Join(t.TempDir(), "none.json"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Plugins)
+}
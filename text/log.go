@@ -0,0 +1,361 @@
+package text
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/muqsitnawaz/mq/internal/outline"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Log files become one H1 section per time period, e.g. "2024-05-01 14:15",
+// with a severity breakdown as the section preview and in metadata.
+// Lines without a timestamp (stack traces, wrapped messages) belong to the
+// entry above them. A table summarizes entries per period and severity.
+
+// timestampFormat recognizes one timestamp style.
+type timestampFormat struct {
+	re        *regexp.Regexp      // first submatch is the timestamp
+	normalize func(string) string // rewrites the match for layout, optional
+	layout    string              // time.Parse layout
+	label     string              // layout for period headings
+}
+
+var timestampFormats = []timestampFormat{
+	// 2024-05-01T14:03:07.123Z, 2024-05-01 14:03:07,123 +0200
+	{
+		re:        regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|\s?[+-]\d{2}:?\d{2})?)`),
+		normalize: normalizeISO,
+		layout:    time.RFC3339Nano,
+		label:     "2006-01-02 15:04",
+	},
+	// Go's log package: 2024/05/01 14:03:07
+	{
+		re:     regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)`),
+		layout: "2006/01/02 15:04:05.999999999",
+		label:  "2006-01-02 15:04",
+	},
+	// syslog: May  1 14:03:07 (no year)
+	{
+		re:     regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`),
+		layout: "Jan _2 15:04:05",
+		label:  "Jan _2 15:04",
+	},
+	// Common Log Format: 127.0.0.1 - - [01/May/2024:14:03:07 +0000]
+	{
+		re:     regexp.MustCompile(`^\S+ \S+ \S+ \[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`),
+		layout: "02/Jan/2006:15:04:05 -0700",
+		label:  "2006-01-02 15:04",
+	},
+}
+
+var (
+	isoZoneRe = regexp.MustCompile(`\s?([+-]\d{2}):?(\d{2})$`)
+
+	severityRe = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERROR|ERR|FATAL|CRIT(?:ICAL)?|PANIC|EMERG|ALERT)\b`)
+	levelKVRe  = regexp.MustCompile(`(?i)"?(?:level|severity|lvl)"?\s*[=:]\s*"?([a-z]+)`)
+)
+
+// severities in display order, most severe first.
+var severities = []string{"fatal", "error", "warn", "info", "debug", "trace"}
+
+// periods are the candidate bucket sizes, smallest first.
+var periods = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 6 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// maxPeriods is the most sections an automatic period size produces.
+const maxPeriods = 24
+
+// LogParser parses log files into time-period sections.
+type LogParser struct {
+	// Options
+	period time.Duration // Period size; 0 chooses one from the time span
+}
+
+// LogOption configures the log parser.
+type LogOption func(*LogParser)
+
+// NewLogParser creates a new log parser with default options.
+func NewLogParser(opts ...LogOption) *LogParser {
+	p := &LogParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithPeriod fixes the period size. By default the smallest of 1m, 5m,
+// 15m, 30m, 1h, 6h, 1d and 1w giving at most 24 periods is used.
+func WithPeriod(d time.Duration) LogOption {
+	return func(p *LogParser) {
+		p.period = d
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *LogParser) Format() mq.Format {
+	return mq.FormatLog
+}
+
+// ParseFile reads and parses a log file.
+func (p *LogParser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatLog, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// logEntry is one timestamped line and its continuation lines.
+type logEntry struct {
+	start, end int // 1-based lines
+	time       time.Time
+	level      string
+	format     *timestampFormat
+}
+
+// Parse parses log content and returns an mq.Document. Content without
+// recognizable timestamps is structured like plain text.
+func (p *LogParser) Parse(content []byte, path string) (*mq.Document, error) {
+	index := mq.NewLineIndex(content)
+	lines := splitLines(string(content))
+	entries := parseEntries(lines)
+	if len(entries) == 0 {
+		headings := inferHeadings(lines)
+		positionHeadings(headings, index)
+		return mq.NewDocument(content, path, mq.FormatLog, "", headings, outline.Nest(headings, len(lines), 0),
+			nil, extractLinks(content, index), nil, nil, nil, string(content)), nil
+	}
+
+	period := p.period
+	if period <= 0 {
+		period = choosePeriod(entries[0].time, entries[len(entries)-1].time)
+	}
+
+	var headings []*mq.Heading
+	var sections []*mq.Section
+	var current *mq.Section
+	var counts map[string]int
+	var currentKey time.Time
+	total := make(map[string]int)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Doc = severitySummary(current.Metadata["entries"].(int), counts)
+		current.Metadata["levels"] = counts
+	}
+
+	for _, e := range entries {
+		key := e.time.Truncate(period)
+		if current == nil || !key.Equal(currentKey) {
+			flush()
+			h := &mq.Heading{Level: 1, Text: key.Format(e.format.label)}
+			h.SetPosition(index.LinePosition(e.start, e.start))
+			current = &mq.Section{
+				Heading: h,
+				Start:   e.start,
+				Metadata: mq.Metadata{
+					"start":   key.Format(time.RFC3339),
+					"end":     key.Add(period).Format(time.RFC3339),
+					"entries": 0,
+				},
+			}
+			counts = make(map[string]int)
+			currentKey = key
+			headings = append(headings, h)
+			sections = append(sections, current)
+		}
+		current.End = e.end
+		current.Metadata["entries"] = current.Metadata["entries"].(int) + 1
+		if e.level != "" {
+			counts[e.level]++
+			total[e.level]++
+		}
+	}
+	flush()
+
+	doc := mq.NewDocument(
+		content,
+		path,
+		mq.FormatLog,
+		"", // title
+		headings,
+		sections,
+		nil, // codeBlocks
+		extractLinks(content, index),
+		nil, // images
+		[]*mq.Table{periodTable(sections, total, index)},
+		nil, // lists
+		string(content),
+	)
+	doc.SetMetadata(mq.Metadata{
+		"entries": len(entries),
+		"first":   entries[0].time.Format(time.RFC3339),
+		"last":    entries[len(entries)-1].time.Format(time.RFC3339),
+		"period":  formatPeriod(period),
+		"levels":  total,
+	})
+	return doc, nil
+}
+
+// parseEntries groups lines into timestamped entries. Lines before the
+// first timestamp are skipped.
+func parseEntries(lines []string) []*logEntry {
+	var entries []*logEntry
+	for i, line := range lines {
+		t, format := parseTimestamp(line)
+		if format == nil {
+			if len(entries) > 0 {
+				entries[len(entries)-1].end = i + 1
+			}
+			continue
+		}
+		entries = append(entries, &logEntry{
+			start:  i + 1,
+			end:    i + 1,
+			time:   t,
+			level:  severity(line),
+			format: format,
+		})
+	}
+	return entries
+}
+
+// parseTimestamp returns the time at the start of a log line.
+func parseTimestamp(line string) (time.Time, *timestampFormat) {
+	for i := range timestampFormats {
+		f := &timestampFormats[i]
+		m := f.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := m[1]
+		if f.normalize != nil {
+			value = f.normalize(value)
+		}
+		if t, err := time.Parse(f.layout, value); err == nil {
+			return t, f
+		}
+	}
+	return time.Time{}, nil
+}
+
+// normalizeISO rewrites ISO 8601 variants to RFC 3339, assuming UTC when
+// no zone is given.
+func normalizeISO(s string) string {
+	s = strings.Replace(s, " ", "T", 1)
+	s = strings.Replace(s, ",", ".", 1)
+	if strings.HasSuffix(s, "Z") {
+		return s
+	}
+	if m := isoZoneRe.FindStringSubmatchIndex(s); m != nil && m[0] > 10 {
+		return s[:m[0]] + s[m[2]:m[3]] + ":" + s[m[4]:m[5]]
+	}
+	return s + "Z"
+}
+
+// severity returns the normalized level of a log line, or "".
+func severity(line string) string {
+	var level string
+	if m := levelKVRe.FindStringSubmatch(line); m != nil {
+		level = strings.ToUpper(m[1])
+	} else if m := severityRe.FindStringSubmatch(line); m != nil {
+		level = m[1]
+	}
+	switch level {
+	case "TRACE":
+		return "trace"
+	case "DEBUG":
+		return "debug"
+	case "INFO", "NOTICE":
+		return "info"
+	case "WARN", "WARNING":
+		return "warn"
+	case "ERROR", "ERR":
+		return "error"
+	case "FATAL", "CRIT", "CRITICAL", "PANIC", "EMERG", "ALERT":
+		return "fatal"
+	}
+	return ""
+}
+
+// choosePeriod picks the smallest period giving at most maxPeriods
+// periods between first and last.
+func choosePeriod(first, last time.Time) time.Duration {
+	span := last.Sub(first)
+	if span < 0 {
+		span = -span
+	}
+	for _, d := range periods {
+		if span/d < maxPeriods {
+			return d
+		}
+	}
+	return periods[len(periods)-1]
+}
+
+// formatPeriod renders a period as "15m", "1h" or "1d".
+func formatPeriod(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+// severitySummary renders "42 entries: 3 error, 5 warn, 34 info".
+func severitySummary(entries int, counts map[string]int) string {
+	noun := "entries"
+	if entries == 1 {
+		noun = "entry"
+	}
+	summary := fmt.Sprintf("%d %s", entries, noun)
+
+	var parts []string
+	for _, level := range severities {
+		if n := counts[level]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, level))
+		}
+	}
+	if len(parts) > 0 {
+		summary += ": " + strings.Join(parts, ", ")
+	}
+	return summary
+}
+
+// periodTable summarizes entries per period, with a column per severity
+// that occurs in the log.
+func periodTable(sections []*mq.Section, total map[string]int, index *mq.LineIndex) *mq.Table {
+	headers := []string{"Period", "Entries"}
+	var levels []string
+	for _, level := range severities {
+		if total[level] > 0 {
+			levels = append(levels, level)
+			headers = append(headers, level)
+		}
+	}
+
+	// The summary covers every entry
+	first, last := sections[0], sections[len(sections)-1]
+	table := &mq.Table{Headers: headers, Position: index.LinePosition(first.Start, last.End)}
+	for _, s := range sections {
+		counts := s.Metadata["levels"].(map[string]int)
+		row := []string{s.Heading.Text, fmt.Sprint(s.Metadata["entries"])}
+		for _, level := range levels {
+			row = append(row, fmt.Sprint(counts[level]))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
@@ -0,0 +1,214 @@
+// Package text provides plain text and log file parsing for mq.
+//
+// Plain text has no markup, so structure is inferred from layout:
+//   - Underlined lines: "Title" over "=====" (H1) or "-----" (H2)
+//   - ALL-CAPS lines standing alone between blank lines (H1)
+//   - Numbered outlines: "1. Scope", "2.3 Limits" (depth from the number)
+//   - URLs as links
+//
+// Log files (see LogParser) are grouped into time periods instead.
+//
+// Example:
+//
+//	parser := text.NewParser()
+//	doc, _ := parser.ParseFile("INSTALL.txt")
+//
+//	for _, s := range doc.GetSections() {
+//		fmt.Println(s.Heading.Text, s.Start, s.End)
+//	}
+package text
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/muqsitnawaz/mq/internal/outline"
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+var (
+	// underlineRe matches setext-style underlines.
+	underlineRe = regexp.MustCompile(`^(={3,}|-{3,})$`)
+
+	// outlineRe matches numbered outline headings: "1. Scope", "2.3 Limits".
+	outlineRe = regexp.MustCompile(`^(\d+(?:\.\d+)*)(\.?)\s+(\S.*)$`)
+
+	// listItemRe matches list items, which are not headings.
+	listItemRe = regexp.MustCompile(`^\s*([-*•]|\d+[.)])\s+`)
+
+	// urlRe matches bare URLs.
+	urlRe = regexp.MustCompile(`https?://[^\s<>"'\x60]+`)
+)
+
+// maxHeadingLen bounds inferred headings; longer lines are prose.
+const maxHeadingLen = 80
+
+// Parser parses plain text files into mq.Document.
+type Parser struct{}
+
+// NewParser creates a new plain text parser.
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatText
+}
+
+// ParseFile reads and parses a text file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatText, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses plain text content and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	index := mq.NewLineIndex(content)
+	lines := splitLines(string(content))
+	headings := inferHeadings(lines)
+	positionHeadings(headings, index)
+	sections := outline.Nest(headings, len(lines), 0)
+
+	return mq.NewDocument(
+		content,
+		path,
+		mq.FormatText,
+		"", // title: first H1
+		headings,
+		sections,
+		nil, // codeBlocks
+		extractLinks(content, index),
+		nil, // images
+		nil, // tables
+		nil, // lists
+		string(content),
+	), nil
+}
+
+// splitLines splits content into lines without a trailing empty line.
+func splitLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// inferHeadings finds heading-like lines.
+func inferHeadings(lines []string) []*mq.Heading {
+	blank := func(i int) bool {
+		return i < 0 || i >= len(lines) || strings.TrimSpace(lines[i]) == ""
+	}
+
+	var headings []*mq.Heading
+	var outlines []*mq.Heading // levels adjusted below
+	hasTitles := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || len(trimmed) > maxHeadingLen || indentOf(line) > 3 {
+			continue
+		}
+
+		// Text over an underline
+		if i+1 < len(lines) && !underlineRe.MatchString(trimmed) {
+			if m := underlineRe.FindString(strings.TrimSpace(lines[i+1])); m != "" && blank(i-1) {
+				level := 1
+				if m[0] == '-' {
+					level = 2
+				}
+				headings = append(headings, &mq.Heading{Level: level, Text: trimmed, Line: i + 1, EndLine: i + 2})
+				hasTitles = hasTitles || level == 1
+				i++ // the underline
+				continue
+			}
+		}
+
+		if !blank(i - 1) {
+			continue
+		}
+
+		// ALL-CAPS line on its own
+		if isAllCaps(trimmed) && blank(i+1) {
+			headings = append(headings, &mq.Heading{Level: 1, Text: strings.TrimSuffix(trimmed, ":"), Line: i + 1})
+			hasTitles = true
+			continue
+		}
+
+		// Numbered outline entry followed by prose, not by more items
+		if m := outlineRe.FindStringSubmatch(trimmed); m != nil && isTitle(m[3]) {
+			depth := strings.Count(m[1], ".") + 1
+			if depth == 1 && m[2] == "" {
+				continue // "3 apples" is not an outline number
+			}
+			if !blank(i+1) && listItemRe.MatchString(lines[i+1]) {
+				continue
+			}
+			h := &mq.Heading{Level: depth, Text: trimmed, Line: i + 1}
+			headings = append(headings, h)
+			outlines = append(outlines, h)
+		}
+	}
+
+	// Outlines nest under titles when both are used
+	if hasTitles {
+		for _, h := range outlines {
+			h.Level = min(h.Level+1, 6)
+		}
+	}
+	return headings
+}
+
+// isAllCaps reports whether s has at least two letters, all upper case.
+func isAllCaps(s string) bool {
+	letters := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 2
+}
+
+// isTitle reports whether an outline entry's text reads like a title:
+// capitalized and not ending like a sentence.
+func isTitle(s string) bool {
+	first := []rune(s)[0]
+	if !unicode.IsUpper(first) {
+		return false
+	}
+	return !strings.ContainsAny(s[len(s)-1:], ".,;!?")
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// positionHeadings fills in columns, covering the underline of underlined
+// headings.
+func positionHeadings(headings []*mq.Heading, index *mq.LineIndex) {
+	for _, h := range headings {
+		h.SetPosition(index.LinePosition(h.Line, h.End()))
+	}
+}
+
+// extractLinks finds bare URLs.
+func extractLinks(content []byte, index *mq.LineIndex) []*mq.Link {
+	var links []*mq.Link
+	for _, loc := range urlRe.FindAllIndex(content, -1) {
+		url := strings.TrimRight(string(content[loc[0]:loc[1]]), ".,;:)!?")
+		links = append(links, &mq.Link{Text: url, URL: url, Position: index.Position(loc[0], loc[0]+len(url))})
+	}
+	return links
+}
@@ -0,0 +1,156 @@
+package text_test
+
+import (
+	"testing"
+	"time"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/muqsitnawaz/mq/text"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const installTxt = `INSTALLATION GUIDE
+
+Read this first.
+
+1. Requirements
+
+You need Go 1.22.
+See https://go.dev/dl/ for downloads.
+
+2. Steps
+
+The steps are:
+1. Clone the repository
+2. Run make
+
+2.1 Custom prefix
+
+Set PREFIX before running make.
+
+Troubleshooting
+---------------
+
+Ask for help.
+`
+
+const serverLog = `2024-05-01T14:03:07Z INFO starting server on :8080
+2024-05-01T14:04:10Z WARN slow request path=/users took=2.1s
+2024-05-01T14:20:00Z ERROR failed to connect to database
+goroutine 1 [running]:
+main.main()
+2024-05-01T14:21:30Z level=info msg="retrying"
+2024-05-01T15:01:00Z level=error msg="giving up"
+`
+
+func TestParserFormat(t *testing.T) {
+	assert.Equal(t, mq.FormatText, text.NewParser().Format())
+	assert.Equal(t, mq.FormatLog, text.NewLogParser().Format())
+}
+
+func TestTextHeadings(t *testing.T) {
+	doc, err := text.NewParser().Parse([]byte(installTxt), "INSTALL.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, "INSTALLATION GUIDE", doc.Title())
+
+	type heading struct {
+		Level int
+		Text  string
+		Line  int
+	}
+	var got []heading
+	for _, s := range doc.GetSections() {
+		got = append(got, heading{s.Heading.Level, s.Heading.Text, s.Heading.Line})
+	}
+	// Numbered outlines nest below the title; list items are not headings
+	assert.Equal(t, []heading{
+		{1, "INSTALLATION GUIDE", 1},
+		{2, "1. Requirements", 5},
+		{2, "2. Steps", 10},
+		{3, "2.1 Custom prefix", 16},
+		{2, "Troubleshooting", 20},
+	}, got)
+
+	steps, ok := doc.GetSection("2. Steps")
+	require.True(t, ok)
+	assert.Equal(t, 10, steps.Start)
+	assert.Equal(t, 19, steps.End)
+	require.Len(t, steps.Children, 1)
+	assert.Equal(t, "2.1 Custom prefix", steps.Children[0].Heading.Text)
+
+	links := doc.GetLinks()
+	require.Len(t, links, 1)
+	assert.Equal(t, "https://go.dev/dl/", links[0].URL)
+}
+
+func TestTextWithoutStructure(t *testing.T) {
+	doc, err := text.NewParser().Parse([]byte("just a note\nwith two lines\n"), "note.txt")
+	require.NoError(t, err)
+	assert.Empty(t, doc.GetHeadings())
+	assert.Equal(t, "just a note\nwith two lines\n", doc.ReadableText())
+}
+
+func TestLogPeriods(t *testing.T) {
+	doc, err := text.NewLogParser().Parse([]byte(serverLog), "server.log")
+	require.NoError(t, err)
+
+	meta := doc.Metadata()
+	assert.Equal(t, 5, meta["entries"])
+	assert.Equal(t, "5m", meta["period"])
+	assert.Equal(t, map[string]int{"error": 2, "warn": 1, "info": 2}, meta["levels"])
+
+	var headings []string
+	for _, s := range doc.GetSections() {
+		headings = append(headings, s.Heading.Text)
+	}
+	assert.Equal(t, []string{"2024-05-01 14:00", "2024-05-01 14:20", "2024-05-01 15:00"}, headings)
+
+	// Continuation lines belong to the entry above
+	failure := doc.GetSections()[1]
+	assert.Equal(t, 3, failure.Start)
+	assert.Equal(t, 6, failure.End)
+	assert.Equal(t, "2 entries: 1 error, 1 info", failure.Doc)
+	assert.Contains(t, failure.GetText(), "main.main()")
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"Period", "Entries", "error", "warn", "info"}, tables[0].Headers)
+	assert.Equal(t, []string{"2024-05-01 14:00", "2", "0", "1", "1"}, tables[0].Rows[0])
+}
+
+func TestLogFixedPeriod(t *testing.T) {
+	doc, err := text.NewLogParser(text.WithPeriod(time.Hour)).Parse([]byte(serverLog), "server.log")
+	require.NoError(t, err)
+	assert.Len(t, doc.GetSections(), 2)
+	assert.Equal(t, "1h", doc.Metadata()["period"])
+}
+
+func TestLogTimestampStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		heading string
+	}{
+		{"go log", "2024/05/01 14:03:07 listening", "2024-05-01 14:03"},
+		{"iso with zone", "2024-05-01 14:03:07,120 +0200 [main] WARNING disk low", "2024-05-01 14:03"},
+		{"syslog", "May  1 14:03:07 host sshd[42]: accepted", "May  1 14:03"},
+		{"common log", `127.0.0.1 - - [01/May/2024:14:03:07 +0000] "GET / HTTP/1.1" 200 12`, "2024-05-01 14:03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := text.NewLogParser().Parse([]byte(tt.line+"\n"), "app.log")
+			require.NoError(t, err)
+			require.Len(t, doc.GetSections(), 1)
+			assert.Equal(t, tt.heading, doc.GetSections()[0].Heading.Text)
+		})
+	}
+}
+
+func TestLogWithoutTimestamps(t *testing.T) {
+	doc, err := text.NewLogParser().Parse([]byte(installTxt), "build.log")
+	require.NoError(t, err)
+	assert.Equal(t, mq.FormatLog, doc.Format())
+	assert.Equal(t, "INSTALLATION GUIDE", doc.Title())
+}
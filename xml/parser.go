@@ -0,0 +1,455 @@
+// Package xml provides generic XML parsing for mq.
+//
+// XML has explicit structure, so the parser maps it directly onto mq's
+// unified types:
+//   - Headings/Sections: every element, nested by the element hierarchy
+//   - Section metadata: element attributes
+//   - Tables: repeated sibling elements with uniform leaf children
+//     (e.g., Maven <dependency>, RSS <item>)
+//   - Links: href attributes and <link>/<url> elements holding URLs
+//   - Metadata: root element attributes
+//
+// Example:
+//
+//	parser := xml.NewParser()
+//	doc, _ := parser.ParseFile("pom.xml")
+//
+//	deps, _ := doc.XPath("/project/dependencies/dependency")
+//	tables := doc.GetTables()
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+)
+
+// Parser parses XML documents into mq.Document.
+type Parser struct {
+	// Options
+	strict bool // Reject malformed XML instead of recovering
+}
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// NewParser creates a new XML parser with default options.
+func NewParser(opts ...Option) *Parser {
+	p := &Parser{
+		strict: false,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithStrict enables strict XML parsing. By default the parser tolerates
+// unknown entities and unclosed elements the way browsers do.
+func WithStrict(enabled bool) Option {
+	return func(p *Parser) {
+		p.strict = enabled
+	}
+}
+
+// Format implements mq.FormatParser.
+func (p *Parser) Format() mq.Format {
+	return mq.FormatXML
+}
+
+// ParseFile reads and parses an XML file.
+func (p *Parser) ParseFile(path string) (*mq.Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatXML, Path: path, Err: err}
+	}
+	return p.Parse(content, path)
+}
+
+// Parse parses XML content and returns an mq.Document.
+func (p *Parser) Parse(content []byte, path string) (*mq.Document, error) {
+	root, err := p.parseTree(content)
+	if err != nil {
+		return nil, &mq.ParseError{Format: mq.FormatXML, Path: path, Err: err}
+	}
+
+	ext := &extractor{
+		source: content,
+		path:   path,
+		root:   root,
+	}
+	return ext.extract(), nil
+}
+
+// element is a parsed XML element with source positions.
+type element struct {
+	name      string
+	attrs     map[string]string
+	attrOrder []string
+	text      string // direct character data, whitespace-trimmed
+	children  []*element
+	parent    *element
+	start     int         // byte offset of the start tag
+	tag       mq.Position // start tag
+	pos       mq.Position // start tag through end tag
+}
+
+// parseTree decodes content into an element tree.
+func (p *Parser) parseTree(content []byte) (*element, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	if !p.strict {
+		dec.Strict = false
+		dec.Entity = xml.HTMLEntity
+	}
+
+	index := mq.NewLineIndex(content)
+
+	var root *element
+	var stack []*element
+	var text []*strings.Builder
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &element{
+				name:  t.Name.Local,
+				attrs: make(map[string]string),
+				start: int(offset),
+				tag:   index.Position(int(offset), int(dec.InputOffset())),
+			}
+			for _, a := range t.Attr {
+				name := a.Name.Local
+				if a.Name.Space == "xmlns" {
+					name = "xmlns:" + name
+				}
+				el.attrs[name] = a.Value
+				el.attrOrder = append(el.attrOrder, name)
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				el.parent = parent
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			} else {
+				// Ignore stray top-level elements after the root.
+				el = nil
+			}
+
+			if el != nil {
+				stack = append(stack, el)
+				text = append(text, &strings.Builder{})
+			}
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			el := stack[len(stack)-1]
+			el.pos = index.Position(el.start, int(dec.InputOffset()))
+			el.text = strings.Join(strings.Fields(text[len(text)-1].String()), " ")
+			stack = stack[:len(stack)-1]
+			text = text[:len(text)-1]
+
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+
+	// Close anything left open by truncated input.
+	for _, el := range stack {
+		if el.pos.Line == 0 {
+			el.pos = index.Position(el.start, len(content))
+		}
+	}
+
+	return root, nil
+}
+
+// extractor maps the element tree onto mq's structural types.
+type extractor struct {
+	source []byte
+	path   string
+	root   *element
+
+	headings []*mq.Heading
+	sections []*mq.Section
+	tables   []*mq.Table
+	links    []*mq.Link
+}
+
+func (e *extractor) extract() *mq.Document {
+	e.walk(e.root, nil, 1)
+
+	doc := mq.NewDocument(
+		e.source,
+		e.path,
+		mq.FormatXML,
+		e.title(),
+		e.headings,
+		e.sections,
+		nil, // codeBlocks
+		e.links,
+		nil, // images
+		e.tables,
+		nil, // lists
+		e.readableText(),
+	)
+
+	if len(e.root.attrs) > 0 {
+		meta := mq.Metadata{}
+		for k, v := range e.root.attrs {
+			meta[k] = v
+		}
+		doc.SetMetadata(meta)
+	}
+
+	return doc
+}
+
+// walk creates a heading and section for el and its descendants.
+func (e *extractor) walk(el *element, parent *mq.Section, depth int) {
+	level := depth
+	if level > 6 {
+		level = 6
+	}
+
+	h := &mq.Heading{
+		Level: level,
+		Text:  el.name,
+	}
+	h.SetPosition(el.tag)
+	if id, ok := el.attrs["id"]; ok {
+		h.ID = id
+	}
+	e.headings = append(e.headings, h)
+
+	s := &mq.Section{
+		Heading: h,
+		Parent:  parent,
+		Start:   el.pos.Line,
+		End:     el.pos.EndLine,
+	}
+	if len(el.attrs) > 0 {
+		s.Metadata = mq.Metadata{}
+		for k, v := range el.attrs {
+			s.Metadata[k] = v
+		}
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	e.sections = append(e.sections, s)
+
+	e.extractLinks(el)
+
+	for _, group := range groupRepeated(el.children) {
+		if table := uniformTable(group); table != nil {
+			e.tables = append(e.tables, table)
+		}
+	}
+
+	for _, child := range el.children {
+		e.walk(child, s, depth+1)
+	}
+}
+
+// extractLinks records href attributes and URL-valued link elements.
+func (e *extractor) extractLinks(el *element) {
+	for _, attr := range []string{"href", "src"} {
+		if url, ok := el.attrs[attr]; ok && url != "" {
+			text := el.text
+			if text == "" {
+				text = el.name
+			}
+			e.links = append(e.links, &mq.Link{Text: text, URL: url, Position: el.pos})
+		}
+	}
+
+	switch strings.ToLower(el.name) {
+	case "link", "url", "loc":
+		if isURL(el.text) {
+			text := el.name
+			if el.parent != nil {
+				if title := childText(el.parent, "title", "name"); title != "" {
+					text = title
+				}
+			}
+			e.links = append(e.links, &mq.Link{Text: text, URL: el.text, Position: el.pos})
+		}
+	}
+}
+
+// groupRepeated returns sibling groups that share an element name and
+// occur more than once, in first-occurrence order.
+func groupRepeated(children []*element) [][]*element {
+	groups := make(map[string][]*element)
+	var order []string
+	for _, c := range children {
+		if _, ok := groups[c.name]; !ok {
+			order = append(order, c.name)
+		}
+		groups[c.name] = append(groups[c.name], c)
+	}
+
+	var result [][]*element
+	for _, name := range order {
+		if len(groups[name]) > 1 {
+			result = append(result, groups[name])
+		}
+	}
+	return result
+}
+
+// uniformTable builds a table when every element in the group has only
+// leaf children and they all share the same child names.
+func uniformTable(group []*element) *mq.Table {
+	var columns []string
+	for i, el := range group {
+		if len(el.children) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(el.children))
+		seen := make(map[string]bool)
+		for _, c := range el.children {
+			if len(c.children) > 0 || seen[c.name] {
+				return nil
+			}
+			seen[c.name] = true
+			names = append(names, c.name)
+		}
+		sort.Strings(names)
+
+		if i == 0 {
+			columns = names
+			continue
+		}
+		if strings.Join(names, "\x00") != strings.Join(columns, "\x00") {
+			return nil
+		}
+	}
+
+	if len(columns) > 20 {
+		return nil
+	}
+
+	// Keep the column order of the first element rather than sorted order.
+	headers := make([]string, 0, len(columns))
+	for _, c := range group[0].children {
+		headers = append(headers, c.name)
+	}
+
+	first, last := group[0].pos, group[len(group)-1].pos
+	table := &mq.Table{
+		Headers: headers,
+		Position: mq.Position{
+			Line:      first.Line,
+			Column:    first.Column,
+			EndLine:   last.EndLine,
+			EndColumn: last.EndColumn,
+		},
+	}
+	for _, el := range group {
+		row := make([]string, len(headers))
+		for i, h := range headers {
+			row[i] = childText(el, h)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// childText returns the text of the first child with one of the given names.
+func childText(el *element, names ...string) string {
+	for _, name := range names {
+		for _, c := range el.children {
+			if c.name == name {
+				return c.text
+			}
+		}
+	}
+	return ""
+}
+
+// title looks for a title or name element near the root, falling back to
+// the root element name.
+func (e *extractor) title() string {
+	level := []*element{e.root}
+	for depth := 0; depth < 3 && len(level) > 0; depth++ {
+		for _, el := range level {
+			if t := childText(el, "title", "name"); t != "" {
+				return t
+			}
+		}
+		var next []*element
+		for _, el := range level {
+			next = append(next, el.children...)
+		}
+		level = next
+	}
+	return e.root.name
+}
+
+// readableText renders the tree as an indented outline of element values.
+func (e *extractor) readableText() string {
+	var buf strings.Builder
+	writeOutline(&buf, e.root, 0)
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func writeOutline(buf *strings.Builder, el *element, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(el.name)
+	for _, name := range el.attrOrder {
+		fmt.Fprintf(buf, " %s=%q", name, el.attrs[name])
+	}
+	if el.text != "" {
+		buf.WriteString(": ")
+		buf.WriteString(el.text)
+	}
+	buf.WriteString("\n")
+
+	for _, c := range el.children {
+		writeOutline(buf, c, depth+1)
+	}
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// Ensure Parser implements mq.FormatParser
+var _ mq.FormatParser = (*Parser)(nil)
+
+// ParseXML is a convenience function for quick parsing.
+func ParseXML(content []byte, path string) (*mq.Document, error) {
+	return NewParser().Parse(content, path)
+}
+
+// ParseXMLFile is a convenience function for quick file parsing.
+func ParseXMLFile(path string) (*mq.Document, error) {
+	return NewParser().ParseFile(path)
+}
@@ -0,0 +1,93 @@
+package xml_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	mq "github.com/muqsitnawaz/mq/lib"
+	"github.com/muqsitnawaz/mq/xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserFormat(t *testing.T) {
+	p := xml.NewParser()
+	assert.Equal(t, mq.FormatXML, p.Format())
+}
+
+func TestParsePOM(t *testing.T) {
+	doc, err := xml.ParseXMLFile(filepath.Join("testdata", "pom.xml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, mq.FormatXML, doc.Format())
+	assert.Equal(t, "Demo Service", doc.Title())
+
+	// Root element is the only top-level section
+	toc := doc.GetTableOfContents()
+	require.Len(t, toc, 1)
+	assert.Equal(t, "project", toc[0].Heading.Text)
+	assert.Equal(t, 2, toc[0].Start)
+	assert.Equal(t, 28, toc[0].End)
+
+	// Root attributes become document metadata
+	assert.Equal(t, "4.0.0", doc.Metadata()["version"])
+
+	// Element line ranges
+	deps, err := doc.XPath("/project/dependencies/dependency")
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+	assert.Equal(t, 9, deps[0].Start)
+	assert.Equal(t, 13, deps[0].End)
+	assert.Contains(t, deps[1].GetText(), "guava")
+
+	// Element attributes become section metadata
+	plugins, err := doc.XPath("//plugin[@id='compiler']")
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "compiler", plugins[0].Metadata["id"])
+	assert.Equal(t, "compiler", plugins[0].Heading.ID)
+}
+
+func TestParseRepeatedElementsAsTable(t *testing.T) {
+	doc, err := xml.ParseXMLFile(filepath.Join("testdata", "pom.xml"))
+	require.NoError(t, err)
+
+	tables := doc.GetTables()
+	require.Len(t, tables, 1)
+	assert.Equal(t, []string{"groupId", "artifactId", "scope"}, tables[0].Headers)
+	require.Len(t, tables[0].Rows, 2)
+	assert.Equal(t, []string{"com.google.guava", "guava", "compile"}, tables[0].Rows[1])
+}
+
+func TestParseLinks(t *testing.T) {
+	content := []byte(`<rss version="2.0">
+  <channel>
+    <title>News</title>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+    </item>
+    <atom:link href="https://example.com/feed" rel="self"/>
+  </channel>
+</rss>`)
+
+	doc, err := xml.ParseXML(content, "feed.rss")
+	require.NoError(t, err)
+
+	links := doc.GetLinks()
+	require.Len(t, links, 2)
+	assert.Equal(t, "First", links[0].Text)
+	assert.Equal(t, "https://example.com/1", links[0].URL)
+	assert.Equal(t, "https://example.com/feed", links[1].URL)
+}
+
+func TestParseStrict(t *testing.T) {
+	content := []byte(`<root><a>&nbsp;</a><b></root>`)
+
+	_, err := xml.NewParser().Parse(content, "loose.xml")
+	assert.NoError(t, err)
+
+	_, err = xml.NewParser(xml.WithStrict(true)).Parse(content, "loose.xml")
+	var parseErr *mq.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+}